@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"image/png"
+	"sync"
+)
+
+// ResponseCache caches complete answers of deterministic requests in memory, so repeated requests (E.g. same ai command on same selection)
+// don't need to call the provider again. Oldest entry is evicted when cache is full
+type ResponseCache struct {
+	maxEntries int
+	entries    map[string]string
+	order      []string
+	lock       sync.Mutex
+}
+
+const contextKeyResponseCache = "ai_response_cache"
+
+// WithResponseCache marks requests made with returned context as cacheable, only used by ai commands
+// because other plugins may request the same conversation again on purpose, E.g. regenerate an answer
+func WithResponseCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyResponseCache, true)
+}
+
+func IsResponseCacheAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(contextKeyResponseCache).(bool)
+	return allowed
+}
+
+func NewResponseCache(maxEntries int) *ResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = 100
+	}
+	return &ResponseCache{maxEntries: maxEntries, entries: map[string]string{}}
+}
+
+// NewResponseCacheKey returns the cache key of a request, which is the md5 of model, roles, texts and images of the conversations
+func NewResponseCacheKey(model Model, conversations []Conversation) string {
+	hash := md5.New()
+	hash.Write([]byte(fmt.Sprintf("%s\x00%s\x00", model.Provider, model.Name)))
+	for _, conversation := range conversations {
+		hash.Write([]byte(fmt.Sprintf("%s\x00%s\x00", conversation.Role, conversation.Text)))
+		for _, img := range conversation.Images {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err == nil {
+				hash.Write(buf.Bytes())
+			}
+			hash.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *ResponseCache) Get(key string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	response, exist := c.entries[key]
+	return response, exist
+}
+
+func (c *ResponseCache) Set(key string, response string) {
+	if response == "" {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, exist := c.entries[key]; !exist {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = response
+	for len(c.order) > c.maxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func (c *ResponseCache) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = map[string]string{}
+	c.order = nil
+}
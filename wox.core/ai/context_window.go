@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"fmt"
+	"wox/setting"
+)
+
+type ContextStrategy = string
+
+const (
+	ContextStrategyDropOldest          ContextStrategy = "drop_oldest"            // drop the oldest conversations until it fits
+	ContextStrategyKeepSystemAndLatest ContextStrategy = "keep_system_and_latest" // keep system conversations, then fill with latest conversations
+)
+
+// tokens reserved for model's answer, we don't want to fill the whole context window with prompt
+const completionReserveRatio = 0.2
+const maxCompletionReserve = 4096
+
+// truncatedTextSuffix will be appended to the text which is cut to fit the context window
+const truncatedTextSuffix = "\n...(truncated)"
+
+// GetContextWindow returns the context window size for given model, user configured size will be used first
+func GetContextWindow(model Model, modelSettings []setting.AIModelSetting) int {
+	for _, modelSetting := range modelSettings {
+		if modelSetting.Provider == string(model.Provider) && modelSetting.Name == model.Name && modelSetting.ContextWindow > 0 {
+			return modelSetting.ContextWindow
+		}
+	}
+
+	return GetDefaultContextWindow(model)
+}
+
+// GetPromptTokenLimit returns how many tokens can be used by prompt in given context window, rest is reserved for answer
+func GetPromptTokenLimit(contextWindow int) int {
+	reserve := int(float64(contextWindow) * completionReserveRatio)
+	if reserve > maxCompletionReserve {
+		reserve = maxCompletionReserve
+	}
+	return contextWindow - reserve
+}
+
+// FitConversations makes sure the conversations fit into tokenLimit using given strategy.
+// The latest conversation is always kept, if it's still too large by itself, its text will be truncated.
+func FitConversations(model Model, conversations []Conversation, tokenLimit int, strategy ContextStrategy) ([]Conversation, error) {
+	if len(conversations) == 0 {
+		return conversations, nil
+	}
+	if EstimateConversationTokens(model, conversations) <= tokenLimit {
+		return conversations, nil
+	}
+
+	var fitted []Conversation
+	switch strategy {
+	case ContextStrategyKeepSystemAndLatest:
+		fitted = keepSystemAndLatest(model, conversations, tokenLimit)
+	case ContextStrategyDropOldest, "":
+		fitted = dropOldest(model, conversations, tokenLimit)
+	default:
+		return nil, fmt.Errorf("unknown context strategy: %s", strategy)
+	}
+
+	// still too large, truncate the latest conversation
+	if EstimateConversationTokens(model, fitted) > tokenLimit {
+		othersTokens := EstimateConversationTokens(model, fitted[:len(fitted)-1])
+		last, err := truncateConversation(model, fitted[len(fitted)-1], tokenLimit-othersTokens)
+		if err != nil {
+			return nil, err
+		}
+		fitted[len(fitted)-1] = last
+	}
+
+	return fitted, nil
+}
+
+func dropOldest(model Model, conversations []Conversation, tokenLimit int) []Conversation {
+	start := 0
+	for start < len(conversations)-1 && EstimateConversationTokens(model, conversations[start:]) > tokenLimit {
+		start++
+	}
+
+	return append([]Conversation{}, conversations[start:]...)
+}
+
+func keepSystemAndLatest(model Model, conversations []Conversation, tokenLimit int) []Conversation {
+	var systemConversations []Conversation
+	for _, conversation := range conversations[:len(conversations)-1] {
+		if conversation.Role == ConversationRoleSystem {
+			systemConversations = append(systemConversations, conversation)
+		}
+	}
+
+	// latest conversation is always kept, then add older non-system conversations while we still have space
+	latest := []Conversation{conversations[len(conversations)-1]}
+	used := EstimateConversationTokens(model, systemConversations) + EstimateConversationTokens(model, latest)
+	for i := len(conversations) - 2; i >= 0; i-- {
+		if conversations[i].Role == ConversationRoleSystem {
+			continue
+		}
+
+		tokens := EstimateConversationTokens(model, conversations[i:i+1])
+		if used+tokens > tokenLimit {
+			break
+		}
+		used += tokens
+		latest = append([]Conversation{conversations[i]}, latest...)
+	}
+
+	return append(systemConversations, latest...)
+}
+
+func truncateConversation(model Model, conversation Conversation, tokenLimit int) (Conversation, error) {
+	textLimit := tokenLimit - EstimateConversationTokens(model, []Conversation{{Role: conversation.Role, Images: conversation.Images}})
+	if textLimit <= 0 {
+		return conversation, fmt.Errorf("conversation is too large for the context window of %s, token limit: %d", model.Name, tokenLimit)
+	}
+
+	runes := []rune(conversation.Text)
+	// shrink proportionally until it fits, estimation is not linear so we may need a few rounds
+	for len(runes) > 0 {
+		textTokens := EstimateTokens(model, string(runes)+truncatedTextSuffix)
+		if textTokens <= textLimit {
+			break
+		}
+
+		newLength := int(float64(len(runes)) * float64(textLimit) / float64(textTokens))
+		if newLength >= len(runes) {
+			newLength = len(runes) - 1
+		}
+		runes = runes[:newLength]
+	}
+
+	conversation.Text = string(runes) + truncatedTextSuffix
+	return conversation, nil
+}
@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"wox/setting"
+)
+
+var testModel = Model{Name: "gpt-4o-mini", Provider: ProviderNameOpenAI}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(testModel, ""))
+	assert.Equal(t, 3, EstimateTokens(testModel, "hello world!"))
+	assert.Equal(t, 2, EstimateTokens(testModel, "document"))
+	assert.Equal(t, 4, EstimateTokens(testModel, "你好世界"))
+	assert.Greater(t, EstimateTokens(Model{Name: "llama3"}, "你好世界"), EstimateTokens(testModel, "你好世界"))
+}
+
+func TestGetContextWindow(t *testing.T) {
+	assert.Equal(t, 128000, GetContextWindow(testModel, nil))
+	assert.Equal(t, 8192, GetContextWindow(Model{Name: "unknown-model", Provider: ProviderNameOllama}, nil))
+	assert.Equal(t, 4096, GetContextWindow(testModel, []setting.AIModelSetting{
+		{Provider: "openai", Name: "gpt-4o-mini", ContextWindow: 4096},
+	}))
+}
+
+func TestFitConversationsDropOldest(t *testing.T) {
+	conversations := []Conversation{
+		{Role: ConversationRoleSystem, Text: strings.Repeat("system ", 50)},
+		{Role: ConversationRoleUser, Text: strings.Repeat("first ", 50)},
+		{Role: ConversationRoleAI, Text: strings.Repeat("second ", 50)},
+		{Role: ConversationRoleUser, Text: "latest question"},
+	}
+
+	fitted, err := FitConversations(testModel, conversations, 120, ContextStrategyDropOldest)
+	assert.Nil(t, err)
+	assert.Len(t, fitted, 2)
+	assert.Equal(t, ConversationRoleAI, fitted[0].Role)
+	assert.Equal(t, "latest question", fitted[1].Text)
+}
+
+func TestFitConversationsKeepSystemAndLatest(t *testing.T) {
+	conversations := []Conversation{
+		{Role: ConversationRoleSystem, Text: strings.Repeat("system ", 50)},
+		{Role: ConversationRoleUser, Text: strings.Repeat("first ", 50)},
+		{Role: ConversationRoleAI, Text: strings.Repeat("second ", 50)},
+		{Role: ConversationRoleUser, Text: "latest question"},
+	}
+
+	fitted, err := FitConversations(testModel, conversations, 120, ContextStrategyKeepSystemAndLatest)
+	assert.Nil(t, err)
+	assert.Len(t, fitted, 2)
+	assert.Equal(t, ConversationRoleSystem, fitted[0].Role)
+	assert.Equal(t, "latest question", fitted[1].Text)
+}
+
+func TestFitConversationsTruncateLatest(t *testing.T) {
+	conversations := []Conversation{
+		{Role: ConversationRoleUser, Text: strings.Repeat("selection ", 1000)},
+	}
+
+	fitted, err := FitConversations(testModel, conversations, 100, ContextStrategyDropOldest)
+	assert.Nil(t, err)
+	assert.Len(t, fitted, 1)
+	assert.LessOrEqual(t, EstimateConversationTokens(testModel, fitted), 100)
+	assert.True(t, strings.HasSuffix(fitted[0].Text, truncatedTextSuffix))
+}
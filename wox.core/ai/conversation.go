@@ -7,8 +7,9 @@ import (
 type ConversationRole string
 
 var (
-	ConversationRoleUser ConversationRole = "user"
-	ConversationRoleAI   ConversationRole = "ai"
+	ConversationRoleSystem ConversationRole = "system"
+	ConversationRoleUser   ConversationRole = "user"
+	ConversationRoleAI     ConversationRole = "ai"
 )
 
 type Conversation struct {
@@ -21,12 +21,18 @@ type Provider interface {
 	Models(ctx context.Context) ([]Model, error)
 }
 
+// EmbeddingProvider is implemented by providers which can convert texts into vectors
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, model Model, texts []string) ([][]float32, error)
+}
+
 type ChatStreamDataType string
 
 const (
 	ChatStreamTypeStreaming ChatStreamDataType = "streaming"
 	ChatStreamTypeFinished  ChatStreamDataType = "finished"
 	ChatStreamTypeError     ChatStreamDataType = "error"
+	ChatStreamTypeRestarted ChatStreamDataType = "restarted" // broken stream is restarted with a different answer, data received before should be discarded
 )
 
 type ChatStreamFunc func(t ChatStreamDataType, data string)
@@ -35,6 +41,11 @@ type ChatStream interface {
 	Receive(ctx context.Context) (string, error) // will return io.EOF if no more messages
 }
 
+// ChatStreamCloser is implemented by streams holding a connection, which should be released if the stream is abandoned before io.EOF
+type ChatStreamCloser interface {
+	Close(ctx context.Context)
+}
+
 func NewProvider(ctx context.Context, providerSetting setting.AIProvider) (Provider, error) {
 	if providerSetting.Name == string(ProviderNameGoogle) {
 		return NewGoogleProvider(ctx, providerSetting), nil
@@ -51,8 +51,9 @@ func (g *GoogleProvider) ChatStream(ctx context.Context, model Model, conversati
 		return nil, ensureClientErr
 	}
 
-	chatMessages, lastConversation := g.convertConversations(conversations)
+	systemInstruction, chatMessages, lastConversation := g.convertConversations(conversations)
 	aiModel := g.client.GenerativeModel(model.Name)
+	aiModel.SystemInstruction = systemInstruction
 	session := aiModel.StartChat()
 	session.History = chatMessages
 	stream := session.SendMessageStream(ctx, lastConversation.Parts...)
@@ -104,9 +105,18 @@ func (g *GoogleProviderStream) Close(ctx context.Context) {
 	// no-op
 }
 
-func (g *GoogleProvider) convertConversations(conversations []Conversation) (msgWithoutLast []*genai.Content, lastMsg *genai.Content) {
+func (g *GoogleProvider) convertConversations(conversations []Conversation) (systemInstruction *genai.Content, msgWithoutLast []*genai.Content, lastMsg *genai.Content) {
 	var chatMessages []*genai.Content
 	for _, conversation := range conversations {
+		// gemini doesn't support system role in history, use system instruction instead
+		if conversation.Role == ConversationRoleSystem {
+			if systemInstruction == nil {
+				systemInstruction = &genai.Content{}
+			}
+			systemInstruction.Parts = append(systemInstruction.Parts, genai.Text(conversation.Text))
+			continue
+		}
+
 		role := ""
 		if conversation.Role == ConversationRoleUser {
 			role = "user"
@@ -115,7 +125,7 @@ func (g *GoogleProvider) convertConversations(conversations []Conversation) (msg
 			role = "model"
 		}
 		if role == "" {
-			return nil, nil
+			return nil, nil, nil
 		}
 
 		chatMessages = append(chatMessages, &genai.Content{
@@ -126,5 +136,5 @@ func (g *GoogleProvider) convertConversations(conversations []Conversation) (msg
 		})
 	}
 
-	return chatMessages[:len(chatMessages)-1], chatMessages[len(chatMessages)-1]
+	return systemInstruction, chatMessages[:len(chatMessages)-1], chatMessages[len(chatMessages)-1]
 }
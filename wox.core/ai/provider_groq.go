@@ -70,6 +70,9 @@ func (g *GroqProvider) Models(ctx context.Context) (models []Model, err error) {
 
 func (g *GroqProvider) convertConversations(conversations []Conversation) (chatMessages []llms.MessageContent) {
 	for _, conversation := range conversations {
+		if conversation.Role == ConversationRoleSystem {
+			chatMessages = append(chatMessages, llms.TextParts(llms.ChatMessageTypeSystem, conversation.Text))
+		}
 		if conversation.Role == ConversationRoleUser {
 			chatMessages = append(chatMessages, llms.TextParts(llms.ChatMessageTypeHuman, conversation.Text))
 		}
@@ -95,3 +98,9 @@ func (s *GroqProviderStream) Receive(ctx context.Context) (string, error) {
 	util.GetLogger().Debug(util.NewTraceContext(), fmt.Sprintf("Groq: Send response: %s", resp))
 	return resp, nil
 }
+
+func (s *GroqProviderStream) Close(ctx context.Context) {
+	if closer, ok := s.reader.(io.Closer); ok {
+		closer.Close()
+	}
+}
@@ -58,6 +58,15 @@ func (o *OllamaProvider) ChatStream(ctx context.Context, model Model, conversati
 	return &OllamaProviderStream{conversations: conversations, reader: r}, nil
 }
 
+func (o *OllamaProvider) Embed(ctx context.Context, model Model, texts []string) ([][]float32, error) {
+	client, clientErr := ollama.New(ollama.WithServerURL(o.connectContext.Host), ollama.WithModel(model.Name))
+	if clientErr != nil {
+		return nil, clientErr
+	}
+
+	return client.CreateEmbedding(ctx, texts)
+}
+
 func (o *OllamaProvider) Models(ctx context.Context) (models []Model, err error) {
 	body, err := util.HttpGet(ctx, o.connectContext.Host+"/api/tags")
 	if err != nil {
@@ -78,6 +87,9 @@ func (o *OllamaProvider) Models(ctx context.Context) (models []Model, err error)
 func (o *OllamaProvider) convertConversations(conversations []Conversation) (chatMessages []llms.MessageContent) {
 	for _, conversation := range conversations {
 		var msg llms.MessageContent
+		if conversation.Role == ConversationRoleSystem {
+			msg = llms.TextParts(llms.ChatMessageTypeSystem, conversation.Text)
+		}
 		if conversation.Role == ConversationRoleUser {
 			msg = llms.TextParts(llms.ChatMessageTypeHuman, conversation.Text)
 		}
@@ -115,3 +127,9 @@ func (s *OllamaProviderStream) Receive(ctx context.Context) (string, error) {
 	util.GetLogger().Debug(util.NewTraceContext(), fmt.Sprintf("OLLAMA: Send response: %s", resp))
 	return resp, nil
 }
+
+func (s *OllamaProviderStream) Close(ctx context.Context) {
+	if closer, ok := s.reader.(io.Closer); ok {
+		closer.Close()
+	}
+}
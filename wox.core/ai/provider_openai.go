@@ -51,6 +51,28 @@ func (o *OpenAIProvider) ChatStream(ctx context.Context, model Model, conversati
 	return &OpenAIProviderStream{conversations: conversations, stream: createdStream}, nil
 }
 
+func (o *OpenAIProvider) Embed(ctx context.Context, model Model, texts []string) ([][]float32, error) {
+	if ensureClientErr := o.ensureClient(ctx); ensureClientErr != nil {
+		return nil, ensureClientErr
+	}
+
+	response, err := o.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: texts,
+		Model: openai.EmbeddingModel(model.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, data := range response.Data {
+		if data.Index >= 0 && data.Index < len(embeddings) {
+			embeddings[data.Index] = data.Embedding
+		}
+	}
+	return embeddings, nil
+}
+
 func (o *OpenAIProvider) Models(ctx context.Context) ([]Model, error) {
 	return []Model{
 		{
@@ -79,10 +101,17 @@ func (s *OpenAIProviderStream) Receive(ctx context.Context) (string, error) {
 	return response.Choices[0].Delta.Content, nil
 }
 
+func (s *OpenAIProviderStream) Close(ctx context.Context) {
+	s.stream.Close()
+}
+
 func (o *OpenAIProvider) convertConversations(conversations []Conversation) []openai.ChatCompletionMessage {
 	var chatMessages []openai.ChatCompletionMessage
 	for _, conversation := range conversations {
 		role := ""
+		if conversation.Role == ConversationRoleSystem {
+			role = openai.ChatMessageRoleSystem
+		}
 		if conversation.Role == ConversationRoleUser {
 			role = openai.ChatMessageRoleUser
 		}
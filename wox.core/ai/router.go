@@ -0,0 +1,314 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+	"wox/util"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type ProviderResolver func(ctx context.Context, provider ProviderName) (Provider, error)
+
+type RouterOptions struct {
+	MaxRetries   int              // retries for each model before failing over, 0 means no retry
+	RetryBackoff time.Duration    // backoff before first retry, doubled after each retry
+	Fallbacks    map[string]Model // key is GetModelKey of the primary model
+	Cache        *ResponseCache   // optional, nil means cache is disabled
+}
+
+// Router sits on top of providers, it retries transient errors with backoff,
+// fails over to the configured fallback model and serves repeated requests from cache
+type Router struct {
+	resolver ProviderResolver
+	options  RouterOptions
+}
+
+func NewRouter(resolver ProviderResolver, options RouterOptions) *Router {
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = 500 * time.Millisecond
+	}
+	return &Router{resolver: resolver, options: options}
+}
+
+func GetModelKey(model Model) string {
+	return fmt.Sprintf("%s/%s", model.Provider, model.Name)
+}
+
+func (r *Router) ChatStream(ctx context.Context, model Model, conversations []Conversation) (ChatStream, error) {
+	var cacheKey string
+	if r.options.Cache != nil {
+		cacheKey = NewResponseCacheKey(model, conversations)
+		if response, hit := r.options.Cache.Get(cacheKey); hit {
+			util.GetLogger().Info(ctx, fmt.Sprintf("ai router: serve %s from cache", GetModelKey(model)))
+			return &RoutedStream{router: r, servedModel: model, isCached: true, cachedResponse: response}, nil
+		}
+	}
+
+	stream := &RoutedStream{
+		router:        r,
+		models:        r.getModelChain(model),
+		conversations: conversations,
+		cacheKey:      cacheKey,
+	}
+	if err := stream.open(ctx); err != nil {
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// getModelChain returns the primary model followed by its fallbacks, fallback of fallback is also supported
+func (r *Router) getModelChain(model Model) []Model {
+	chain := []Model{model}
+	visited := map[string]bool{GetModelKey(model): true}
+	current := model
+	for {
+		fallback, exist := r.options.Fallbacks[GetModelKey(current)]
+		if !exist || visited[GetModelKey(fallback)] {
+			return chain
+		}
+		visited[GetModelKey(fallback)] = true
+		chain = append(chain, fallback)
+		current = fallback
+	}
+}
+
+// ErrChatStreamRestarted is returned by RoutedStream.Receive when a broken stream is restarted and the new answer is different from
+// text already returned. Text returned before should be discarded, following Receive calls return the new answer from its beginning
+var ErrChatStreamRestarted = errors.New("chat stream is restarted with a different answer")
+
+type RoutedStream struct {
+	router        *Router
+	models        []Model
+	conversations []Conversation
+	cacheKey      string
+
+	modelIndex  int
+	attempt     int
+	servedModel Model
+	stream      ChatStream
+
+	// text already returned to the caller, used to skip repeated text after the stream is restarted
+	emitted strings.Builder
+	// text of the restarted stream which is not returned yet, because it may still be the same as emitted text
+	replayed    strings.Builder
+	isReplay    bool
+	isDiverged  bool
+	isRestarted bool
+	// text of the restarted stream to return after ErrChatStreamRestarted
+	pendingRestart string
+
+	isCached       bool
+	cachedResponse string
+}
+
+// ServedModel returns the model which actually answered, and whether the answer is from cache
+func (s *RoutedStream) ServedModel() (Model, bool) {
+	return s.servedModel, s.isCached
+}
+
+// open starts the stream on current model, transient errors are retried and then failed over to next model
+func (s *RoutedStream) open(ctx context.Context) error {
+	var lastErr error
+	for s.modelIndex < len(s.models) {
+		model := s.models[s.modelIndex]
+		provider, providerErr := s.router.resolver(ctx, model.Provider)
+		if providerErr != nil {
+			lastErr = providerErr
+			s.nextModel(ctx, providerErr)
+			continue
+		}
+
+		stream, err := provider.ChatStream(ctx, model, s.conversations)
+		if err == nil {
+			s.closeStream(ctx)
+			s.stream = stream
+			s.servedModel = model
+			return nil
+		}
+
+		lastErr = err
+		if waitErr := s.retryOrFailover(ctx, err); waitErr != nil {
+			return waitErr
+		}
+	}
+
+	return lastErr
+}
+
+// retryOrFailover decides what to do with an error: retry on same model, fail over to next model or give up
+func (s *RoutedStream) retryOrFailover(ctx context.Context, err error) error {
+	if !IsTransientError(err) {
+		s.nextModel(ctx, err)
+		return nil
+	}
+
+	if s.attempt >= s.router.options.MaxRetries {
+		s.nextModel(ctx, err)
+		return nil
+	}
+
+	backoff := s.router.options.RetryBackoff * time.Duration(1<<s.attempt)
+	s.attempt++
+	util.GetLogger().Warn(ctx, fmt.Sprintf("ai router: %s failed with transient error, retry %d/%d in %s: %s", GetModelKey(s.models[s.modelIndex]), s.attempt, s.router.options.MaxRetries, backoff, err.Error()))
+	select {
+	case <-time.After(backoff):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *RoutedStream) nextModel(ctx context.Context, err error) {
+	current := s.models[s.modelIndex]
+	s.modelIndex++
+	s.attempt = 0
+	if s.modelIndex < len(s.models) {
+		util.GetLogger().Warn(ctx, fmt.Sprintf("ai router: %s failed, fail over to %s: %s", GetModelKey(current), GetModelKey(s.models[s.modelIndex]), err.Error()))
+	}
+}
+
+// closeStream releases connection of the current stream, it's called before the stream is replaced by a restarted one
+func (s *RoutedStream) closeStream(ctx context.Context) {
+	if closer, ok := s.stream.(ChatStreamCloser); ok {
+		closer.Close(ctx)
+	}
+}
+
+func (s *RoutedStream) Receive(ctx context.Context) (string, error) {
+	if s.isCached {
+		if s.cachedResponse == "" {
+			return "", io.EOF
+		}
+		response := s.cachedResponse
+		s.cachedResponse = ""
+		return response, nil
+	}
+
+	if s.pendingRestart != "" {
+		response := s.pendingRestart
+		s.pendingRestart = ""
+		s.emitted.WriteString(response)
+		return response, nil
+	}
+
+	for {
+		response, err := s.stream.Receive(ctx)
+		if err == nil {
+			if s.isReplay && !s.isDiverged {
+				if chunk, ok := s.filterReplayed(response); ok {
+					if s.isDiverged {
+						// caller discards what it received, the new answer is returned from its beginning
+						s.emitted.Reset()
+						s.pendingRestart = chunk
+						return "", ErrChatStreamRestarted
+					}
+					s.emitted.WriteString(chunk)
+					return chunk, nil
+				}
+				continue
+			}
+			s.emitted.WriteString(response)
+			return response, nil
+		}
+
+		if errors.Is(err, io.EOF) {
+			// restarted stream finished before it produced more than what we already returned
+			if s.isReplay && !s.isDiverged && s.replayed.Len() > 0 {
+				util.GetLogger().Warn(ctx, "ai router: restarted stream finished without new content")
+			}
+			// answer of a restarted stream may be joined from different answers, don't reuse it
+			if s.router.options.Cache != nil && s.cacheKey != "" && !s.isRestarted {
+				s.router.options.Cache.Set(s.cacheKey, s.emitted.String())
+			}
+			return "", io.EOF
+		}
+
+		if ctx.Err() != nil {
+			return "", err
+		}
+
+		// stream broken, restart it on the same model (retry) or next model (failover)
+		if waitErr := s.retryOrFailover(ctx, err); waitErr != nil {
+			return "", waitErr
+		}
+		if s.modelIndex >= len(s.models) {
+			return "", err
+		}
+		if openErr := s.open(ctx); openErr != nil {
+			return "", openErr
+		}
+		s.isRestarted = true
+		s.isReplay = s.emitted.Len() > 0
+		s.isDiverged = false
+		s.replayed.Reset()
+	}
+}
+
+// filterReplayed hides text of a restarted stream which was already returned to caller.
+// If the restarted answer is different from what we already returned, the stream is marked as diverged and the new answer is returned completely
+func (s *RoutedStream) filterReplayed(response string) (string, bool) {
+	s.replayed.WriteString(response)
+	emitted := s.emitted.String()
+	replayed := s.replayed.String()
+	if strings.HasPrefix(emitted, replayed) {
+		// still replaying the same text, wait for more
+		return "", false
+	}
+
+	s.isReplay = false
+	if strings.HasPrefix(replayed, emitted) {
+		return replayed[len(emitted):], true
+	}
+
+	s.isDiverged = true
+	return replayed, true
+}
+
+// IsTransientError reports whether the error is worth retrying, E.g. rate limit, server overload or network issues
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return isTransientHttpStatus(apiErr.HTTPStatusCode)
+	}
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		return isTransientHttpStatus(requestErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	// some providers only return plain text errors
+	msg := strings.ToLower(err.Error())
+	for _, keyword := range []string{"429", "500", "502", "503", "504", "rate limit", "overloaded", "timeout", "connection reset", "temporarily unavailable", "unexpected eof"} {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTransientHttpStatus(code int) bool {
+	return code == 408 || code == 429 || code >= 500
+}
@@ -0,0 +1,227 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeStream struct {
+	chunks   []string
+	err      error // returned after all chunks are sent, nil means io.EOF
+	isClosed bool
+}
+
+func (s *fakeStream) Close(ctx context.Context) {
+	s.isClosed = true
+}
+
+func (s *fakeStream) Receive(ctx context.Context) (string, error) {
+	if len(s.chunks) > 0 {
+		chunk := s.chunks[0]
+		s.chunks = s.chunks[1:]
+		return chunk, nil
+	}
+	if s.err != nil {
+		return "", s.err
+	}
+	return "", io.EOF
+}
+
+type fakeProvider struct {
+	calls   int
+	streams []func() (ChatStream, error) // one per call, last one is reused
+}
+
+func (p *fakeProvider) Close(ctx context.Context) error {
+	return nil
+}
+
+func (p *fakeProvider) ChatStream(ctx context.Context, model Model, conversations []Conversation) (ChatStream, error) {
+	index := p.calls
+	if index >= len(p.streams) {
+		index = len(p.streams) - 1
+	}
+	p.calls++
+	return p.streams[index]()
+}
+
+func (p *fakeProvider) Models(ctx context.Context) ([]Model, error) {
+	return nil, nil
+}
+
+func newTestRouter(providers map[ProviderName]*fakeProvider, options RouterOptions) *Router {
+	options.RetryBackoff = time.Millisecond
+	return NewRouter(func(ctx context.Context, name ProviderName) (Provider, error) {
+		if provider, exist := providers[name]; exist {
+			return provider, nil
+		}
+		return nil, fmt.Errorf("provider not found: %s", name)
+	}, options)
+}
+
+func readAll(t *testing.T, stream ChatStream) (string, error) {
+	var sb strings.Builder
+	for {
+		response, err := stream.Receive(context.Background())
+		if errors.Is(err, io.EOF) {
+			return sb.String(), nil
+		}
+		if errors.Is(err, ErrChatStreamRestarted) {
+			sb.Reset()
+			continue
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		sb.WriteString(response)
+	}
+}
+
+var testConversations = []Conversation{{Role: ConversationRoleUser, Text: "hello"}}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(errors.New("error, status code: 429, message: Rate limit reached")))
+	assert.True(t, IsTransientError(io.ErrUnexpectedEOF))
+	assert.True(t, IsTransientError(errors.New("read: connection reset by peer")))
+	assert.False(t, IsTransientError(errors.New("invalid api key")))
+	assert.False(t, IsTransientError(context.Canceled))
+	assert.False(t, IsTransientError(nil))
+}
+
+func TestRouterRetryTransientError(t *testing.T) {
+	primary := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) { return nil, errors.New("429 rate limit") },
+		func() (ChatStream, error) { return &fakeStream{chunks: []string{"hi"}}, nil },
+	}}
+	router := newTestRouter(map[ProviderName]*fakeProvider{ProviderNameOpenAI: primary}, RouterOptions{MaxRetries: 2})
+
+	stream, err := router.ChatStream(context.Background(), testModel, testConversations)
+	assert.Nil(t, err)
+	text, err := readAll(t, stream)
+	assert.Nil(t, err)
+	assert.Equal(t, "hi", text)
+	assert.Equal(t, 2, primary.calls)
+}
+
+func TestRouterFailover(t *testing.T) {
+	fallbackModel := Model{Name: "llama3", Provider: ProviderNameOllama}
+	primary := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) { return nil, errors.New("503 overloaded") },
+	}}
+	fallback := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) { return &fakeStream{chunks: []string{"from fallback"}}, nil },
+	}}
+	router := newTestRouter(map[ProviderName]*fakeProvider{ProviderNameOpenAI: primary, ProviderNameOllama: fallback}, RouterOptions{
+		MaxRetries: 1,
+		Fallbacks:  map[string]Model{GetModelKey(testModel): fallbackModel},
+	})
+
+	stream, err := router.ChatStream(context.Background(), testModel, testConversations)
+	assert.Nil(t, err)
+	text, err := readAll(t, stream)
+	assert.Nil(t, err)
+	assert.Equal(t, "from fallback", text)
+	assert.Equal(t, 2, primary.calls)
+
+	servedModel, isCached := stream.(*RoutedStream).ServedModel()
+	assert.Equal(t, fallbackModel, servedModel)
+	assert.False(t, isCached)
+}
+
+func TestRouterResumeBrokenStream(t *testing.T) {
+	primary := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) {
+			return &fakeStream{chunks: []string{"Hello", " wor"}, err: io.ErrUnexpectedEOF}, nil
+		},
+		func() (ChatStream, error) { return &fakeStream{chunks: []string{"Hel", "lo wo", "rld"}}, nil },
+	}}
+	router := newTestRouter(map[ProviderName]*fakeProvider{ProviderNameOpenAI: primary}, RouterOptions{MaxRetries: 1})
+
+	stream, err := router.ChatStream(context.Background(), testModel, testConversations)
+	assert.Nil(t, err)
+	text, err := readAll(t, stream)
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello world", text)
+}
+
+func TestRouterNonTransientError(t *testing.T) {
+	primary := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) { return nil, errors.New("invalid api key") },
+	}}
+	router := newTestRouter(map[ProviderName]*fakeProvider{ProviderNameOpenAI: primary}, RouterOptions{MaxRetries: 3})
+
+	_, err := router.ChatStream(context.Background(), testModel, testConversations)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, primary.calls)
+}
+
+func TestRouterResponseCache(t *testing.T) {
+	primary := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) { return &fakeStream{chunks: []string{"cached ", "answer"}}, nil },
+	}}
+	router := newTestRouter(map[ProviderName]*fakeProvider{ProviderNameOpenAI: primary}, RouterOptions{Cache: NewResponseCache(10)})
+
+	for i := 0; i < 2; i++ {
+		stream, err := router.ChatStream(context.Background(), testModel, testConversations)
+		assert.Nil(t, err)
+		text, err := readAll(t, stream)
+		assert.Nil(t, err)
+		assert.Equal(t, "cached answer", text)
+	}
+	assert.Equal(t, 1, primary.calls)
+}
+
+func TestRouterRestartedStreamWithDifferentAnswer(t *testing.T) {
+	brokenStream := &fakeStream{chunks: []string{"first"}, err: io.ErrUnexpectedEOF}
+	primary := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) { return brokenStream, nil },
+		func() (ChatStream, error) { return &fakeStream{chunks: []string{"sec", "ond"}}, nil },
+	}}
+	router := newTestRouter(map[ProviderName]*fakeProvider{ProviderNameOpenAI: primary}, RouterOptions{MaxRetries: 1})
+
+	stream, err := router.ChatStream(context.Background(), testModel, testConversations)
+	assert.Nil(t, err)
+	response, err := stream.Receive(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "first", response)
+
+	// restart is reported instead of being joined into the answer text
+	_, err = stream.Receive(context.Background())
+	assert.ErrorIs(t, err, ErrChatStreamRestarted)
+	assert.True(t, brokenStream.isClosed)
+
+	text, err := readAll(t, stream)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", text)
+}
+
+func TestRouterResponseCacheSkipsRestartedStream(t *testing.T) {
+	primary := &fakeProvider{streams: []func() (ChatStream, error){
+		func() (ChatStream, error) {
+			return &fakeStream{chunks: []string{"first"}, err: io.ErrUnexpectedEOF}, nil
+		},
+		func() (ChatStream, error) { return &fakeStream{chunks: []string{"second"}}, nil },
+	}}
+	cache := NewResponseCache(10)
+	router := newTestRouter(map[ProviderName]*fakeProvider{ProviderNameOpenAI: primary}, RouterOptions{MaxRetries: 1, Cache: cache})
+
+	stream, err := router.ChatStream(context.Background(), testModel, testConversations)
+	assert.Nil(t, err)
+	text, err := readAll(t, stream)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", text)
+
+	_, hit := cache.Get(NewResponseCacheKey(testModel, testConversations))
+	assert.False(t, hit)
+}
+
+func TestIsResponseCacheAllowed(t *testing.T) {
+	assert.False(t, IsResponseCacheAllowed(context.Background()))
+	assert.True(t, IsResponseCacheAllowed(WithResponseCache(context.Background())))
+}
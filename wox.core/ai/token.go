@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+type ModelFamily string
+
+const (
+	ModelFamilyGPT     ModelFamily = "gpt"
+	ModelFamilyGemini  ModelFamily = "gemini"
+	ModelFamilyLlama   ModelFamily = "llama"
+	ModelFamilyGemma   ModelFamily = "gemma"
+	ModelFamilyQwen    ModelFamily = "qwen"
+	ModelFamilyMistral ModelFamily = "mistral"
+	ModelFamilyUnknown ModelFamily = "unknown"
+)
+
+// tokenizerProfile describes how a model family splits text into tokens.
+// The numbers are averages measured on english and code samples, they don't need to be exact,
+// we only need a stable upper estimation to decide whether a conversation fits into the context window
+type tokenizerProfile struct {
+	charsPerToken      float64 // average latin chars per token
+	cjkTokensPerRune   float64 // CJK characters are usually encoded as one or more tokens
+	messageOverhead    int     // tokens used by role and separators of each message
+	imageTokens        int     // tokens used by a single (resized) image
+	defaultContextSize int     // context window size if user doesn't configure one
+}
+
+var tokenizerProfiles = map[ModelFamily]tokenizerProfile{
+	ModelFamilyGPT:     {charsPerToken: 4, cjkTokensPerRune: 1, messageOverhead: 4, imageTokens: 765, defaultContextSize: 128000},
+	ModelFamilyGemini:  {charsPerToken: 4, cjkTokensPerRune: 1, messageOverhead: 4, imageTokens: 258, defaultContextSize: 1000000},
+	ModelFamilyLlama:   {charsPerToken: 3.6, cjkTokensPerRune: 1.5, messageOverhead: 5, imageTokens: 1600, defaultContextSize: 8192},
+	ModelFamilyGemma:   {charsPerToken: 3.8, cjkTokensPerRune: 1, messageOverhead: 5, imageTokens: 256, defaultContextSize: 8192},
+	ModelFamilyQwen:    {charsPerToken: 3.8, cjkTokensPerRune: 1, messageOverhead: 5, imageTokens: 1024, defaultContextSize: 32768},
+	ModelFamilyMistral: {charsPerToken: 3.5, cjkTokensPerRune: 1.5, messageOverhead: 5, imageTokens: 1024, defaultContextSize: 32768},
+	ModelFamilyUnknown: {charsPerToken: 3.5, cjkTokensPerRune: 1.5, messageOverhead: 5, imageTokens: 1024, defaultContextSize: 8192},
+}
+
+func GetModelFamily(model Model) ModelFamily {
+	name := strings.ToLower(model.Name)
+	switch {
+	case strings.Contains(name, "gpt"), strings.HasPrefix(name, "o1"), strings.HasPrefix(name, "o3"):
+		return ModelFamilyGPT
+	case strings.Contains(name, "gemini"):
+		return ModelFamilyGemini
+	case strings.Contains(name, "gemma"):
+		return ModelFamilyGemma
+	case strings.Contains(name, "llama"):
+		return ModelFamilyLlama
+	case strings.Contains(name, "qwen"):
+		return ModelFamilyQwen
+	case strings.Contains(name, "mistral"), strings.Contains(name, "mixtral"):
+		return ModelFamilyMistral
+	}
+
+	// fallback to provider defaults
+	switch model.Provider {
+	case ProviderNameOpenAI:
+		return ModelFamilyGPT
+	case ProviderNameGoogle:
+		return ModelFamilyGemini
+	}
+
+	return ModelFamilyUnknown
+}
+
+func getTokenizerProfile(model Model) tokenizerProfile {
+	return tokenizerProfiles[GetModelFamily(model)]
+}
+
+// GetDefaultContextWindow returns the built-in context window size (in tokens) for given model
+func GetDefaultContextWindow(model Model) int {
+	return getTokenizerProfile(model).defaultContextSize
+}
+
+// EstimateTokens estimates how many tokens the text will be encoded to by the model.
+// Text is split into words, numbers, punctuations and CJK runes, which is close to how BPE tokenizers behave
+func EstimateTokens(model Model, text string) int {
+	profile := getTokenizerProfile(model)
+
+	var tokens float64
+	wordLength := 0
+	flushWord := func() {
+		if wordLength > 0 {
+			tokens += math.Max(1, math.Round(float64(wordLength)/profile.charsPerToken))
+			wordLength = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flushWord()
+			tokens += profile.cjkTokensPerRune
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLength++
+		case unicode.IsSpace(r):
+			// spaces are usually merged into the following word
+			flushWord()
+		default:
+			flushWord()
+			tokens += 1
+		}
+	}
+	flushWord()
+
+	return int(math.Ceil(tokens))
+}
+
+// EstimateConversationTokens estimates the total prompt tokens of the conversations, including images and message overhead
+func EstimateConversationTokens(model Model, conversations []Conversation) int {
+	profile := getTokenizerProfile(model)
+
+	total := 0
+	for _, conversation := range conversations {
+		total += profile.messageOverhead
+		total += EstimateTokens(model, conversation.Text)
+		total += len(conversation.Images) * profile.imageTokens
+	}
+
+	return total
+}
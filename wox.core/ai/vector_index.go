@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"wox/util"
+)
+
+type VectorDocument struct {
+	Id       string
+	Text     string
+	Metadata map[string]string
+	Vector   []float32 `json:",omitempty"`
+}
+
+type VectorSearchResult struct {
+	Id       string
+	Text     string
+	Metadata map[string]string
+	Score    float64 // cosine similarity, from -1 to 1, higher is more similar
+}
+
+// VectorIndex is a small on-disk vector index, all vectors are kept in memory and searched by brute force,
+// which is fast enough for thousands of documents (clipboard history, bookmarks, notes etc)
+type VectorIndex struct {
+	path      string
+	Model     Model // embedding model used to build this index, vectors from different models can't be compared
+	Documents map[string]VectorDocument
+	lock      sync.RWMutex
+}
+
+// LoadVectorIndex loads index from file, a new empty index is returned if file doesn't exist.
+// If index is built by another embedding model, an empty index is returned and the previous index file is kept as backup
+func LoadVectorIndex(ctx context.Context, indexPath string, model Model) (*VectorIndex, error) {
+	index := &VectorIndex{path: indexPath, Model: model, Documents: map[string]VectorDocument{}}
+
+	data, readErr := os.ReadFile(indexPath)
+	if readErr != nil {
+		if errors.Is(readErr, os.ErrNotExist) {
+			return index, nil
+		}
+		return nil, readErr
+	}
+
+	var stored VectorIndex
+	if unmarshalErr := json.Unmarshal(data, &stored); unmarshalErr != nil {
+		return nil, fmt.Errorf("failed to load vector index %s: %w", indexPath, unmarshalErr)
+	}
+
+	// embedding model changed, existing vectors are useless
+	if stored.Model != model {
+		backupPath := indexPath + ".bak"
+		util.GetLogger().Warn(ctx, fmt.Sprintf("embedding model of vector index %s changed from %s to %s, %d documents need to be indexed again, previous index is kept in %s", indexPath, GetModelKey(stored.Model), GetModelKey(model), len(stored.Documents), backupPath))
+		if renameErr := os.Rename(indexPath, backupPath); renameErr != nil {
+			return nil, fmt.Errorf("failed to backup vector index %s: %w", indexPath, renameErr)
+		}
+		return index, nil
+	}
+
+	if stored.Documents != nil {
+		index.Documents = stored.Documents
+	}
+	return index, nil
+}
+
+func (v *VectorIndex) Upsert(documents []VectorDocument) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	for _, document := range documents {
+		if document.Id == "" {
+			return errors.New("document id is empty")
+		}
+		if len(document.Vector) == 0 {
+			return fmt.Errorf("document %s has no vector", document.Id)
+		}
+		v.Documents[document.Id] = document
+	}
+
+	return v.save()
+}
+
+func (v *VectorIndex) Delete(ids []string) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	for _, id := range ids {
+		delete(v.Documents, id)
+	}
+
+	return v.save()
+}
+
+func (v *VectorIndex) Len() int {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return len(v.Documents)
+}
+
+// Search returns the most similar documents to the vector, ordered by score desc
+func (v *VectorIndex) Search(vector []float32, limit int) []VectorSearchResult {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	var results []VectorSearchResult
+	for _, document := range v.Documents {
+		if len(document.Vector) != len(vector) {
+			continue
+		}
+		results = append(results, VectorSearchResult{
+			Id:       document.Id,
+			Text:     document.Text,
+			Metadata: document.Metadata,
+			Score:    cosineSimilarity(vector, document.Vector),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Id < results[j].Id
+		}
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+func (v *VectorIndex) save() error {
+	data, marshalErr := json.Marshal(v)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	if mkdirErr := os.MkdirAll(filepath.Dir(v.path), os.ModePerm); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	// write to temp file first, so the index won't be corrupted if wox exits while saving
+	tempPath := v.path + ".tmp"
+	if writeErr := os.WriteFile(tempPath, data, 0644); writeErr != nil {
+		return writeErr
+	}
+	return os.Rename(tempPath, v.path)
+}
+
+func cosineSimilarity(a []float32, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVectorIndexSearch(t *testing.T) {
+	model := Model{Name: "text-embedding-3-small", Provider: ProviderNameOpenAI}
+	index, err := LoadVectorIndex(context.Background(), filepath.Join(t.TempDir(), "index.json"), model)
+	assert.Nil(t, err)
+
+	assert.Nil(t, index.Upsert([]VectorDocument{
+		{Id: "a", Text: "apple", Vector: []float32{1, 0}},
+		{Id: "b", Text: "banana", Vector: []float32{0, 1}},
+		{Id: "c", Text: "cherry", Vector: []float32{1, 1}},
+	}))
+	assert.NotNil(t, index.Upsert([]VectorDocument{{Id: "d", Text: "no vector"}}))
+
+	results := index.Search([]float32{1, 0.1}, 2)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "a", results[0].Id)
+	assert.Equal(t, "c", results[1].Id)
+	assert.Greater(t, results[0].Score, results[1].Score)
+
+	// upsert replaces document with same id
+	assert.Nil(t, index.Upsert([]VectorDocument{{Id: "a", Text: "avocado", Vector: []float32{-1, 0}}}))
+	assert.Equal(t, 3, index.Len())
+	assert.Equal(t, "c", index.Search([]float32{1, 0}, 1)[0].Id)
+
+	assert.Nil(t, index.Delete([]string{"a", "b"}))
+	assert.Equal(t, 1, index.Len())
+}
+
+func TestVectorIndexPersistence(t *testing.T) {
+	model := Model{Name: "nomic-embed-text", Provider: ProviderNameOllama}
+	indexPath := filepath.Join(t.TempDir(), "plugin", "index.json")
+
+	index, err := LoadVectorIndex(context.Background(), indexPath, model)
+	assert.Nil(t, err)
+	assert.Nil(t, index.Upsert([]VectorDocument{{Id: "a", Text: "apple", Metadata: map[string]string{"source": "note"}, Vector: []float32{1, 0}}}))
+
+	reloaded, err := LoadVectorIndex(context.Background(), indexPath, model)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, reloaded.Len())
+	results := reloaded.Search([]float32{1, 0}, 10)
+	assert.Equal(t, "note", results[0].Metadata["source"])
+	assert.InDelta(t, 1.0, results[0].Score, 0.0001)
+
+	// vectors of another model can't be compared, index is reset
+	otherModel := Model{Name: "mxbai-embed-large", Provider: ProviderNameOllama}
+	reset, err := LoadVectorIndex(context.Background(), indexPath, otherModel)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, reset.Len())
+	_, statErr := os.Stat(indexPath + ".bak")
+	assert.Nil(t, statErr)
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"wox/plugin"
+	"wox/share"
+	"wox/ui/dto"
+
+	"github.com/samber/lo"
+)
+
+const usage = `Usage: wox <command> [arguments]
+
+Control the running Wox instance from shell scripts and editors.
+
+Commands:
+  query [--json] <text>                   run query and print its results, default actions are marked with *
+  results [--json]                        print results of the latest query
+  action <result> [action]                execute action of a result in the latest query,
+                                          result is its index (starting from 1) or id, action is its name or id,
+                                          the default action is executed if action is omitted
+  toggle                                  show or hide Wox
+  setting [--plugin <id>] <key> <value>   change a Wox setting, or a plugin setting with --plugin
+`
+
+type command struct {
+	name string
+	run  func(ctx context.Context, client *Client, args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{name: "query", run: runQuery},
+	{name: "results", run: runResults},
+	{name: "action", run: runAction},
+	{name: "toggle", run: runToggle},
+	{name: "setting", run: runSetting},
+}
+
+// IsCommand returns true if wox is started as cli, E.g. wox query "calculator 1+2"
+func IsCommand(arg string) bool {
+	return arg == "help" || lo.ContainsBy(commands, func(item command) bool { return item.name == arg })
+}
+
+// Run runs cli command against the running wox instance, and returns exit code
+func Run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	cmd, found := lo.Find(commands, func(item command) bool { return item.name == args[0] })
+	if !found {
+		fmt.Fprintf(stderr, "unknown command: %s\n\n%s", args[0], usage)
+		return 2
+	}
+
+	client, clientErr := NewClient()
+	if clientErr != nil {
+		fmt.Fprintln(stderr, clientErr.Error())
+		return 1
+	}
+
+	return runCommand(ctx, cmd, client, args[1:], stdout, stderr)
+}
+
+func runCommand(ctx context.Context, cmd command, client *Client, args []string, stdout io.Writer, stderr io.Writer) int {
+	if runErr := cmd.run(ctx, client, args, stdout); runErr != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", cmd.name, runErr.Error())
+		return 1
+	}
+	return 0
+}
+
+func runQuery(ctx context.Context, client *Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	asJson := flags.Bool("json", false, "print results in json")
+	if parseErr := flags.Parse(args); parseErr != nil {
+		return parseErr
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("query text is empty")
+	}
+
+	results, queryErr := client.Query(ctx, share.PlainQuery{
+		QueryType: plugin.QueryTypeInput,
+		QueryText: strings.Join(flags.Args(), " "),
+	})
+	if queryErr != nil {
+		return queryErr
+	}
+
+	return printResults(stdout, results, *asJson)
+}
+
+func runResults(ctx context.Context, client *Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("results", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	asJson := flags.Bool("json", false, "print results in json")
+	if parseErr := flags.Parse(args); parseErr != nil {
+		return parseErr
+	}
+
+	results, resultsErr := client.Results(ctx)
+	if resultsErr != nil {
+		return resultsErr
+	}
+
+	return printResults(stdout, results, *asJson)
+}
+
+func runAction(ctx context.Context, client *Client, args []string, stdout io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: wox action <result> [action]")
+	}
+
+	resultId := args[0]
+	// result index is shown by query and results commands
+	if index, atoiErr := strconv.Atoi(args[0]); atoiErr == nil {
+		results, resultsErr := client.Results(ctx)
+		if resultsErr != nil {
+			return resultsErr
+		}
+		if index < 1 || index > len(results) {
+			return fmt.Errorf("result index out of range: %d, there are %d results", index, len(results))
+		}
+		resultId = results[index-1].Id
+	}
+
+	var action string
+	if len(args) == 2 {
+		action = args[1]
+	}
+	return client.ExecuteAction(ctx, resultId, action)
+}
+
+func runToggle(ctx context.Context, client *Client, args []string, stdout io.Writer) error {
+	return client.Toggle(ctx)
+}
+
+func runSetting(ctx context.Context, client *Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("setting", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	pluginId := flags.String("plugin", "", "id of plugin")
+	if parseErr := flags.Parse(args); parseErr != nil {
+		return parseErr
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: wox setting [--plugin <id>] <key> <value>")
+	}
+
+	return client.ChangeSetting(ctx, *pluginId, flags.Arg(0), flags.Arg(1))
+}
+
+func printResults(stdout io.Writer, results []dto.ControlResultDto, asJson bool) error {
+	if asJson {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	writer := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for i, result := range results {
+		actionNames := lo.Map(result.Actions, func(action dto.ControlActionDto, _ int) string {
+			if action.IsDefault {
+				return action.Name + "*"
+			}
+			return action.Name
+		})
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", i+1, result.Title, result.SubTitle, strings.Join(actionNames, ", "))
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wox/ui/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	results := []dto.ControlResultDto{
+		{Id: "r1", Title: "7", SubTitle: "1+2*3", Actions: []dto.ControlActionDto{{Id: "a1", Name: "Copy result", IsDefault: true}, {Id: "a2", Name: "Add to favorite"}}},
+		{Id: "r2", Title: "8"},
+	}
+	var requests []string
+	var requestBodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.URL.Path)
+		requestBodies = append(requestBodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer token" {
+			json.NewEncoder(w).Encode(map[string]any{"Success": false, "Message": "session token is invalid"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Success": true, "Data": results})
+	}))
+	defer server.Close()
+
+	run := func(client *Client, args ...string) (int, string, string) {
+		cmd := commands[0]
+		for _, c := range commands {
+			if c.name == args[0] {
+				cmd = c
+			}
+		}
+		var stdout, stderr bytes.Buffer
+		code := runCommand(context.Background(), cmd, client, args[1:], &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+	client := newClient(server.URL, "token")
+
+	code, stdout, _ := run(client, "query", "1+2*3")
+	assert.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	assert.Equal(t, "1  7  1+2*3  Copy result*, Add to favorite", lines[0])
+	assert.Equal(t, "2  8", strings.TrimSpace(lines[1]))
+	assert.JSONEq(t, `{"query":{"QueryType":"input","QueryText":"1+2*3","QuerySelection":{"Type":"","Text":"","FilePaths":null}}}`, requestBodies[0])
+
+	code, stdout, _ = run(client, "results", "--json")
+	assert.Equal(t, 0, code)
+	var printed []dto.ControlResultDto
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &printed))
+	assert.Equal(t, results, printed)
+
+	// result index is resolved by results of the latest query
+	requests = nil
+	requestBodies = nil
+	code, _, _ = run(client, "action", "1", "add to favorite")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"/control/results", "/control/action"}, requests)
+	assert.JSONEq(t, `{"resultId":"r1","action":"add to favorite"}`, requestBodies[1])
+
+	code, _, stderr := run(client, "action", "3")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "action: result index out of range: 3, there are 2 results\n", stderr)
+
+	requestBodies = nil
+	code, _, _ = run(client, "setting", "--plugin", "calculator", "Key", "Value")
+	assert.Equal(t, 0, code)
+	assert.JSONEq(t, `{"PluginId":"calculator","Key":"Key","Value":"Value"}`, requestBodies[0])
+
+	code, _, stderr = run(newClient(server.URL, "wrong"), "toggle")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "toggle: session token is invalid\n", stderr)
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"wox/share"
+	"wox/ui"
+	"wox/ui/dto"
+	"wox/util"
+)
+
+// Client calls control api of the running wox instance, see docs/control_api.md
+type Client struct {
+	baseUrl    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient finds the running wox instance by its lock file and reads token of its session
+func NewClient() (*Client, error) {
+	portData, readErr := os.ReadFile(util.GetLocation().GetAppLockPath())
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read lock file, is wox running? %w", readErr)
+	}
+	port, parseErr := strconv.Atoi(strings.TrimSpace(string(portData)))
+	if parseErr != nil {
+		return nil, fmt.Errorf("invalid port in lock file: %w", parseErr)
+	}
+
+	token, tokenErr := ui.ReadSessionToken()
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	return newClient(fmt.Sprintf("http://localhost:%d", port), token), nil
+}
+
+func newClient(baseUrl string, token string) *Client {
+	return &Client{
+		baseUrl: baseUrl,
+		token:   token,
+		// query waits until all plugins finished
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	return c.call(ctx, http.MethodGet, "/ping", nil, nil)
+}
+
+func (c *Client) Show(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/show", nil, nil)
+}
+
+func (c *Client) Toggle(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/control/toggle", nil, nil)
+}
+
+// Launch forwards launch arguments to the running instance, which shows what they ask for
+func (c *Client) Launch(ctx context.Context, args ui.LaunchArgs) error {
+	return c.call(ctx, http.MethodPost, "/control/launch", args, nil)
+}
+
+// Query runs query and returns its results once every plugin has finished
+func (c *Client) Query(ctx context.Context, query share.PlainQuery) ([]dto.ControlResultDto, error) {
+	var results []dto.ControlResultDto
+	err := c.call(ctx, http.MethodPost, "/control/query", map[string]any{"query": query}, &results)
+	return results, err
+}
+
+// Results returns results of the latest query
+func (c *Client) Results(ctx context.Context) ([]dto.ControlResultDto, error) {
+	var results []dto.ControlResultDto
+	err := c.call(ctx, http.MethodGet, "/control/results", nil, &results)
+	return results, err
+}
+
+// ExecuteAction executes action of a result in the latest query by action id or name, default action is executed if action is empty
+func (c *Client) ExecuteAction(ctx context.Context, resultId string, action string) error {
+	return c.call(ctx, http.MethodPost, "/control/action", map[string]string{"resultId": resultId, "action": action}, nil)
+}
+
+// ChangeSetting changes a wox setting, or a plugin setting if pluginId is not empty
+func (c *Client) ChangeSetting(ctx context.Context, pluginId string, key string, value string) error {
+	return c.call(ctx, http.MethodPost, "/control/setting", map[string]string{"PluginId": pluginId, "Key": key, "Value": value}, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, path string, body any, data any) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyData, marshalErr := json.Marshal(body)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		bodyReader = bytes.NewReader(bodyData)
+	}
+
+	request, requestErr := http.NewRequestWithContext(ctx, method, c.baseUrl+path, bodyReader)
+	if requestErr != nil {
+		return requestErr
+	}
+	request.Header.Set("Content-Type", "application/json")
+	ui.SetSessionTokenHeader(request, c.token)
+
+	response, responseErr := c.httpClient.Do(request)
+	if responseErr != nil {
+		return responseErr
+	}
+	defer response.Body.Close()
+
+	var restResponse struct {
+		Success bool
+		Message string
+		Data    json.RawMessage
+	}
+	if decodeErr := json.NewDecoder(response.Body).Decode(&restResponse); decodeErr != nil {
+		return fmt.Errorf("invalid response from wox (%s): %w", response.Status, decodeErr)
+	}
+	if !restResponse.Success {
+		return errors.New(restResponse.Message)
+	}
+
+	if data != nil {
+		return json.Unmarshal(restResponse.Data, data)
+	}
+	return nil
+}
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"os"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
+	"wox/cli"
 	"wox/i18n"
 	"wox/plugin"
 	"wox/resource"
@@ -40,6 +40,11 @@ func main() {
 		panic(locationErr)
 	}
 
+	// wox query "..." etc. controls the running instance instead of starting a new one
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	defer util.GoRecover(context.Background(), "main panic", func(err error) {
 		util.GetLogger().Error(context.Background(), fmt.Sprintf("main panic: %s", err.Error()))
 	})
@@ -51,8 +56,17 @@ func main() {
 	util.GetLogger().Info(ctx, fmt.Sprintf("wox data location: %s", util.GetLocation().GetWoxDataDirectory()))
 	util.GetLogger().Info(ctx, fmt.Sprintf("user data location: %s", util.GetLocation().GetUserDataDirectory()))
 
+	workingDirectory, _ := os.Getwd()
+	args := ui.ParseLaunchArgs(os.Args[1:], workingDirectory)
+	if args.Headless {
+		util.GetLogger().Info(ctx, "running in headless mode")
+		ui.GetUIManager().EnableHeadless()
+	}
+
 	serverPort := 34987
-	if util.IsProd() {
+	if args.Port > 0 {
+		serverPort = args.Port
+	} else if util.IsProd() {
 		availablePort, portErr := util.GetAvailableTcpPort(ctx)
 		if portErr != nil {
 			util.GetLogger().Error(ctx, fmt.Sprintf("failed to get server port: %s", portErr.Error()))
@@ -64,9 +78,24 @@ func main() {
 	ui.GetUIManager().UpdateServerPort(serverPort)
 
 	// check if there is existing instance running
-	if existingPort := getExistingInstancePort(ctx); existingPort > 0 {
-		util.GetLogger().Error(ctx, fmt.Sprintf("there is existing instance running, port: %d", existingPort))
-		_, postShowErr := util.HttpPost(ctx, fmt.Sprintf("http://localhost:%d/show", existingPort), "")
+	if existingInstance := getExistingInstance(ctx); existingInstance != nil {
+		util.GetLogger().Error(ctx, "there is existing instance running")
+		if args.Headless {
+			// don't show ui of existing instance, tests would run against it otherwise
+			os.Exit(1)
+		}
+		if args.HasContent() {
+			// E.g. wox --query "wpm install", the running instance shows what arguments ask for
+			launchErr := existingInstance.Launch(ctx, args)
+			if launchErr != nil {
+				util.GetLogger().Error(ctx, fmt.Sprintf("failed to forward arguments to existing instance: %s", launchErr.Error()))
+				fmt.Fprintln(os.Stderr, launchErr.Error())
+				os.Exit(1)
+			}
+			util.GetLogger().Info(ctx, "forwarded arguments to existing instance successfully, bye~")
+			return
+		}
+		postShowErr := existingInstance.Show(ctx)
 		if postShowErr != nil {
 			util.GetLogger().Error(ctx, fmt.Sprintf("failed to show existing instance: %s", postShowErr.Error()))
 		} else {
@@ -79,9 +108,19 @@ func main() {
 		if writeErr != nil {
 			util.GetLogger().Error(ctx, fmt.Sprintf("failed to write lock file: %s", writeErr.Error()))
 		}
+		tokenErr := ui.GetUIManager().InitSessionToken(ctx)
+		if tokenErr != nil {
+			util.GetLogger().Error(ctx, fmt.Sprintf("failed to initialize session token: %s", tokenErr.Error()))
+			return
+		}
 	}
 
-	extractErr := resource.Extract(ctx)
+	var extractErr error
+	if args.Headless {
+		extractErr = resource.ExtractWithoutUIApp(ctx)
+	} else {
+		extractErr = resource.Extract(ctx)
+	}
 	if extractErr != nil {
 		util.GetLogger().Error(ctx, fmt.Sprintf("failed to extract embed file: %s", extractErr.Error()))
 		return
@@ -106,13 +145,25 @@ func main() {
 		return
 	}
 
-	if woxSetting.ShowTray {
+	if woxSetting.ShowTray && !args.Headless {
 		ui.GetUIManager().ShowTray()
 	}
 
 	shareUI := ui.GetUIManager().GetUI(ctx)
 	plugin.GetPluginManager().Start(ctx, shareUI)
 
+	if args.Headless {
+		if args.HasContent() {
+			if launchErr := ui.GetUIManager().PostLaunch(ctx, args); launchErr != nil {
+				util.GetLogger().Error(ctx, fmt.Sprintf("failed to handle launch arguments: %s", launchErr.Error()))
+			}
+		}
+		// no ui app, tray and hotkeys, queries and actions come in through http, see docs/control_api.md
+		ui.GetUIManager().StartWebsocketAndWait(ctx)
+		return
+	}
+	ui.GetUIManager().SetStartupArgs(args)
+
 	util.InitSelection()
 
 	// hotkey must be registered in main thread
@@ -147,33 +198,17 @@ func main() {
 	})
 }
 
-// retrieves the instance port from the existing instance lock file.
-// It returns 0 if the lock file doesn't exist or fails to read the file.
-func getExistingInstancePort(ctx context.Context) int {
-	filePath := util.GetLocation().GetAppLockPath()
-	if !util.IsFileExists(filePath) {
-		return 0
-	}
-
-	file, err := os.ReadFile(filePath)
-	if err != nil {
-		return 0
-	}
-
-	port, err := strconv.Atoi(string(file))
-	if err != nil {
-		return 0
-	}
-
-	//check if the port is valid
-	response, err := util.HttpGet(ctx, fmt.Sprintf("http://localhost:%d/ping", port))
-	if err != nil {
-		return 0
+// returns client of the existing instance found by lock file and session token file,
+// nil if there is no existing instance or it's not responding
+func getExistingInstance(ctx context.Context) *cli.Client {
+	client, clientErr := cli.NewClient()
+	if clientErr != nil {
+		return nil
 	}
 
-	if !strings.Contains(string(response), "pong") {
-		return 0
+	if pingErr := client.Ping(ctx); pingErr != nil {
+		return nil
 	}
 
-	return port
+	return client
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strings"
 	"wox/ai"
 	"wox/i18n"
 	"wox/setting"
@@ -40,6 +41,9 @@ type API interface {
 	OnUnload(ctx context.Context, callback func())
 	RegisterQueryCommands(ctx context.Context, commands []MetadataCommand)
 	AIChatStream(ctx context.Context, model ai.Model, conversations []ai.Conversation, callback ai.ChatStreamFunc) error
+	AIIndexDocuments(ctx context.Context, index string, model ai.Model, documents []ai.VectorDocument) error
+	AIRemoveDocuments(ctx context.Context, index string, model ai.Model, ids []string) error
+	AIQueryDocuments(ctx context.Context, index string, model ai.Model, query string, limit int) ([]ai.VectorSearchResult, error)
 }
 
 type APIImpl struct {
@@ -180,11 +184,6 @@ func (a *APIImpl) AIChatStream(ctx context.Context, model ai.Model, conversation
 		return fmt.Errorf("plugin has no access to ai feature")
 	}
 
-	provider, providerErr := GetPluginManager().GetAIProvider(ctx, model.Provider)
-	if providerErr != nil {
-		return providerErr
-	}
-
 	// resize images in the conversation
 	for i, conversation := range conversations {
 		for j, image := range conversation.Images {
@@ -198,36 +197,102 @@ func (a *APIImpl) AIChatStream(ctx context.Context, model ai.Model, conversation
 		}
 	}
 
-	stream, err := provider.ChatStream(ctx, model, conversations)
+	// make sure conversations fit into the context window of the model, otherwise provider will return opaque errors
+	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
+	contextWindow := ai.GetContextWindow(model, woxSetting.AIModelSettings)
+	promptTokenLimit := ai.GetPromptTokenLimit(contextWindow)
+	originalTokens := ai.EstimateConversationTokens(model, conversations)
+	fittedConversations, fitErr := ai.FitConversations(model, conversations, promptTokenLimit, woxSetting.AIContextStrategy)
+	if fitErr != nil {
+		return fitErr
+	}
+	promptTokens := ai.EstimateConversationTokens(model, fittedConversations)
+	if promptTokens != originalTokens {
+		a.Log(ctx, LogLevelInfo, fmt.Sprintf("conversations exceed the context window of %s (%d > %d tokens), fitted with %s strategy: %d -> %d conversations, %d tokens", model.Name, originalTokens, promptTokenLimit, woxSetting.AIContextStrategy, len(conversations), len(fittedConversations), promptTokens))
+	}
+
+	stream, err := GetPluginManager().GetAIRouter(ctx).ChatStream(ctx, model, fittedConversations)
 	if err != nil {
 		return err
 	}
 
-	if callback != nil {
-		util.Go(ctx, "ai chat stream", func() {
-			for {
-				util.GetLogger().Info(ctx, fmt.Sprintf("reading chat stream"))
-				response, streamErr := stream.Receive(ctx)
-				if errors.Is(streamErr, io.EOF) {
-					util.GetLogger().Info(ctx, "read stream completed")
+	util.Go(ctx, "ai chat stream", func() {
+		var completion strings.Builder
+		defer func() {
+			// usage belongs to the model which actually answered, cached answers cost nothing
+			servedModel, isCached := model, false
+			if routedStream, ok := stream.(*ai.RoutedStream); ok {
+				servedModel, isCached = routedStream.ServedModel()
+			}
+			if !isCached {
+				setting.GetSettingManager().AddAIUsage(ctx, string(servedModel.Provider), promptTokens, ai.EstimateTokens(servedModel, completion.String()))
+			}
+		}()
+
+		for {
+			util.GetLogger().Info(ctx, fmt.Sprintf("reading chat stream"))
+			response, streamErr := stream.Receive(ctx)
+			if errors.Is(streamErr, io.EOF) {
+				util.GetLogger().Info(ctx, "read stream completed")
+				if callback != nil {
 					callback(ai.ChatStreamTypeFinished, "")
-					return
 				}
+				return
+			}
+
+			if errors.Is(streamErr, ai.ErrChatStreamRestarted) {
+				util.GetLogger().Info(ctx, "chat stream restarted with a different answer")
+				completion.Reset()
+				if callback != nil {
+					callback(ai.ChatStreamTypeRestarted, "")
+				}
+				continue
+			}
 
-				if streamErr != nil {
-					util.GetLogger().Info(ctx, fmt.Sprintf("failed to read stream: %s", streamErr.Error()))
+			if streamErr != nil {
+				util.GetLogger().Info(ctx, fmt.Sprintf("failed to read stream: %s", streamErr.Error()))
+				if callback != nil {
 					callback(ai.ChatStreamTypeError, streamErr.Error())
-					return
 				}
+				return
+			}
 
+			completion.WriteString(response)
+			if callback != nil {
 				callback(ai.ChatStreamTypeStreaming, response)
 			}
-		})
-	}
+		}
+	})
 
 	return nil
 }
 
+// AIIndexDocuments embeds documents with given model and adds them into the vector index of the plugin, documents with same id will be replaced
+func (a *APIImpl) AIIndexDocuments(ctx context.Context, index string, model ai.Model, documents []ai.VectorDocument) error {
+	if !a.pluginInstance.Metadata.IsSupportFeature(MetadataFeatureAI) {
+		return fmt.Errorf("plugin has no access to ai feature")
+	}
+
+	return GetPluginManager().IndexDocuments(ctx, a.pluginInstance.Metadata.Id, index, model, documents)
+}
+
+func (a *APIImpl) AIRemoveDocuments(ctx context.Context, index string, model ai.Model, ids []string) error {
+	if !a.pluginInstance.Metadata.IsSupportFeature(MetadataFeatureAI) {
+		return fmt.Errorf("plugin has no access to ai feature")
+	}
+
+	return GetPluginManager().RemoveDocuments(ctx, a.pluginInstance.Metadata.Id, index, model, ids)
+}
+
+// AIQueryDocuments returns the most similar documents to the query in the vector index of the plugin
+func (a *APIImpl) AIQueryDocuments(ctx context.Context, index string, model ai.Model, query string, limit int) ([]ai.VectorSearchResult, error) {
+	if !a.pluginInstance.Metadata.IsSupportFeature(MetadataFeatureAI) {
+		return nil, fmt.Errorf("plugin has no access to ai feature")
+	}
+
+	return GetPluginManager().QueryDocuments(ctx, a.pluginInstance.Metadata.Id, index, model, query, limit)
+}
+
 func NewAPI(instance *Instance) API {
 	apiImpl := &APIImpl{pluginInstance: instance}
 	logFolder := path.Join(util.GetLocation().GetLogPluginDirectory(), instance.Metadata.Name)
@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"wox/updater"
+	"wox/util"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/samber/lo"
+)
+
+// CheckCompatibility returns error if plugin requires a newer wox, doesn't support current os or its runtime is not available.
+// Empty supportedOS means unknown (e.g. old store manifests), it's not treated as incompatible
+func CheckCompatibility(ctx context.Context, minWoxVersion string, supportedOS []string, runtime string) error {
+	if minWoxVersion != "" {
+		requiredVersion, requiredErr := semver.NewVersion(minWoxVersion)
+		if requiredErr != nil {
+			return fmt.Errorf("invalid min wox version %s: %w", minWoxVersion, requiredErr)
+		}
+		currentVersion, currentErr := semver.NewVersion(updater.CURRENT_VERSION)
+		if currentErr == nil && currentVersion.LessThan(requiredVersion) {
+			return fmt.Errorf("requires wox %s or later, current version is %s", requiredVersion, currentVersion)
+		}
+	}
+
+	if len(supportedOS) > 0 && !IsSupportedOSAny(supportedOS) {
+		return fmt.Errorf("supports %s only, current os is %s", strings.Join(supportedOS, ", "), util.GetCurrentPlatform())
+	}
+
+	if runtime != "" {
+		_, runtimeErr := getRuntimeInfo(ctx, runtime)
+		if runtimeErr != nil {
+			return runtimeErr
+		}
+	}
+
+	return nil
+}
+
+// CheckMetadataCompatibility checks compatibility of a plugin parsed from plugin.json, including runtime version required by plugin
+func CheckMetadataCompatibility(ctx context.Context, metadata Metadata) error {
+	if compatibleErr := CheckCompatibility(ctx, metadata.MinWoxVersion, metadata.SupportedOS, metadata.Runtime); compatibleErr != nil {
+		return compatibleErr
+	}
+
+	info, runtimeErr := getRuntimeInfo(ctx, metadata.Runtime)
+	if runtimeErr != nil {
+		return runtimeErr
+	}
+	if info != nil {
+		return CheckRuntimeVersion(metadata, *info)
+	}
+
+	return nil
+}
+
+// getRuntimeInfo returns error if there is no host for the runtime or no usable interpreter is found.
+// Info is nil if host of the runtime doesn't need an interpreter
+func getRuntimeInfo(ctx context.Context, runtime string) (*RuntimeInfo, error) {
+	host, exist := lo.Find(AllHosts, func(item Host) bool {
+		return item.GetRuntime(ctx) == ConvertToRuntime(runtime)
+	})
+	if !exist {
+		return nil, fmt.Errorf("unsupported runtime: %s", runtime)
+	}
+
+	discoverer, ok := host.(RuntimeDiscoverer)
+	if !ok {
+		return nil, nil
+	}
+	info := discoverer.GetRuntimeInfo(ctx, false)
+	if info.Path == "" {
+		return nil, fmt.Errorf("no usable %s interpreter is found, run doctor for details", strings.ToLower(runtime))
+	}
+	return &info, nil
+}
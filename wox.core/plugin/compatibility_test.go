@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"wox/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRuntimeHost struct {
+	info RuntimeInfo
+}
+
+func (h *testRuntimeHost) GetRuntime(ctx context.Context) Runtime { return PLUGIN_RUNTIME_PYTHON }
+func (h *testRuntimeHost) Start(ctx context.Context) error        { return nil }
+func (h *testRuntimeHost) Stop(ctx context.Context)               {}
+func (h *testRuntimeHost) IsStarted(ctx context.Context) bool     { return true }
+func (h *testRuntimeHost) LoadPlugin(ctx context.Context, metadata Metadata, pluginDirectory string) (Plugin, error) {
+	return nil, nil
+}
+func (h *testRuntimeHost) UnloadPlugin(ctx context.Context, metadata Metadata) {}
+func (h *testRuntimeHost) GetRuntimeInfo(ctx context.Context, refresh bool) RuntimeInfo {
+	return h.info
+}
+
+func withTestRuntimeHost(t *testing.T, info RuntimeInfo) {
+	oldHosts := AllHosts
+	AllHosts = []Host{&testRuntimeHost{info: info}}
+	t.Cleanup(func() {
+		AllHosts = oldHosts
+	})
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	ctx := context.Background()
+	currentOS := []string{util.GetCurrentPlatform()}
+	otherOS := []string{"Windows"}
+	if util.IsWindows() {
+		otherOS = []string{"Linux"}
+	}
+
+	assert.NoError(t, CheckCompatibility(ctx, "", nil, ""))
+	assert.NoError(t, CheckCompatibility(ctx, "2.0.0", currentOS, ""))
+	assert.ErrorContains(t, CheckCompatibility(ctx, "99.0.0", currentOS, ""), "requires wox 99.0.0 or later")
+	assert.ErrorContains(t, CheckCompatibility(ctx, "latest", currentOS, ""), "invalid min wox version")
+	assert.ErrorContains(t, CheckCompatibility(ctx, "", otherOS, ""), "supports "+otherOS[0]+" only")
+
+	withTestRuntimeHost(t, RuntimeInfo{})
+	assert.ErrorContains(t, CheckCompatibility(ctx, "", nil, "nodejs"), "unsupported runtime")
+	assert.ErrorContains(t, CheckCompatibility(ctx, "", nil, "python"), "no usable python")
+}
+
+func TestCheckMetadataCompatibility(t *testing.T) {
+	ctx := context.Background()
+	withTestRuntimeHost(t, RuntimeInfo{Path: "/usr/bin/python3", Version: "3.11.2"})
+
+	metadata := Metadata{Runtime: "python", MinWoxVersion: "2.0.0", SupportedOS: []string{util.GetCurrentPlatform()}}
+	assert.NoError(t, CheckMetadataCompatibility(ctx, metadata))
+
+	metadata.RuntimeVersion = ">=3.12"
+	assert.ErrorContains(t, CheckMetadataCompatibility(ctx, metadata), "requires python >=3.12")
+}
+
+func TestStorePluginManifestVersions(t *testing.T) {
+	ctx := context.Background()
+	withTestRuntimeHost(t, RuntimeInfo{Path: "/usr/bin/python3", Version: "3.11.2"})
+
+	manifest := StorePluginManifest{
+		Name:          "demo",
+		Version:       "3.0.0",
+		MinWoxVersion: "99.0.0",
+		Runtime:       PLUGIN_RUNTIME_PYTHON,
+		DownloadUrl:   "https://example.com/demo-3.0.0.wox",
+		Versions: []StorePluginVersion{
+			{Version: "1.0.0", MinWoxVersion: "2.0.0", DownloadUrl: "https://example.com/demo-1.0.0.wox"},
+			{Version: "2.0.0", MinWoxVersion: "2.0.0", DownloadUrl: "https://example.com/demo-2.0.0.wox"},
+		},
+	}
+
+	compatibleVersions := manifest.GetCompatibleVersions(ctx)
+	assert.Len(t, compatibleVersions, 2)
+	assert.Equal(t, "2.0.0", compatibleVersions[0].Version)
+	assert.Equal(t, "https://example.com/demo-2.0.0.wox", compatibleVersions[0].DownloadUrl)
+	assert.Equal(t, "1.0.0", compatibleVersions[1].Version)
+
+	installManifest, installErr := manifest.GetInstallVersion(ctx, "")
+	assert.NoError(t, installErr)
+	assert.Equal(t, "2.0.0", installManifest.Version)
+
+	installManifest, installErr = manifest.GetInstallVersion(ctx, "1.0.0")
+	assert.NoError(t, installErr)
+	assert.Equal(t, "https://example.com/demo-1.0.0.wox", installManifest.DownloadUrl)
+
+	_, installErr = manifest.GetInstallVersion(ctx, "0.1.0")
+	assert.ErrorContains(t, installErr, "version 0.1.0 of plugin demo not found")
+
+	manifest.Versions = nil
+	_, installErr = manifest.GetInstallVersion(ctx, "")
+	assert.ErrorContains(t, installErr, "requires wox 99.0.0 or later")
+}
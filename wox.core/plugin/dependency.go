@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// PluginVirtualEnvDirectory is the python virtualenv created in plugin directory for plugins with dependencies
+	PluginVirtualEnvDirectory = ".venv"
+
+	dependencyInstallTimeout = 10 * time.Minute
+)
+
+var pyprojectDependenciesRegex = regexp.MustCompile(`(?s)\ndependencies\s*=\s*\[(.*?)\]`)
+var pyprojectTableRegex = regexp.MustCompile(`\n\[`)
+var pyprojectStringRegex = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+// GetPluginVirtualEnvPython returns python interpreter in virtualenv of plugin, empty if plugin has no virtualenv
+func GetPluginVirtualEnvPython(pluginDirectory string) string {
+	pythonPath := filepath.Join(pluginDirectory, PluginVirtualEnvDirectory, "bin", "python")
+	if util.IsWindows() {
+		pythonPath = filepath.Join(pluginDirectory, PluginVirtualEnvDirectory, "Scripts", "python.exe")
+	}
+	if !util.IsFileExists(pythonPath) {
+		return ""
+	}
+	return pythonPath
+}
+
+// InstallDependencies installs third-party packages declared by python (requirements.txt, pyproject.toml) and nodejs (package.json) plugins
+// into plugin directory, so plugins don't need to vendor them. Python packages are installed into a virtualenv, nodejs packages into node_modules
+func InstallDependencies(ctx context.Context, metadata Metadata, pluginDirectory string) error {
+	switch ConvertToRuntime(metadata.Runtime) {
+	case PLUGIN_RUNTIME_PYTHON:
+		return installPythonDependencies(ctx, metadata, pluginDirectory)
+	case PLUGIN_RUNTIME_NODEJS:
+		return installNodejsDependencies(ctx, metadata, pluginDirectory)
+	}
+	return nil
+}
+
+func installPythonDependencies(ctx context.Context, metadata Metadata, pluginDirectory string) error {
+	var installArgs []string
+	if util.IsFileExists(filepath.Join(pluginDirectory, "requirements.txt")) {
+		installArgs = []string{"-r", "requirements.txt"}
+	} else if pyproject, readErr := os.ReadFile(filepath.Join(pluginDirectory, "pyproject.toml")); readErr == nil {
+		installArgs = parsePyprojectDependencies(string(pyproject))
+	}
+	if len(installArgs) == 0 {
+		return nil
+	}
+
+	pythonPath, pythonErr := getRuntimeInterpreter(ctx, PLUGIN_RUNTIME_PYTHON)
+	if pythonErr != nil {
+		return pythonErr
+	}
+
+	logger.Info(ctx, fmt.Sprintf("start to install python dependencies of plugin %s", metadata.Name))
+	if _, venvErr := runDependencyCommand(ctx, pluginDirectory, nil, pythonPath, "-m", "venv", PluginVirtualEnvDirectory); venvErr != nil {
+		return fmt.Errorf("failed to create virtualenv: %w", venvErr)
+	}
+
+	args := []string{"-m", "pip", "install", "--disable-pip-version-check", "--cache-dir", filepath.Join(util.GetLocation().GetCacheDirectory(), "pip")}
+	if indexUrl := setting.GetSettingManager().GetWoxSetting(ctx).PipIndexUrl; indexUrl != "" {
+		args = append(args, "--index-url", indexUrl)
+	}
+	args = append(args, installArgs...)
+	if _, installErr := runDependencyCommand(ctx, pluginDirectory, nil, GetPluginVirtualEnvPython(pluginDirectory), args...); installErr != nil {
+		return fmt.Errorf("failed to install python dependencies: %w", installErr)
+	}
+
+	logger.Info(ctx, fmt.Sprintf("installed python dependencies of plugin %s", metadata.Name))
+	return nil
+}
+
+func installNodejsDependencies(ctx context.Context, metadata Metadata, pluginDirectory string) error {
+	packageJson, readErr := os.ReadFile(filepath.Join(pluginDirectory, "package.json"))
+	if readErr != nil {
+		return nil
+	}
+	var packageInfo struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if unmarshalErr := json.Unmarshal(packageJson, &packageInfo); unmarshalErr != nil {
+		return fmt.Errorf("failed to parse package.json: %w", unmarshalErr)
+	}
+	// plugins bundled with their dependencies don't need to install again
+	if len(packageInfo.Dependencies) == 0 || util.IsDirExists(filepath.Join(pluginDirectory, "node_modules")) {
+		return nil
+	}
+
+	nodePath, nodeErr := getRuntimeInterpreter(ctx, PLUGIN_RUNTIME_NODEJS)
+	if nodeErr != nil {
+		return nodeErr
+	}
+	npmPath := getNpmPath(nodePath)
+	if npmPath == "" {
+		return fmt.Errorf("npm is not found, it should be installed along with %s", nodePath)
+	}
+
+	logger.Info(ctx, fmt.Sprintf("start to install nodejs dependencies of plugin %s", metadata.Name))
+	command := "install"
+	if util.IsFileExists(filepath.Join(pluginDirectory, "package-lock.json")) {
+		command = "ci"
+	}
+	args := []string{command, "--omit=dev", "--no-audit", "--no-fund", "--cache", filepath.Join(util.GetLocation().GetCacheDirectory(), "npm")}
+	if registry := setting.GetSettingManager().GetWoxSetting(ctx).NpmRegistry; registry != "" {
+		args = append(args, "--registry", registry)
+	}
+	// npm is a node script, the node found by wox may not be in PATH
+	envs := []string{"PATH=" + filepath.Dir(nodePath) + string(os.PathListSeparator) + os.Getenv("PATH")}
+	if _, installErr := runDependencyCommand(ctx, pluginDirectory, envs, npmPath, args...); installErr != nil {
+		return fmt.Errorf("failed to install nodejs dependencies: %w", installErr)
+	}
+
+	logger.Info(ctx, fmt.Sprintf("installed nodejs dependencies of plugin %s", metadata.Name))
+	return nil
+}
+
+// parsePyprojectDependencies returns dependencies in [project] table of pyproject.toml
+func parsePyprojectDependencies(pyproject string) []string {
+	projectStart := strings.Index(pyproject, "[project]")
+	if projectStart == -1 {
+		return nil
+	}
+	project := pyproject[projectStart+len("[project]"):]
+	if nextTable := pyprojectTableRegex.FindStringIndex(project); nextTable != nil {
+		project = project[:nextTable[0]]
+	}
+
+	matches := pyprojectDependenciesRegex.FindStringSubmatch("\n" + project)
+	if matches == nil {
+		return nil
+	}
+
+	var dependencies []string
+	for _, item := range pyprojectStringRegex.FindAllStringSubmatch(matches[1], -1) {
+		dependency := strings.TrimSpace(item[1] + item[2])
+		if dependency != "" {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
+func getRuntimeInterpreter(ctx context.Context, runtime Runtime) (string, error) {
+	host, exist := lo.Find(AllHosts, func(item Host) bool {
+		return item.GetRuntime(ctx) == runtime
+	})
+	if !exist {
+		return "", fmt.Errorf("unsupported runtime: %s", runtime)
+	}
+	discoverer, ok := host.(RuntimeDiscoverer)
+	if !ok {
+		return "", fmt.Errorf("%s host doesn't use an interpreter", runtime)
+	}
+
+	info := discoverer.GetRuntimeInfo(ctx, false)
+	if info.Path == "" {
+		return "", fmt.Errorf("no usable %s interpreter is found: %s", strings.ToLower(string(runtime)), info.GetProblemSummary())
+	}
+	return info.Path, nil
+}
+
+func getNpmPath(nodePath string) string {
+	npmName := "npm"
+	if util.IsWindows() {
+		npmName = "npm.cmd"
+	}
+	if npmPath := filepath.Join(filepath.Dir(nodePath), npmName); util.IsFileExists(npmPath) {
+		return npmPath
+	}
+	if npmPath, lookErr := exec.LookPath(npmName); lookErr == nil {
+		return npmPath
+	}
+	return ""
+}
+
+// runDependencyCommand runs package manager in plugin directory, output is returned in error so user knows why installing failed
+func runDependencyCommand(ctx context.Context, directory string, envs []string, name string, args ...string) ([]byte, error) {
+	logger.Info(ctx, fmt.Sprintf("run %s %s", name, strings.Join(args, " ")))
+	var output bytes.Buffer
+	runErr := util.ShellRunWithTimeout(ctx, dependencyInstallTimeout, func(cmd *exec.Cmd) {
+		cmd.Dir = directory
+		cmd.Env = append(os.Environ(), envs...)
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+	}, name, args...)
+	if errors.Is(runErr, util.ErrShellTimeout) || ctx.Err() != nil {
+		return output.Bytes(), runErr
+	}
+	if runErr != nil {
+		return output.Bytes(), fmt.Errorf("%s: %s", runErr, getOutputTail(output.String(), 10))
+	}
+	return output.Bytes(), nil
+}
+
+func getOutputTail(output string, maxLines int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePyprojectDependencies(t *testing.T) {
+	dependencies := parsePyprojectDependencies(`[build-system]
+requires = ["hatchling"]
+
+[project]
+name = "demo"
+dependencies = [
+    "requests>=2.31",
+    'pyyaml',
+]
+
+[project.optional-dependencies]
+dev = ["pytest"]
+`)
+	assert.Equal(t, []string{"requests>=2.31", "pyyaml"}, dependencies)
+
+	assert.Empty(t, parsePyprojectDependencies("[project]\nname = \"demo\"\n\n[tool.dependencies]\ndependencies = [\"x\"]\n"))
+	assert.Empty(t, parsePyprojectDependencies("[tool.poetry]\nname = \"demo\"\n"))
+}
+
+func TestInstallDependenciesSkipped(t *testing.T) {
+	ctx := context.Background()
+
+	// plugins without dependencies don't need an interpreter
+	pythonDirectory := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(pythonDirectory, "pyproject.toml"), []byte("[project]\nname = \"demo\"\n"), 0644))
+	assert.NoError(t, InstallDependencies(ctx, Metadata{Runtime: "python"}, pythonDirectory))
+	assert.Empty(t, GetPluginVirtualEnvPython(pythonDirectory))
+
+	// bundled node_modules are used as is
+	nodejsDirectory := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(nodejsDirectory, "package.json"), []byte(`{"dependencies": {"dayjs": "^1.11.13"}}`), 0644))
+	assert.NoError(t, os.Mkdir(filepath.Join(nodejsDirectory, "node_modules"), 0755))
+	assert.NoError(t, InstallDependencies(ctx, Metadata{Runtime: "nodejs"}, nodejsDirectory))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(nodejsDirectory, "package.json"), []byte(`{"dependencies": `), 0644))
+	assert.NoError(t, os.RemoveAll(filepath.Join(nodejsDirectory, "node_modules")))
+	assert.ErrorContains(t, InstallDependencies(ctx, Metadata{Runtime: "nodejs"}, nodejsDirectory), "failed to parse package.json")
+}
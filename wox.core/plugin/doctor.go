@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 	"wox/i18n"
+	"wox/share"
 	"wox/updater"
 	"wox/util"
 	"wox/util/permission"
+
+	"github.com/samber/lo"
 )
 
 type DoctorCheckResult struct {
@@ -23,6 +28,9 @@ func RunDoctorChecks(ctx context.Context) []DoctorCheckResult {
 	results := []DoctorCheckResult{
 		checkWoxVersion(ctx),
 	}
+	results = append(results, checkPluginHosts(ctx)...)
+	results = append(results, checkPluginRuntimes(ctx)...)
+	results = append(results, checkPluginMetadata(ctx)...)
 
 	if util.IsMacOS() {
 		results = append(results, checkAccessibilityPermission(ctx))
@@ -94,3 +102,181 @@ func checkAccessibilityPermission(ctx context.Context) DoctorCheckResult {
 		},
 	}
 }
+
+func checkPluginHosts(ctx context.Context) []DoctorCheckResult {
+	var results []DoctorCheckResult
+	for _, host := range AllHosts {
+		supervisor, ok := host.(HostSupervisor)
+		if !ok {
+			continue
+		}
+
+		runtime := host.GetRuntime(ctx)
+		pluginCount := 0
+		for _, instance := range GetPluginManager().GetPluginInstances() {
+			if instance.Host == host {
+				pluginCount++
+			}
+		}
+
+		status := supervisor.GetStatus(ctx)
+		// host without any plugin is not a problem even if it's not started
+		if pluginCount == 0 && status.CrashCount == 0 {
+			continue
+		}
+
+		name := fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_host"), runtime)
+		restartAction := func(ctx context.Context) {
+			restartErr := supervisor.Restart(ctx)
+			if restartErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to restart %s host: %s", runtime, restartErr))
+			}
+		}
+
+		if !status.IsStarted {
+			results = append(results, DoctorCheckResult{
+				Name:        name,
+				Status:      false,
+				Description: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_host_not_running"), pluginCount, getHostErrorSummary(status)),
+				ActionName:  "i18n:plugin_doctor_host_restart",
+				Action:      restartAction,
+			})
+			continue
+		}
+
+		if status.CrashCount > 0 {
+			lastCrash := time.UnixMilli(status.LastCrashTimestamp).Format("2006-01-02 15:04:05")
+			results = append(results, DoctorCheckResult{
+				Name:        name,
+				Status:      false,
+				Description: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_host_crashed"), status.CrashCount, lastCrash, getHostErrorSummary(status)),
+				ActionName:  "i18n:plugin_doctor_host_restart",
+				Action:      restartAction,
+			})
+			continue
+		}
+
+		results = append(results, DoctorCheckResult{
+			Name:        name,
+			Status:      true,
+			Description: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_host_running"), pluginCount),
+			ActionName:  "",
+			Action: func(ctx context.Context) {
+			},
+		})
+	}
+
+	return results
+}
+
+// checkPluginRuntimes explains why interpreters needed by python and nodejs plugins are not available
+func checkPluginRuntimes(ctx context.Context) []DoctorCheckResult {
+	var userPlugins []Metadata
+	if metadataList, parseErr := GetPluginManager().parseUserPluginMetadata(ctx); parseErr == nil {
+		for _, metadata := range metadataList {
+			userPlugins = append(userPlugins, metadata.Metadata)
+		}
+	}
+	for _, instance := range GetPluginManager().GetPluginInstances() {
+		if instance.IsDevPlugin {
+			userPlugins = append(userPlugins, instance.Metadata)
+		}
+	}
+
+	var results []DoctorCheckResult
+	for _, host := range AllHosts {
+		discoverer, ok := host.(RuntimeDiscoverer)
+		if !ok {
+			continue
+		}
+
+		runtime := host.GetRuntime(ctx)
+		plugins := lo.Filter(userPlugins, func(item Metadata, _ int) bool {
+			return ConvertToRuntime(item.Runtime) == runtime
+		})
+		// runtime is not needed if no plugin uses it
+		if len(plugins) == 0 {
+			continue
+		}
+
+		name := fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_runtime"), runtime)
+		openSettingAction := func(ctx context.Context) {
+			GetPluginManager().GetUI().OpenSettingWindow(ctx, share.SettingWindowContext{})
+		}
+
+		info := discoverer.GetRuntimeInfo(ctx, false)
+		if info.Path == "" {
+			results = append(results, DoctorCheckResult{
+				Name:        name,
+				Status:      false,
+				Description: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_runtime_not_found"), info.MinVersion, len(plugins), info.GetProblemSummary()),
+				ActionName:  "i18n:plugin_doctor_runtime_open_settings",
+				Action:      openSettingAction,
+			})
+			continue
+		}
+
+		var mismatchPlugins []string
+		for _, metadata := range plugins {
+			if CheckRuntimeVersion(metadata, info) != nil {
+				mismatchPlugins = append(mismatchPlugins, fmt.Sprintf("%s (%s)", metadata.Name, metadata.RuntimeVersion))
+			}
+		}
+		if len(mismatchPlugins) > 0 {
+			results = append(results, DoctorCheckResult{
+				Name:        name,
+				Status:      false,
+				Description: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_runtime_version_mismatch"), info.Version, info.Path, strings.Join(mismatchPlugins, ", ")),
+				ActionName:  "i18n:plugin_doctor_runtime_open_settings",
+				Action:      openSettingAction,
+			})
+			continue
+		}
+
+		results = append(results, DoctorCheckResult{
+			Name:        name,
+			Status:      true,
+			Description: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_runtime_found"), info.Version, info.Path),
+			ActionName:  "",
+			Action: func(ctx context.Context) {
+			},
+		})
+	}
+
+	return results
+}
+
+// checkPluginMetadata lists plugins which are not loaded because plugin.json is invalid, user plugins are parsed again by checkPluginRuntimes
+func checkPluginMetadata(ctx context.Context) []DoctorCheckResult {
+	var results []DoctorCheckResult
+	for _, loadError := range GetPluginManager().GetLoadErrors() {
+		description := loadError.Message
+		if len(loadError.SchemaErrors) > 0 {
+			description = strings.Join(lo.Map(loadError.SchemaErrors, func(schemaErr MetadataSchemaError, _ int) string {
+				return fmt.Sprintf("plugin.json:%s", schemaErr.String())
+			}), " | ")
+		}
+
+		file := loadError.File
+		results = append(results, DoctorCheckResult{
+			Name:        fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_doctor_plugin_metadata"), loadError.Name),
+			Status:      false,
+			Description: description,
+			ActionName:  "i18n:plugin_doctor_plugin_metadata_open",
+			Action: func(ctx context.Context) {
+				if openErr := util.ShellOpen(file); openErr != nil {
+					logger.Error(ctx, fmt.Sprintf("failed to open %s: %s", file, openErr.Error()))
+				}
+			},
+		})
+	}
+
+	return results
+}
+
+func getHostErrorSummary(status HostStatus) string {
+	if len(status.LastErrorLines) == 0 {
+		return "-"
+	}
+	return strings.Join(status.LastErrorLines, " | ")
+}
@@ -2,6 +2,8 @@ package plugin
 
 import (
 	"context"
+	"fmt"
+	"strings"
 )
 
 var AllHosts []Host
@@ -14,3 +16,74 @@ type Host interface {
 	LoadPlugin(ctx context.Context, metadata Metadata, pluginDirectory string) (Plugin, error)
 	UnloadPlugin(ctx context.Context, metadata Metadata)
 }
+
+type HostStatus struct {
+	IsStarted          bool
+	CrashCount         int      // total crash count since wox started
+	LastCrashTimestamp int64    // 0 means never crashed
+	LastErrorLines     []string // last stderr lines of host process
+}
+
+// HostSupervisor is implemented by hosts that run plugins in a separate process, the process is restarted automatically after crash
+type HostSupervisor interface {
+	GetStatus(ctx context.Context) HostStatus
+	Restart(ctx context.Context) error
+}
+
+// IsolatedHostProvider is implemented by hosts that run all plugins of a runtime in one shared process,
+// it creates a host which runs a single plugin in a dedicated process
+type IsolatedHostProvider interface {
+	NewIsolatedHost(ctx context.Context, metadata Metadata, pluginDirectory string) Host
+	// IsIsolatedHostRequired returns true if plugin can't run in the shared process, e.g. python plugin with its own virtualenv
+	IsIsolatedHostRequired(ctx context.Context, metadata Metadata, pluginDirectory string) bool
+}
+
+// ProcessStat is the resource usage of the process running a plugin
+type ProcessStat struct {
+	Pid      int
+	CPU      float64 // percent of one cpu core
+	Memory   float64 // resident memory in bytes
+	IsShared bool    // process is shared with other plugins of the same runtime, usage is not only caused by this plugin
+}
+
+// ProcessStatProvider is implemented by hosts that run plugins in separate processes
+type ProcessStatProvider interface {
+	GetProcessStat(ctx context.Context, metadata Metadata) (ProcessStat, error)
+}
+
+// RuntimeInfo is the interpreter found for hosts that run plugins with an interpreter installed on user's machine, e.g. python or nodejs
+type RuntimeInfo struct {
+	Path       string // empty means no usable interpreter is found
+	Version    string
+	MinVersion string             // minimum version required by host
+	IsCustom   bool               // path is set by user in settings
+	Candidates []RuntimeCandidate // all interpreters found during discovery
+}
+
+// GetProblemSummary explains why no interpreter can be used
+func (r RuntimeInfo) GetProblemSummary() string {
+	if len(r.Candidates) == 0 {
+		return "no interpreter is found"
+	}
+
+	var problems []string
+	for _, candidate := range r.Candidates {
+		if candidate.Problem != "" {
+			problems = append(problems, fmt.Sprintf("%s (%s): %s", candidate.Path, candidate.Source, candidate.Problem))
+		}
+	}
+	return strings.Join(problems, "; ")
+}
+
+type RuntimeCandidate struct {
+	Path    string
+	Source  string // where the interpreter is found, e.g. pyenv, nvm, PATH
+	Version string
+	Problem string // why the interpreter can't be used, empty means it's usable
+}
+
+// RuntimeDiscoverer is implemented by hosts that need an interpreter installed on user's machine
+type RuntimeDiscoverer interface {
+	// GetRuntimeInfo returns the interpreter found when host started, discover again if refresh is true
+	GetRuntimeInfo(ctx context.Context, refresh bool) RuntimeInfo
+}
@@ -0,0 +1,306 @@
+package host
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"wox/plugin"
+	"wox/util"
+)
+
+func init() {
+	plugin.AllHosts = append(plugin.AllHosts, &ExecutableHost{
+		processes:  util.NewHashMap[string, *WebsocketHost](),
+		supervisor: hostSupervisor{stderr: util.NewTailWriter(hostStderrMaxLines)},
+	})
+}
+
+// ExecutableHost runs each plugin as a standalone executable, so plugins can be written in any language.
+// Every plugin process speaks the same json rpc protocol as python and nodejs hosts, one json message per line over stdin/stdout.
+// Plugin process is restarted after crash, like hosts of python and nodejs plugins
+type ExecutableHost struct {
+	processes  *util.HashMap[string, *WebsocketHost] // plugin id -> json rpc client of plugin process
+	supervisor hostSupervisor                        // crash statistics and last errors of all plugin processes, shown in doctor
+}
+
+func (e *ExecutableHost) GetRuntime(ctx context.Context) plugin.Runtime {
+	return plugin.PLUGIN_RUNTIME_EXECUTABLE
+}
+
+// Start does nothing, plugin processes are started when loading plugins
+func (e *ExecutableHost) Start(ctx context.Context) error {
+	return nil
+}
+
+func (e *ExecutableHost) Stop(ctx context.Context) {
+	e.processes.Range(func(pluginId string, process *WebsocketHost) bool {
+		if process.conn != nil {
+			process.conn.Close(ctx)
+		}
+		return true
+	})
+	e.processes.Clear()
+}
+
+func (e *ExecutableHost) IsStarted(ctx context.Context) bool {
+	return true
+}
+
+func (e *ExecutableHost) LoadPlugin(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) (plugin.Plugin, error) {
+	util.GetLogger().Info(ctx, fmt.Sprintf("start loading %s plugin, directory: %s", metadata.Name, pluginDirectory))
+
+	// plugin may be reloaded, e.g. dev plugin changed
+	existProcess, exist := e.processes.Load(metadata.Id)
+	if exist && existProcess.conn != nil {
+		existProcess.conn.Close(ctx)
+	}
+
+	entry, entryErr := getExecutableEntry(pluginDirectory, metadata.Entry)
+	if entryErr != nil {
+		return nil, entryErr
+	}
+
+	process := &WebsocketHost{
+		host:       e,
+		pluginId:   metadata.Id,
+		requestMap: util.NewHashMap[string, chan JsonRpcResponse](),
+	}
+	if exist {
+		// keep counting crashes in a row after process is restarted
+		existProcess.supervisor.lock.Lock()
+		process.supervisor.consecutiveCrashCount = existProcess.supervisor.consecutiveCrashCount
+		existProcess.supervisor.lock.Unlock()
+	}
+	conn, startErr := e.startStdioConnection(ctx, process, metadata, entry, pluginDirectory)
+	if startErr != nil {
+		return nil, startErr
+	}
+	process.conn = conn
+	e.processes.Store(metadata.Id, process)
+
+	return NewWebsocketPlugin(metadata, process), nil
+}
+
+func (e *ExecutableHost) UnloadPlugin(ctx context.Context, metadata plugin.Metadata) {
+	process, exist := e.processes.Load(metadata.Id)
+	if !exist {
+		return
+	}
+
+	_, unloadPluginErr := process.invokeMethod(ctx, metadata, "unloadPlugin", map[string]string{
+		"PluginId": metadata.Id,
+	})
+	if unloadPluginErr != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("failed to unload %s plugin: %s", metadata.Name, unloadPluginErr))
+	}
+
+	process.conn.Close(ctx)
+	e.processes.Delete(metadata.Id)
+}
+
+func (e *ExecutableHost) GetProcessStat(ctx context.Context, metadata plugin.Metadata) (plugin.ProcessStat, error) {
+	process, exist := e.processes.Load(metadata.Id)
+	if !exist {
+		return plugin.ProcessStat{}, fmt.Errorf("plugin process is not started")
+	}
+	conn, ok := process.conn.(*stdioConnection)
+	if !ok || conn.cmd.Process == nil {
+		return plugin.ProcessStat{}, fmt.Errorf("plugin process is not started")
+	}
+
+	return getProcessStat(conn.cmd.Process.Pid, false)
+}
+
+func (e *ExecutableHost) GetStatus(ctx context.Context) plugin.HostStatus {
+	isStarted := true
+	e.processes.Range(func(pluginId string, process *WebsocketHost) bool {
+		if process.conn == nil || !process.conn.IsConnected() {
+			isStarted = false
+			return false
+		}
+		return true
+	})
+
+	e.supervisor.lock.Lock()
+	defer e.supervisor.lock.Unlock()
+	return plugin.HostStatus{
+		IsStarted:          isStarted,
+		CrashCount:         e.supervisor.crashCount,
+		LastCrashTimestamp: e.supervisor.lastCrashTimestamp,
+		LastErrorLines:     e.supervisor.getStderr().Lines(),
+	}
+}
+
+// Restart restarts all plugin processes and resets crash statistics
+func (e *ExecutableHost) Restart(ctx context.Context) error {
+	e.supervisor.lock.Lock()
+	e.supervisor.crashCount = 0
+	e.supervisor.lastCrashTimestamp = 0
+	e.supervisor.lock.Unlock()
+	e.supervisor.getStderr().Reset()
+
+	plugin.GetPluginManager().ReloadHostPlugins(ctx, e)
+	return nil
+}
+
+// onProcessExited fails pending requests immediately and restarts the plugin process with backoff
+func (e *ExecutableHost) onProcessExited(ctx context.Context, metadata plugin.Metadata, process *WebsocketHost, reason string) {
+	process.supervisor.lock.Lock()
+	if util.GetSystemTimestamp()-process.supervisor.startTimestamp > hostStableDuration.Milliseconds() {
+		process.supervisor.consecutiveCrashCount = 0
+	}
+	process.supervisor.consecutiveCrashCount++
+	consecutiveCrashCount := process.supervisor.consecutiveCrashCount
+	process.supervisor.lock.Unlock()
+
+	e.supervisor.lock.Lock()
+	e.supervisor.crashCount++
+	e.supervisor.lastCrashTimestamp = util.GetSystemTimestamp()
+	e.supervisor.lock.Unlock()
+
+	// responses of requests sent to the dead process will never arrive
+	process.failPendingRequests(ctx, fmt.Errorf("plugin process exited: %s", reason))
+
+	backoff := getRestartBackoff(consecutiveCrashCount)
+	util.GetLogger().Error(ctx, fmt.Sprintf("<%s> plugin process exited unexpectedly (%d times in a row): %s, restarting in %s", metadata.Name, consecutiveCrashCount, reason, backoff))
+	time.Sleep(backoff)
+
+	// plugin may be unloaded or reloaded while waiting
+	if current, exist := e.processes.Load(metadata.Id); !exist || current != process {
+		return
+	}
+	plugin.GetPluginManager().ReloadHostPlugin(ctx, e, metadata.Id)
+}
+
+// getExecutableEntry returns the absolute path of plugin executable.
+// Entry can contain {os} and {arch} placeholders, so one plugin package can ship binaries for all platforms
+func getExecutableEntry(pluginDirectory string, entry string) (string, error) {
+	if entry == "" {
+		return "", fmt.Errorf("entry is empty")
+	}
+
+	entry = strings.ReplaceAll(entry, "{os}", runtime.GOOS)
+	entry = strings.ReplaceAll(entry, "{arch}", runtime.GOARCH)
+	entryPath := filepath.Join(pluginDirectory, entry)
+	if util.IsWindows() && filepath.Ext(entryPath) == "" && util.IsFileExists(entryPath+".exe") {
+		entryPath = entryPath + ".exe"
+	}
+
+	stat, statErr := os.Stat(entryPath)
+	if statErr != nil {
+		return "", fmt.Errorf("plugin executable not found: %s", entryPath)
+	}
+
+	// executable permission may be lost when plugin is extracted from zip
+	if !util.IsWindows() && stat.Mode()&0111 == 0 {
+		if chmodErr := os.Chmod(entryPath, stat.Mode()|0111); chmodErr != nil {
+			return "", fmt.Errorf("failed to make plugin executable: %w", chmodErr)
+		}
+	}
+
+	return entryPath, nil
+}
+
+// stdioConnection sends json rpc messages to plugin process by stdin and reads messages from stdout, one message per line
+type stdioConnection struct {
+	cmd         *exec.Cmd
+	stdin       io.WriteCloser
+	isConnected bool
+	isClosed    bool
+	lock        sync.Mutex
+}
+
+func (e *ExecutableHost) startStdioConnection(ctx context.Context, process *WebsocketHost, metadata plugin.Metadata, entry string, pluginDirectory string) (*stdioConnection, error) {
+	cmd := util.ShellCommand(entry)
+	cmd.Dir = pluginDirectory
+	cmd.Env = append(os.Environ(),
+		"WOX_PLUGIN_ID="+metadata.Id,
+		"WOX_PLUGIN_DIRECTORY="+pluginDirectory,
+	)
+	cmd.Stderr = io.MultiWriter(util.GetLogger().GetWriter(), e.supervisor.getStderr())
+
+	stdin, stdinErr := cmd.StdinPipe()
+	if stdinErr != nil {
+		return nil, stdinErr
+	}
+	stdout, stdoutErr := cmd.StdoutPipe()
+	if stdoutErr != nil {
+		return nil, stdoutErr
+	}
+	if startErr := cmd.Start(); startErr != nil {
+		return nil, fmt.Errorf("failed to start plugin executable: %w", startErr)
+	}
+	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> plugin process started, pid: %d, entry: %s", metadata.Name, cmd.Process.Pid, entry))
+	process.supervisor.lock.Lock()
+	process.supervisor.startTimestamp = util.GetSystemTimestamp()
+	process.supervisor.lock.Unlock()
+
+	conn := &stdioConnection{cmd: cmd, stdin: stdin, isConnected: true}
+	util.Go(ctx, fmt.Sprintf("<%s> read plugin stdout", metadata.Name), func() {
+		reader := bufio.NewReader(stdout)
+		for {
+			line, readErr := reader.ReadString('\n')
+			if strings.TrimSpace(line) != "" {
+				util.Go(ctx, fmt.Sprintf("<%s> onMessage", metadata.Name), func() {
+					process.onMessage(line)
+				})
+			}
+			if readErr != nil {
+				break
+			}
+		}
+
+		waitErr := cmd.Wait()
+		conn.lock.Lock()
+		isClosed := conn.isClosed
+		conn.isConnected = false
+		conn.lock.Unlock()
+		if !isClosed {
+			e.onProcessExited(ctx, metadata, process, fmt.Sprintf("%v", waitErr))
+		}
+	})
+
+	return conn, nil
+}
+
+func (s *stdioConnection) Send(ctx context.Context, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.isConnected {
+		return fmt.Errorf("plugin process is not running")
+	}
+
+	_, writeErr := s.stdin.Write(append(data, '\n'))
+	return writeErr
+}
+
+func (s *stdioConnection) IsConnected() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.isConnected
+}
+
+func (s *stdioConnection) Close(ctx context.Context) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.isClosed {
+		return
+	}
+	s.isClosed = true
+	s.isConnected = false
+	s.stdin.Close()
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,44 @@
+package host
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wox/plugin"
+	"wox/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutableHostProcessCrash(t *testing.T) {
+	if util.IsWindows() {
+		t.Skip("shell script plugin is not supported on windows")
+	}
+
+	ctx := context.Background()
+	pluginDirectory := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(pluginDirectory, "plugin.sh"), []byte("#!/bin/sh\nread line\necho broken >&2\nexit 1\n"), 0755))
+
+	e := &ExecutableHost{
+		processes:  util.NewHashMap[string, *WebsocketHost](),
+		supervisor: hostSupervisor{stderr: util.NewTailWriter(hostStderrMaxLines)},
+	}
+	metadata := plugin.Metadata{Id: "crash", Name: "crash", Entry: "plugin.sh"}
+	_, loadErr := e.LoadPlugin(ctx, metadata, pluginDirectory)
+	assert.Nil(t, loadErr)
+	defer e.Stop(ctx)
+
+	// pending request fails as soon as the process exits instead of waiting for timeout
+	process, _ := e.processes.Load(metadata.Id)
+	start := time.Now()
+	_, invokeErr := process.invokeMethod(ctx, metadata, "query", nil)
+	assert.ErrorContains(t, invokeErr, "plugin process exited")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	status := e.GetStatus(ctx)
+	assert.False(t, status.IsStarted)
+	assert.Equal(t, 1, status.CrashCount)
+	assert.Contains(t, status.LastErrorLines, "broken")
+}
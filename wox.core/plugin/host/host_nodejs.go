@@ -3,17 +3,16 @@ package host
 import (
 	"context"
 	"fmt"
+	"os"
 	"path"
-	"strings"
+	"path/filepath"
 	"wox/plugin"
+	"wox/setting"
 	"wox/util"
-
-	"github.com/Masterminds/semver/v3"
-	"github.com/mitchellh/go-homedir"
 )
 
 func init() {
-	host := &NodejsHost{}
+	host := &NodejsHost{runtime: newRuntimeFinder(nodejsRuntimeSpec)}
 	host.websocketHost = &WebsocketHost{
 		host:       host,
 		requestMap: util.NewHashMap[string, chan JsonRpcResponse](),
@@ -21,8 +20,41 @@ func init() {
 	plugin.AllHosts = append(plugin.AllHosts, host)
 }
 
+var nodejsRuntimeSpec = runtimeSpec{
+	name:        "nodejs",
+	minVersion:  "16.0.0",
+	versionArgs: []string{"-v"},
+	customPath: func(ctx context.Context) string {
+		return setting.GetSettingManager().GetWoxSetting(ctx).NodejsPath
+	},
+	candidates: func() []runtimeSearchPath {
+		nvmDirectory := getEnvOrHomeDirectory("NVM_DIR", "~/.nvm")
+		asdfDirectory := getEnvOrHomeDirectory("ASDF_DATA_DIR", "~/.asdf")
+		voltaDirectory := getEnvOrHomeDirectory("VOLTA_HOME", "~/.volta")
+		if util.IsWindows() {
+			var searchPaths []runtimeSearchPath
+			if nvmHome := os.Getenv("NVM_HOME"); nvmHome != "" {
+				searchPaths = append(searchPaths, getRuntimeVersionDirectories(nvmHome, "node.exe", "nvm")...)
+			}
+			searchPaths = append(searchPaths, getRuntimeVersionDirectories(filepath.Join(voltaDirectory, "tools", "image", "node"), "node.exe", "volta")...)
+			searchPaths = append(searchPaths, getRuntimeFromPath("node.exe")...)
+			return searchPaths
+		}
+
+		var searchPaths []runtimeSearchPath
+		searchPaths = append(searchPaths, getRuntimeVersionDirectories(filepath.Join(nvmDirectory, "versions", "node"), filepath.Join("bin", "node"), "nvm")...)
+		searchPaths = append(searchPaths, getRuntimeVersionDirectories(filepath.Join(asdfDirectory, "installs", "nodejs"), filepath.Join("bin", "node"), "asdf")...)
+		// volta/bin/node is a shim which needs volta env, use installed node images directly
+		searchPaths = append(searchPaths, getRuntimeVersionDirectories(filepath.Join(voltaDirectory, "tools", "image", "node"), filepath.Join("bin", "node"), "volta")...)
+		searchPaths = append(searchPaths, getSystemRuntimePaths("/opt/homebrew/bin/node", "/usr/local/bin/node", "/usr/bin/node")...)
+		searchPaths = append(searchPaths, getRuntimeFromPath("node")...)
+		return searchPaths
+	},
+}
+
 type NodejsHost struct {
 	websocketHost *WebsocketHost
+	runtime       *runtimeFinder
 }
 
 func (n *NodejsHost) GetRuntime(ctx context.Context) plugin.Runtime {
@@ -30,54 +62,17 @@ func (n *NodejsHost) GetRuntime(ctx context.Context) plugin.Runtime {
 }
 
 func (n *NodejsHost) Start(ctx context.Context) error {
-	return n.websocketHost.StartHost(ctx, n.findNodejsPath(ctx), path.Join(util.GetLocation().GetHostDirectory(), "node-host.js"), nil)
-}
-
-func (n *NodejsHost) findNodejsPath(ctx context.Context) string {
-	util.GetLogger().Debug(ctx, "start finding nodejs path")
-
-	var possibleNodejsPaths = []string{
-		"/opt/homebrew/bin/node",
-		"/usr/local/bin/node",
-		"/usr/bin/node",
-		"/usr/local/node",
-	}
-
-	nvmNodePaths, _ := homedir.Expand("~/.nvm/versions/node")
-	if util.IsDirExists(nvmNodePaths) {
-		versions, _ := util.ListDir(nvmNodePaths)
-		for _, v := range versions {
-			possibleNodejsPaths = append(possibleNodejsPaths, path.Join(nvmNodePaths, v, "bin", "node"))
-		}
-	}
-
-	foundVersion, _ := semver.NewVersion("v0.0.1")
-	foundPath := ""
-	for _, p := range possibleNodejsPaths {
-		if util.IsFileExists(p) {
-			versionOriginal, versionErr := util.ShellRunOutput(p, "-v")
-			if versionErr != nil {
-				util.GetLogger().Error(ctx, fmt.Sprintf("failed to get nodejs version: %s, path=%s", versionErr, p))
-				continue
-			}
-			version := strings.TrimSpace(string(versionOriginal))
-			installedVersion, _ := semver.NewVersion(version)
-			util.GetLogger().Debug(ctx, fmt.Sprintf("found nodejs path: %s, version: %s", p, installedVersion.String()))
-
-			if installedVersion.GreaterThan(foundVersion) {
-				foundPath = p
-				foundVersion = installedVersion
-			}
-		}
+	// isolated hosts reuse the interpreter found by shared host
+	runtimeInfo := n.runtime.find(ctx, n.websocketHost.pluginId == "")
+	if runtimeInfo.Path == "" {
+		return fmt.Errorf("no usable nodejs is found: %s", runtimeInfo.GetProblemSummary())
 	}
 
-	if foundPath != "" {
-		util.GetLogger().Info(ctx, fmt.Sprintf("finally use nodejs path: %s, version: %s", foundPath, foundVersion.String()))
-		return foundPath
-	}
+	return n.websocketHost.StartHost(ctx, runtimeInfo.Path, path.Join(util.GetLocation().GetHostDirectory(), "node-host.js"), nil)
+}
 
-	util.GetLogger().Info(ctx, "finally use default node from env path")
-	return "node"
+func (n *NodejsHost) GetRuntimeInfo(ctx context.Context, refresh bool) plugin.RuntimeInfo {
+	return n.runtime.find(ctx, refresh)
 }
 
 func (n *NodejsHost) IsStarted(ctx context.Context) bool {
@@ -95,3 +90,31 @@ func (n *NodejsHost) LoadPlugin(ctx context.Context, metadata plugin.Metadata, p
 func (n *NodejsHost) UnloadPlugin(ctx context.Context, metadata plugin.Metadata) {
 	n.websocketHost.UnloadPlugin(ctx, metadata)
 }
+
+func (n *NodejsHost) GetStatus(ctx context.Context) plugin.HostStatus {
+	return n.websocketHost.GetStatus(ctx)
+}
+
+func (n *NodejsHost) Restart(ctx context.Context) error {
+	return n.websocketHost.Restart(ctx)
+}
+
+// NewIsolatedHost creates a host which runs the plugin in a dedicated nodejs process
+func (n *NodejsHost) NewIsolatedHost(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) plugin.Host {
+	host := &NodejsHost{runtime: n.runtime}
+	host.websocketHost = &WebsocketHost{
+		host:       host,
+		pluginId:   metadata.Id,
+		requestMap: util.NewHashMap[string, chan JsonRpcResponse](),
+	}
+	return host
+}
+
+func (n *NodejsHost) GetProcessStat(ctx context.Context, metadata plugin.Metadata) (plugin.ProcessStat, error) {
+	return n.websocketHost.getProcessStat(ctx)
+}
+
+// IsIsolatedHostRequired returns false, node resolves node_modules in plugin directory even in the shared process
+func (n *NodejsHost) IsIsolatedHostRequired(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) bool {
+	return false
+}
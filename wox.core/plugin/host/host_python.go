@@ -4,16 +4,14 @@ import (
 	"context"
 	"fmt"
 	"path"
-	"strings"
+	"path/filepath"
 	"wox/plugin"
+	"wox/setting"
 	"wox/util"
-
-	"github.com/Masterminds/semver/v3"
-	"github.com/mitchellh/go-homedir"
 )
 
 func init() {
-	host := &PythonHost{}
+	host := &PythonHost{runtime: newRuntimeFinder(pythonRuntimeSpec)}
 	host.websocketHost = &WebsocketHost{
 		host:       host,
 		requestMap: util.NewHashMap[string, chan JsonRpcResponse](),
@@ -21,8 +19,36 @@ func init() {
 	plugin.AllHosts = append(plugin.AllHosts, host)
 }
 
+var pythonRuntimeSpec = runtimeSpec{
+	name:        "python",
+	minVersion:  "3.10.0", // see requires-python of python host
+	versionArgs: []string{"--version"},
+	customPath: func(ctx context.Context) string {
+		return setting.GetSettingManager().GetWoxSetting(ctx).PythonPath
+	},
+	candidates: func() []runtimeSearchPath {
+		pyenvDirectory := getEnvOrHomeDirectory("PYENV_ROOT", "~/.pyenv")
+		asdfDirectory := getEnvOrHomeDirectory("ASDF_DATA_DIR", "~/.asdf")
+		if util.IsWindows() {
+			var searchPaths []runtimeSearchPath
+			searchPaths = append(searchPaths, getRuntimeVersionDirectories(filepath.Join(pyenvDirectory, "pyenv-win", "versions"), "python.exe", "pyenv")...)
+			searchPaths = append(searchPaths, getRuntimeFromPath("python3.exe", "python.exe")...)
+			return searchPaths
+		}
+
+		var searchPaths []runtimeSearchPath
+		searchPaths = append(searchPaths, getRuntimeVersionDirectories(filepath.Join(pyenvDirectory, "versions"), filepath.Join("bin", "python3"), "pyenv")...)
+		searchPaths = append(searchPaths, getRuntimeVersionDirectories(filepath.Join(asdfDirectory, "installs", "python"), filepath.Join("bin", "python3"), "asdf")...)
+		searchPaths = append(searchPaths, getSystemRuntimePaths("/opt/homebrew/bin/python3", "/usr/local/bin/python3", "/usr/bin/python3")...)
+		searchPaths = append(searchPaths, getRuntimeFromPath("python3", "python")...)
+		return searchPaths
+	},
+}
+
 type PythonHost struct {
-	websocketHost *WebsocketHost
+	websocketHost   *WebsocketHost
+	runtime         *runtimeFinder
+	interpreterPath string // python in virtualenv of plugin, only set for isolated host
 }
 
 func (n *PythonHost) GetRuntime(ctx context.Context) plugin.Runtime {
@@ -30,61 +56,21 @@ func (n *PythonHost) GetRuntime(ctx context.Context) plugin.Runtime {
 }
 
 func (n *PythonHost) Start(ctx context.Context) error {
-	return n.websocketHost.StartHost(ctx, n.findPythonPath(ctx), path.Join(util.GetLocation().GetHostDirectory(), "python-host.pyz"), []string{"SHIV_ROOT=" + util.GetLocation().GetCacheDirectory()})
-}
-
-func (n *PythonHost) findPythonPath(ctx context.Context) string {
-	util.GetLogger().Debug(ctx, "start finding python path")
-
-	var possiblePythonPaths = []string{
-		"/opt/homebrew/bin/python3",
-		"/usr/local/bin/python3",
-		"/usr/bin/python3",
-		"/usr/local/python3",
+	// isolated hosts reuse the interpreter found by shared host
+	runtimeInfo := n.runtime.find(ctx, n.websocketHost.pluginId == "")
+	if runtimeInfo.Path == "" {
+		return fmt.Errorf("no usable python is found: %s", runtimeInfo.GetProblemSummary())
 	}
-
-	pyenvPaths, _ := homedir.Expand("~/.pyenv/versions")
-	if util.IsDirExists(pyenvPaths) {
-		versions, _ := util.ListDir(pyenvPaths)
-		for _, v := range versions {
-			possiblePythonPaths = append(possiblePythonPaths, path.Join(pyenvPaths, v, "bin", "python3"))
-		}
+	pythonPath := runtimeInfo.Path
+	if n.interpreterPath != "" {
+		pythonPath = n.interpreterPath
 	}
 
-	foundVersion, _ := semver.NewVersion("v0.0.1")
-	foundPath := ""
-	for _, p := range possiblePythonPaths {
-		if util.IsFileExists(p) {
-			versionOriginal, versionErr := util.ShellRunOutput(p, "--version")
-			if versionErr != nil {
-				util.GetLogger().Error(ctx, fmt.Sprintf("failed to get python version: %s, path=%s", versionErr, p))
-				continue
-			}
-			// Python version output format is like "Python 3.9.0"
-			version := strings.TrimSpace(string(versionOriginal))
-			version = strings.TrimPrefix(version, "Python ")
-			version = "v" + version
-			installedVersion, err := semver.NewVersion(version)
-			if err != nil {
-				util.GetLogger().Error(ctx, fmt.Sprintf("failed to parse python version: %s, path=%s", err, p))
-				continue
-			}
-			util.GetLogger().Debug(ctx, fmt.Sprintf("found python path: %s, version: %s", p, installedVersion.String()))
-
-			if installedVersion.GreaterThan(foundVersion) {
-				foundPath = p
-				foundVersion = installedVersion
-			}
-		}
-	}
-
-	if foundPath != "" {
-		util.GetLogger().Info(ctx, fmt.Sprintf("finally use python path: %s, version: %s", foundPath, foundVersion.String()))
-		return foundPath
-	}
+	return n.websocketHost.StartHost(ctx, pythonPath, path.Join(util.GetLocation().GetHostDirectory(), "python-host.pyz"), []string{"SHIV_ROOT=" + util.GetLocation().GetCacheDirectory()})
+}
 
-	util.GetLogger().Info(ctx, "finally use default python3 from env path")
-	return "python3"
+func (n *PythonHost) GetRuntimeInfo(ctx context.Context, refresh bool) plugin.RuntimeInfo {
+	return n.runtime.find(ctx, refresh)
 }
 
 func (n *PythonHost) IsStarted(ctx context.Context) bool {
@@ -102,3 +88,31 @@ func (n *PythonHost) LoadPlugin(ctx context.Context, metadata plugin.Metadata, p
 func (n *PythonHost) UnloadPlugin(ctx context.Context, metadata plugin.Metadata) {
 	n.websocketHost.UnloadPlugin(ctx, metadata)
 }
+
+func (n *PythonHost) GetStatus(ctx context.Context) plugin.HostStatus {
+	return n.websocketHost.GetStatus(ctx)
+}
+
+func (n *PythonHost) Restart(ctx context.Context) error {
+	return n.websocketHost.Restart(ctx)
+}
+
+// NewIsolatedHost creates a host which runs the plugin in a dedicated python process, virtualenv of plugin is used if exists
+func (n *PythonHost) NewIsolatedHost(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) plugin.Host {
+	host := &PythonHost{runtime: n.runtime, interpreterPath: plugin.GetPluginVirtualEnvPython(pluginDirectory)}
+	host.websocketHost = &WebsocketHost{
+		host:       host,
+		pluginId:   metadata.Id,
+		requestMap: util.NewHashMap[string, chan JsonRpcResponse](),
+	}
+	return host
+}
+
+func (n *PythonHost) GetProcessStat(ctx context.Context, metadata plugin.Metadata) (plugin.ProcessStat, error) {
+	return n.websocketHost.getProcessStat(ctx)
+}
+
+// IsIsolatedHostRequired returns true if plugin has its own virtualenv, packages in it can only be used by the python of virtualenv
+func (n *PythonHost) IsIsolatedHostRequired(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) bool {
+	return plugin.GetPluginVirtualEnvPython(pluginDirectory) != ""
+}
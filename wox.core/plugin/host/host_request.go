@@ -0,0 +1,110 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"wox/plugin"
+	"wox/util"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMethodTimeout         = 30 * time.Second
+	maxInflightRequestsPerPlugin = 16 // requests to a busy plugin are queued until a previous request finishes
+)
+
+// default timeouts of methods invoked in plugins, plugin can override them by methodTimeout feature
+var defaultMethodTimeouts = map[string]time.Duration{
+	"loadPlugin": time.Minute, // importing modules of big plugins may be slow
+	"query":      defaultMethodTimeout,
+	"action":     defaultMethodTimeout,
+	"refresh":    10 * time.Second, // refresh runs at interval, a slow refresh is useless
+}
+
+// requestLimiter limits in-flight requests of each plugin, so a slow plugin can't pile up requests in host
+type requestLimiter struct {
+	slots map[string]chan struct{} // plugin id -> semaphore
+	lock  sync.Mutex
+}
+
+func (l *requestLimiter) getSlots(pluginId string) chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.slots == nil {
+		l.slots = map[string]chan struct{}{}
+	}
+	slots, exist := l.slots[pluginId]
+	if !exist {
+		slots = make(chan struct{}, maxInflightRequestsPerPlugin)
+		l.slots[pluginId] = slots
+	}
+	return slots
+}
+
+// acquire waits until plugin has a free slot, caller must call release after the request finished
+func (l *requestLimiter) acquire(ctx context.Context, pluginId string) (release func(), err error) {
+	slots := l.getSlots(pluginId)
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func getMethodTimeout(metadata plugin.Metadata, method string) time.Duration {
+	if params, err := metadata.GetFeatureParamsForMethodTimeout(); err == nil {
+		if timeout, exist := params.Timeouts[method]; exist {
+			return timeout
+		}
+	}
+
+	if timeout, exist := defaultMethodTimeouts[method]; exist {
+		return timeout
+	}
+	return defaultMethodTimeout
+}
+
+// cancelRequest notifies plugin that wox doesn't wait for the request anymore, plugin should stop handling it.
+// It's a notification, plugin doesn't respond to it
+func (w *WebsocketHost) cancelRequest(ctx context.Context, request JsonRpcRequest) {
+	if w.conn == nil || !w.conn.IsConnected() {
+		return
+	}
+
+	cancel := JsonRpcRequest{
+		TraceId:    request.TraceId,
+		Id:         uuid.NewString(),
+		PluginId:   request.PluginId,
+		PluginName: request.PluginName,
+		Method:     JsonRpcMethodCancel,
+		Type:       JsonRpcTypeRequest,
+		Params: map[string]string{
+			"Id": request.Id,
+		},
+	}
+	cancelJson, marshalErr := json.Marshal(cancel)
+	if marshalErr != nil {
+		return
+	}
+
+	if sendErr := w.conn.Send(ctx, cancelJson); sendErr != nil {
+		util.GetLogger().Warn(ctx, fmt.Sprintf("<%s> failed to cancel request %s: %s", w.getHostName(ctx), request.Id, sendErr))
+	}
+}
+
+// failPendingRequests answers all waiting requests with error, e.g. host crashed and responses will never arrive
+func (w *WebsocketHost) failPendingRequests(ctx context.Context, err error) {
+	w.requestMap.Range(func(id string, resultChan chan JsonRpcResponse) bool {
+		select {
+		case resultChan <- JsonRpcResponse{Id: id, Type: JsonRpcTypeResponse, Error: err.Error()}:
+		default:
+		}
+		return true
+	})
+}
@@ -0,0 +1,193 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	"wox/plugin"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConnection records requests sent to host
+type fakeConnection struct {
+	requests chan JsonRpcRequest
+}
+
+func (f *fakeConnection) Send(ctx context.Context, data []byte) error {
+	var request JsonRpcRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	f.requests <- request
+	return nil
+}
+
+func (f *fakeConnection) IsConnected() bool {
+	return true
+}
+
+func (f *fakeConnection) Close(ctx context.Context) {
+}
+
+func newTestWebsocketHost() (*WebsocketHost, *fakeConnection) {
+	conn := &fakeConnection{requests: make(chan JsonRpcRequest, 100)}
+	return &WebsocketHost{
+		conn:       conn,
+		host:       &ScriptHost{},
+		requestMap: util.NewHashMap[string, chan JsonRpcResponse](),
+	}, conn
+}
+
+func TestInvokeMethodTimeoutCancelsRequest(t *testing.T) {
+	w, conn := newTestWebsocketHost()
+	metadata := plugin.Metadata{
+		Id:       "test",
+		Features: []plugin.MetadataFeature{{Name: plugin.MetadataFeatureMethodTimeout, Params: map[string]string{"query": "50"}}},
+	}
+
+	start := time.Now()
+	_, err := w.invokeMethod(context.Background(), metadata, "query", nil)
+	assert.ErrorContains(t, err, "timeout")
+	assert.Less(t, time.Since(start), time.Second)
+
+	request := <-conn.requests
+	assert.Equal(t, "query", request.Method)
+	assert.InDelta(t, start.Add(50*time.Millisecond).UnixMilli(), request.Deadline, 20)
+
+	cancel := <-conn.requests
+	assert.Equal(t, JsonRpcMethodCancel, cancel.Method)
+	assert.Equal(t, request.Id, cancel.Params["Id"])
+	assert.Equal(t, 0, w.requestMap.Len())
+
+	// late response is dropped
+	w.handleResponseFromPlugin(context.Background(), JsonRpcResponse{Id: request.Id, Type: JsonRpcTypeResponse})
+}
+
+func TestInvokeMethodCanceledByCaller(t *testing.T) {
+	w, conn := newTestWebsocketHost()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-conn.requests
+		cancel()
+	}()
+
+	_, err := w.invokeMethod(ctx, plugin.Metadata{Id: "test"}, "query", nil)
+	assert.ErrorContains(t, err, "canceled")
+	assert.Equal(t, JsonRpcMethodCancel, (<-conn.requests).Method)
+	assert.Equal(t, 0, w.requestMap.Len())
+}
+
+func TestNewQueryCancelsPreviousQuery(t *testing.T) {
+	ctx := plugin.NewQuerySourceContext(context.Background(), "ui")
+	w, conn := newTestWebsocketHost()
+	metadata := plugin.Metadata{Id: "cancel-test-plugin", Name: "Cancel Test", TriggerKeywords: []string{"ct"}}
+	instance := &plugin.Instance{
+		Metadata: metadata,
+		Plugin:   NewWebsocketPlugin(metadata, w),
+		Host:     &ExecutableHost{processes: util.NewHashMap[string, *WebsocketHost]()},
+		Setting:  &setting.PluginSetting{Settings: util.NewHashMap[string, string]()},
+	}
+	plugin.GetPluginManager().RegisterInstance(instance)
+	t.Cleanup(func() {
+		plugin.GetPluginManager().UnloadPlugin(ctx, instance)
+	})
+
+	query := plugin.Query{Type: plugin.QueryTypeInput, RawQuery: "ct first", TriggerKeyword: "ct", Search: "first"}
+	firstResults, firstDone := plugin.GetPluginManager().Query(ctx, query)
+	first := <-conn.requests
+	assert.Equal(t, "query", first.Method)
+	assert.Equal(t, "first", first.Params["Search"])
+
+	query.RawQuery, query.Search = "ct second", "second"
+	plugin.GetPluginManager().Query(ctx, query)
+
+	// order of cancel notification and new query is not guaranteed
+	var cancel, second JsonRpcRequest
+	for i := 0; i < 2; i++ {
+		request := <-conn.requests
+		if request.Method == JsonRpcMethodCancel {
+			cancel = request
+		} else {
+			second = request
+		}
+	}
+	assert.Equal(t, first.Id, cancel.Params["Id"])
+	assert.Equal(t, "second", second.Params["Search"])
+
+	// superseded query finishes without results instead of a failed result
+	assert.Empty(t, <-firstResults)
+	<-firstDone
+
+	// query from another source doesn't supersede queries of ui
+	query.RawQuery, query.Search = "ct third", "third"
+	plugin.GetPluginManager().Query(plugin.NewQuerySourceContext(context.Background(), "control"), query)
+	third := <-conn.requests
+	assert.Equal(t, "third", third.Params["Search"])
+	select {
+	case request := <-conn.requests:
+		assert.Fail(t, "unexpected request", request.Method)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestInvokeMethodFailedByHostCrash(t *testing.T) {
+	w, conn := newTestWebsocketHost()
+	go func() {
+		<-conn.requests
+		w.failPendingRequests(context.Background(), errors.New("host crashed"))
+	}()
+
+	_, err := w.invokeMethod(context.Background(), plugin.Metadata{Id: "test"}, "query", nil)
+	assert.EqualError(t, err, "host crashed")
+	assert.Equal(t, 0, w.requestMap.Len())
+}
+
+func TestRequestLimiter(t *testing.T) {
+	var limiter requestLimiter
+	var releases []func()
+	for i := 0; i < maxInflightRequestsPerPlugin; i++ {
+		release, err := limiter.acquire(context.Background(), "a")
+		assert.NoError(t, err)
+		releases = append(releases, release)
+	}
+
+	// other plugins are not affected by a busy plugin
+	release, err := limiter.acquire(context.Background(), "b")
+	assert.NoError(t, err)
+	release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// queued request gets the slot after a request finished
+	acquired := make(chan struct{})
+	go func() {
+		release, err := limiter.acquire(context.Background(), "a")
+		assert.NoError(t, err)
+		release()
+		close(acquired)
+	}()
+	releases[0]()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("queued request is not executed")
+	}
+}
+
+func TestGetMethodTimeout(t *testing.T) {
+	metadata := plugin.Metadata{
+		Features: []plugin.MetadataFeature{{Name: plugin.MetadataFeatureMethodTimeout, Params: map[string]string{"action": "120000"}}},
+	}
+
+	assert.Equal(t, 2*time.Minute, getMethodTimeout(metadata, "action"))
+	assert.Equal(t, defaultMethodTimeouts["refresh"], getMethodTimeout(metadata, "refresh"))
+	assert.Equal(t, defaultMethodTimeout, getMethodTimeout(plugin.Metadata{}, "onDeepLink"))
+}
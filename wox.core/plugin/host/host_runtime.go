@@ -0,0 +1,181 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"wox/plugin"
+	"wox/util"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/mitchellh/go-homedir"
+)
+
+var runtimeVersionRegex = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// runtimeSpec describes how to find an interpreter of a runtime
+type runtimeSpec struct {
+	name        string
+	minVersion  string   // minimum version required by host
+	versionArgs []string // args to print interpreter version
+	customPath  func(ctx context.Context) string
+	candidates  func() []runtimeSearchPath
+}
+
+type runtimeSearchPath struct {
+	path   string
+	source string
+}
+
+// runtimeFinder finds the interpreter used by host, result is shared by the shared host and isolated hosts of the same runtime
+type runtimeFinder struct {
+	spec         runtimeSpec
+	info         plugin.RuntimeInfo
+	isDiscovered bool
+	lock         sync.Mutex
+}
+
+func newRuntimeFinder(spec runtimeSpec) *runtimeFinder {
+	return &runtimeFinder{spec: spec}
+}
+
+func (f *runtimeFinder) find(ctx context.Context, refresh bool) plugin.RuntimeInfo {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if !f.isDiscovered || refresh {
+		f.info = discoverRuntime(ctx, f.spec)
+		f.isDiscovered = true
+	}
+	return f.info
+}
+
+// discoverRuntime returns the interpreter with highest version which satisfies min version.
+// If user set interpreter path in settings, only that path is checked
+func discoverRuntime(ctx context.Context, spec runtimeSpec) plugin.RuntimeInfo {
+	util.GetLogger().Debug(ctx, fmt.Sprintf("start finding %s path", spec.name))
+	minVersion := semver.MustParse(spec.minVersion)
+	info := plugin.RuntimeInfo{MinVersion: minVersion.String()}
+
+	var searchPaths []runtimeSearchPath
+	if customPath := spec.customPath(ctx); customPath != "" {
+		expandedPath, _ := homedir.Expand(customPath)
+		// user may only set the executable name, e.g. python3.12
+		if lookPath, lookErr := exec.LookPath(expandedPath); lookErr == nil {
+			expandedPath = lookPath
+		}
+		searchPaths = []runtimeSearchPath{{path: expandedPath, source: "setting"}}
+		info.IsCustom = true
+	} else {
+		searchPaths = spec.candidates()
+	}
+
+	var foundVersion *semver.Version
+	checkedPaths := map[string]bool{}
+	for _, searchPath := range searchPaths {
+		if searchPath.path == "" {
+			continue
+		}
+		// same interpreter may be found from different sources, e.g. /usr/bin/python3 is also in PATH
+		realPath, evalErr := filepath.EvalSymlinks(searchPath.path)
+		if evalErr != nil {
+			if info.IsCustom {
+				info.Candidates = append(info.Candidates, plugin.RuntimeCandidate{Path: searchPath.path, Source: searchPath.source, Problem: "file not found"})
+			}
+			continue
+		}
+		if checkedPaths[realPath] {
+			continue
+		}
+		checkedPaths[realPath] = true
+
+		candidate := plugin.RuntimeCandidate{Path: searchPath.path, Source: searchPath.source}
+		version, versionErr := getRuntimeVersion(searchPath.path, spec.versionArgs)
+		if versionErr != nil {
+			candidate.Problem = versionErr.Error()
+		} else {
+			candidate.Version = version.String()
+			if version.LessThan(minVersion) {
+				candidate.Problem = fmt.Sprintf("version %s is lower than required %s", version, minVersion)
+			}
+		}
+		info.Candidates = append(info.Candidates, candidate)
+		util.GetLogger().Debug(ctx, fmt.Sprintf("found %s path: %s, source: %s, version: %s, problem: %s", spec.name, candidate.Path, candidate.Source, candidate.Version, candidate.Problem))
+
+		if candidate.Problem == "" && (foundVersion == nil || version.GreaterThan(foundVersion)) {
+			foundVersion = version
+			info.Path = candidate.Path
+			info.Version = candidate.Version
+		}
+	}
+
+	if info.Path == "" {
+		util.GetLogger().Error(ctx, fmt.Sprintf("no usable %s is found: %s", spec.name, info.GetProblemSummary()))
+	} else {
+		util.GetLogger().Info(ctx, fmt.Sprintf("finally use %s path: %s, version: %s", spec.name, info.Path, info.Version))
+	}
+
+	return info
+}
+
+func getRuntimeVersion(executablePath string, versionArgs []string) (*semver.Version, error) {
+	output, runErr := util.ShellRunOutput(executablePath, versionArgs...)
+	if runErr != nil {
+		return nil, fmt.Errorf("failed to get version: %s", runErr)
+	}
+
+	// output is like "Python 3.9.0" or "v20.1.0"
+	matches := runtimeVersionRegex.FindStringSubmatch(string(output))
+	if matches == nil {
+		return nil, fmt.Errorf("failed to parse version: %s", strings.TrimSpace(string(output)))
+	}
+	patch := matches[3]
+	if patch == "" {
+		patch = "0"
+	}
+	return semver.NewVersion(fmt.Sprintf("%s.%s.%s", matches[1], matches[2], patch))
+}
+
+// getRuntimeVersionDirectories returns executables in version directories of version managers, e.g. ~/.pyenv/versions/3.12.1/bin/python3
+func getRuntimeVersionDirectories(versionsDirectory string, executableRelativePath string, source string) []runtimeSearchPath {
+	var searchPaths []runtimeSearchPath
+	versions, _ := util.ListDir(versionsDirectory)
+	sort.Strings(versions)
+	for _, v := range versions {
+		searchPaths = append(searchPaths, runtimeSearchPath{path: filepath.Join(versionsDirectory, v, executableRelativePath), source: source})
+	}
+	return searchPaths
+}
+
+// getEnvOrHomeDirectory returns directory in env, or the default directory in home directory
+func getEnvOrHomeDirectory(env string, defaultDirectory string) string {
+	if directory := os.Getenv(env); directory != "" {
+		return directory
+	}
+	directory, _ := homedir.Expand(defaultDirectory)
+	return directory
+}
+
+func getRuntimeFromPath(executableNames ...string) []runtimeSearchPath {
+	var searchPaths []runtimeSearchPath
+	for _, name := range executableNames {
+		if p, lookErr := exec.LookPath(name); lookErr == nil {
+			searchPaths = append(searchPaths, runtimeSearchPath{path: p, source: "PATH"})
+		}
+	}
+	return searchPaths
+}
+
+func getSystemRuntimePaths(paths ...string) []runtimeSearchPath {
+	var searchPaths []runtimeSearchPath
+	for _, p := range paths {
+		searchPaths = append(searchPaths, runtimeSearchPath{path: p, source: "system"})
+	}
+	return searchPaths
+}
@@ -0,0 +1,83 @@
+package host
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"wox/plugin"
+	"wox/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestInterpreter(t *testing.T, name string, versionOutput string) string {
+	if util.IsWindows() {
+		t.Skip("fake interpreter is a shell script")
+	}
+
+	interpreterPath := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(interpreterPath, []byte("#!/bin/sh\necho '"+versionOutput+"'\n"), 0755))
+	return interpreterPath
+}
+
+func newTestRuntimeSpec(customPath string, searchPaths ...runtimeSearchPath) runtimeSpec {
+	return runtimeSpec{
+		name:        "python",
+		minVersion:  "3.10.0",
+		versionArgs: []string{"--version"},
+		customPath: func(ctx context.Context) string {
+			return customPath
+		},
+		candidates: func() []runtimeSearchPath {
+			return searchPaths
+		},
+	}
+}
+
+func TestDiscoverRuntime(t *testing.T) {
+	oldPython := writeTestInterpreter(t, "python3", "Python 3.8.10")
+	newPython := writeTestInterpreter(t, "python3", "Python 3.12.1")
+	rcPython := writeTestInterpreter(t, "python3", "Python 3.11rc1")
+	brokenPython := writeTestInterpreter(t, "python3", "unknown")
+
+	info := discoverRuntime(context.Background(), newTestRuntimeSpec("",
+		runtimeSearchPath{path: oldPython, source: "system"},
+		runtimeSearchPath{path: rcPython, source: "asdf"},
+		runtimeSearchPath{path: newPython, source: "pyenv"},
+		runtimeSearchPath{path: newPython, source: "PATH"},
+		runtimeSearchPath{path: brokenPython, source: "PATH"},
+		runtimeSearchPath{path: "/not/exist/python3", source: "system"},
+	))
+	assert.Equal(t, newPython, info.Path)
+	assert.Equal(t, "3.12.1", info.Version)
+	assert.False(t, info.IsCustom)
+	// duplicated and missing interpreters are not candidates
+	assert.Len(t, info.Candidates, 4)
+	assert.Contains(t, info.Candidates[0].Problem, "lower than required")
+	assert.Equal(t, "3.11.0", info.Candidates[1].Version)
+	assert.Contains(t, info.Candidates[3].Problem, "failed to parse version")
+}
+
+func TestDiscoverRuntimeCustomPath(t *testing.T) {
+	newPython := writeTestInterpreter(t, "python3", "Python 3.12.1")
+
+	// interpreter set by user is used even if another one has a higher version
+	info := discoverRuntime(context.Background(), newTestRuntimeSpec(newPython, runtimeSearchPath{path: writeTestInterpreter(t, "python3", "Python 3.13.0"), source: "system"}))
+	assert.Equal(t, newPython, info.Path)
+	assert.True(t, info.IsCustom)
+
+	// no fallback if interpreter set by user is not usable
+	info = discoverRuntime(context.Background(), newTestRuntimeSpec("/not/exist/python3", runtimeSearchPath{path: newPython, source: "system"}))
+	assert.Empty(t, info.Path)
+	assert.Contains(t, info.GetProblemSummary(), "/not/exist/python3 (setting): file not found")
+}
+
+func TestCheckRuntimeVersion(t *testing.T) {
+	info := plugin.RuntimeInfo{Path: "/usr/bin/python3", Version: "3.11.2"}
+
+	assert.NoError(t, plugin.CheckRuntimeVersion(plugin.Metadata{Runtime: "python"}, info))
+	assert.NoError(t, plugin.CheckRuntimeVersion(plugin.Metadata{Runtime: "python", RuntimeVersion: ">=3.10"}, info))
+	assert.ErrorContains(t, plugin.CheckRuntimeVersion(plugin.Metadata{Runtime: "python", RuntimeVersion: ">=3.12"}, info), "requires python >=3.12")
+	assert.ErrorContains(t, plugin.CheckRuntimeVersion(plugin.Metadata{Runtime: "python"}, plugin.RuntimeInfo{}), "no usable python")
+}
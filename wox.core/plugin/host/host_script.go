@@ -0,0 +1,309 @@
+package host
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"wox/i18n"
+	"wox/plugin"
+	"wox/util"
+	"wox/util/clipboard"
+)
+
+const (
+	scriptQueryTimeout  = 10 * time.Second // preview and lines scripts run while user is typing, they should be fast
+	scriptActionTimeout = 5 * time.Minute
+	scriptMaxLines      = 200
+)
+
+// interpreters of scripts without shebang, by file extension
+var scriptInterpreters = map[string][]string{
+	".sh":          {"bash"},
+	".bash":        {"bash"},
+	".zsh":         {"zsh"},
+	".py":          {"python3"},
+	".js":          {"node"},
+	".mjs":         {"node"},
+	".ts":          {"deno", "run", "--allow-all"},
+	".rb":          {"ruby"},
+	".php":         {"php"},
+	".pl":          {"perl"},
+	".lua":         {"lua"},
+	".swift":       {"swift"},
+	".applescript": {"osascript"},
+	".ps1":         {"powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-File"},
+	".bat":         {"cmd", "/c"},
+	".cmd":         {"cmd", "/c"},
+}
+
+func init() {
+	plugin.AllHosts = append(plugin.AllHosts, &ScriptHost{})
+}
+
+// ScriptHost runs script commands, each script is executed as a new process when it's queried or executed
+type ScriptHost struct {
+}
+
+func (s *ScriptHost) GetRuntime(ctx context.Context) plugin.Runtime {
+	return plugin.PLUGIN_RUNTIME_SCRIPT
+}
+
+func (s *ScriptHost) Start(ctx context.Context) error {
+	return nil
+}
+
+func (s *ScriptHost) Stop(ctx context.Context) {
+}
+
+func (s *ScriptHost) IsStarted(ctx context.Context) bool {
+	return true
+}
+
+func (s *ScriptHost) LoadPlugin(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) (plugin.Plugin, error) {
+	command, parseErr := plugin.ParseScriptCommand(filepath.Join(pluginDirectory, metadata.Entry))
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	return &ScriptPlugin{metadata: metadata, command: command, pluginDirectory: pluginDirectory}, nil
+}
+
+func (s *ScriptHost) UnloadPlugin(ctx context.Context, metadata plugin.Metadata) {
+}
+
+type ScriptPlugin struct {
+	metadata        plugin.Metadata
+	command         plugin.ScriptCommand
+	pluginDirectory string
+	api             plugin.API
+}
+
+func (s *ScriptPlugin) Init(ctx context.Context, initParams plugin.InitParams) {
+	s.api = initParams.API
+}
+
+func (s *ScriptPlugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	icon := s.metadata.GetIconOrDefault(s.pluginDirectory, plugin.NewWoxImageEmoji("📜"))
+	arguments, missingArgument := s.parseArguments(query.Search)
+	if missingArgument != "" {
+		return []plugin.QueryResult{
+			{
+				Title:    s.command.Title,
+				SubTitle: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_script_missing_argument"), missingArgument),
+				Icon:     icon,
+			},
+		}
+	}
+
+	switch s.command.Mode {
+	case plugin.ScriptCommandModePreview:
+		output, runErr := s.run(ctx, query, arguments, scriptQueryTimeout)
+		if runErr != nil {
+			return []plugin.QueryResult{plugin.GetPluginManager().GetResultForFailedQuery(ctx, s.metadata, query, runErr)}
+		}
+		return []plugin.QueryResult{
+			{
+				Title:    s.command.Title,
+				SubTitle: s.command.Description,
+				Icon:     icon,
+				Preview: plugin.WoxPreview{
+					PreviewType: plugin.WoxPreviewTypeText,
+					PreviewData: output,
+				},
+				Actions: []plugin.QueryResultAction{
+					s.getCopyAction(ctx, output),
+				},
+			},
+		}
+	case plugin.ScriptCommandModeLines:
+		output, runErr := s.run(ctx, query, arguments, scriptQueryTimeout)
+		if runErr != nil {
+			return []plugin.QueryResult{plugin.GetPluginManager().GetResultForFailedQuery(ctx, s.metadata, query, runErr)}
+		}
+		return s.getLineResults(ctx, query, output, icon)
+	default:
+		return []plugin.QueryResult{
+			{
+				Title:    s.command.Title,
+				SubTitle: s.command.Description,
+				Icon:     icon,
+				Actions: []plugin.QueryResultAction{
+					{
+						Name: i18n.GetI18nManager().TranslateWox(ctx, "plugin_script_run"),
+						Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+							s.runInAction(ctx, query, arguments)
+						},
+					},
+				},
+			},
+		}
+	}
+}
+
+func (s *ScriptPlugin) getLineResults(ctx context.Context, query plugin.Query, output string, icon plugin.WoxImage) []plugin.QueryResult {
+	var results []plugin.QueryResult
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() && len(results) < scriptMaxLines {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// script doesn't receive search as arguments, so filter lines by search for it
+		if len(s.command.Arguments) == 0 && query.Search != "" && !util.IsStringMatch(line, query.Search, false) {
+			continue
+		}
+
+		results = append(results, plugin.QueryResult{
+			Title:   line,
+			Icon:    icon,
+			Actions: []plugin.QueryResultAction{s.getCopyAction(ctx, line)},
+		})
+	}
+
+	return results
+}
+
+func (s *ScriptPlugin) getCopyAction(ctx context.Context, text string) plugin.QueryResultAction {
+	return plugin.QueryResultAction{
+		Name: i18n.GetI18nManager().TranslateWox(ctx, "plugin_script_copy"),
+		Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+			clipboard.WriteText(text)
+		},
+	}
+}
+
+func (s *ScriptPlugin) runInAction(ctx context.Context, query plugin.Query, arguments []string) {
+	output, runErr := s.run(ctx, query, arguments, scriptActionTimeout)
+	if runErr != nil {
+		s.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_script_run_failed"), s.command.Title, runErr.Error()))
+		return
+	}
+
+	if s.command.Mode == plugin.ScriptCommandModeNotify {
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		lastLine := strings.TrimSpace(lines[len(lines)-1])
+		if lastLine == "" {
+			lastLine = fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_script_run_finished"), s.command.Title)
+		}
+		s.api.Notify(ctx, lastLine)
+	}
+}
+
+// parseArguments splits search into declared arguments, last argument takes the rest of search.
+// If a required argument is not provided, its placeholder is returned as missingArgument
+func (s *ScriptPlugin) parseArguments(search string) (arguments []string, missingArgument string) {
+	if len(s.command.Arguments) == 0 {
+		return nil, ""
+	}
+
+	fields := strings.Fields(search)
+	if len(fields) > len(s.command.Arguments) {
+		last := strings.Join(fields[len(s.command.Arguments)-1:], " ")
+		fields = append(fields[:len(s.command.Arguments)-1], last)
+	}
+
+	for i, argument := range s.command.Arguments {
+		if i < len(fields) {
+			arguments = append(arguments, fields[i])
+			continue
+		}
+		if !argument.Optional {
+			placeholder := argument.Placeholder
+			if placeholder == "" {
+				placeholder = fmt.Sprintf("argument%d", i+1)
+			}
+			return nil, placeholder
+		}
+		arguments = append(arguments, "")
+	}
+
+	return arguments, ""
+}
+
+func (s *ScriptPlugin) run(ctx context.Context, query plugin.Query, arguments []string, timeout time.Duration) (string, error) {
+	name := s.command.ScriptPath
+	var args []string
+	if interpreter := getScriptInterpreter(s.command.ScriptPath); len(interpreter) > 0 {
+		name = interpreter[0]
+		args = append(args, interpreter[1:]...)
+		args = append(args, s.command.ScriptPath)
+	}
+	args = append(args, arguments...)
+
+	var stdout, stderr bytes.Buffer
+	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> run script: %s %s", s.command.Title, name, strings.Join(args, " ")))
+	runErr := util.ShellRunWithTimeout(ctx, timeout, func(cmd *exec.Cmd) {
+		cmd.Dir = s.pluginDirectory
+		cmd.Env = append(os.Environ(),
+			"WOX_QUERY="+query.Search,
+			"WOX_SCRIPT_DIRECTORY="+s.pluginDirectory,
+		)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+	}, name, args...)
+	if errors.Is(runErr, util.ErrShellTimeout) {
+		return "", fmt.Errorf("script %w", runErr)
+	}
+	if runErr != nil {
+		errMsg := strings.TrimSpace(stderr.String())
+		if errMsg == "" {
+			errMsg = runErr.Error()
+		}
+		return "", fmt.Errorf("%s", errMsg)
+	}
+
+	return stdout.String(), nil
+}
+
+// getScriptInterpreter returns the interpreter command of the script, resolved from shebang or file extension.
+// Empty means the script is run directly, e.g. it has executable permission
+func getScriptInterpreter(scriptPath string) []string {
+	if !util.IsWindows() {
+		if stat, statErr := os.Stat(scriptPath); statErr == nil && stat.Mode()&0111 != 0 {
+			return nil
+		}
+	}
+
+	if shebang := readShebang(scriptPath); len(shebang) > 0 {
+		if !util.IsWindows() {
+			return shebang
+		}
+		// unix paths in shebang don't exist on windows, e.g. "#!/usr/bin/env python3" => python3
+		if filepath.Base(shebang[0]) == "env" && len(shebang) > 1 {
+			return shebang[1:]
+		}
+		return []string{filepath.Base(shebang[0])}
+	}
+
+	if interpreter, exist := scriptInterpreters[strings.ToLower(filepath.Ext(scriptPath))]; exist {
+		if interpreter[0] == "python3" && util.IsWindows() {
+			return []string{"python"}
+		}
+		return interpreter
+	}
+
+	return nil
+}
+
+func readShebang(scriptPath string) []string {
+	file, openErr := os.Open(scriptPath)
+	if openErr != nil {
+		return nil
+	}
+	defer file.Close()
+
+	firstLine, _ := bufio.NewReader(file).ReadString('\n')
+	if !strings.HasPrefix(firstLine, "#!") {
+		return nil
+	}
+
+	return strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+}
@@ -0,0 +1,199 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"wox/i18n"
+	"wox/plugin"
+	"wox/share"
+	"wox/util"
+)
+
+const (
+	hostStableDuration        = time.Minute      // host running longer than this is considered stable, consecutive crash count is reset
+	hostMaxRestartBackoff     = time.Minute      // max wait time before restarting a crashed host
+	hostDisconnectGracePeriod = 10 * time.Second // host process is killed if websocket can't reconnect within this period
+	hostCrashNotifyThreshold  = 3                // notify user after host crashed this many times in a row
+	hostStderrMaxLines        = 20
+)
+
+type hostStartOptions struct {
+	executablePath string
+	entry          string
+	envs           []string
+	executableArgs []string
+}
+
+// hostSupervisor keeps the state used to restart host process after crash
+type hostSupervisor struct {
+	options               hostStartOptions
+	stderr                *util.TailWriter
+	isStopping            bool // host is stopped by wox, exit of host process is expected
+	isRestarting          bool
+	startTimestamp        int64
+	consecutiveCrashCount int
+	crashCount            int
+	lastCrashTimestamp    int64
+	lock                  sync.Mutex
+}
+
+func (s *hostSupervisor) getStderr() *util.TailWriter {
+	if s.stderr == nil {
+		s.stderr = util.NewTailWriter(hostStderrMaxLines)
+	}
+	return s.stderr
+}
+
+// getRestartBackoff returns the wait time before restarting host, doubles with each consecutive crash
+func getRestartBackoff(consecutiveCrashCount int) time.Duration {
+	if consecutiveCrashCount <= 1 {
+		return time.Second
+	}
+	backoff := time.Second << min(consecutiveCrashCount-1, 6)
+	return min(backoff, hostMaxRestartBackoff)
+}
+
+func (w *WebsocketHost) watchHostProcess(ctx context.Context, cmd *exec.Cmd) {
+	waitErr := cmd.Wait()
+
+	w.supervisor.lock.Lock()
+	isExpected := w.supervisor.isStopping || w.hostProcess != cmd.Process
+	w.supervisor.lock.Unlock()
+	if isExpected {
+		util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host process(%d) exited", w.getHostName(ctx), cmd.Process.Pid))
+		return
+	}
+
+	reason := "host process exited"
+	if waitErr != nil {
+		reason = fmt.Sprintf("host process exited: %s", waitErr)
+	}
+	w.onHostCrashed(ctx, reason)
+}
+
+// onHostDisconnected is called when websocket to host is lost, host may hang even if the process is still alive
+func (w *WebsocketHost) onHostDisconnected(ctx context.Context, ws *util.WebsocketClient) {
+	util.GetLogger().Warn(ctx, fmt.Sprintf("<%s> lost connection to host", w.getHostName(ctx)))
+	hostProcess := w.getHostProcess()
+
+	util.Go(ctx, fmt.Sprintf("<%s> check host connection", w.getHostName(ctx)), func() {
+		time.Sleep(hostDisconnectGracePeriod)
+
+		w.supervisor.lock.Lock()
+		isStopping := w.supervisor.isStopping
+		w.supervisor.lock.Unlock()
+		if isStopping || w.conn != hostConnection(ws) || ws.IsConnected() || hostProcess == nil || hostProcess != w.getHostProcess() {
+			return
+		}
+
+		// kill the unresponsive host, watchHostProcess will restart it
+		util.GetLogger().Error(ctx, fmt.Sprintf("<%s> host is not reconnected in %s, killing host process(%d)", w.getHostName(ctx), hostDisconnectGracePeriod, hostProcess.Pid))
+		if killErr := hostProcess.Kill(); killErr != nil {
+			util.GetLogger().Error(ctx, fmt.Sprintf("<%s> failed to kill host process(%d): %s", w.getHostName(ctx), hostProcess.Pid, killErr))
+		}
+	})
+}
+
+// onHostCrashed restarts host with backoff, it keeps trying until host is started or stopped by wox
+func (w *WebsocketHost) onHostCrashed(ctx context.Context, reason string) {
+	isStartFailed := false
+	for {
+		w.supervisor.lock.Lock()
+		if w.supervisor.isStopping || w.supervisor.isRestarting {
+			w.supervisor.lock.Unlock()
+			return
+		}
+		w.supervisor.isRestarting = true
+		// host which failed to start never ran, it's not stable
+		if !isStartFailed && util.GetSystemTimestamp()-w.supervisor.startTimestamp > hostStableDuration.Milliseconds() {
+			w.supervisor.consecutiveCrashCount = 0
+		}
+		w.supervisor.consecutiveCrashCount++
+		w.supervisor.crashCount++
+		w.supervisor.lastCrashTimestamp = util.GetSystemTimestamp()
+		consecutiveCrashCount := w.supervisor.consecutiveCrashCount
+		w.supervisor.lock.Unlock()
+
+		// responses of requests sent to the dead host will never arrive
+		w.failPendingRequests(ctx, fmt.Errorf("host crashed: %s", reason))
+
+		stderrLines := w.supervisor.getStderr().Lines()
+		util.GetLogger().Error(ctx, fmt.Sprintf("<%s> host crashed (%d times in a row): %s, last errors: %s", w.getHostName(ctx), consecutiveCrashCount, reason, strings.Join(stderrLines, "\n")))
+
+		if w.conn != nil {
+			w.conn.Close(ctx)
+		}
+
+		if consecutiveCrashCount == hostCrashNotifyThreshold {
+			plugin.GetPluginManager().GetUI().Notify(ctx, share.NotifyMsg{
+				Text:           fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_host_crashed_notify"), w.host.GetRuntime(ctx), consecutiveCrashCount),
+				DisplaySeconds: 8,
+			})
+		}
+
+		backoff := getRestartBackoff(consecutiveCrashCount)
+		util.GetLogger().Info(ctx, fmt.Sprintf("<%s> restarting host in %s", w.getHostName(ctx), backoff))
+		time.Sleep(backoff)
+
+		w.supervisor.lock.Lock()
+		w.supervisor.isRestarting = false
+		isStopping := w.supervisor.isStopping
+		w.supervisor.lock.Unlock()
+		if isStopping {
+			return
+		}
+
+		startErr := w.startHostProcess(ctx)
+		if startErr == nil {
+			break
+		}
+		isStartFailed = true
+		reason = startErr.Error()
+	}
+
+	plugin.GetPluginManager().ReloadHostPlugins(ctx, w.host)
+}
+
+func (w *WebsocketHost) GetStatus(ctx context.Context) plugin.HostStatus {
+	w.supervisor.lock.Lock()
+	defer w.supervisor.lock.Unlock()
+
+	return plugin.HostStatus{
+		IsStarted:          w.IsHostStarted(ctx),
+		CrashCount:         w.supervisor.crashCount,
+		LastCrashTimestamp: w.supervisor.lastCrashTimestamp,
+		LastErrorLines:     w.supervisor.getStderr().Lines(),
+	}
+}
+
+// Restart restarts host process manually and resets crash statistics
+func (w *WebsocketHost) Restart(ctx context.Context) error {
+	w.supervisor.lock.Lock()
+	executablePath := w.supervisor.options.executablePath
+	w.supervisor.lock.Unlock()
+	if executablePath == "" {
+		return fmt.Errorf("host is never started")
+	}
+
+	w.StopHost(ctx)
+
+	w.supervisor.lock.Lock()
+	w.supervisor.isStopping = false
+	w.supervisor.consecutiveCrashCount = 0
+	w.supervisor.crashCount = 0
+	w.supervisor.lastCrashTimestamp = 0
+	w.supervisor.lock.Unlock()
+	w.supervisor.getStderr().Reset()
+
+	startErr := w.startHostProcess(ctx)
+	if startErr != nil {
+		return startErr
+	}
+
+	plugin.GetPluginManager().ReloadHostPlugins(ctx, w.host)
+	return nil
+}
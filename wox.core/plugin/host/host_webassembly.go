@@ -0,0 +1,585 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"wox/plugin"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/google/uuid"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	wasmCallTimeout        = 30 * time.Second
+	wasmMemoryLimitPages   = 4096 // 256MB, one page is 64KB
+	wasmHttpMaxBodySize    = 10 * 1024 * 1024
+	wasmPluginGuestPath    = "/plugin"
+	wasmHostModuleName     = "wox"
+	wasmHostCallFunction   = "call"
+	wasmGuestAllocFunction = "wox_alloc"
+	wasmGuestFreeFunction  = "wox_free"
+	wasmGuestCallFunction  = "wox_handle"
+)
+
+func init() {
+	plugin.AllHosts = append(plugin.AllHosts, &WasmHost{
+		connections: util.NewHashMap[string, *wasmConnection](),
+	})
+}
+
+// WasmHost runs webassembly plugins in a sandbox, plugin can only access its own directory and the files and network granted by user.
+//
+// Plugin is a WASI reactor module which exports:
+//   - wox_alloc(size i32) i32: allocate memory for messages sent to plugin
+//   - wox_handle(ptr i32, size i32) i64: handle a json rpc request, returns response as ptr<<32|size
+//   - wox_free(ptr i32, size i32): optional, free memory allocated by wox_alloc
+//
+// and imports wox.call(ptr i32, size i32) i64 to send json rpc requests to Wox. Messages are the same as python and nodejs hosts.
+type WasmHost struct {
+	runtime     wazero.Runtime
+	connections *util.HashMap[string, *wasmConnection] // plugin id -> connection of plugin module
+	lock        sync.Mutex
+}
+
+func (w *WasmHost) GetRuntime(ctx context.Context) plugin.Runtime {
+	return plugin.PLUGIN_RUNTIME_WASM
+}
+
+func (w *WasmHost) Start(ctx context.Context) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.runtime != nil {
+		return nil
+	}
+
+	// close module when call is timeout, so a plugin with infinite loop can't block wox
+	runtimeConfig := wazero.NewRuntimeConfig().WithCloseOnContextDone(true).WithMemoryLimitPages(wasmMemoryLimitPages)
+	cache, cacheErr := wazero.NewCompilationCacheWithDir(path.Join(util.GetLocation().GetCacheDirectory(), "wasm"))
+	if cacheErr != nil {
+		util.GetLogger().Warn(ctx, fmt.Sprintf("failed to create wasm compilation cache: %s", cacheErr))
+	} else {
+		runtimeConfig = runtimeConfig.WithCompilationCache(cache)
+	}
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	if _, wasiErr := wasi_snapshot_preview1.Instantiate(ctx, runtime); wasiErr != nil {
+		runtime.Close(ctx)
+		return fmt.Errorf("failed to instantiate wasi: %w", wasiErr)
+	}
+	_, hostModuleErr := runtime.NewHostModuleBuilder(wasmHostModuleName).
+		NewFunctionBuilder().WithFunc(w.onHostCall).Export(wasmHostCallFunction).
+		Instantiate(ctx)
+	if hostModuleErr != nil {
+		runtime.Close(ctx)
+		return fmt.Errorf("failed to instantiate wox host module: %w", hostModuleErr)
+	}
+
+	w.runtime = runtime
+	return nil
+}
+
+func (w *WasmHost) Stop(ctx context.Context) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.connections.Range(func(pluginId string, conn *wasmConnection) bool {
+		conn.Close(ctx)
+		return true
+	})
+	w.connections.Clear()
+	if w.runtime != nil {
+		w.runtime.Close(ctx)
+		w.runtime = nil
+	}
+}
+
+func (w *WasmHost) IsStarted(ctx context.Context) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.runtime != nil
+}
+
+func (w *WasmHost) LoadPlugin(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) (plugin.Plugin, error) {
+	util.GetLogger().Info(ctx, fmt.Sprintf("start loading %s plugin, directory: %s", metadata.Name, pluginDirectory))
+
+	w.lock.Lock()
+	runtime := w.runtime
+	w.lock.Unlock()
+	if runtime == nil {
+		return nil, fmt.Errorf("wasm host is not started")
+	}
+
+	// plugin may be reloaded, e.g. dev plugin changed
+	if existConn, exist := w.connections.Load(metadata.Id); exist {
+		existConn.Close(ctx)
+		w.connections.Delete(metadata.Id)
+	}
+
+	wasmBytes, readErr := os.ReadFile(filepath.Join(pluginDirectory, metadata.Entry))
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read wasm module: %w", readErr)
+	}
+	compiledModule, compileErr := runtime.CompileModule(ctx, wasmBytes)
+	if compileErr != nil {
+		return nil, fmt.Errorf("failed to compile wasm module: %w", compileErr)
+	}
+
+	pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Id, metadata.Name, metadata.SettingDefinitions)
+	if settingErr != nil {
+		return nil, fmt.Errorf("failed to load plugin setting: %w", settingErr)
+	}
+	permissions := plugin.GetEffectivePermissions(metadata.Permissions, pluginSetting.GrantedPermissions)
+	if len(permissions.FileSystem) != len(metadata.Permissions.FileSystem) || len(permissions.Network) != len(metadata.Permissions.Network) {
+		util.GetLogger().Warn(ctx, fmt.Sprintf("<%s> some permissions are not granted, granted: %+v, requested: %+v", metadata.Name, permissions, metadata.Permissions))
+	}
+
+	moduleConfig, configErr := getWasmModuleConfig(metadata, pluginDirectory, permissions)
+	if configErr != nil {
+		return nil, configErr
+	}
+
+	process := &WebsocketHost{
+		host:       w,
+		pluginId:   metadata.Id,
+		requestMap: util.NewHashMap[string, chan JsonRpcResponse](),
+	}
+	conn := newWasmConnection(w, process, metadata, permissions)
+	process.conn = conn
+	// plugin may call wox in _initialize, connection must be registered before instantiating
+	w.connections.Store(metadata.Id, conn)
+
+	module, instantiateErr := runtime.InstantiateModule(ctx, compiledModule, moduleConfig)
+	if instantiateErr != nil {
+		w.connections.Delete(metadata.Id)
+		return nil, fmt.Errorf("failed to instantiate wasm module: %w", instantiateErr)
+	}
+	if module.ExportedFunction(wasmGuestAllocFunction) == nil || module.ExportedFunction(wasmGuestCallFunction) == nil {
+		module.Close(ctx)
+		w.connections.Delete(metadata.Id)
+		return nil, fmt.Errorf("wasm module must export %s and %s", wasmGuestAllocFunction, wasmGuestCallFunction)
+	}
+	conn.setModule(module)
+
+	return NewWebsocketPlugin(metadata, process), nil
+}
+
+func (w *WasmHost) UnloadPlugin(ctx context.Context, metadata plugin.Metadata) {
+	conn, exist := w.connections.Load(metadata.Id)
+	if !exist {
+		return
+	}
+
+	_, unloadPluginErr := conn.process.invokeMethod(ctx, metadata, "unloadPlugin", map[string]string{
+		"PluginId": metadata.Id,
+	})
+	if unloadPluginErr != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("failed to unload %s plugin: %s", metadata.Name, unloadPluginErr))
+	}
+
+	conn.Close(ctx)
+	w.connections.Delete(metadata.Id)
+}
+
+// onHostCall is invoked when plugin calls wox.call, module name is the plugin id
+func (w *WasmHost) onHostCall(ctx context.Context, module api.Module, ptr uint32, size uint32) uint64 {
+	conn, exist := w.connections.Load(module.Name())
+	if !exist {
+		util.GetLogger().Error(ctx, fmt.Sprintf("failed to find wasm connection of module %s", module.Name()))
+		return 0
+	}
+
+	return conn.onHostCall(ctx, module, ptr, size)
+}
+
+// getWasmModuleConfig grants resources to plugin module. Plugin directory is mounted read-only at /plugin,
+// granted directories are mounted at the same path as on host, nothing else is accessible
+func getWasmModuleConfig(metadata plugin.Metadata, pluginDirectory string, permissions setting.PluginPermissions) (wazero.ModuleConfig, error) {
+	fsConfig := wazero.NewFSConfig().WithReadOnlyDirMount(pluginDirectory, wasmPluginGuestPath)
+	for _, permission := range permissions.FileSystem {
+		hostPath, expandErr := plugin.ExpandPermissionPath(permission.Path)
+		if expandErr != nil {
+			return nil, expandErr
+		}
+		guestPath := getWasmGuestPath(hostPath)
+		if permission.ReadOnly {
+			fsConfig = fsConfig.WithReadOnlyDirMount(hostPath, guestPath)
+		} else {
+			fsConfig = fsConfig.WithDirMount(hostPath, guestPath)
+		}
+	}
+
+	return wazero.NewModuleConfig().
+		WithName(metadata.Id).
+		WithFSConfig(fsConfig).
+		WithStartFunctions("_initialize").
+		WithStdout(util.GetLogger().GetWriter()).
+		WithStderr(util.GetLogger().GetWriter()).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader).
+		WithEnv("WOX_PLUGIN_ID", metadata.Id).
+		WithEnv("WOX_PLUGIN_DIRECTORY", wasmPluginGuestPath), nil
+}
+
+// getWasmGuestPath converts host path to path inside sandbox, e.g. C:\Users\wox => /c/Users/wox
+func getWasmGuestPath(hostPath string) string {
+	guestPath := filepath.ToSlash(hostPath)
+	if volume := filepath.VolumeName(hostPath); volume != "" {
+		guestPath = "/" + strings.ToLower(strings.TrimSuffix(volume, ":")) + filepath.ToSlash(strings.TrimPrefix(hostPath, volume))
+	}
+	return guestPath
+}
+
+type wasmGuestCall struct {
+	data   []byte
+	result chan []byte
+}
+
+// wasmConnection delivers json rpc messages between wox and plugin module by calling module functions directly
+type wasmConnection struct {
+	host        *WasmHost
+	process     *WebsocketHost
+	metadata    plugin.Metadata
+	permissions setting.PluginPermissions
+	module      api.Module
+	moduleLock  sync.RWMutex
+
+	busy              chan struct{}      // module is single threaded, a token is held while module is running
+	inbox             chan wasmGuestCall // requests to module while module is waiting for a host call
+	hostCallResponses *util.HashMap[string, chan []byte]
+	closed            chan struct{}
+	closeOnce         sync.Once
+}
+
+func newWasmConnection(host *WasmHost, process *WebsocketHost, metadata plugin.Metadata, permissions setting.PluginPermissions) *wasmConnection {
+	return &wasmConnection{
+		host:              host,
+		process:           process,
+		metadata:          metadata,
+		permissions:       permissions,
+		busy:              make(chan struct{}, 1),
+		inbox:             make(chan wasmGuestCall),
+		hostCallResponses: util.NewHashMap[string, chan []byte](),
+		closed:            make(chan struct{}),
+	}
+}
+
+func (c *wasmConnection) Send(ctx context.Context, data []byte) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("wasm module is closed")
+	}
+
+	if gjson.GetBytes(data, "Type").String() == string(JsonRpcTypeResponse) {
+		// response of a host call, the module is waiting for it in onHostCall
+		if responseChan, exist := c.hostCallResponses.Load(gjson.GetBytes(data, "Id").String()); exist {
+			select {
+			case responseChan <- data:
+			default:
+			}
+		}
+		return nil
+	}
+
+	if gjson.GetBytes(data, "Method").String() == JsonRpcMethodCancel {
+		// module is single threaded, a running call can't be canceled, it's stopped when its deadline is exceeded
+		return nil
+	}
+
+	util.Go(ctx, fmt.Sprintf("<%s> call wasm module", c.metadata.Name), func() {
+		response := c.callGuest(ctx, data)
+		if response != nil {
+			c.process.onMessage(string(response))
+		}
+	})
+	return nil
+}
+
+func (c *wasmConnection) IsConnected() bool {
+	select {
+	case <-c.closed:
+		return false
+	default:
+		return c.getModule() != nil
+	}
+}
+
+func (c *wasmConnection) Close(ctx context.Context) {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		if module := c.getModule(); module != nil {
+			module.Close(ctx)
+		}
+	})
+}
+
+func (c *wasmConnection) getModule() api.Module {
+	c.moduleLock.RLock()
+	defer c.moduleLock.RUnlock()
+	return c.module
+}
+
+func (c *wasmConnection) setModule(module api.Module) {
+	c.moduleLock.Lock()
+	defer c.moduleLock.Unlock()
+	c.module = module
+}
+
+// callGuest waits until module is idle and calls it. If module is waiting for a host call,
+// the request is handed over to onHostCall, which calls module re-entrantly, e.g. setting changed callback triggered by SaveSetting
+func (c *wasmConnection) callGuest(ctx context.Context, data []byte) []byte {
+	call := wasmGuestCall{data: data, result: make(chan []byte, 1)}
+	select {
+	case c.busy <- struct{}{}:
+		defer func() { <-c.busy }()
+		return c.invokeGuest(ctx, data)
+	case c.inbox <- call:
+		return <-call.result
+	case <-c.closed:
+		return nil
+	}
+}
+
+func (c *wasmConnection) invokeGuest(ctx context.Context, data []byte) []byte {
+	// module is closed if it's still running after timeout, so an infinite loop can't block wox.
+	// Canceled requests (e.g. superseded queries) must not close module, so caller cancellation is ignored
+	callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), wasmCallTimeout)
+	defer cancel()
+
+	ptr, writeErr := c.writeToGuest(callCtx, data)
+	if writeErr != nil {
+		return newWasmErrorResponse(data, writeErr)
+	}
+	module := c.getModule()
+	results, callErr := module.ExportedFunction(wasmGuestCallFunction).Call(callCtx, uint64(ptr), uint64(len(data)))
+	c.freeGuest(callCtx, ptr, uint32(len(data)))
+	if callErr != nil {
+		if module.IsClosed() {
+			c.onModuleClosed(ctx, callErr)
+		}
+		return newWasmErrorResponse(data, callErr)
+	}
+
+	response, readErr := c.readFromGuest(callCtx, results[0])
+	if readErr != nil {
+		return newWasmErrorResponse(data, readErr)
+	}
+	return response
+}
+
+// onModuleClosed instantiates the plugin again after wazero closed the module of a timeout call, the module can't be called anymore
+func (c *wasmConnection) onModuleClosed(ctx context.Context, reason error) {
+	select {
+	case <-c.closed:
+		// closed by unloading plugin or stopping host
+		return
+	default:
+	}
+
+	util.GetLogger().Error(ctx, fmt.Sprintf("<%s> wasm module is closed: %s, instantiating again", c.metadata.Name, reason))
+	c.Close(ctx)
+	util.Go(ctx, fmt.Sprintf("<%s> reinstantiate wasm module", c.metadata.Name), func() {
+		// plugin may be unloaded or reloaded meanwhile
+		if current, exist := c.host.connections.Load(c.metadata.Id); !exist || current != c {
+			return
+		}
+		plugin.GetPluginManager().ReloadHostPlugin(context.WithoutCancel(ctx), c.host, c.metadata.Id)
+	})
+}
+
+// onHostCall handles a json rpc request from module, it's running in the goroutine of the module call
+func (c *wasmConnection) onHostCall(ctx context.Context, module api.Module, ptr uint32, size uint32) uint64 {
+	if c.getModule() == nil {
+		// called during instantiating
+		c.setModule(module)
+	}
+
+	data, readOk := module.Memory().Read(ptr, size)
+	if !readOk {
+		return 0
+	}
+	data = bytes.Clone(data)
+
+	var request JsonRpcRequest
+	if unmarshalErr := json.Unmarshal(data, &request); unmarshalErr != nil {
+		return c.writeResponse(ctx, newWasmErrorResponse(data, unmarshalErr))
+	}
+	if request.Id == "" {
+		request.Id = uuid.NewString()
+	}
+	request.Type = JsonRpcTypeRequest
+	request.PluginName = c.metadata.Name
+
+	if request.Method == "HttpRequest" {
+		return c.writeResponse(ctx, c.httpRequest(ctx, request))
+	}
+
+	responseChan := make(chan []byte, 1)
+	c.hostCallResponses.Store(request.Id, responseChan)
+	defer c.hostCallResponses.Delete(request.Id)
+
+	util.Go(ctx, fmt.Sprintf("<%s> handle wasm host call", c.metadata.Name), func() {
+		c.process.handleRequestFromPlugin(ctx, request)
+		// some methods don't respond if parameters are invalid, don't let module wait forever
+		select {
+		case responseChan <- newWasmErrorResponse(data, fmt.Errorf("%s has no response", request.Method)):
+		default:
+		}
+	})
+
+	timeout := time.NewTimer(wasmCallTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case response := <-responseChan:
+			return c.writeResponse(ctx, response)
+		case call := <-c.inbox:
+			call.result <- c.invokeGuest(ctx, call.data)
+		case <-timeout.C:
+			return c.writeResponse(ctx, newWasmErrorResponse(data, fmt.Errorf("%s timeout", request.Method)))
+		}
+	}
+}
+
+// httpRequest sends http request for module, only hosts granted in network permission are allowed
+func (c *wasmConnection) httpRequest(ctx context.Context, request JsonRpcRequest) []byte {
+	requestJson, _ := json.Marshal(request)
+
+	targetUrl, parseErr := url.Parse(request.Params["url"])
+	if parseErr != nil {
+		return newWasmErrorResponse(requestJson, parseErr)
+	}
+	if !plugin.IsNetworkAllowed(c.permissions, targetUrl.Hostname()) {
+		return newWasmErrorResponse(requestJson, fmt.Errorf("network access to %s is not granted", targetUrl.Hostname()))
+	}
+
+	method := request.Params["method"]
+	if method == "" {
+		method = http.MethodGet
+	}
+	httpRequest, requestErr := http.NewRequestWithContext(ctx, method, targetUrl.String(), strings.NewReader(request.Params["body"]))
+	if requestErr != nil {
+		return newWasmErrorResponse(requestJson, requestErr)
+	}
+	if headers := request.Params["headers"]; headers != "" {
+		var headerMap map[string]string
+		if unmarshalErr := json.Unmarshal([]byte(headers), &headerMap); unmarshalErr != nil {
+			return newWasmErrorResponse(requestJson, fmt.Errorf("failed to unmarshal headers: %w", unmarshalErr))
+		}
+		for key, value := range headerMap {
+			httpRequest.Header.Set(key, value)
+		}
+	}
+
+	client := &http.Client{
+		Timeout: wasmCallTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !plugin.IsNetworkAllowed(c.permissions, req.URL.Hostname()) {
+				return fmt.Errorf("network access to %s is not granted", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+	httpResponse, doErr := client.Do(httpRequest)
+	if doErr != nil {
+		return newWasmErrorResponse(requestJson, doErr)
+	}
+	defer httpResponse.Body.Close()
+
+	body, readErr := io.ReadAll(io.LimitReader(httpResponse.Body, wasmHttpMaxBodySize))
+	if readErr != nil {
+		return newWasmErrorResponse(requestJson, readErr)
+	}
+	headers := map[string]string{}
+	for key := range httpResponse.Header {
+		headers[key] = httpResponse.Header.Get(key)
+	}
+	result, _ := json.Marshal(map[string]any{
+		"StatusCode": httpResponse.StatusCode,
+		"Headers":    headers,
+		"Body":       string(body),
+	})
+
+	response, _ := json.Marshal(JsonRpcResponse{
+		TraceId: request.TraceId,
+		Id:      request.Id,
+		Method:  request.Method,
+		Type:    JsonRpcTypeResponse,
+		Result:  string(result),
+	})
+	return response
+}
+
+// writeResponse copies response into module memory, module should free it after reading
+func (c *wasmConnection) writeResponse(ctx context.Context, response []byte) uint64 {
+	ptr, writeErr := c.writeToGuest(ctx, response)
+	if writeErr != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("<%s> failed to write response to wasm module: %s", c.metadata.Name, writeErr))
+		return 0
+	}
+
+	return uint64(ptr)<<32 | uint64(len(response))
+}
+
+func (c *wasmConnection) writeToGuest(ctx context.Context, data []byte) (uint32, error) {
+	module := c.getModule()
+	results, allocErr := module.ExportedFunction(wasmGuestAllocFunction).Call(ctx, uint64(len(data)))
+	if allocErr != nil {
+		return 0, fmt.Errorf("failed to allocate wasm memory: %w", allocErr)
+	}
+
+	ptr := uint32(results[0])
+	if !module.Memory().Write(ptr, data) {
+		return 0, errors.New("failed to write wasm memory: out of range")
+	}
+	return ptr, nil
+}
+
+func (c *wasmConnection) readFromGuest(ctx context.Context, packed uint64) ([]byte, error) {
+	ptr, size := uint32(packed>>32), uint32(packed)
+	data, readOk := c.getModule().Memory().Read(ptr, size)
+	if !readOk {
+		return nil, errors.New("failed to read wasm memory: out of range")
+	}
+
+	data = bytes.Clone(data)
+	c.freeGuest(ctx, ptr, size)
+	return data, nil
+}
+
+func (c *wasmConnection) freeGuest(ctx context.Context, ptr uint32, size uint32) {
+	if free := c.getModule().ExportedFunction(wasmGuestFreeFunction); free != nil {
+		free.Call(ctx, uint64(ptr), uint64(size))
+	}
+}
+
+func newWasmErrorResponse(requestData []byte, err error) []byte {
+	response, _ := json.Marshal(JsonRpcResponse{
+		TraceId: gjson.GetBytes(requestData, "TraceId").String(),
+		Id:      gjson.GetBytes(requestData, "Id").String(),
+		Method:  gjson.GetBytes(requestData, "Method").String(),
+		Type:    JsonRpcTypeResponse,
+		Error:   err.Error(),
+	})
+	return response
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 	"wox/ai"
@@ -15,14 +16,25 @@ import (
 	"wox/util"
 
 	"github.com/google/uuid"
+	"github.com/struCoder/pidusage"
 	"github.com/tidwall/gjson"
 )
 
+// hostConnection is the transport of json rpc messages between wox and host
+type hostConnection interface {
+	Send(ctx context.Context, data []byte) error
+	IsConnected() bool
+	Close(ctx context.Context)
+}
+
 type WebsocketHost struct {
-	ws          *util.WebsocketClient
+	conn        hostConnection
 	host        plugin.Host
 	requestMap  *util.HashMap[string, chan JsonRpcResponse]
-	hostProcess *os.Process
+	hostProcess *os.Process // guarded by supervisor.lock
+	supervisor  hostSupervisor
+	limiter     requestLimiter
+	pluginId    string // set when the host process only runs one plugin, e.g. executable plugins
 }
 
 func (w *WebsocketHost) getHostName(ctx context.Context) string {
@@ -30,39 +42,73 @@ func (w *WebsocketHost) getHostName(ctx context.Context) string {
 }
 
 func (w *WebsocketHost) StartHost(ctx context.Context, executablePath string, entry string, envs []string, executableArgs ...string) error {
+	w.supervisor.lock.Lock()
+	w.supervisor.options = hostStartOptions{
+		executablePath: executablePath,
+		entry:          entry,
+		envs:           envs,
+		executableArgs: executableArgs,
+	}
+	w.supervisor.isStopping = false
+	w.supervisor.lock.Unlock()
+
+	return w.startHostProcess(ctx)
+}
+
+func (w *WebsocketHost) startHostProcess(ctx context.Context) error {
+	w.supervisor.lock.Lock()
+	options := w.supervisor.options
+	w.supervisor.lock.Unlock()
+
 	port, portErr := util.GetAvailableTcpPort(ctx)
 	if portErr != nil {
 		return fmt.Errorf("failed to get available port: %w", portErr)
 	}
 
 	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> starting host on port %d", w.getHostName(ctx), port))
-	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host path: %s", w.getHostName(ctx), executablePath))
-	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host entry: %s", w.getHostName(ctx), entry))
-	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host args: %s", w.getHostName(ctx), strings.Join(executableArgs, " ")))
+	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host path: %s", w.getHostName(ctx), options.executablePath))
+	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host entry: %s", w.getHostName(ctx), options.entry))
+	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host args: %s", w.getHostName(ctx), strings.Join(options.executableArgs, " ")))
 	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host log directory: %s", w.getHostName(ctx), util.GetLocation().GetLogHostsDirectory()))
 
 	var args []string
-	args = append(args, executableArgs...)
-	args = append(args, entry, fmt.Sprintf("%d", port), util.GetLocation().GetLogHostsDirectory(), fmt.Sprintf("%d", os.Getpid()))
+	args = append(args, options.executableArgs...)
+	args = append(args, options.entry, fmt.Sprintf("%d", port), util.GetLocation().GetLogHostsDirectory(), fmt.Sprintf("%d", os.Getpid()))
 
-	cmd, err := util.ShellRunWithEnv(executablePath, envs, args...)
+	cmd, err := util.ShellRunWithStderr(options.executablePath, options.envs, w.supervisor.getStderr(), args...)
 	if err != nil {
 		return fmt.Errorf("failed to start host: %w", err)
 	}
 	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> host pid: %d", w.getHostName(ctx), cmd.Process.Pid))
 
+	w.supervisor.lock.Lock()
+	w.hostProcess = cmd.Process
+	w.supervisor.startTimestamp = util.GetSystemTimestamp()
+	w.supervisor.lock.Unlock()
+	util.Go(ctx, fmt.Sprintf("<%s> watch host process", w.getHostName(ctx)), func() {
+		w.watchHostProcess(util.NewTraceContext(), cmd)
+	})
+
 	time.Sleep(time.Second) // wait for host to start
 	w.startWebsocketServer(ctx, port)
 
-	w.hostProcess = cmd.Process
 	return nil
 }
 
 func (w *WebsocketHost) StopHost(ctx context.Context) {
 	util.GetLogger().Info(ctx, fmt.Sprintf("<%s> stopping host", w.getHostName(ctx)))
-	if w.hostProcess != nil {
-		var pid = w.hostProcess.Pid
-		killErr := w.hostProcess.Kill()
+	w.supervisor.lock.Lock()
+	w.supervisor.isStopping = true
+	hostProcess := w.hostProcess
+	w.hostProcess = nil
+	w.supervisor.lock.Unlock()
+
+	if w.conn != nil {
+		w.conn.Close(ctx)
+	}
+	if hostProcess != nil {
+		var pid = hostProcess.Pid
+		killErr := hostProcess.Kill()
 		if killErr != nil {
 			util.GetLogger().Error(ctx, fmt.Sprintf("<%s> failed to kill host process(%d): %s", w.getHostName(ctx), pid, killErr))
 		} else {
@@ -71,8 +117,38 @@ func (w *WebsocketHost) StopHost(ctx context.Context) {
 	}
 }
 
+func (w *WebsocketHost) getHostProcess() *os.Process {
+	w.supervisor.lock.Lock()
+	defer w.supervisor.lock.Unlock()
+	return w.hostProcess
+}
+
 func (w *WebsocketHost) IsHostStarted(ctx context.Context) bool {
-	return w.ws != nil && w.ws.IsConnected()
+	return w.conn != nil && w.conn.IsConnected()
+}
+
+// getProcessStat returns resource usage of host process
+func (w *WebsocketHost) getProcessStat(ctx context.Context) (plugin.ProcessStat, error) {
+	hostProcess := w.getHostProcess()
+	if hostProcess == nil {
+		return plugin.ProcessStat{}, fmt.Errorf("host process is not started")
+	}
+
+	return getProcessStat(hostProcess.Pid, w.pluginId == "")
+}
+
+func getProcessStat(pid int, isShared bool) (plugin.ProcessStat, error) {
+	stat, statErr := pidusage.GetStat(pid)
+	if statErr != nil {
+		return plugin.ProcessStat{}, fmt.Errorf("failed to get stat of process(%d): %w", pid, statErr)
+	}
+
+	return plugin.ProcessStat{
+		Pid:      pid,
+		CPU:      stat.CPU,
+		Memory:   stat.Memory,
+		IsShared: isShared,
+	}, nil
 }
 
 func (w *WebsocketHost) LoadPlugin(ctx context.Context, metadata plugin.Metadata, pluginDirectory string) (plugin.Plugin, error) {
@@ -99,10 +175,23 @@ func (w *WebsocketHost) UnloadPlugin(ctx context.Context, metadata plugin.Metada
 }
 
 func (w *WebsocketHost) invokeMethod(ctx context.Context, metadata plugin.Metadata, method string, params map[string]string) (result any, err error) {
-	if w.ws == nil || !w.ws.IsConnected() {
+	if w.conn == nil || !w.conn.IsConnected() {
 		return "", fmt.Errorf("host is not connected")
 	}
 
+	// deadline of ctx is kept if it's earlier, e.g. caller only waits for a short time
+	timeout := getMethodTimeout(metadata, method)
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	release, acquireErr := w.limiter.acquire(requestCtx, metadata.Id)
+	if acquireErr != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("<%s> too many requests in flight, invoke plugin <%s> method: %s failed", w.getHostName(ctx), metadata.Name, method))
+		return "", fmt.Errorf("too many requests in flight: %w", acquireErr)
+	}
+	defer release()
+
+	deadline, _ := requestCtx.Deadline()
 	request := JsonRpcRequest{
 		TraceId:    util.GetContextTraceId(ctx),
 		Id:         uuid.NewString(),
@@ -111,6 +200,7 @@ func (w *WebsocketHost) invokeMethod(ctx context.Context, metadata plugin.Metada
 		Method:     method,
 		Type:       JsonRpcTypeRequest,
 		Params:     params,
+		Deadline:   deadline.UnixMilli(),
 	}
 	util.GetLogger().Debug(ctx, fmt.Sprintf("<Wox -> %s> inovke plugin <%s> method: %s, request id: %s", w.getHostName(ctx), metadata.Name, method, request.Id))
 
@@ -119,20 +209,26 @@ func (w *WebsocketHost) invokeMethod(ctx context.Context, metadata plugin.Metada
 		return "", marshalErr
 	}
 
-	resultChan := make(chan JsonRpcResponse)
+	// buffered, so a response arriving after timeout doesn't block the sender
+	resultChan := make(chan JsonRpcResponse, 1)
 	w.requestMap.Store(request.Id, resultChan)
 	defer w.requestMap.Delete(request.Id)
 
 	startTimestamp := util.GetSystemTimestamp()
-	sendErr := w.ws.Send(ctx, jsonData)
+	sendErr := w.conn.Send(ctx, jsonData)
 	if sendErr != nil {
 		return "", sendErr
 	}
 
 	select {
-	case <-time.NewTimer(time.Second * 30).C:
-		util.GetLogger().Error(ctx, fmt.Sprintf("invoke %s response timeout, response time: %dms", metadata.Name, util.GetSystemTimestamp()-startTimestamp))
-		return "", fmt.Errorf("request timeout, request id: %s", request.Id)
+	case <-requestCtx.Done():
+		w.cancelRequest(ctx, request)
+		if errors.Is(requestCtx.Err(), context.DeadlineExceeded) {
+			util.GetLogger().Error(ctx, fmt.Sprintf("invoke %s method: %s response timeout, response time: %dms", metadata.Name, method, util.GetSystemTimestamp()-startTimestamp))
+			return "", fmt.Errorf("request timeout after %s, request id: %s", timeout, request.Id)
+		}
+		util.GetLogger().Info(ctx, fmt.Sprintf("invoke %s method: %s is canceled, response time: %dms", metadata.Name, method, util.GetSystemTimestamp()-startTimestamp))
+		return "", fmt.Errorf("request canceled, request id: %s", request.Id)
 	case response := <-resultChan:
 		util.GetLogger().Debug(ctx, fmt.Sprintf("inovke plugin <%s> method: %s finished, response time: %dms", metadata.Name, method, util.GetSystemTimestamp()-startTimestamp))
 		if response.Error != "" {
@@ -144,13 +240,22 @@ func (w *WebsocketHost) invokeMethod(ctx context.Context, metadata plugin.Metada
 }
 
 func (w *WebsocketHost) startWebsocketServer(ctx context.Context, port int) {
-	w.ws = util.NewWebsocketClient(fmt.Sprintf("ws://localhost:%d", port))
-	w.ws.OnMessage(ctx, func(data []byte) {
+	if w.conn != nil {
+		// client of the previous host process, stop it from reconnecting to the dead port
+		w.conn.Close(ctx)
+	}
+
+	ws := util.NewWebsocketClient(fmt.Sprintf("ws://localhost:%d", port))
+	ws.OnMessage(ctx, func(data []byte) {
 		util.Go(ctx, fmt.Sprintf("<%s> onMessage", w.getHostName(ctx)), func() {
 			w.onMessage(string(data))
 		})
 	})
-	connErr := w.ws.Connect(ctx)
+	ws.OnDisconnected(ctx, func() {
+		w.onHostDisconnected(util.NewTraceContext(), ws)
+	})
+	w.conn = ws
+	connErr := ws.Connect(ctx)
 	if connErr != nil {
 		util.GetLogger().Error(ctx, fmt.Sprintf("<%s> failed to connect to host: %s", w.getHostName(ctx), connErr))
 		return
@@ -203,6 +308,11 @@ func (w *WebsocketHost) handleRequestFromPlugin(ctx context.Context, request Jso
 		util.GetLogger().Info(ctx, fmt.Sprintf("got request from plugin <%s>, method: %s", request.PluginName, request.Method))
 	}
 
+	if w.pluginId != "" {
+		// process of a single plugin can only act as itself
+		request.PluginId = w.pluginId
+	}
+
 	var pluginInstance *plugin.Instance
 	for _, instance := range plugin.GetPluginManager().GetPluginInstances() {
 		if instance.Metadata.Id == request.PluginId {
@@ -472,17 +582,118 @@ func (w *WebsocketHost) handleRequestFromPlugin(ctx context.Context, request Jso
 		}
 
 		w.sendResponseToHost(ctx, request, "")
+	case "AIIndexDocuments":
+		index, model, ok := w.getVectorIndexParams(ctx, request)
+		if !ok {
+			return
+		}
+		var documents []ai.VectorDocument
+		unmarshalErr := json.Unmarshal([]byte(request.Params["documents"]), &documents)
+		if unmarshalErr != nil {
+			w.sendErrorResponseToHost(ctx, request, fmt.Errorf("failed to unmarshal documents: %w", unmarshalErr))
+			return
+		}
+
+		indexErr := pluginInstance.API.AIIndexDocuments(ctx, index, model, documents)
+		if indexErr != nil {
+			w.sendErrorResponseToHost(ctx, request, indexErr)
+			return
+		}
+		w.sendResponseToHost(ctx, request, "")
+	case "AIRemoveDocuments":
+		index, model, ok := w.getVectorIndexParams(ctx, request)
+		if !ok {
+			return
+		}
+		var ids []string
+		unmarshalErr := json.Unmarshal([]byte(request.Params["ids"]), &ids)
+		if unmarshalErr != nil {
+			w.sendErrorResponseToHost(ctx, request, fmt.Errorf("failed to unmarshal ids: %w", unmarshalErr))
+			return
+		}
+
+		removeErr := pluginInstance.API.AIRemoveDocuments(ctx, index, model, ids)
+		if removeErr != nil {
+			w.sendErrorResponseToHost(ctx, request, removeErr)
+			return
+		}
+		w.sendResponseToHost(ctx, request, "")
+	case "AIQueryDocuments":
+		index, model, ok := w.getVectorIndexParams(ctx, request)
+		if !ok {
+			return
+		}
+		limit, limitErr := strconv.Atoi(request.Params["limit"])
+		if limitErr != nil {
+			limit = 10
+		}
+
+		results, queryErr := pluginInstance.API.AIQueryDocuments(ctx, index, model, request.Params["query"], limit)
+		if queryErr != nil {
+			w.sendErrorResponseToHost(ctx, request, queryErr)
+			return
+		}
+		resultsJson, marshalErr := json.Marshal(results)
+		if marshalErr != nil {
+			w.sendErrorResponseToHost(ctx, request, marshalErr)
+			return
+		}
+		w.sendResponseToHost(ctx, request, string(resultsJson))
 	}
 }
 
+// getVectorIndexParams parses the index and model parameters shared by vector index methods
+func (w *WebsocketHost) getVectorIndexParams(ctx context.Context, request JsonRpcRequest) (string, ai.Model, bool) {
+	index, exist := request.Params["index"]
+	if !exist {
+		w.sendErrorResponseToHost(ctx, request, fmt.Errorf("%s method must have an index parameter", request.Method))
+		return "", ai.Model{}, false
+	}
+
+	var model ai.Model
+	unmarshalErr := json.Unmarshal([]byte(request.Params["model"]), &model)
+	if unmarshalErr != nil {
+		w.sendErrorResponseToHost(ctx, request, fmt.Errorf("failed to unmarshal model: %w", unmarshalErr))
+		return "", ai.Model{}, false
+	}
+
+	return index, model, true
+}
+
 func (w *WebsocketHost) handleResponseFromPlugin(ctx context.Context, response JsonRpcResponse) {
 	resultChan, exist := w.requestMap.Load(response.Id)
 	if !exist {
-		util.GetLogger().Error(ctx, fmt.Sprintf("%s failed to find request id: %s", w.getHostName(ctx), response.Id))
+		// request is timeout or canceled
+		util.GetLogger().Warn(ctx, fmt.Sprintf("%s failed to find request id: %s, method: %s", w.getHostName(ctx), response.Id, response.Method))
+		return
+	}
+
+	select {
+	case resultChan <- response:
+	default:
+		util.GetLogger().Warn(ctx, fmt.Sprintf("%s duplicated response of request id: %s", w.getHostName(ctx), response.Id))
+	}
+}
+
+func (w *WebsocketHost) sendErrorResponseToHost(ctx context.Context, request JsonRpcRequest, err error) {
+	util.GetLogger().Error(ctx, fmt.Sprintf("[%s] %s failed: %s", request.PluginName, request.Method, err))
+
+	response := JsonRpcResponse{
+		Id:     request.Id,
+		Method: request.Method,
+		Type:   JsonRpcTypeResponse,
+		Error:  err.Error(),
+	}
+	responseJson, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("[%s] failed to marshal response: %s", request.PluginName, marshalErr))
 		return
 	}
 
-	resultChan <- response
+	sendErr := w.conn.Send(ctx, responseJson)
+	if sendErr != nil {
+		util.GetLogger().Error(ctx, fmt.Sprintf("[%s] failed to send response: %s", request.PluginName, sendErr))
+	}
 }
 
 func (w *WebsocketHost) sendResponseToHost(ctx context.Context, request JsonRpcRequest, result string) {
@@ -498,7 +709,7 @@ func (w *WebsocketHost) sendResponseToHost(ctx context.Context, request JsonRpcR
 		return
 	}
 
-	sendErr := w.ws.Send(ctx, responseJson)
+	sendErr := w.conn.Send(ctx, responseJson)
 	if sendErr != nil {
 		util.GetLogger().Error(ctx, fmt.Sprintf("[%s] failed to send response: %s", request.PluginName, sendErr))
 		return
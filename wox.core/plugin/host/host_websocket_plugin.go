@@ -41,7 +41,9 @@ func (w *WebsocketPlugin) Query(ctx context.Context, query plugin.Query) []plugi
 		return []plugin.QueryResult{}
 	}
 
-	rawResults, queryErr := w.websocketHost.invokeMethod(ctx, w.metadata, "query", map[string]string{
+	requestCtx, cancel := plugin.NewQueryRequestContext(ctx)
+	defer cancel()
+	rawResults, queryErr := w.websocketHost.invokeMethod(requestCtx, w.metadata, "query", map[string]string{
 		"Type":           query.Type,
 		"RawQuery":       query.RawQuery,
 		"TriggerKeyword": query.TriggerKeyword,
@@ -50,6 +52,10 @@ func (w *WebsocketPlugin) Query(ctx context.Context, query plugin.Query) []plugi
 		"Selection":      string(selectionJson),
 		"Env":            string(envJson),
 	})
+	if queryErr != nil && requestCtx.Err() != nil {
+		// query is superseded by a new query or canceled, nobody will see its results
+		return []plugin.QueryResult{}
+	}
 	if queryErr != nil {
 		util.GetLogger().Error(ctx, fmt.Sprintf("[%s] query failed: %s", w.metadata.Name, queryErr.Error()))
 		return []plugin.QueryResult{
@@ -0,0 +1,34 @@
+package host
+
+import (
+	"context"
+	"os"
+	"testing"
+	"wox/plugin"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIsolatedHost(t *testing.T) {
+	ctx := context.Background()
+	shared := &PythonHost{websocketHost: &WebsocketHost{}}
+	var provider plugin.IsolatedHostProvider = shared
+
+	isolated, ok := provider.NewIsolatedHost(ctx, plugin.Metadata{Id: "test"}, t.TempDir()).(*PythonHost)
+	assert.True(t, ok)
+	assert.NotSame(t, shared.websocketHost, isolated.websocketHost)
+	assert.Same(t, isolated, isolated.websocketHost.host)
+	assert.Equal(t, "test", isolated.websocketHost.pluginId)
+	assert.Equal(t, plugin.PLUGIN_RUNTIME_PYTHON, isolated.GetRuntime(ctx))
+
+	_, err := isolated.GetProcessStat(ctx, plugin.Metadata{Id: "test"})
+	assert.Error(t, err)
+}
+
+func TestGetProcessStat(t *testing.T) {
+	stat, err := getProcessStat(os.Getpid(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, os.Getpid(), stat.Pid)
+	assert.True(t, stat.IsShared)
+	assert.Greater(t, stat.Memory, float64(0))
+}
@@ -8,6 +8,9 @@ const (
 	JsonRpcTypeSystemLog JsonRpcType = "WOX_JSONRPC_SYSTEM_LOG"
 )
 
+// JsonRpcMethodCancel is a notification sent to plugin when wox gives up waiting for a request, Params["Id"] is the id of the request
+const JsonRpcMethodCancel = "$/cancel"
+
 type JsonRpcRequest struct {
 	TraceId    string
 	Id         string
@@ -16,6 +19,7 @@ type JsonRpcRequest struct {
 	Method     string
 	Type       JsonRpcType
 	Params     map[string]string
+	Deadline   int64 `json:",omitempty"` // unix milliseconds, wox gives up waiting for the response after it
 }
 
 type JsonRpcResponse struct {
@@ -14,6 +14,7 @@ type Instance struct {
 	DevPluginDirectory string                 // absolute path to dev plugin directory defined in wpm settings
 	PluginDirectory    string                 // absolute path to plugin directory
 	Host               Host                   // plugin host to run this plugin
+	IsIsolatedHost     bool                   // Host is created only for this plugin, it's stopped when plugin is unloaded
 	Setting            *setting.PluginSetting // setting for this plugin
 
 	DynamicSettingCallbacks []func(key string) string // dynamic setting callbacks
@@ -48,6 +49,20 @@ func (i *Instance) GetQueryCommands() []MetadataCommand {
 	return commands
 }
 
+// GetProcessStat returns resource usage of the process running this plugin, false if plugin runs inside wox process
+func (i *Instance) GetProcessStat(ctx context.Context) (ProcessStat, bool) {
+	provider, ok := i.Host.(ProcessStatProvider)
+	if !ok {
+		return ProcessStat{}, false
+	}
+
+	stat, err := provider.GetProcessStat(ctx, i.Metadata)
+	if err != nil {
+		return ProcessStat{}, false
+	}
+	return stat, true
+}
+
 func (i *Instance) String() string {
 	return i.Metadata.Name
 }
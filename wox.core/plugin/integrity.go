@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"wox/setting"
+	"wox/util"
+)
+
+// officialStorePublicKey is the base64 encoded ed25519 public key of official plugin store, it's injected when building release, see Makefile
+var officialStorePublicKey string
+
+// pluginPackageLimits protects user from plugin packages which fill up disk when extracting
+var pluginPackageLimits = util.UnzipLimits{
+	MaxFileCount: 20000,
+	MaxTotalSize: 512 * 1024 * 1024,
+}
+
+// VerifyPluginPackage checks the package downloaded from store matches SHA256 in manifest and is signed by one of trusted keys.
+// Signature is ed25519 signature of the whole package file. If package is unsigned (or signed by an untrusted key) but allowed by trust policy, a warning is returned
+func VerifyPluginPackage(manifest StorePluginManifest, packagePath string, policy setting.PluginTrustPolicy, trustedPublicKeys []string) (warning string, err error) {
+	content, readErr := os.ReadFile(packagePath)
+	if readErr != nil {
+		return "", fmt.Errorf("failed to read plugin package: %w", readErr)
+	}
+
+	if manifest.SHA256 != "" {
+		checksum := sha256.Sum256(content)
+		if !strings.EqualFold(hex.EncodeToString(checksum[:]), manifest.SHA256) {
+			return "", fmt.Errorf("checksum of plugin package doesn't match store, expected %s, got %s", manifest.SHA256, hex.EncodeToString(checksum[:]))
+		}
+	}
+
+	// package signed by an untrusted or unknown key is treated as unsigned, trust policy decides whether it can be installed
+	if manifest.Signature != "" {
+		signature, decodeErr := base64.StdEncoding.DecodeString(manifest.Signature)
+		if decodeErr != nil {
+			return "", fmt.Errorf("invalid signature of plugin package: %w", decodeErr)
+		}
+		for _, key := range trustedPublicKeys {
+			publicKey, keyErr := base64.StdEncoding.DecodeString(key)
+			if keyErr != nil || len(publicKey) != ed25519.PublicKeySize {
+				continue
+			}
+			if ed25519.Verify(publicKey, content, signature) {
+				return "", nil
+			}
+		}
+	}
+
+	if policy == setting.PluginTrustPolicyOfficialOnly {
+		return "", fmt.Errorf("plugin package is not signed by a trusted store, change plugin trust policy to install it")
+	}
+	if manifest.SHA256 == "" {
+		return fmt.Sprintf("%s(%s) has no checksum and is not signed by a trusted store", manifest.Name, manifest.Version), nil
+	}
+	return fmt.Sprintf("%s(%s) is not signed by a trusted store", manifest.Name, manifest.Version), nil
+}
+
+// getTrustedStorePublicKeys returns keys to verify plugin from store, key of third-party store is only trusted if unofficial plugins are allowed
+func getTrustedStorePublicKeys(manifest StorePluginManifest, policy setting.PluginTrustPolicy) []string {
+	var keys []string
+	if officialStorePublicKey != "" {
+		keys = append(keys, officialStorePublicKey)
+	}
+	if manifest.storePublicKey != "" && policy != setting.PluginTrustPolicyOfficialOnly {
+		keys = append(keys, manifest.storePublicKey)
+	}
+	return keys
+}
@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"wox/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPluginPackage(t *testing.T) {
+	content := []byte("plugin package")
+	packagePath := filepath.Join(t.TempDir(), "demo@1.0.0.wox")
+	assert.NoError(t, os.WriteFile(packagePath, content, 0644))
+
+	publicKey, privateKey, keyErr := ed25519.GenerateKey(nil)
+	assert.NoError(t, keyErr)
+	_, otherPrivateKey, _ := ed25519.GenerateKey(nil)
+	trustedKeys := []string{base64.StdEncoding.EncodeToString(publicKey)}
+	checksum := sha256.Sum256(content)
+
+	manifest := StorePluginManifest{
+		Name:      "demo",
+		Version:   "1.0.0",
+		SHA256:    hex.EncodeToString(checksum[:]),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, content)),
+	}
+	warning, verifyErr := VerifyPluginPackage(manifest, packagePath, setting.PluginTrustPolicyOfficialOnly, trustedKeys)
+	assert.NoError(t, verifyErr)
+	assert.Empty(t, warning)
+
+	tampered := manifest
+	tampered.SHA256 = hex.EncodeToString(make([]byte, sha256.Size))
+	_, verifyErr = VerifyPluginPackage(tampered, packagePath, setting.PluginTrustPolicyAllowUnsigned, trustedKeys)
+	assert.ErrorContains(t, verifyErr, "checksum of plugin package doesn't match")
+
+	// signed by an unknown key is same as unsigned
+	untrusted := manifest
+	untrusted.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(otherPrivateKey, content))
+	_, verifyErr = VerifyPluginPackage(untrusted, packagePath, setting.PluginTrustPolicyOfficialOnly, trustedKeys)
+	assert.ErrorContains(t, verifyErr, "change plugin trust policy")
+	warning, verifyErr = VerifyPluginPackage(untrusted, packagePath, setting.PluginTrustPolicyAllowUnsigned, trustedKeys)
+	assert.NoError(t, verifyErr)
+	assert.Equal(t, "demo(1.0.0) is not signed by a trusted store", warning)
+
+	unsigned := manifest
+	unsigned.Signature = ""
+	_, verifyErr = VerifyPluginPackage(unsigned, packagePath, setting.PluginTrustPolicyOfficialOnly, trustedKeys)
+	assert.ErrorContains(t, verifyErr, "change plugin trust policy")
+	warning, verifyErr = VerifyPluginPackage(unsigned, packagePath, setting.PluginTrustPolicyAllowUnsigned, trustedKeys)
+	assert.NoError(t, verifyErr)
+	assert.Equal(t, "demo(1.0.0) is not signed by a trusted store", warning)
+
+	unsigned.SHA256 = ""
+	warning, verifyErr = VerifyPluginPackage(unsigned, packagePath, setting.PluginTrustPolicyAllowUnsigned, nil)
+	assert.NoError(t, verifyErr)
+	assert.Contains(t, warning, "has no checksum")
+}
@@ -0,0 +1,365 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"wox/i18n"
+	"wox/setting/definition"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+)
+
+type LintSeverity = string
+
+const (
+	LintSeverityError   LintSeverity = "error"   // plugin can't be loaded or published
+	LintSeverityWarning LintSeverity = "warning" // plugin works, but something is likely a mistake
+)
+
+// LintProblem is a problem of plugin found by LintPlugin
+type LintProblem struct {
+	Severity LintSeverity
+	Field    string // field of plugin.json or file path relative to plugin directory, e.g. SettingDefinitions[1], lang/en_US.json
+	Line     int    // position in plugin.json, only available for problems found by schema validation
+	Column   int
+	Message  string
+}
+
+func (p LintProblem) String() string {
+	location := p.Field
+	if p.Line > 0 {
+		location = fmt.Sprintf("plugin.json:%d:%d: %s", p.Line, p.Column, p.Field)
+	}
+	if location == "" {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, strings.TrimSuffix(location, ": "), p.Message)
+}
+
+// publishRequiredMetadataFields are optional for loading a plugin, but a published plugin must have them
+var publishRequiredMetadataFields = []string{"Author", "MinWoxVersion", "Description", "Icon"}
+
+var knownMetadataFeatures = []MetadataFeatureName{
+	MetadataFeatureQuerySelection,
+	MetadataFeatureDebounce,
+	MetadataFeatureIgnoreAutoScore,
+	MetadataFeatureQueryEnv,
+	MetadataFeatureAI,
+	MetadataFeatureDeepLink,
+	MetadataFeatureMethodTimeout,
+	MetadataFeatureIsolatedHost,
+}
+
+var settingReferenceRegex = regexp.MustCompile(`\{(?:wox:)?setting:([^{}|]+)(?:\|[^{}]*)?\}`)
+
+type pluginLinter struct {
+	directory      string
+	problems       []LintProblem
+	settingIndexes []int // index in plugin.json of every valid setting definition, invalid ones are skipped when decoding
+}
+
+// LintPlugin validates plugin directory before it's packed or published.
+// plugin.json is validated against its schema first, then files it references and conventions of published plugins are checked.
+// Unlike ParseMetadata which fails on an invalid plugin.json, every problem is reported so authors can fix them at once
+func LintPlugin(ctx context.Context, pluginDirectory string) []LintProblem {
+	l := &pluginLinter{directory: pluginDirectory}
+	l.lint(ctx)
+	return l.problems
+}
+
+// HasLintErrors returns true if any problem prevents plugin from being packed
+func HasLintErrors(problems []LintProblem) bool {
+	return lo.ContainsBy(problems, func(problem LintProblem) bool {
+		return problem.Severity == LintSeverityError
+	})
+}
+
+func (l *pluginLinter) error(field string, format string, args ...any) {
+	l.problems = append(l.problems, LintProblem{Severity: LintSeverityError, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *pluginLinter) warning(field string, format string, args ...any) {
+	l.problems = append(l.problems, LintProblem{Severity: LintSeverityWarning, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *pluginLinter) lint(ctx context.Context) {
+	metadataJson, readErr := os.ReadFile(path.Join(l.directory, "plugin.json"))
+	if readErr != nil {
+		l.error("plugin.json", "failed to read plugin.json: %s", readErr.Error())
+		return
+	}
+
+	schemaErrors := ValidateMetadata(metadataJson)
+	for _, schemaErr := range schemaErrors {
+		l.problems = append(l.problems, LintProblem{Severity: LintSeverityError, Field: schemaErr.Path, Line: schemaErr.Line, Column: schemaErr.Column, Message: schemaErr.Message})
+	}
+	if !gjson.ValidBytes(metadataJson) {
+		return
+	}
+	// a few values match the schema but still can't be decoded, E.g. validators of settings
+	if len(schemaErrors) == 0 {
+		var metadata Metadata
+		if unmarshalErr := json.Unmarshal(metadataJson, &metadata); unmarshalErr != nil {
+			l.error("plugin.json", "failed to decode plugin.json: %s", unmarshalErr.Error())
+		}
+	}
+
+	metadata := l.lintFields(metadataJson)
+	l.lintVersions(metadata)
+	l.lintRuntime(metadata)
+	l.lintIcon(metadata)
+	l.lintKeywords(metadata)
+	l.lintFeatures(metadata)
+	l.lintSettings(metadataJson, metadata)
+	l.lintTranslations(metadataJson)
+}
+
+// lintFields decodes every field of plugin.json separately, so a field which doesn't match the schema doesn't hide problems of other fields
+func (l *pluginLinter) lintFields(metadataJson []byte) Metadata {
+	var metadata Metadata
+	metadataValue := reflect.ValueOf(&metadata).Elem()
+	metadataType := metadataValue.Type()
+
+	gjson.ParseBytes(metadataJson).ForEach(func(key, value gjson.Result) bool {
+		if key.String() == "$schema" {
+			return true
+		}
+
+		field, found := metadataType.FieldByName(key.String())
+		if !found {
+			// encoding/json matches fields case-insensitively, so plugin still works but the name is misleading
+			if caseField, caseFound := lo.Find(reflect.VisibleFields(metadataType), func(item reflect.StructField) bool {
+				return strings.EqualFold(item.Name, key.String())
+			}); caseFound {
+				l.warning(key.String(), "field name should be %s", caseField.Name)
+				field = caseField
+			} else {
+				l.warning(key.String(), "unknown field")
+				return true
+			}
+		}
+
+		if field.Name == "SettingDefinitions" {
+			metadata.SettingDefinitions = l.decodeSettingDefinitions(value)
+			return true
+		}
+
+		fieldValue := reflect.New(field.Type)
+		if json.Unmarshal([]byte(value.Raw), fieldValue.Interface()) == nil {
+			metadataValue.FieldByIndex(field.Index).Set(fieldValue.Elem())
+		}
+		return true
+	})
+
+	for _, requiredField := range publishRequiredMetadataFields {
+		if metadataValue.FieldByName(requiredField).IsZero() {
+			l.error(requiredField, "required for publishing")
+		}
+	}
+
+	return metadata
+}
+
+func (l *pluginLinter) decodeSettingDefinitions(value gjson.Result) definition.PluginSettingDefinitions {
+	var definitions definition.PluginSettingDefinitions
+	for i, item := range value.Array() {
+		var settingDefinition definition.PluginSettingDefinitionItem
+		if json.Unmarshal([]byte(item.Raw), &settingDefinition) != nil {
+			continue
+		}
+		definitions = append(definitions, settingDefinition)
+		l.settingIndexes = append(l.settingIndexes, i)
+	}
+	return definitions
+}
+
+func (l *pluginLinter) lintVersions(metadata Metadata) {
+	if metadata.Version == "" {
+		return
+	}
+	// loose versions like 1.0 can be loaded, but stores compare versions in major.minor.patch
+	if _, versionErr := semver.NewVersion(metadata.Version); versionErr == nil {
+		if _, strictErr := semver.StrictNewVersion(metadata.Version); strictErr != nil {
+			l.warning("Version", "%s should be in major.minor.patch format, e.g. 1.0.0", metadata.Version)
+		}
+	}
+}
+
+func (l *pluginLinter) lintRuntime(metadata Metadata) {
+	isInterpreted := strings.EqualFold(metadata.Runtime, string(PLUGIN_RUNTIME_PYTHON)) || strings.EqualFold(metadata.Runtime, string(PLUGIN_RUNTIME_NODEJS))
+	if metadata.RuntimeVersion != "" && !isInterpreted {
+		l.warning("RuntimeVersion", "only used by python and nodejs plugins")
+	}
+
+	if metadata.Entry != "" {
+		if filepath.IsAbs(metadata.Entry) {
+			l.error("Entry", "must be relative to plugin directory")
+		} else if _, statErr := os.Stat(path.Join(l.directory, metadata.Entry)); statErr != nil {
+			l.error("Entry", "entry file %s doesn't exist", metadata.Entry)
+		}
+	}
+
+	if !metadata.Permissions.IsEmpty() && !strings.EqualFold(metadata.Runtime, string(PLUGIN_RUNTIME_WASM)) {
+		l.warning("Permissions", "only used by wasm plugins, plugins of other runtimes are not sandboxed")
+	}
+}
+
+func (l *pluginLinter) lintIcon(metadata Metadata) {
+	if metadata.Icon == "" {
+		return
+	}
+
+	icon, parseErr := ParseWoxImage(metadata.Icon)
+	if parseErr != nil {
+		l.error("Icon", "%s, e.g. relative:images/app.png", parseErr.Error())
+		return
+	}
+	if icon.ImageType == WoxImageTypeAbsolutePath {
+		l.error("Icon", "absolute path doesn't exist on other computers, use relative path instead")
+	}
+	if icon.ImageType == WoxImageTypeRelativePath {
+		if _, statErr := os.Stat(path.Join(l.directory, icon.ImageData)); statErr != nil {
+			l.error("Icon", "icon file %s doesn't exist", icon.ImageData)
+		}
+	}
+}
+
+func (l *pluginLinter) lintKeywords(metadata Metadata) {
+	for i, keyword := range metadata.TriggerKeywords {
+		if lo.IndexOf(metadata.TriggerKeywords, keyword) != i {
+			l.warning(fmt.Sprintf("TriggerKeywords[%d]", i), "duplicate trigger keyword %s", keyword)
+		}
+	}
+
+	commands := lo.Map(metadata.Commands, func(command MetadataCommand, _ int) string { return command.Command })
+	for i, command := range commands {
+		if lo.IndexOf(commands, command) != i {
+			l.error(fmt.Sprintf("Commands[%d]", i), "duplicate command %s", command)
+		}
+	}
+}
+
+// lintFeatures warns about unknown features, params of known features are validated by the schema
+func (l *pluginLinter) lintFeatures(metadata Metadata) {
+	for i, feature := range metadata.Features {
+		if !lo.ContainsBy(knownMetadataFeatures, func(name MetadataFeatureName) bool { return strings.EqualFold(name, feature.Name) }) {
+			l.warning(fmt.Sprintf("Features[%d]", i), "unknown feature %s", feature.Name)
+		}
+	}
+}
+
+// lintSettings checks keys of setting definitions and the settings referenced by query templates, E.g. {wox:setting:api_key}
+func (l *pluginLinter) lintSettings(metadataJson []byte, metadata Metadata) {
+	var keys []string
+	for i, item := range metadata.SettingDefinitions {
+		field := fmt.Sprintf("SettingDefinitions[%d]", l.settingIndexes[i])
+		switch item.Type {
+		case definition.PluginSettingDefinitionTypeHead, definition.PluginSettingDefinitionTypeLabel, definition.PluginSettingDefinitionTypeNewLine:
+			continue
+		}
+
+		// missing key is reported by the schema
+		key := item.Value.GetKey()
+		if key == "" {
+			continue
+		}
+		if lo.Contains(keys, key) {
+			l.error(field, "duplicate setting key %s", key)
+		}
+		keys = append(keys, key)
+
+		if selectValue, ok := item.Value.(*definition.PluginSettingValueSelect); ok && selectValue.DefaultValue != "" {
+			if !lo.ContainsBy(selectValue.Options, func(option definition.PluginSettingValueSelectOption) bool {
+				return option.Value == selectValue.DefaultValue
+			}) {
+				l.warning(field, "default value %s is not one of the options", selectValue.DefaultValue)
+			}
+		}
+	}
+
+	for _, match := range settingReferenceRegex.FindAllStringSubmatch(string(metadataJson), -1) {
+		if !lo.Contains(keys, match[1]) {
+			l.error("SettingDefinitions", "setting %s is referenced by %s but not defined", match[1], match[0])
+		}
+	}
+}
+
+// lintTranslations checks every "i18n:key" in plugin.json can be translated by lang files of plugin
+func (l *pluginLinter) lintTranslations(metadataJson []byte) {
+	var i18nKeys []string
+	var collect func(value gjson.Result)
+	collect = func(value gjson.Result) {
+		if value.IsObject() || value.IsArray() {
+			value.ForEach(func(_, child gjson.Result) bool {
+				collect(child)
+				return true
+			})
+			return
+		}
+		if value.Type == gjson.String && strings.HasPrefix(value.String(), "i18n:") {
+			i18nKeys = append(i18nKeys, strings.TrimPrefix(value.String(), "i18n:"))
+		}
+	}
+	collect(gjson.ParseBytes(metadataJson))
+	i18nKeys = lo.Uniq(i18nKeys)
+
+	langDirectory := path.Join(l.directory, "lang")
+	entries, readErr := os.ReadDir(langDirectory)
+	if readErr != nil {
+		if len(i18nKeys) > 0 {
+			l.error("lang", "plugin.json uses i18n keys but lang directory doesn't exist")
+		}
+		return
+	}
+
+	hasEnUs := false
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		field := path.Join("lang", entry.Name())
+		langCode := strings.TrimSuffix(entry.Name(), ".json")
+		if !i18n.IsSupportedLangCode(langCode) {
+			l.warning(field, "%s is not a language supported by Wox", langCode)
+			continue
+		}
+
+		langJson, langErr := os.ReadFile(path.Join(langDirectory, entry.Name()))
+		if langErr != nil {
+			l.error(field, "failed to read lang file: %s", langErr.Error())
+			continue
+		}
+		if !gjson.ValidBytes(langJson) {
+			l.error(field, "lang file is not a valid json")
+			continue
+		}
+
+		// authors may not speak every language, so only missing english translations are errors
+		isEnUs := langCode == string(i18n.LangCodeEnUs)
+		hasEnUs = hasEnUs || isEnUs
+		for _, key := range i18nKeys {
+			if gjson.GetBytes(langJson, key).Exists() {
+				continue
+			}
+			if isEnUs {
+				l.error(field, "missing translation of i18n:%s", key)
+			} else {
+				l.warning(field, "missing translation of i18n:%s", key)
+			}
+		}
+	}
+
+	if len(i18nKeys) > 0 && !hasEnUs {
+		l.error(path.Join("lang", "en_US.json"), "plugin.json uses i18n keys but en_US translation doesn't exist")
+	}
+}
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestPlugin(t *testing.T, metadataJson string, files map[string]string) string {
+	directory := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "plugin.json"), []byte(metadataJson), 0644))
+	for name, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(directory, name)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(directory, name), []byte(content), 0644))
+	}
+	return directory
+}
+
+func TestLintPlugin(t *testing.T) {
+	ctx := context.Background()
+
+	directory := writeTestPlugin(t, `{
+		"Id": "demo",
+		"Name": "demo",
+		"Author": "wox",
+		"Version": "1.0.0",
+		"MinWoxVersion": "2.0.0",
+		"Runtime": "python",
+		"Description": "i18n:description",
+		"Icon": "relative:images/app.png",
+		"Entry": "main.py",
+		"TriggerKeywords": ["demo"],
+		"SupportedOS": ["Windows", "Macos", "Linux"],
+		"SettingDefinitions": [
+			{"Type": "textbox", "Value": {"Key": "api_key", "Label": "i18n:api_key"}}
+		],
+		"Commands": [{"Command": "search", "Description": "search {wox:setting:api_key}"}]
+	}`, map[string]string{
+		"main.py":         "",
+		"images/app.png":  "",
+		"lang/en_US.json": `{"description": "demo plugin", "api_key": "API key"}`,
+		"lang/zh_CN.json": `{"description": "示例插件"}`,
+	})
+	problems := LintPlugin(ctx, directory)
+	assert.False(t, HasLintErrors(problems))
+	assert.Equal(t, []LintProblem{{Severity: LintSeverityWarning, Field: "lang/zh_CN.json", Message: "missing translation of i18n:api_key"}}, problems)
+
+	// every problem is reported instead of the first one
+	directory = writeTestPlugin(t, `{
+		"Id": "demo",
+		"Name": "demo",
+		"Version": "1.0",
+		"Runtime": "ruby",
+		"Entry": "main.rb",
+		"Icon": "relative:app.png",
+		"TriggerKeywords": "demo",
+		"SupportedOS": ["BeOS"],
+		"Feature": [],
+		"Features": [{"Name": "debounce"}],
+		"SettingDefinitions": [
+			{"Type": "textbox", "Value": {"Key": "api_key"}},
+			{"Type": "slider", "Value": {"Key": "size"}},
+			{"Type": "select", "Value": {"Key": "api_key", "DefaultValue": "c", "Options": [{"Label": "A", "Value": "a"}]}}
+		],
+		"Commands": [{"Command": "search", "Description": "i18n:search {wox:setting:token}"}]
+	}`, nil)
+	problems = LintPlugin(ctx, directory)
+	fields := lo.Map(problems, func(problem LintProblem, _ int) string { return problem.Field })
+	assert.True(t, HasLintErrors(problems))
+	for _, field := range []string{"Feature", "TriggerKeywords", "SettingDefinitions[1].Type", "Author", "MinWoxVersion", "Description", "Version",
+		"Runtime", "Entry", "SupportedOS[0]", "Icon", "Features[0]", "SettingDefinitions[2]", "SettingDefinitions", "lang"} {
+		assert.Contains(t, fields, field)
+	}
+	// problems found by schema have positions in plugin.json
+	runtimeProblem, _ := lo.Find(problems, func(problem LintProblem) bool { return problem.Field == "Runtime" })
+	assert.Equal(t, 5, runtimeProblem.Line)
+
+	problems = LintPlugin(ctx, t.TempDir())
+	assert.Len(t, problems, 1)
+	assert.Equal(t, "plugin.json", problems[0].Field)
+}
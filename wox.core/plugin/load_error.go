@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path"
+	"sort"
+	"wox/util"
+
+	"github.com/tidwall/gjson"
+)
+
+// PluginLoadError explains why plugin.json of a user plugin can't be parsed, so the plugin is not loaded
+type PluginLoadError struct {
+	Directory    string
+	File         string // path of plugin.json
+	Name         string // plugin name if plugin.json has one, otherwise name of plugin directory
+	Message      string
+	SchemaErrors []MetadataSchemaError // problems with positions in plugin.json, empty if plugin.json can't be read or validated
+	Timestamp    int64
+}
+
+// UpdateLoadError records the result of parsing plugin.json in plugin directory, a nil error clears the previous one
+func (m *Manager) UpdateLoadError(ctx context.Context, pluginDirectory string, parseErr error) {
+	if parseErr == nil {
+		m.loadErrors.Delete(pluginDirectory)
+		return
+	}
+
+	configPath := path.Join(pluginDirectory, "plugin.json")
+	name := path.Base(pluginDirectory)
+	if metadataJson, readErr := os.ReadFile(configPath); readErr == nil {
+		if nameResult := getJsonProperty(gjson.ParseBytes(metadataJson), "Name"); nameResult.Type == gjson.String && nameResult.String() != "" {
+			name = nameResult.String()
+		}
+	}
+
+	m.loadErrors.Store(pluginDirectory, PluginLoadError{
+		Directory:    pluginDirectory,
+		File:         configPath,
+		Name:         name,
+		Message:      parseErr.Error(),
+		SchemaErrors: GetMetadataSchemaErrors(parseErr),
+		Timestamp:    util.GetSystemTimestamp(),
+	})
+}
+
+// GetLoadErrors returns plugins which are not loaded because of invalid plugin.json, sorted by name
+func (m *Manager) GetLoadErrors() []PluginLoadError {
+	// plugin directory may be deleted by user since the error was recorded
+	loadErrors := m.loadErrors.FilterList(func(directory string, _ PluginLoadError) bool {
+		return util.IsDirExists(directory)
+	})
+	if loadErrors == nil {
+		return []PluginLoadError{}
+	}
+	sort.Slice(loadErrors, func(i, j int) bool {
+		return loadErrors[i].Name < loadErrors[j].Name
+	})
+	return loadErrors
+}
@@ -22,6 +22,7 @@ import (
 	"wox/util/notifier"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
 	"github.com/jinzhu/copier"
 	"github.com/samber/lo"
@@ -39,10 +40,22 @@ type debounceTimer struct {
 
 type Manager struct {
 	instances          []*Instance
+	instancesLock      sync.RWMutex
 	ui                 share.UI
 	resultCache        *util.HashMap[string, *QueryResultCache]
 	debounceQueryTimer *util.HashMap[string, *debounceTimer]
 	aiProviders        *util.HashMap[ai.ProviderName, ai.Provider]
+	aiResponseCache    *ai.ResponseCache
+	vectorIndexes      *util.HashMap[string, *ai.VectorIndex]
+	loadErrors         *util.HashMap[string, PluginLoadError] // plugin directory -> error of plugin.json
+
+	scriptCommandWatcher     *fsnotify.Watcher
+	scriptCommandReloadTimer *time.Timer
+	scriptCommandLock        sync.Mutex
+
+	// cancels query requests to plugins of the previous query from the same source, only the latest query of a source is shown
+	queryCancels    map[string]context.CancelFunc
+	queryCancelLock sync.Mutex
 
 	activeBrowserUrl string //active browser url before wox is activated
 }
@@ -53,6 +66,10 @@ func GetPluginManager() *Manager {
 			resultCache:        util.NewHashMap[string, *QueryResultCache](),
 			debounceQueryTimer: util.NewHashMap[string, *debounceTimer](),
 			aiProviders:        util.NewHashMap[ai.ProviderName, ai.Provider](),
+			aiResponseCache:    ai.NewResponseCache(100),
+			vectorIndexes:      util.NewHashMap[string, *ai.VectorIndex](),
+			loadErrors:         util.NewHashMap[string, PluginLoadError](),
+			queryCancels:       map[string]context.CancelFunc{},
 		}
 		logger = util.GetLogger()
 	})
@@ -78,6 +95,11 @@ func (m *Manager) Stop(ctx context.Context) {
 	for _, host := range AllHosts {
 		host.Stop(ctx)
 	}
+	for _, instance := range m.GetPluginInstances() {
+		if instance.IsIsolatedHost {
+			instance.Host.Stop(ctx)
+		}
+	}
 }
 
 func (m *Manager) SetActiveBrowserUrl(url string) {
@@ -90,11 +112,77 @@ func (m *Manager) loadPlugins(ctx context.Context) error {
 	// load system plugin first
 	m.loadSystemPlugins(ctx)
 
+	metaDataList, parseErr := m.parseUserPluginMetadata(ctx)
+	if parseErr != nil {
+		return parseErr
+	}
+	logger.Info(ctx, fmt.Sprintf("start loading user plugins, found %d user plugins", len(metaDataList)))
+
+	// script commands are loaded by script host like other user plugins
+	metaDataList = append(metaDataList, m.parseScriptCommands(ctx)...)
+	m.watchScriptCommands(ctx)
+
+	for _, host := range AllHosts {
+		util.Go(ctx, fmt.Sprintf("[%s] start host", host.GetRuntime(ctx)), func() {
+			newCtx := util.NewTraceContext()
+			hostErr := host.Start(newCtx)
+			if hostErr != nil {
+				logger.Error(newCtx, fmt.Errorf("[%s HOST] %w", host.GetRuntime(newCtx), hostErr).Error())
+				return
+			}
+
+			for _, metadata := range metaDataList {
+				if strings.ToUpper(metadata.Metadata.Runtime) != strings.ToUpper(string(host.GetRuntime(newCtx))) {
+					continue
+				}
+
+				loadErr := m.loadHostPlugin(newCtx, host, metadata)
+				if loadErr != nil {
+					logger.Error(newCtx, fmt.Errorf("[%s HOST] %w", host.GetRuntime(newCtx), loadErr).Error())
+					continue
+				}
+			}
+		})
+	}
+
+	return nil
+}
+
+func (m *Manager) ReloadPlugin(ctx context.Context, metadata MetadataWithDirectory) error {
+	logger.Info(ctx, fmt.Sprintf("start reloading dev plugin: %s", metadata.Metadata.Name))
+
+	pluginHost, exist := lo.Find(AllHosts, func(item Host) bool {
+		return strings.ToLower(string(item.GetRuntime(ctx))) == strings.ToLower(metadata.Metadata.Runtime)
+	})
+	if !exist {
+		return fmt.Errorf("unsupported runtime: %s", metadata.Metadata.Runtime)
+	}
+
+	pluginInstance, pluginInstanceExist := lo.Find(m.GetPluginInstances(), func(item *Instance) bool {
+		return item.Metadata.Id == metadata.Metadata.Id
+	})
+	if pluginInstanceExist {
+		logger.Info(ctx, fmt.Sprintf("plugin(%s) is loaded, unload first", metadata.Metadata.Name))
+		m.UnloadPlugin(ctx, pluginInstance)
+	} else {
+		logger.Info(ctx, fmt.Sprintf("plugin(%s) is not loaded, skip unload", metadata.Metadata.Name))
+	}
+
+	loadErr := m.loadHostPlugin(ctx, pluginHost, metadata)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	return nil
+}
+
+// parseUserPluginMetadata parses metadata of plugins in plugin directory, only the newest version is kept if a plugin has multiple versions
+func (m *Manager) parseUserPluginMetadata(ctx context.Context) ([]MetadataWithDirectory, error) {
 	logger.Debug(ctx, "start loading user plugin metadata")
 	basePluginDirectory := util.GetLocation().GetPluginDirectory()
 	pluginDirectories, readErr := os.ReadDir(basePluginDirectory)
 	if readErr != nil {
-		return fmt.Errorf("failed to read plugin directory: %w", readErr)
+		return nil, fmt.Errorf("failed to read plugin directory: %w", readErr)
 	}
 
 	var metaDataList []MetadataWithDirectory
@@ -108,6 +196,7 @@ func (m *Manager) loadPlugins(ctx context.Context) error {
 
 		pluginDirectory := path.Join(basePluginDirectory, entry.Name())
 		metadata, metadataErr := m.ParseMetadata(ctx, pluginDirectory)
+		m.UpdateLoadError(ctx, pluginDirectory, metadataErr)
 		if metadataErr != nil {
 			logger.Error(ctx, metadataErr.Error())
 			continue
@@ -139,67 +228,85 @@ func (m *Manager) loadPlugins(ctx context.Context) error {
 		}
 		metaDataList = append(metaDataList, MetadataWithDirectory{Metadata: metadata, Directory: pluginDirectory})
 	}
-	logger.Info(ctx, fmt.Sprintf("start loading user plugins, found %d user plugins", len(metaDataList)))
-
-	for _, host := range AllHosts {
-		util.Go(ctx, fmt.Sprintf("[%s] start host", host.GetRuntime(ctx)), func() {
-			newCtx := util.NewTraceContext()
-			hostErr := host.Start(newCtx)
-			if hostErr != nil {
-				logger.Error(newCtx, fmt.Errorf("[%s HOST] %w", host.GetRuntime(newCtx), hostErr).Error())
-				return
-			}
-
-			for _, metadata := range metaDataList {
-				if strings.ToUpper(metadata.Metadata.Runtime) != strings.ToUpper(string(host.GetRuntime(newCtx))) {
-					continue
-				}
-
-				loadErr := m.loadHostPlugin(newCtx, host, metadata)
-				if loadErr != nil {
-					logger.Error(newCtx, fmt.Errorf("[%s HOST] %w", host.GetRuntime(newCtx), loadErr).Error())
-					continue
-				}
-			}
-		})
-	}
 
-	return nil
+	return metaDataList, nil
 }
 
-func (m *Manager) ReloadPlugin(ctx context.Context, metadata MetadataWithDirectory) error {
-	logger.Info(ctx, fmt.Sprintf("start reloading dev plugin: %s", metadata.Metadata.Name))
-
-	pluginHost, exist := lo.Find(AllHosts, func(item Host) bool {
-		return strings.ToLower(string(item.GetRuntime(ctx))) == strings.ToLower(metadata.Metadata.Runtime)
+// RestartHost starts host of the runtime again and reloads its plugins, e.g. user changed interpreter path in settings
+func (m *Manager) RestartHost(ctx context.Context, runtime Runtime) error {
+	host, exist := lo.Find(AllHosts, func(item Host) bool {
+		return item.GetRuntime(ctx) == runtime
 	})
 	if !exist {
-		return fmt.Errorf("unsupported runtime: %s", metadata.Metadata.Runtime)
+		return fmt.Errorf("unsupported runtime: %s", runtime)
 	}
 
-	pluginInstance, pluginInstanceExist := lo.Find(m.instances, func(item *Instance) bool {
-		return item.Metadata.Id == metadata.Metadata.Id
-	})
-	if pluginInstanceExist {
-		logger.Info(ctx, fmt.Sprintf("plugin(%s) is loaded, unload first", metadata.Metadata.Name))
-		m.UnloadPlugin(ctx, pluginInstance)
-	} else {
-		logger.Info(ctx, fmt.Sprintf("plugin(%s) is not loaded, skip unload", metadata.Metadata.Name))
+	// dev plugins are not in plugin directory, remember them to load again
+	var devPlugins []MetadataWithDirectory
+	for _, instance := range m.GetPluginInstances() {
+		if instance.Host != nil && instance.Host.GetRuntime(ctx) == runtime {
+			if instance.IsDevPlugin {
+				devPlugins = append(devPlugins, MetadataWithDirectory{Metadata: instance.Metadata, Directory: instance.PluginDirectory, IsDev: true, DevPluginDirectory: instance.DevPluginDirectory})
+			}
+			m.UnloadPlugin(ctx, instance)
+		}
+	}
+	host.Stop(ctx)
+	if startErr := host.Start(ctx); startErr != nil {
+		return fmt.Errorf("[%s HOST] %w", runtime, startErr)
 	}
 
-	loadErr := m.loadHostPlugin(ctx, pluginHost, metadata)
-	if loadErr != nil {
-		return loadErr
+	metaDataList, parseErr := m.parseUserPluginMetadata(ctx)
+	if parseErr != nil {
+		return parseErr
+	}
+	metaDataList = append(lo.Filter(metaDataList, func(item MetadataWithDirectory, _ int) bool {
+		return !lo.ContainsBy(devPlugins, func(devPlugin MetadataWithDirectory) bool { return devPlugin.Metadata.Id == item.Metadata.Id })
+	}), devPlugins...)
+	for _, metadata := range metaDataList {
+		if ConvertToRuntime(metadata.Metadata.Runtime) != runtime {
+			continue
+		}
+		if loadErr := m.loadHostPlugin(ctx, host, metadata); loadErr != nil {
+			logger.Error(ctx, fmt.Errorf("[%s HOST] %w", runtime, loadErr).Error())
+		}
 	}
 
 	return nil
 }
 
 func (m *Manager) loadHostPlugin(ctx context.Context, host Host, metadata MetadataWithDirectory) error {
+	pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Metadata.Id, metadata.Metadata.Name, metadata.Metadata.SettingDefinitions)
+	if settingErr != nil {
+		logger.Error(ctx, fmt.Errorf("[%s HOST] failed to load plugin[%s] setting: %w", host.GetRuntime(ctx), metadata.Metadata.Name, settingErr).Error())
+		return settingErr
+	}
+
+	if compatibleErr := CheckMetadataCompatibility(ctx, metadata.Metadata); compatibleErr != nil {
+		logger.Error(ctx, fmt.Errorf("[%s HOST] plugin %s(%s) is not compatible: %w", host.GetRuntime(ctx), metadata.Metadata.Name, metadata.Metadata.Version, compatibleErr).Error())
+		return fmt.Errorf("plugin %s(%s) is not compatible: %w", metadata.Metadata.Name, metadata.Metadata.Version, compatibleErr)
+	}
+
+	isIsolatedHost := false
+	if provider, ok := host.(IsolatedHostProvider); ok && (pluginSetting.IsolatedHost || metadata.Metadata.IsSupportFeature(MetadataFeatureIsolatedHost) || provider.IsIsolatedHostRequired(ctx, metadata.Metadata, metadata.Directory)) {
+		logger.Info(ctx, fmt.Sprintf("[%s HOST] start isolated host for plugin: %s", host.GetRuntime(ctx), metadata.Metadata.Name))
+		isolatedHost := provider.NewIsolatedHost(ctx, metadata.Metadata, metadata.Directory)
+		if startErr := isolatedHost.Start(ctx); startErr != nil {
+			isolatedHost.Stop(ctx)
+			logger.Error(ctx, fmt.Errorf("[%s HOST] failed to start isolated host: %w", host.GetRuntime(ctx), startErr).Error())
+			return startErr
+		}
+		host = isolatedHost
+		isIsolatedHost = true
+	}
+
 	loadStartTimestamp := util.GetSystemTimestamp()
 	plugin, loadErr := host.LoadPlugin(ctx, metadata.Metadata, metadata.Directory)
 	if loadErr != nil {
 		logger.Error(ctx, fmt.Errorf("[%s HOST] failed to load plugin: %w", host.GetRuntime(ctx), loadErr).Error())
+		if isIsolatedHost {
+			host.Stop(ctx)
+		}
 		return loadErr
 	}
 	loadFinishTimestamp := util.GetSystemTimestamp()
@@ -209,20 +316,18 @@ func (m *Manager) loadHostPlugin(ctx context.Context, host Host, metadata Metada
 		PluginDirectory:       metadata.Directory,
 		Plugin:                plugin,
 		Host:                  host,
+		IsIsolatedHost:        isIsolatedHost,
+		Setting:               pluginSetting,
 		LoadStartTimestamp:    loadStartTimestamp,
 		LoadFinishedTimestamp: loadFinishTimestamp,
 		IsDevPlugin:           metadata.IsDev,
 		DevPluginDirectory:    metadata.DevPluginDirectory,
 	}
 	instance.API = NewAPI(instance)
-	pluginSetting, settingErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Metadata.Id, metadata.Metadata.Name, metadata.Metadata.SettingDefinitions)
-	if settingErr != nil {
-		instance.API.Log(ctx, LogLevelError, fmt.Errorf("[SYS] failed to load plugin[%s] setting: %w", metadata.Metadata.Name, settingErr).Error())
-		return settingErr
-	}
-	instance.Setting = pluginSetting
 
+	m.instancesLock.Lock()
 	m.instances = append(m.instances, instance)
+	m.instancesLock.Unlock()
 
 	if pluginSetting.Disabled {
 		logger.Info(ctx, fmt.Errorf("[%s HOST] plugin is disabled by user, skip init: %s", host.GetRuntime(ctx), metadata.Metadata.Name).Error())
@@ -237,6 +342,52 @@ func (m *Manager) loadHostPlugin(ctx context.Context, host Host, metadata Metada
 	return nil
 }
 
+// ReloadHostPlugins loads and inits all plugins of the host again, used after host process restarted
+func (m *Manager) ReloadHostPlugins(ctx context.Context, host Host) {
+	for _, instance := range m.GetPluginInstances() {
+		if instance.Host != host {
+			continue
+		}
+		m.reloadHostPlugin(ctx, instance)
+	}
+}
+
+// ReloadHostPlugin loads and inits a plugin of the host again, used after the process of the plugin restarted
+func (m *Manager) ReloadHostPlugin(ctx context.Context, host Host, pluginId string) {
+	instance, found := lo.Find(m.GetPluginInstances(), func(item *Instance) bool {
+		return item.Host == host && item.Metadata.Id == pluginId
+	})
+	if !found {
+		return
+	}
+	m.reloadHostPlugin(ctx, instance)
+}
+
+func (m *Manager) reloadHostPlugin(ctx context.Context, instance *Instance) {
+	host := instance.Host
+
+	// callbacks registered in the dead host process are invalid, plugin will register them again in init
+	instance.DynamicSettingCallbacks = nil
+	instance.SettingChangeCallbacks = nil
+	instance.DeepLinkCallbacks = nil
+	instance.UnloadCallbacks = nil
+
+	loadStartTimestamp := util.GetSystemTimestamp()
+	pluginImpl, loadErr := host.LoadPlugin(ctx, instance.Metadata, instance.PluginDirectory)
+	if loadErr != nil {
+		logger.Error(ctx, fmt.Errorf("[%s HOST] failed to reload plugin %s: %w", host.GetRuntime(ctx), instance.Metadata.Name, loadErr).Error())
+		return
+	}
+	instance.Plugin = pluginImpl
+	instance.LoadStartTimestamp = loadStartTimestamp
+	instance.LoadFinishedTimestamp = util.GetSystemTimestamp()
+
+	if instance.Setting.Disabled {
+		return
+	}
+	m.initPlugin(ctx, instance)
+}
+
 func (m *Manager) LoadPlugin(ctx context.Context, pluginDirectory string) error {
 	metadata, parseErr := m.ParseMetadata(ctx, pluginDirectory)
 	if parseErr != nil {
@@ -262,8 +413,16 @@ func (m *Manager) UnloadPlugin(ctx context.Context, pluginInstance *Instance) {
 	for _, callback := range pluginInstance.UnloadCallbacks {
 		callback()
 	}
-	pluginInstance.Host.UnloadPlugin(ctx, pluginInstance.Metadata)
+	// system plugins run inside wox process, there is no host
+	if pluginInstance.Host != nil {
+		pluginInstance.Host.UnloadPlugin(ctx, pluginInstance.Metadata)
+		if pluginInstance.IsIsolatedHost {
+			pluginInstance.Host.Stop(ctx)
+		}
+	}
 
+	m.instancesLock.Lock()
+	defer m.instancesLock.Unlock()
 	var newInstances []*Instance
 	for _, instance := range m.instances {
 		if instance.Metadata.Id != pluginInstance.Metadata.Id {
@@ -305,7 +464,9 @@ func (m *Manager) loadSystemPlugins(ctx context.Context) {
 				logger.Warn(ctx, fmt.Sprintf("load system plugin[%s] setting too slow, cost %d ms", metadata.Name, util.GetSystemTimestamp()-startTimestamp))
 			}
 
+			m.instancesLock.Lock()
 			m.instances = append(m.instances, instance)
+			m.instancesLock.Unlock()
 
 			m.initPlugin(util.NewTraceContext(), instance)
 		})
@@ -325,6 +486,7 @@ func (m *Manager) initPlugin(ctx context.Context, instance *Instance) {
 	logger.Info(ctx, fmt.Sprintf("init plugin %s finished, cost %d ms", instance.Metadata.Name, instance.InitFinishedTimestamp-instance.InitStartTimestamp))
 }
 
+// ParseMetadata parses plugin.json in plugin directory, a *MetadataError with positions of every problem is returned if it doesn't match its schema
 func (m *Manager) ParseMetadata(ctx context.Context, pluginDirectory string) (Metadata, error) {
 	configPath := path.Join(pluginDirectory, "plugin.json")
 	if _, statErr := os.Stat(configPath); statErr != nil {
@@ -336,27 +498,31 @@ func (m *Manager) ParseMetadata(ctx context.Context, pluginDirectory string) (Me
 		return Metadata{}, fmt.Errorf("failed to read plugin.json file: %w", err)
 	}
 
+	if schemaErrors := ValidateMetadata(metadataJson); len(schemaErrors) > 0 {
+		return Metadata{}, &MetadataError{File: configPath, Errors: schemaErrors}
+	}
+
 	var metadata Metadata
 	unmarshalErr := json.Unmarshal(metadataJson, &metadata)
 	if unmarshalErr != nil {
 		return Metadata{}, fmt.Errorf("failed to unmarshal plugin.json file (%s): %w", pluginDirectory, unmarshalErr)
 	}
 
-	if len(metadata.TriggerKeywords) == 0 {
-		return Metadata{}, fmt.Errorf("missing trigger keywords in plugin.json file (%s)", pluginDirectory)
-	}
-	if !IsSupportedRuntime(metadata.Runtime) {
-		return Metadata{}, fmt.Errorf("unsupported runtime in plugin.json file (%s), runtime=%s", pluginDirectory, metadata.Runtime)
-	}
-	if !IsSupportedOSAny(metadata.SupportedOS) {
-		return Metadata{}, fmt.Errorf("unsupported os in plugin.json file (%s), os=%s", pluginDirectory, metadata.SupportedOS)
-	}
-
 	return metadata, nil
 }
 
+// GetPluginInstances returns a snapshot of loaded plugin instances, it's safe to load or unload plugins while iterating it
 func (m *Manager) GetPluginInstances() []*Instance {
-	return m.instances
+	m.instancesLock.RLock()
+	defer m.instancesLock.RUnlock()
+	return append([]*Instance(nil), m.instances...)
+}
+
+// RegisterInstance adds a plugin instance loaded outside of manager, so requests from its host can find it. Use UnloadPlugin to remove it
+func (m *Manager) RegisterInstance(instance *Instance) {
+	m.instancesLock.Lock()
+	defer m.instancesLock.Unlock()
+	m.instances = append(m.instances, instance)
 }
 
 func (m *Manager) canOperateQuery(ctx context.Context, pluginInstance *Instance, query Query) bool {
@@ -778,10 +944,24 @@ func (m *Manager) Query(ctx context.Context, query Query) (results chan []QueryR
 	// clear old result cache
 	m.resultCache.Clear()
 
+	// previous query of the same source is superseded, stop waiting for its query requests (plugin hosts are notified by $/cancel).
+	// Only query requests are canceled, ctx is kept for work started by the query, e.g. refreshing results
+	if source, ok := ctx.Value(contextKeyQuerySource).(string); ok && source != "" {
+		supersededCtx, cancel := context.WithCancel(context.Background())
+		m.queryCancelLock.Lock()
+		if previousCancel, exist := m.queryCancels[source]; exist {
+			previousCancel()
+		}
+		m.queryCancels[source] = cancel
+		m.queryCancelLock.Unlock()
+		ctx = context.WithValue(ctx, contextKeyQuerySuperseded, supersededCtx)
+	}
+
+	instances := m.GetPluginInstances()
 	counter := &atomic.Int32{}
-	counter.Store(int32(len(m.instances)))
+	counter.Store(int32(len(instances)))
 
-	for _, pluginInstance := range m.instances {
+	for _, pluginInstance := range instances {
 		if !m.canOperateQuery(ctx, pluginInstance, query) {
 			counter.Add(-1)
 			if counter.Load() == 0 {
@@ -861,7 +1041,7 @@ func (m *Manager) QuerySilent(ctx context.Context, query Query) bool {
 func (m *Manager) QueryFallback(ctx context.Context, query Query, queryPlugin *Instance) (results []QueryResultUI) {
 	var queryResults []QueryResult
 	if query.IsGlobalQuery() {
-		for _, instance := range m.instances {
+		for _, instance := range m.GetPluginInstances() {
 			pluginInstance := instance
 			if v, ok := pluginInstance.Plugin.(FallbackSearcher); ok {
 				queryResults = v.QueryFallback(ctx, query)
@@ -944,6 +1124,11 @@ func (m *Manager) GetUI() share.UI {
 	return m.ui
 }
 
+// SetUI replaces ui used by plugins without starting manager, E.g. a fake ui in plugin tests
+func (m *Manager) SetUI(ui share.UI) {
+	m.ui = ui
+}
+
 func (m *Manager) NewQuery(ctx context.Context, plainQuery share.PlainQuery) (Query, *Instance, error) {
 	if plainQuery.QueryType == QueryTypeInput {
 		newQuery := plainQuery.QueryText
@@ -1142,25 +1327,7 @@ func (m *Manager) polishPreview(ctx context.Context, preview WoxPreview) WoxPrev
 }
 
 func (m *Manager) ReplaceQueryVariable(ctx context.Context, query string) string {
-	if strings.Contains(query, QueryVariableSelectedText) {
-		selection, selectedErr := util.GetSelected()
-		if selectedErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to get selected text: %s", selectedErr.Error()))
-		} else {
-			if selection.Type == util.SelectionTypeText {
-				query = strings.ReplaceAll(query, QueryVariableSelectedText, selection.Text)
-			} else {
-				logger.Error(ctx, fmt.Sprintf("selected data is not text, type: %s", selection.Type))
-			}
-		}
-	}
-
-	if strings.Contains(query, QueryVariableActiveBrowserUrl) {
-		activeBrowserUrl := m.activeBrowserUrl
-		query = strings.ReplaceAll(query, QueryVariableActiveBrowserUrl, activeBrowserUrl)
-	}
-
-	return query
+	return m.RenderQueryTemplate(ctx, query, QueryVariableContext{GrabSelection: true})
 }
 
 func (m *Manager) IsHostStarted(ctx context.Context, runtime Runtime) bool {
@@ -1199,8 +1366,47 @@ func (m *Manager) GetAIProvider(ctx context.Context, provider ai.ProviderName) (
 	return newProvider, nil
 }
 
+// GetAIRouter returns a router which retries and fails over ai requests according to current settings,
+// answers are cached only if cache is enabled and the request is marked by ai.WithResponseCache
+func (m *Manager) GetAIRouter(ctx context.Context) *ai.Router {
+	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
+
+	maxRetries := woxSetting.AIMaxRetries
+	if maxRetries == 0 {
+		maxRetries = 2
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	fallbacks := map[string]ai.Model{}
+	for _, modelSetting := range woxSetting.AIModelSettings {
+		if modelSetting.FallbackModel == "" {
+			continue
+		}
+
+		var fallbackModel ai.Model
+		if unmarshalErr := json.Unmarshal([]byte(modelSetting.FallbackModel), &fallbackModel); unmarshalErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to unmarshal fallback model of %s: %s", modelSetting.Name, unmarshalErr.Error()))
+			continue
+		}
+		fallbacks[ai.GetModelKey(ai.Model{Provider: ai.ProviderName(modelSetting.Provider), Name: modelSetting.Name})] = fallbackModel
+	}
+
+	var cache *ai.ResponseCache
+	if woxSetting.AIEnableResponseCache && ai.IsResponseCacheAllowed(ctx) {
+		cache = m.aiResponseCache
+	}
+
+	return ai.NewRouter(m.GetAIProvider, ai.RouterOptions{
+		MaxRetries: maxRetries,
+		Fallbacks:  fallbacks,
+		Cache:      cache,
+	})
+}
+
 func (m *Manager) ExecutePluginDeeplink(ctx context.Context, pluginId string, arguments map[string]string) {
-	pluginInstance, exist := lo.Find(m.instances, func(item *Instance) bool {
+	pluginInstance, exist := lo.Find(m.GetPluginInstances(), func(item *Instance) bool {
 		return item.Metadata.Id == pluginId
 	})
 	if !exist {
@@ -6,6 +6,8 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
+	"wox/setting"
 	"wox/setting/definition"
 )
 
@@ -31,6 +33,15 @@ const (
 
 	// enable this feature to execute custom deep link in plugin
 	MetadataFeatureDeepLink MetadataFeatureName = "deepLink"
+
+	// enable this feature to change how long Wox waits for methods of plugin, e.g. a slow query or action
+	// params are method names (query, action, refresh, loadPlugin) with timeout in milliseconds, see MetadataFeatureParamsMethodTimeout
+	MetadataFeatureMethodTimeout MetadataFeatureName = "methodTimeout"
+
+	// enable this feature to run plugin in a dedicated host process instead of the host process shared by all plugins of the same runtime,
+	// so a plugin that leaks memory or blocks the event loop doesn't slow down other plugins. Only python and nodejs plugins support this.
+	// User can also enable it in plugin setting, see setting.PluginSetting.IsolatedHost
+	MetadataFeatureIsolatedHost MetadataFeatureName = "isolatedHost"
 )
 
 // Metadata parsed from plugin.json, see `Plugin.json.md` for more detail
@@ -42,6 +53,7 @@ type Metadata struct {
 	Version            string
 	MinWoxVersion      string
 	Runtime            string
+	RuntimeVersion     string // semver constraint of interpreter version, e.g. ">=3.11", only used by python and nodejs plugins
 	Description        string
 	Icon               string
 	Website            string
@@ -51,6 +63,7 @@ type Metadata struct {
 	SupportedOS        []string
 	Features           []MetadataFeature
 	SettingDefinitions definition.PluginSettingDefinitions
+	Permissions        setting.PluginPermissions // only used by sandboxed runtimes (WASM), user confirms them when installing the plugin
 }
 
 func (m *Metadata) GetIconOrDefault(pluginDirectory string, defaultImage WoxImage) WoxImage {
@@ -126,6 +139,28 @@ func (m *Metadata) GetFeatureParamsForQueryEnv() (MetadataFeatureParamsQueryEnv,
 	return MetadataFeatureParamsQueryEnv{}, errors.New("plugin does not support queryEnv feature")
 }
 
+func (m *Metadata) GetFeatureParamsForMethodTimeout() (MetadataFeatureParamsMethodTimeout, error) {
+	for _, feature := range m.Features {
+		if strings.ToLower(feature.Name) == strings.ToLower(MetadataFeatureMethodTimeout) {
+			params := MetadataFeatureParamsMethodTimeout{
+				Timeouts: map[string]time.Duration{},
+			}
+
+			for method, v := range feature.Params {
+				timeInMilliseconds, convertErr := strconv.Atoi(v)
+				if convertErr != nil || timeInMilliseconds <= 0 {
+					return MetadataFeatureParamsMethodTimeout{}, fmt.Errorf("methodTimeout feature %s param is not a valid positive number: %s", method, v)
+				}
+				params.Timeouts[method] = time.Duration(timeInMilliseconds) * time.Millisecond
+			}
+
+			return params, nil
+		}
+	}
+
+	return MetadataFeatureParamsMethodTimeout{}, errors.New("plugin does not support methodTimeout feature")
+}
+
 type MetadataFeature struct {
 	Name   MetadataFeatureName
 	Params map[string]string
@@ -149,6 +184,10 @@ type MetadataFeatureParamsDebounce struct {
 	intervalMs int
 }
 
+type MetadataFeatureParamsMethodTimeout struct {
+	Timeouts map[string]time.Duration // method name -> timeout
+}
+
 type MetadataFeatureParamsQueryEnv struct {
 	RequireActiveWindowName bool
 	RequireActiveWindowPid  bool
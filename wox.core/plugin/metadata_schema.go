@@ -0,0 +1,391 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"wox/resource"
+	"wox/util"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+)
+
+// LatestMetadataSchemaVersion is the version of plugin.json schema used when plugin.json doesn't declare $schema
+const LatestMetadataSchemaVersion = 1
+
+// plugin.json declares schema version by the file name in $schema, E.g. "$schema": ".../plugin.v1.json"
+var metadataSchemaVersionRegex = regexp.MustCompile(`plugin\.v(\d+)\.json$`)
+
+var metadataSchemas = util.NewHashMap[int, *jsonSchema]()
+
+// MetadataSchemaError is a problem of plugin.json found by schema validation
+type MetadataSchemaError struct {
+	Path    string // path of the invalid value, E.g. Features[0].Params.intervalMs, empty for the whole file
+	Line    int    // 1-based position of the invalid value in plugin.json
+	Column  int
+	Message string
+}
+
+func (e MetadataSchemaError) String() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// MetadataError is returned by ParseMetadata if plugin.json doesn't match its schema
+type MetadataError struct {
+	File   string // path of plugin.json
+	Errors []MetadataSchemaError
+}
+
+func (e *MetadataError) Error() string {
+	return fmt.Sprintf("invalid plugin.json: %s", strings.Join(lo.Map(e.Errors, func(schemaErr MetadataSchemaError, _ int) string {
+		return fmt.Sprintf("%s:%s", e.File, schemaErr.String())
+	}), "; "))
+}
+
+// GetMetadataSchemaErrors returns schema errors if err is (or wraps) a MetadataError
+func GetMetadataSchemaErrors(err error) []MetadataSchemaError {
+	var metadataErr *MetadataError
+	if errors.As(err, &metadataErr) {
+		return metadataErr.Errors
+	}
+	return nil
+}
+
+// ValidateMetadata validates content of plugin.json against the schema version it declares.
+// Property names are matched case-insensitively, the same as how plugin.json is decoded
+func ValidateMetadata(metadataJson []byte) []MetadataSchemaError {
+	var syntaxCheck any
+	if unmarshalErr := json.Unmarshal(metadataJson, &syntaxCheck); unmarshalErr != nil {
+		offset := 0
+		var syntaxErr *json.SyntaxError
+		if errors.As(unmarshalErr, &syntaxErr) {
+			offset = int(syntaxErr.Offset)
+		}
+		line, column := getJsonPosition(metadataJson, offset)
+		return []MetadataSchemaError{{Line: line, Column: column, Message: unmarshalErr.Error()}}
+	}
+
+	document := gjson.ParseBytes(metadataJson)
+	version := LatestMetadataSchemaVersion
+	if schemaUrl := getJsonProperty(document, "$schema"); schemaUrl.Exists() {
+		line, column := getJsonPosition(metadataJson, schemaUrl.Index)
+		matches := metadataSchemaVersionRegex.FindStringSubmatch(schemaUrl.String())
+		if matches == nil {
+			return []MetadataSchemaError{{Path: "$schema", Line: line, Column: column, Message: "schema url must end with plugin.v<version>.json"}}
+		}
+		version, _ = strconv.Atoi(matches[1])
+	}
+
+	schema, schemaErr := getMetadataSchema(version)
+	if schemaErr != nil {
+		line, column := getJsonPosition(metadataJson, getJsonProperty(document, "$schema").Index)
+		return []MetadataSchemaError{{Path: "$schema", Line: line, Column: column, Message: schemaErr.Error()}}
+	}
+
+	v := &jsonSchemaValidator{root: schema, content: metadataJson}
+	return v.validate(schema, document, "")
+}
+
+func getMetadataSchema(version int) (*jsonSchema, error) {
+	if schema, ok := metadataSchemas.Load(version); ok {
+		return schema, nil
+	}
+
+	schemaJson, readErr := resource.SchemaFS.ReadFile(fmt.Sprintf("schema/plugin.v%d.json", version))
+	if readErr != nil {
+		return nil, fmt.Errorf("unsupported schema version %d, latest version is %d", version, LatestMetadataSchemaVersion)
+	}
+	var schema jsonSchema
+	if unmarshalErr := json.Unmarshal(schemaJson, &schema); unmarshalErr != nil {
+		return nil, fmt.Errorf("failed to parse schema version %d: %w", version, unmarshalErr)
+	}
+	metadataSchemas.Store(version, &schema)
+	return &schema, nil
+}
+
+// getJsonProperty returns property of object, exact name wins over names in other cases
+func getJsonProperty(object gjson.Result, name string) gjson.Result {
+	var found gjson.Result
+	object.ForEach(func(key, value gjson.Result) bool {
+		if key.String() == name {
+			found = value
+			return false
+		}
+		if !found.Exists() && strings.EqualFold(key.String(), name) {
+			found = value
+		}
+		return true
+	})
+	return found
+}
+
+// getJsonPosition converts byte offset in content to 1-based line and column
+func getJsonPosition(content []byte, offset int) (line int, column int) {
+	offset = min(max(offset, 0), len(content))
+	lineStart := 0
+	line = 1
+	for i := 0; i < offset; i++ {
+		if content[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+	return line, utf8.RuneCount(content[lineStart:offset]) + 1
+}
+
+// jsonSchema is the subset of JSON Schema (draft 2020-12) used by plugin.json schemas
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+	Type                 jsonSchemaTypes        `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Const                json.RawMessage        `json:"const"`
+	Pattern              string                 `json:"pattern"`
+	Format               string                 `json:"format"`
+	MinLength            *int                   `json:"minLength"`
+	MinItems             *int                   `json:"minItems"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	If                   *jsonSchema            `json:"if"`
+	Then                 *jsonSchema            `json:"then"`
+
+	isFalse bool // boolean schema false, nothing is valid
+	pattern *regexp.Regexp
+}
+
+func (s *jsonSchema) UnmarshalJSON(b []byte) error {
+	var boolSchema bool
+	if json.Unmarshal(b, &boolSchema) == nil {
+		s.isFalse = !boolSchema
+		return nil
+	}
+
+	type jsonSchemaAlias jsonSchema
+	var alias jsonSchemaAlias
+	if unmarshalErr := json.Unmarshal(b, &alias); unmarshalErr != nil {
+		return unmarshalErr
+	}
+	*s = jsonSchema(alias)
+	if s.Pattern != "" {
+		pattern, compileErr := regexp.Compile(s.Pattern)
+		if compileErr != nil {
+			return compileErr
+		}
+		s.pattern = pattern
+	}
+	return nil
+}
+
+// jsonSchemaTypes is value of "type", which is a type name or a list of type names
+type jsonSchemaTypes []string
+
+func (t *jsonSchemaTypes) UnmarshalJSON(b []byte) error {
+	var single string
+	if json.Unmarshal(b, &single) == nil {
+		*t = []string{single}
+		return nil
+	}
+	var multiple []string
+	if unmarshalErr := json.Unmarshal(b, &multiple); unmarshalErr != nil {
+		return unmarshalErr
+	}
+	*t = multiple
+	return nil
+}
+
+// jsonSchemaFormats validates "format" of string values
+var jsonSchemaFormats = map[string]func(value string) error{
+	"semver": func(value string) error {
+		_, versionErr := semver.NewVersion(value)
+		return versionErr
+	},
+	"semver-constraint": func(value string) error {
+		_, constraintErr := semver.NewConstraint(value)
+		return constraintErr
+	},
+}
+
+type jsonSchemaValidator struct {
+	root    *jsonSchema
+	content []byte
+}
+
+func (v *jsonSchemaValidator) newError(value gjson.Result, path string, format string, args ...any) MetadataSchemaError {
+	line, column := getJsonPosition(v.content, value.Index)
+	return MetadataSchemaError{Path: path, Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (v *jsonSchemaValidator) resolve(schema *jsonSchema) *jsonSchema {
+	for schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/$defs/")
+		definition, found := v.root.Defs[name]
+		if !found {
+			return &jsonSchema{}
+		}
+		schema = definition
+	}
+	return schema
+}
+
+func (v *jsonSchemaValidator) validate(schema *jsonSchema, value gjson.Result, path string) []MetadataSchemaError {
+	schema = v.resolve(schema)
+	if schema.isFalse {
+		return []MetadataSchemaError{v.newError(value, path, "value is not allowed")}
+	}
+
+	if len(schema.Type) > 0 && !lo.ContainsBy(schema.Type, func(typeName string) bool { return isJsonType(value, typeName) }) {
+		return []MetadataSchemaError{v.newError(value, path, "must be %s, got %s", strings.Join(schema.Type, " or "), getJsonTypeName(value))}
+	}
+
+	var errs []MetadataSchemaError
+	if len(schema.Enum) > 0 && !lo.ContainsBy(schema.Enum, func(item any) bool { return reflect.DeepEqual(item, value.Value()) }) {
+		errs = append(errs, v.newError(value, path, "must be one of %s", strings.Join(lo.Map(schema.Enum, func(item any, _ int) string {
+			itemJson, _ := json.Marshal(item)
+			return string(itemJson)
+		}), ", ")))
+	}
+	if len(schema.Const) > 0 {
+		var constValue any
+		json.Unmarshal(schema.Const, &constValue)
+		if !reflect.DeepEqual(constValue, value.Value()) {
+			errs = append(errs, v.newError(value, path, "must be %s", string(schema.Const)))
+		}
+	}
+
+	if value.Type == gjson.String {
+		errs = append(errs, v.validateString(schema, value, path)...)
+	}
+	if value.IsArray() {
+		errs = append(errs, v.validateArray(schema, value, path)...)
+	}
+	if value.IsObject() {
+		errs = append(errs, v.validateObject(schema, value, path)...)
+	}
+
+	for _, subSchema := range schema.AllOf {
+		errs = append(errs, v.validate(subSchema, value, path)...)
+	}
+	if schema.If != nil && schema.Then != nil && len(v.validate(schema.If, value, path)) == 0 {
+		errs = append(errs, v.validate(schema.Then, value, path)...)
+	}
+
+	return errs
+}
+
+func (v *jsonSchemaValidator) validateString(schema *jsonSchema, value gjson.Result, path string) []MetadataSchemaError {
+	var errs []MetadataSchemaError
+	if schema.MinLength != nil && utf8.RuneCountInString(value.String()) < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			errs = append(errs, v.newError(value, path, "must not be empty"))
+		} else {
+			errs = append(errs, v.newError(value, path, "must be at least %d characters", *schema.MinLength))
+		}
+	}
+	if schema.pattern != nil && !schema.pattern.MatchString(value.String()) {
+		errs = append(errs, v.newError(value, path, "%q doesn't match pattern %s", value.String(), schema.Pattern))
+	}
+	if validateFormat, ok := jsonSchemaFormats[schema.Format]; ok {
+		if formatErr := validateFormat(value.String()); formatErr != nil {
+			errs = append(errs, v.newError(value, path, "%q is not a valid %s: %s", value.String(), schema.Format, formatErr.Error()))
+		}
+	}
+	return errs
+}
+
+func (v *jsonSchemaValidator) validateArray(schema *jsonSchema, value gjson.Result, path string) []MetadataSchemaError {
+	var errs []MetadataSchemaError
+	items := value.Array()
+	if schema.MinItems != nil && len(items) < *schema.MinItems {
+		errs = append(errs, v.newError(value, path, "must have at least %d items", *schema.MinItems))
+	}
+	if schema.Items != nil {
+		for i, item := range items {
+			errs = append(errs, v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return errs
+}
+
+func (v *jsonSchemaValidator) validateObject(schema *jsonSchema, value gjson.Result, path string) []MetadataSchemaError {
+	var errs []MetadataSchemaError
+	for _, required := range schema.Required {
+		if !getJsonProperty(value, required).Exists() {
+			errs = append(errs, v.newError(value, path, "missing required property %s", required))
+		}
+	}
+
+	value.ForEach(func(key, propertyValue gjson.Result) bool {
+		propertyPath := key.String()
+		if path != "" {
+			propertyPath = path + "." + key.String()
+		}
+
+		propertySchema, found := schema.Properties[key.String()]
+		if !found {
+			if name, caseFound := lo.Find(lo.Keys(schema.Properties), func(name string) bool { return strings.EqualFold(name, key.String()) }); caseFound {
+				propertySchema, found = schema.Properties[name], true
+			}
+		}
+		if !found {
+			propertySchema = schema.AdditionalProperties
+		}
+		if propertySchema != nil {
+			if propertySchema.isFalse {
+				errs = append(errs, v.newError(key, propertyPath, "unknown property %s", key.String()))
+			} else {
+				errs = append(errs, v.validate(propertySchema, propertyValue, propertyPath)...)
+			}
+		}
+		return true
+	})
+	return errs
+}
+
+func isJsonType(value gjson.Result, typeName string) bool {
+	switch typeName {
+	case "object":
+		return value.IsObject()
+	case "array":
+		return value.IsArray()
+	case "string":
+		return value.Type == gjson.String
+	case "number":
+		return value.Type == gjson.Number
+	case "integer":
+		return value.Type == gjson.Number && value.Float() == float64(int64(value.Float()))
+	case "boolean":
+		return value.IsBool()
+	case "null":
+		return value.Type == gjson.Null
+	}
+	return false
+}
+
+func getJsonTypeName(value gjson.Result) string {
+	switch {
+	case value.IsObject():
+		return "object"
+	case value.IsArray():
+		return "array"
+	case value.IsBool():
+		return "boolean"
+	case value.Type == gjson.Number:
+		return "number"
+	case value.Type == gjson.String:
+		return "string"
+	}
+	return "null"
+}
@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	validJson := `{
+  "$schema": "https://raw.githubusercontent.com/Wox-launcher/Wox/v2/wox.core/resource/schema/plugin.v1.json",
+  "Id": "demo",
+  "Name": "demo",
+  "Version": "1.0.0",
+  "MinWoxVersion": "2.0.0",
+  "Runtime": "Python",
+  "RuntimeVersion": ">=3.11",
+  "Entry": "main.py",
+  "TriggerKeywords": ["demo"],
+  "SupportedOS": ["Windows", "Macos", "Linux"],
+  "Features": [
+    {"Name": "debounce", "Params": {"intervalMs": "300"}},
+    {"Name": "queryEnv", "Params": {"requireActiveWindowName": "true"}},
+    {"Name": "ai"}
+  ],
+  "SettingDefinitions": [
+    {"Type": "head", "Value": {"Content": "Demo"}},
+    {"Type": "select", "Value": {"Key": "mode", "DefaultValue": "a", "Options": [{"Label": "A", "Value": "a"}]}},
+    {"Type": "newline"}
+  ]
+}`
+	assert.Empty(t, ValidateMetadata([]byte(validJson)))
+
+	// property names are matched case-insensitively, the same as decoding
+	assert.Empty(t, ValidateMetadata([]byte(`{"id": "demo", "name": "demo", "version": "1.0.0", "runtime": "go", "entry": "demo", "triggerKeywords": ["*"], "supportedOS": ["linux"]}`)))
+
+	invalidJson := `{
+  "Id": "demo",
+  "Version": "one",
+  "Runtime": "ruby",
+  "Entry": "main.rb",
+  "TriggerKeywords": ["de mo"],
+  "SupportedOS": ["Linux"],
+  "Features": [
+    {"Name": "debounce", "Params": {}},
+    {"Name": "queryEnv", "Params": {"requireActiveWindowTitle": "true"}}
+  ],
+  "SettingDefinitions": [
+    {"Type": "slider", "Value": {"Key": "size"}}
+  ]
+}`
+	errs := ValidateMetadata([]byte(invalidJson))
+	positions := lo.Map(errs, func(err MetadataSchemaError, _ int) string {
+		return fmt.Sprintf("%d:%d %s", err.Line, err.Column, err.Path)
+	})
+	assert.Equal(t, []string{
+		"1:1 ",
+		"3:14 Version",
+		"4:14 Runtime",
+		"6:23 TriggerKeywords[0]",
+		"9:36 Features[0].Params",
+		"10:37 Features[1].Params.requireActiveWindowTitle",
+		"13:14 SettingDefinitions[0].Type",
+	}, positions)
+	assert.Equal(t, "missing required property Name", errs[0].Message)
+
+	errs = ValidateMetadata([]byte("{\n  \"$schema\": \"plugin.v99.json\"\n}"))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "$schema", errs[0].Path)
+	assert.Equal(t, 2, errs[0].Line)
+
+	errs = ValidateMetadata([]byte("{\n  \"Id\": \"demo\",\n}"))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 3, errs[0].Line)
+}
+
+func TestGetMetadataSchemaErrors(t *testing.T) {
+	metadataErr := &MetadataError{File: "plugin.json", Errors: []MetadataSchemaError{{Path: "Runtime", Line: 4, Column: 14, Message: "invalid"}}}
+	wrappedErr := fmt.Errorf("failed to load plugin: %w", metadataErr)
+
+	assert.Equal(t, metadataErr.Errors, GetMetadataSchemaErrors(wrappedErr))
+	assert.Contains(t, wrappedErr.Error(), "plugin.json:4:14: Runtime: invalid")
+	assert.Empty(t, GetMetadataSchemaErrors(errors.New("failed to read plugin.json")))
+}
@@ -11,6 +11,9 @@ const (
 	PLUGIN_OS_WINDOWS OS = "WINDOWS"
 	PLUGIN_OS_DARWIN  OS = "DARWIN"
 	PLUGIN_OS_LINUX   OS = "LINUX"
+
+	// documented name of darwin in plugin.json, see Plugin.json.md
+	PLUGIN_OS_MACOS OS = "MACOS"
 )
 
 func IsSupportedOS(os string) bool {
@@ -18,7 +21,7 @@ func IsSupportedOS(os string) bool {
 	if osUpper == string(PLUGIN_OS_WINDOWS) {
 		return util.IsWindows()
 	}
-	if osUpper == string(PLUGIN_OS_DARWIN) {
+	if osUpper == string(PLUGIN_OS_DARWIN) || osUpper == string(PLUGIN_OS_MACOS) {
 		return util.IsMacOS()
 	}
 	if osUpper == string(PLUGIN_OS_LINUX) {
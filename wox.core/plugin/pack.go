@@ -0,0 +1,222 @@
+package plugin
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/samber/lo"
+)
+
+// packIgnoredNames are files and directories created by tools and editors, they are never packed
+var packIgnoredNames = []string{".git", ".svn", ".hg", ".idea", ".vscode", ".venv", "__pycache__", ".DS_Store", "Thumbs.db"}
+
+// packModifiedTime is used as modified time of every zip entry, so packing the same files always produces the same package
+var packModifiedTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// PackResult is the output of PackPlugin
+type PackResult struct {
+	PackagePath  string        // path of packed .wox file
+	ManifestPath string        // path of store manifest snippet
+	Manifest     []byte        // store manifest snippet, authors fill in the urls and submit it to a store
+	Problems     []LintProblem // lint problems, plugin is not packed if any of them is an error
+}
+
+// storeManifestSnippet is the part of StorePluginManifest a store needs from plugin author
+type storeManifestSnippet struct {
+	Id             string
+	Name           string
+	Author         string
+	Version        string
+	MinWoxVersion  string
+	SupportedOS    []string
+	Runtime        string
+	Description    string
+	IconUrl        string
+	Website        string
+	DownloadUrl    string
+	SHA256         string
+	ScreenshotUrls []string
+	Permissions    *setting.PluginPermissions `json:",omitempty"`
+	DateCreated    string
+	DateUpdated    string
+}
+
+// PackPlugin lints plugin directory and packs it into <id>@<version>.wox in output directory, along with a store manifest snippet containing checksum of the package.
+// Packing is reproducible, entries are sorted and have fixed modified time, so the checksum only changes when content changes
+func PackPlugin(ctx context.Context, pluginDirectory string, outputDirectory string) (PackResult, error) {
+	result := PackResult{Problems: LintPlugin(ctx, pluginDirectory)}
+	if HasLintErrors(result.Problems) {
+		return result, fmt.Errorf("plugin has %d problems, run wpm lint for details", len(lo.Filter(result.Problems, func(problem LintProblem, _ int) bool {
+			return problem.Severity == LintSeverityError
+		})))
+	}
+
+	metadata, parseErr := GetPluginManager().ParseMetadata(ctx, pluginDirectory)
+	if parseErr != nil {
+		return result, parseErr
+	}
+
+	if directoryErr := util.GetLocation().EnsureDirectoryExist(outputDirectory); directoryErr != nil {
+		return result, fmt.Errorf("failed to create output directory %s: %w", outputDirectory, directoryErr)
+	}
+	packageName := fmt.Sprintf("%s@%s.wox", metadata.Id, metadata.Version)
+	result.PackagePath = path.Join(outputDirectory, packageName)
+
+	checksum, zipErr := zipPluginDirectory(pluginDirectory, result.PackagePath)
+	if zipErr != nil {
+		return result, zipErr
+	}
+
+	iconUrl := ""
+	if icon, iconErr := ParseWoxImage(metadata.Icon); iconErr == nil && icon.ImageType == WoxImageTypeUrl {
+		iconUrl = icon.ImageData
+	}
+	snippet := storeManifestSnippet{
+		Id:             metadata.Id,
+		Name:           metadata.Name,
+		Author:         metadata.Author,
+		Version:        metadata.Version,
+		MinWoxVersion:  metadata.MinWoxVersion,
+		SupportedOS:    metadata.SupportedOS,
+		Runtime:        strings.ToLower(metadata.Runtime),
+		Description:    metadata.Description,
+		IconUrl:        iconUrl,
+		Website:        metadata.Website,
+		DownloadUrl:    packageName,
+		SHA256:         checksum,
+		ScreenshotUrls: []string{},
+		DateCreated:    util.FormatTimestamp(util.GetSystemTimestamp()),
+		DateUpdated:    util.FormatTimestamp(util.GetSystemTimestamp()),
+	}
+	if !metadata.Permissions.IsEmpty() {
+		snippet.Permissions = &metadata.Permissions
+	}
+
+	manifest, marshalErr := json.MarshalIndent(snippet, "", "  ")
+	if marshalErr != nil {
+		return result, marshalErr
+	}
+	result.Manifest = manifest
+	result.ManifestPath = path.Join(outputDirectory, fmt.Sprintf("%s@%s.json", metadata.Id, metadata.Version))
+	if writeErr := os.WriteFile(result.ManifestPath, manifest, 0644); writeErr != nil {
+		return result, fmt.Errorf("failed to write store manifest: %w", writeErr)
+	}
+
+	return result, nil
+}
+
+// zipPluginDirectory writes files of plugin directory into a zip file and returns hex encoded sha256 of it
+func zipPluginDirectory(pluginDirectory string, zipPath string) (string, error) {
+	absZipPath, _ := filepath.Abs(zipPath)
+
+	var files []string
+	var totalSize int64
+	walkErr := filepath.WalkDir(pluginDirectory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath != pluginDirectory && lo.Contains(packIgnoredNames, entry.Name()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return fmt.Errorf("%s is not a regular file, symlinks are not supported in plugin package", filePath)
+		}
+		// don't pack previous packages if output directory is inside plugin directory
+		if absFilePath, _ := filepath.Abs(filePath); absFilePath == absZipPath || strings.HasSuffix(entry.Name(), ".wox") {
+			return nil
+		}
+
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		relativePath, relErr := filepath.Rel(pluginDirectory, filePath)
+		if relErr != nil {
+			return relErr
+		}
+		totalSize += info.Size()
+		files = append(files, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if walkErr != nil {
+		return "", fmt.Errorf("failed to read plugin directory: %w", walkErr)
+	}
+	if len(files) > pluginPackageLimits.MaxFileCount {
+		return "", fmt.Errorf("plugin has %d files, more than %d files can't be installed", len(files), pluginPackageLimits.MaxFileCount)
+	}
+	if uint64(totalSize) > pluginPackageLimits.MaxTotalSize {
+		return "", fmt.Errorf("plugin files are %d MB in total, more than %d MB can't be installed", totalSize/1024/1024, pluginPackageLimits.MaxTotalSize/1024/1024)
+	}
+	sort.Strings(files)
+
+	zipFile, createErr := os.Create(zipPath)
+	if createErr != nil {
+		return "", fmt.Errorf("failed to create package: %w", createErr)
+	}
+	defer zipFile.Close()
+
+	hash := sha256.New()
+	zipWriter := zip.NewWriter(io.MultiWriter(zipFile, hash))
+	for _, file := range files {
+		if writeErr := writePackEntry(zipWriter, pluginDirectory, file); writeErr != nil {
+			zipWriter.Close()
+			return "", fmt.Errorf("failed to pack %s: %w", file, writeErr)
+		}
+	}
+	if closeErr := zipWriter.Close(); closeErr != nil {
+		return "", fmt.Errorf("failed to write package: %w", closeErr)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writePackEntry(zipWriter *zip.Writer, pluginDirectory string, file string) error {
+	info, statErr := os.Stat(filepath.Join(pluginDirectory, file))
+	if statErr != nil {
+		return statErr
+	}
+
+	// only the executable bit is kept, other permission bits depend on umask of author
+	mode := fs.FileMode(0644)
+	if util.IsFileExecAny(info.Mode()) {
+		mode = 0755
+	}
+	header := &zip.FileHeader{
+		Name:     file,
+		Method:   zip.Deflate,
+		Modified: packModifiedTime,
+	}
+	header.SetMode(mode)
+
+	writer, headerErr := zipWriter.CreateHeader(header)
+	if headerErr != nil {
+		return headerErr
+	}
+	content, openErr := os.Open(filepath.Join(pluginDirectory, file))
+	if openErr != nil {
+		return openErr
+	}
+	defer content.Close()
+	_, copyErr := io.Copy(writer, content)
+	return copyErr
+}
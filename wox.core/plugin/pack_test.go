@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"wox/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackPlugin(t *testing.T) {
+	ctx := context.Background()
+	directory := writeTestPlugin(t, `{
+		"Id": "demo",
+		"Name": "demo",
+		"Author": "wox",
+		"Version": "1.0.0",
+		"MinWoxVersion": "2.0.0",
+		"Runtime": "python",
+		"Description": "demo plugin",
+		"Icon": "emoji:🔍",
+		"Entry": "main.py",
+		"TriggerKeywords": ["demo"],
+		"SupportedOS": ["Linux"]
+	}`, map[string]string{
+		"main.py":                      "print('demo')",
+		"lib/util.py":                  "",
+		".git/HEAD":                    "",
+		"__pycache__/main.cpython.pyc": "",
+	})
+
+	outputDirectory := t.TempDir()
+	result, packErr := PackPlugin(ctx, directory, outputDirectory)
+	assert.NoError(t, packErr)
+	assert.Equal(t, filepath.Join(outputDirectory, "demo@1.0.0.wox"), result.PackagePath)
+
+	reader, openErr := zip.OpenReader(result.PackagePath)
+	assert.NoError(t, openErr)
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	reader.Close()
+	assert.Equal(t, []string{"lib/util.py", "main.py", "plugin.json"}, names)
+
+	var manifest StorePluginManifest
+	assert.NoError(t, json.Unmarshal(result.Manifest, &manifest))
+	assert.Equal(t, "demo@1.0.0.wox", manifest.DownloadUrl)
+	assert.Len(t, manifest.SHA256, 64)
+	_, verifyErr := VerifyPluginPackage(manifest, result.PackagePath, setting.PluginTrustPolicyAllowUnsigned, nil)
+	assert.NoError(t, verifyErr)
+
+	// packing again produces the same package even if files are touched
+	assert.NoError(t, os.Chtimes(filepath.Join(directory, "main.py"), packModifiedTime, packModifiedTime))
+	repacked, packErr := PackPlugin(ctx, directory, t.TempDir())
+	assert.NoError(t, packErr)
+	var repackedManifest StorePluginManifest
+	assert.NoError(t, json.Unmarshal(repacked.Manifest, &repackedManifest))
+	assert.Equal(t, manifest.SHA256, repackedManifest.SHA256)
+
+	_, packErr = PackPlugin(ctx, t.TempDir(), outputDirectory)
+	assert.Error(t, packErr)
+}
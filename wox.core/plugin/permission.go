@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"wox/i18n"
+	"wox/setting"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/samber/lo"
+)
+
+// GetEffectivePermissions returns permissions that are both requested by plugin and granted by user.
+// Plugin may request more permissions after upgrading, those permissions are not available until user grants them
+func GetEffectivePermissions(requested setting.PluginPermissions, granted *setting.PluginPermissions) setting.PluginPermissions {
+	var effective setting.PluginPermissions
+	if granted == nil {
+		return effective
+	}
+
+	for _, permission := range requested.FileSystem {
+		grantedPermission, exist := lo.Find(granted.FileSystem, func(item setting.PluginFileSystemPermission) bool {
+			return item.Path == permission.Path
+		})
+		if !exist {
+			continue
+		}
+		effective.FileSystem = append(effective.FileSystem, setting.PluginFileSystemPermission{
+			Path:     permission.Path,
+			ReadOnly: permission.ReadOnly || grantedPermission.ReadOnly,
+		})
+	}
+
+	for _, host := range requested.Network {
+		if lo.Contains(granted.Network, host) {
+			effective.Network = append(effective.Network, host)
+		}
+	}
+
+	return effective
+}
+
+// IsPermissionsCovered checks if all requested permissions are included in the confirmed permissions
+func IsPermissionsCovered(requested setting.PluginPermissions, confirmed setting.PluginPermissions) bool {
+	effective := GetEffectivePermissions(requested, &confirmed)
+	if len(effective.Network) != len(requested.Network) || len(effective.FileSystem) != len(requested.FileSystem) {
+		return false
+	}
+	for i, permission := range effective.FileSystem {
+		if permission.ReadOnly != requested.FileSystem[i].ReadOnly {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsNetworkAllowed checks if plugin can send requests to the host
+func IsNetworkAllowed(permissions setting.PluginPermissions, host string) bool {
+	host = strings.ToLower(host)
+	for _, allowedHost := range permissions.Network {
+		allowedHost = strings.ToLower(allowedHost)
+		if allowedHost == "*" || allowedHost == host {
+			return true
+		}
+		if strings.HasPrefix(allowedHost, "*.") && strings.HasSuffix(host, allowedHost[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpandPermissionPath returns the absolute path on disk of a file system permission
+func ExpandPermissionPath(permissionPath string) (string, error) {
+	expandedPath, expandErr := homedir.Expand(permissionPath)
+	if expandErr != nil {
+		return "", expandErr
+	}
+	if !filepath.IsAbs(expandedPath) {
+		return "", fmt.Errorf("permission path must be absolute: %s", permissionPath)
+	}
+
+	return filepath.Clean(expandedPath), nil
+}
+
+// FormatPermissions returns markdown list of permissions, used to let user confirm permissions before installing plugin
+func FormatPermissions(ctx context.Context, permissions setting.PluginPermissions) string {
+	var lines []string
+	for _, permission := range permissions.FileSystem {
+		key := "plugin_permission_file_read_write"
+		if permission.ReadOnly {
+			key = "plugin_permission_file_read_only"
+		}
+		lines = append(lines, "- "+fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, key), permission.Path))
+	}
+	for _, host := range permissions.Network {
+		lines = append(lines, "- "+fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_permission_network"), host))
+	}
+	if len(lines) == 0 {
+		return i18n.GetI18nManager().TranslateWox(ctx, "plugin_permission_none")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GrantPermissions saves permissions requested by plugin as granted, it's called after user confirmed installing the plugin
+func (m *Manager) GrantPermissions(ctx context.Context, metadata Metadata) error {
+	pluginSetting, loadErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Id, metadata.Name, metadata.SettingDefinitions)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	permissions := metadata.Permissions
+	pluginSetting.GrantedPermissions = &permissions
+	for _, instance := range m.GetPluginInstances() {
+		if instance.Metadata.Id == metadata.Id && instance.Setting != nil {
+			instance.Setting.GrantedPermissions = &permissions
+		}
+	}
+	logger.Info(ctx, fmt.Sprintf("grant permissions to plugin %s: %d file system, %d network", metadata.Name, len(permissions.FileSystem), len(permissions.Network)))
+	return setting.GetSettingManager().SavePluginSetting(ctx, metadata.Id, pluginSetting)
+}
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"wox/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEffectivePermissions(t *testing.T) {
+	requested := setting.PluginPermissions{
+		FileSystem: []setting.PluginFileSystemPermission{
+			{Path: "~/notes"},
+			{Path: "~/photos", ReadOnly: true},
+			{Path: "~/secret"},
+		},
+		Network: []string{"api.github.com", "example.com"},
+	}
+
+	assert.True(t, GetEffectivePermissions(requested, nil).IsEmpty())
+
+	// permissions requested after upgrading are not granted yet, read only granted path stays read only
+	effective := GetEffectivePermissions(requested, &setting.PluginPermissions{
+		FileSystem: []setting.PluginFileSystemPermission{
+			{Path: "~/notes", ReadOnly: true},
+			{Path: "~/photos"},
+		},
+		Network: []string{"api.github.com"},
+	})
+	assert.Equal(t, []setting.PluginFileSystemPermission{
+		{Path: "~/notes", ReadOnly: true},
+		{Path: "~/photos", ReadOnly: true},
+	}, effective.FileSystem)
+	assert.Equal(t, []string{"api.github.com"}, effective.Network)
+}
+
+func TestIsPermissionsCovered(t *testing.T) {
+	requested := setting.PluginPermissions{
+		FileSystem: []setting.PluginFileSystemPermission{{Path: "~/notes"}},
+		Network:    []string{"api.github.com"},
+	}
+
+	assert.True(t, IsPermissionsCovered(requested, requested))
+	assert.True(t, IsPermissionsCovered(setting.PluginPermissions{}, setting.PluginPermissions{}))
+	assert.False(t, IsPermissionsCovered(requested, setting.PluginPermissions{Network: []string{"api.github.com"}}))
+	assert.False(t, IsPermissionsCovered(requested, setting.PluginPermissions{
+		FileSystem: []setting.PluginFileSystemPermission{{Path: "~/notes", ReadOnly: true}},
+		Network:    []string{"api.github.com"},
+	}))
+}
+
+func TestGrantPermissionsRequiresConfirmation(t *testing.T) {
+	metadata := Metadata{
+		Name:        "demo",
+		Runtime:     string(PLUGIN_RUNTIME_WASM),
+		Permissions: setting.PluginPermissions{Network: []string{"api.github.com"}},
+	}
+
+	// nothing confirmed means nothing can be granted, e.g. installing from local file or rolling back
+	assert.NotNil(t, (&Store{}).grantPermissions(context.Background(), metadata, setting.PluginPermissions{}))
+
+	// only sandboxed plugins are restricted by permissions
+	metadata.Runtime = string(PLUGIN_RUNTIME_PYTHON)
+	assert.Nil(t, (&Store{}).grantPermissions(context.Background(), metadata, setting.PluginPermissions{}))
+}
+
+func TestIsNetworkAllowed(t *testing.T) {
+	permissions := setting.PluginPermissions{Network: []string{"api.github.com", "*.example.com"}}
+
+	assert.True(t, IsNetworkAllowed(permissions, "api.github.com"))
+	assert.True(t, IsNetworkAllowed(permissions, "API.GitHub.com"))
+	assert.True(t, IsNetworkAllowed(permissions, "a.example.com"))
+	assert.True(t, IsNetworkAllowed(permissions, "a.b.example.com"))
+	assert.False(t, IsNetworkAllowed(permissions, "example.com"))
+	assert.False(t, IsNetworkAllowed(permissions, "evilexample.com"))
+	assert.False(t, IsNetworkAllowed(permissions, "github.com"))
+	assert.False(t, IsNetworkAllowed(setting.PluginPermissions{}, "github.com"))
+	assert.True(t, IsNetworkAllowed(setting.PluginPermissions{Network: []string{"*"}}, "github.com"))
+}
+
+func TestExpandPermissionPath(t *testing.T) {
+	_, err := ExpandPermissionPath("relative/path")
+	assert.Error(t, err)
+
+	expanded, err := ExpandPermissionPath("~/notes/../notes")
+	assert.NoError(t, err)
+	assert.NotContains(t, expanded, "~")
+	assert.NotContains(t, expanded, "..")
+}
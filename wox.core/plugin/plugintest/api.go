@@ -0,0 +1,172 @@
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"wox/ai"
+	"wox/i18n"
+	"wox/plugin"
+	"wox/setting"
+	"wox/share"
+
+	"github.com/samber/lo"
+)
+
+var errAINotAvailable = errors.New("ai is not available in plugin tests")
+
+// LogEntry is a message logged by plugin through API
+type LogEntry struct {
+	Level   plugin.LogLevel
+	Message string
+}
+
+// API is an in-memory plugin.API. It works like the real API, but settings are kept in SettingStore, ui calls go to the fake UI
+// and logs are recorded instead of written into plugin log files
+type API struct {
+	t        testing.TB
+	instance *plugin.Instance
+	ui       *UI
+	settings *SettingStore
+
+	lock sync.Mutex
+	logs []LogEntry
+}
+
+func NewAPI(t testing.TB, instance *plugin.Instance, ui *UI, settings *SettingStore) *API {
+	return &API{t: t, instance: instance, ui: ui, settings: settings}
+}
+
+func (a *API) ChangeQuery(ctx context.Context, query share.PlainQuery) {
+	a.ui.ChangeQuery(ctx, query)
+}
+
+func (a *API) HideApp(ctx context.Context) {
+	a.ui.HideApp(ctx)
+}
+
+func (a *API) ShowApp(ctx context.Context) {
+	a.ui.ShowApp(ctx, share.ShowContext{SelectAll: true})
+}
+
+func (a *API) Notify(ctx context.Context, description string) {
+	a.ui.Notify(ctx, share.NotifyMsg{
+		PluginId:       a.instance.Metadata.Id,
+		Text:           a.GetTranslation(ctx, description),
+		DisplaySeconds: 3,
+	})
+}
+
+func (a *API) Log(ctx context.Context, level plugin.LogLevel, msg string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.logs = append(a.logs, LogEntry{Level: level, Message: msg})
+
+	// shown with go test -v or when test fails
+	if a.t != nil {
+		a.t.Logf("[%s] %s: %s", a.instance.Metadata.Name, level, msg)
+	}
+}
+
+// stopLogging stops writing logs into test output, host may still send logs after test finished which makes go test panic
+func (a *API) stopLogging() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.t = nil
+}
+
+func (a *API) GetTranslation(ctx context.Context, key string) string {
+	if a.instance.IsSystemPlugin {
+		return i18n.GetI18nManager().TranslateWox(ctx, key)
+	}
+	return i18n.GetI18nManager().TranslatePlugin(ctx, key, a.instance.PluginDirectory)
+}
+
+func (a *API) GetSetting(ctx context.Context, key string) string {
+	// platform specific setting has higher priority, the same as real API
+	if v, exist := a.settings.Get(getPlatformSpecificKey(key)); exist {
+		return v
+	}
+	if v, exist := a.settings.Get(key); exist {
+		return v
+	}
+	return ""
+}
+
+func (a *API) SaveSetting(ctx context.Context, key string, value string, isPlatformSpecific bool) {
+	finalKey := key
+	if isPlatformSpecific {
+		finalKey = getPlatformSpecificKey(key)
+	} else {
+		a.settings.PluginSetting().Settings.Delete(getPlatformSpecificKey(key))
+	}
+
+	existValue, exist := a.settings.Get(finalKey)
+	a.settings.Set(finalKey, value)
+	if !exist || existValue != value {
+		for _, callback := range a.instance.SettingChangeCallbacks {
+			callback(key, value)
+		}
+	}
+}
+
+func (a *API) OnSettingChanged(ctx context.Context, callback func(key string, value string)) {
+	a.instance.SettingChangeCallbacks = append(a.instance.SettingChangeCallbacks, callback)
+}
+
+func (a *API) OnGetDynamicSetting(ctx context.Context, callback func(key string) string) {
+	a.instance.DynamicSettingCallbacks = append(a.instance.DynamicSettingCallbacks, callback)
+}
+
+func (a *API) OnDeepLink(ctx context.Context, callback func(arguments map[string]string)) {
+	if !a.instance.Metadata.IsSupportFeature(plugin.MetadataFeatureDeepLink) {
+		a.Log(ctx, plugin.LogLevelError, "plugin has no access to deep link feature")
+		return
+	}
+
+	a.instance.DeepLinkCallbacks = append(a.instance.DeepLinkCallbacks, callback)
+}
+
+func (a *API) OnUnload(ctx context.Context, callback func()) {
+	a.instance.UnloadCallbacks = append(a.instance.UnloadCallbacks, callback)
+}
+
+func (a *API) RegisterQueryCommands(ctx context.Context, commands []plugin.MetadataCommand) {
+	a.settings.PluginSetting().QueryCommands = lo.Map(commands, func(command plugin.MetadataCommand, _ int) setting.PluginQueryCommand {
+		return setting.PluginQueryCommand{
+			Command:     command.Command,
+			Description: command.Description,
+		}
+	})
+}
+
+func (a *API) AIChatStream(ctx context.Context, model ai.Model, conversations []ai.Conversation, callback ai.ChatStreamFunc) error {
+	return errAINotAvailable
+}
+
+func (a *API) AIIndexDocuments(ctx context.Context, index string, model ai.Model, documents []ai.VectorDocument) error {
+	return errAINotAvailable
+}
+
+func (a *API) AIRemoveDocuments(ctx context.Context, index string, model ai.Model, ids []string) error {
+	return errAINotAvailable
+}
+
+func (a *API) AIQueryDocuments(ctx context.Context, index string, model ai.Model, query string, limit int) ([]ai.VectorSearchResult, error) {
+	return nil, errAINotAvailable
+}
+
+// Logs returns messages logged by plugin, in order
+func (a *API) Logs() []LogEntry {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([]LogEntry{}, a.logs...)
+}
+
+// LogsOfLevel returns messages logged by plugin with given level, E.g. to assert plugin logged no errors
+func (a *API) LogsOfLevel(level plugin.LogLevel) []string {
+	return lo.FilterMap(a.Logs(), func(entry LogEntry, _ int) (string, bool) {
+		return entry.Message, entry.Level == level
+	})
+}
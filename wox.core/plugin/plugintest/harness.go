@@ -0,0 +1,247 @@
+package plugintest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"wox/plugin"
+	"wox/util"
+
+	"github.com/samber/lo"
+)
+
+// Harness runs a plugin with fake API, ui and settings, so plugin can be tested without starting Wox.
+// Plugin manager is shared by the whole process, so harnesses must not run in parallel tests
+type Harness struct {
+	t        testing.TB
+	ctx      context.Context
+	Instance *plugin.Instance
+	API      *API
+	UI       *UI
+	Settings *SettingStore
+}
+
+// NewSystemPlugin inits a system plugin with fake API, unload callbacks are called when test finishes
+func NewSystemPlugin(t testing.TB, systemPlugin plugin.SystemPlugin) *Harness {
+	h := newHarness(t, &plugin.Instance{
+		Metadata:       systemPlugin.GetMetadata(),
+		Plugin:         systemPlugin,
+		IsSystemPlugin: true,
+	})
+	t.Cleanup(func() {
+		for _, callback := range h.Instance.UnloadCallbacks {
+			callback()
+		}
+	})
+
+	h.init()
+	return h
+}
+
+func newHarness(t testing.TB, instance *plugin.Instance) *Harness {
+	ui := NewUI()
+	settings := NewSettingStore(instance.Metadata.SettingDefinitions)
+	instance.Setting = settings.PluginSetting()
+	api := NewAPI(t, instance, ui, settings)
+	instance.API = api
+
+	// some system plugins use ui of plugin manager directly instead of API
+	previousUI := plugin.GetPluginManager().GetUI()
+	plugin.GetPluginManager().SetUI(ui)
+	t.Cleanup(func() {
+		plugin.GetPluginManager().SetUI(previousUI)
+		api.stopLogging()
+	})
+
+	return &Harness{
+		t:        t,
+		ctx:      util.NewTraceContext(),
+		Instance: instance,
+		API:      api,
+		UI:       ui,
+		Settings: settings,
+	}
+}
+
+func (h *Harness) init() {
+	h.Instance.Plugin.Init(h.ctx, plugin.InitParams{
+		API:             h.API,
+		PluginDirectory: h.Instance.PluginDirectory,
+	})
+}
+
+// Query runs input query as if user typed it in Wox, E.g. "calculator 1+2".
+// Query without a trigger keyword of plugin is a global query
+func (h *Harness) Query(rawQuery string) Results {
+	query, _ := plugin.NewQueryInputWithPlugins(rawQuery, []*plugin.Instance{h.Instance})
+	return h.RunQuery(query)
+}
+
+// QuerySelection runs selection query, plugin only gets selection queries in Wox if querySelection feature is enabled
+func (h *Harness) QuerySelection(selection util.Selection) Results {
+	return h.RunQuery(plugin.Query{
+		Type:      plugin.QueryTypeSelection,
+		Selection: selection,
+	})
+}
+
+// RunQuery runs query as it is, env of query is filled from UI if it's empty
+func (h *Harness) RunQuery(query plugin.Query) Results {
+	if query.Env == (plugin.QueryEnv{}) {
+		query.Env.ActiveWindowTitle = h.UI.GetActiveWindowName()
+		query.Env.ActiveWindowPid = h.UI.GetActiveWindowPid()
+	}
+
+	return Results{h: h, Items: lo.Map(h.Instance.Plugin.Query(h.ctx, query), func(result plugin.QueryResult, _ int) Result {
+		return Result{QueryResult: h.translateResult(result), h: h}
+	})}
+}
+
+// ChangeSetting changes setting as if user changed it in setting window, plugin is notified if value is changed
+func (h *Harness) ChangeSetting(key string, value string) {
+	h.API.SaveSetting(h.ctx, key, value, false)
+}
+
+// GetDynamicSetting returns the setting definition plugin provides for dynamic setting key, in json
+func (h *Harness) GetDynamicSetting(key string) string {
+	for _, callback := range h.Instance.DynamicSettingCallbacks {
+		if value := callback(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// OpenDeepLink calls deep link callbacks of plugin as if user opened wox://plugin/<id>?arguments
+func (h *Harness) OpenDeepLink(arguments map[string]string) {
+	for _, callback := range h.Instance.DeepLinkCallbacks {
+		callback(arguments)
+	}
+}
+
+func (h *Harness) translate(text string) string {
+	if !strings.HasPrefix(text, "i18n:") {
+		return text
+	}
+	return h.API.GetTranslation(h.ctx, text)
+}
+
+// translateResult translates texts of result like plugin manager does before sending results to ui
+func (h *Harness) translateResult(result plugin.QueryResult) plugin.QueryResult {
+	result.Title = h.translate(result.Title)
+	result.SubTitle = h.translate(result.SubTitle)
+	result.Tails = lo.Map(result.Tails, func(tail plugin.QueryResultTail, _ int) plugin.QueryResultTail {
+		if tail.Type == plugin.QueryResultTailTypeText {
+			tail.Text = h.translate(tail.Text)
+		}
+		return tail
+	})
+	result.Actions = lo.Map(result.Actions, func(action plugin.QueryResultAction, _ int) plugin.QueryResultAction {
+		action.Name = h.translate(action.Name)
+		return action
+	})
+	if result.Preview.PreviewType == plugin.WoxPreviewTypeText {
+		result.Preview.PreviewData = h.translate(result.Preview.PreviewData)
+	}
+	return result
+}
+
+// Result is a query result with translated texts, the same as user sees it in Wox
+type Result struct {
+	plugin.QueryResult
+	h *Harness
+}
+
+type Results struct {
+	h     *Harness
+	Items []Result
+}
+
+func (r Results) Len() int {
+	return len(r.Items)
+}
+
+func (r Results) Titles() []string {
+	return lo.Map(r.Items, func(result Result, _ int) string { return result.Title })
+}
+
+// Find returns the first result with given title, test fails immediately if there is no such result
+func (r Results) Find(title string) Result {
+	r.h.t.Helper()
+	result, found := lo.Find(r.Items, func(result Result) bool { return result.Title == title })
+	if !found {
+		r.h.t.Fatalf("no result titled %q in %q", title, r.Titles())
+	}
+	return result
+}
+
+// ActionNames returns names of actions, default action is the first one
+func (r Result) ActionNames() []string {
+	return lo.Map(r.sortedActions(), func(action plugin.QueryResultAction, _ int) string { return action.Name })
+}
+
+// ExecuteAction executes action with given name or id, app is hidden after action unless action prevents it
+func (r Result) ExecuteAction(name string) {
+	r.h.t.Helper()
+	action, found := lo.Find(r.Actions, func(action plugin.QueryResultAction) bool {
+		return action.Name == name || (action.Id != "" && action.Id == name)
+	})
+	if !found {
+		r.h.t.Fatalf("result %q has no action %q, actions: %q", r.Title, name, r.ActionNames())
+	}
+	r.executeAction(action)
+}
+
+// ExecuteDefaultAction executes the action run by pressing enter, which is the default action or the first one
+func (r Result) ExecuteDefaultAction() {
+	r.h.t.Helper()
+	actions := r.sortedActions()
+	if len(actions) == 0 {
+		r.h.t.Fatalf("result %q has no action", r.Title)
+	}
+	r.executeAction(actions[0])
+}
+
+func (r Result) executeAction(action plugin.QueryResultAction) {
+	if action.Action != nil {
+		action.Action(r.h.ctx, plugin.ActionContext{ContextData: r.ContextData})
+	}
+	if !action.PreventHideAfterAction {
+		r.h.UI.HideApp(r.h.ctx)
+	}
+}
+
+func (r Result) sortedActions() []plugin.QueryResultAction {
+	defaultActions := lo.Filter(r.Actions, func(action plugin.QueryResultAction, _ int) bool { return action.IsDefault })
+	otherActions := lo.Filter(r.Actions, func(action plugin.QueryResultAction, _ int) bool { return !action.IsDefault })
+	return append(defaultActions, otherActions...)
+}
+
+// Refresh calls OnRefresh of result once, as Wox does every RefreshInterval, and returns the refreshed result
+func (r Result) Refresh() Result {
+	r.h.t.Helper()
+	if r.OnRefresh == nil {
+		r.h.t.Fatalf("result %q is not refreshable", r.Title)
+	}
+
+	refreshed := r.OnRefresh(r.h.ctx, plugin.RefreshableResult{
+		Title:           r.Title,
+		SubTitle:        r.SubTitle,
+		Icon:            r.Icon,
+		Preview:         r.Preview,
+		Tails:           r.Tails,
+		ContextData:     r.ContextData,
+		RefreshInterval: r.RefreshInterval,
+		Actions:         r.Actions,
+	})
+	r.Title = refreshed.Title
+	r.SubTitle = refreshed.SubTitle
+	r.Icon = refreshed.Icon
+	r.Preview = refreshed.Preview
+	r.Tails = refreshed.Tails
+	r.ContextData = refreshed.ContextData
+	r.RefreshInterval = refreshed.RefreshInterval
+	r.Actions = refreshed.Actions
+	r.QueryResult = r.h.translateResult(r.QueryResult)
+	return r
+}
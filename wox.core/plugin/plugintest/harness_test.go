@@ -0,0 +1,160 @@
+package plugintest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wox/plugin"
+	"wox/setting/definition"
+	"wox/share"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type greetingPlugin struct {
+	api            plugin.API
+	changedSetting []string
+}
+
+func (g *greetingPlugin) GetMetadata() plugin.Metadata {
+	return plugin.Metadata{
+		Id:              "plugintest-greeting",
+		Name:            "Greeting",
+		TriggerKeywords: []string{"greet"},
+		Commands:        []plugin.MetadataCommand{{Command: "loud"}},
+		SettingDefinitions: definition.PluginSettingDefinitions{
+			{
+				Type:  definition.PluginSettingDefinitionTypeTextBox,
+				Value: &definition.PluginSettingValueTextBox{Key: "greeting", DefaultValue: "hello"},
+			},
+		},
+	}
+}
+
+func (g *greetingPlugin) Init(ctx context.Context, initParams plugin.InitParams) {
+	g.api = initParams.API
+	g.api.OnSettingChanged(ctx, func(key string, value string) {
+		g.changedSetting = append(g.changedSetting, key+"="+value)
+	})
+}
+
+func (g *greetingPlugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	greeting := g.api.GetSetting(ctx, "greeting")
+	if query.Command == "loud" {
+		greeting += "!"
+	}
+	g.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("greeting %s", query.Search))
+
+	refreshCount := 0
+	return []plugin.QueryResult{
+		{
+			Title:           fmt.Sprintf("%s %s", greeting, query.Search),
+			SubTitle:        "refreshed 0 times",
+			RefreshInterval: 100,
+			OnRefresh: func(ctx context.Context, current plugin.RefreshableResult) plugin.RefreshableResult {
+				refreshCount++
+				current.SubTitle = fmt.Sprintf("refreshed %d times", refreshCount)
+				return current
+			},
+			Actions: []plugin.QueryResultAction{
+				{
+					Name: "i18n:plugin_calculator_recalculate",
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						g.api.ChangeQuery(ctx, share.PlainQuery{QueryType: plugin.QueryTypeInput, QueryText: "greet again"})
+					},
+				},
+				{
+					Name:                   "notify",
+					IsDefault:              true,
+					PreventHideAfterAction: true,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						g.api.Notify(ctx, "greeted "+query.Search)
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestHarness(t *testing.T) {
+	greeting := &greetingPlugin{}
+	h := NewSystemPlugin(t, greeting)
+
+	results := h.Query("greet wox")
+	assert.Equal(t, []string{"hello wox"}, results.Titles())
+	assert.Equal(t, []string{"greeting wox"}, h.API.LogsOfLevel(plugin.LogLevelInfo))
+	assert.Equal(t, []string{"hello! wox"}, h.Query("greet loud wox").Titles())
+
+	// action names are translated and default action comes first
+	result := results.Find("hello wox")
+	assert.Equal(t, []string{"notify", "Recalculate"}, result.ActionNames())
+
+	h.UI.ShowApp(context.Background(), share.ShowContext{})
+	result.ExecuteDefaultAction()
+	assert.Equal(t, "greeted wox", h.UI.Notifications()[0].Text)
+	assert.True(t, h.UI.IsVisible())
+
+	result.ExecuteAction("Recalculate")
+	assert.Equal(t, []share.PlainQuery{{QueryType: plugin.QueryTypeInput, QueryText: "greet again"}}, h.UI.ChangedQueries())
+	assert.False(t, h.UI.IsVisible())
+
+	result = result.Refresh().Refresh()
+	assert.Equal(t, "refreshed 2 times", result.SubTitle)
+
+	h.ChangeSetting("greeting", "hi")
+	h.ChangeSetting("greeting", "hi")
+	assert.Equal(t, []string{"greeting=hi"}, greeting.changedSetting)
+	assert.Equal(t, []string{"hi wox"}, h.Query("greet wox").Titles())
+	assert.Equal(t, map[string]string{"greeting": "hi"}, h.Settings.Values())
+
+	// global query doesn't start with trigger keyword
+	assert.Equal(t, []string{"hi greet"}, h.Query("greet").Titles())
+}
+
+func TestNewHostPlugin(t *testing.T) {
+	pluginDirectory := filepath.Join(t.TempDir(), "greeting")
+	assert.NoError(t, os.MkdirAll(pluginDirectory, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDirectory, "plugin.json"), []byte(`{
+		"Id": "plugintest-python-greeting",
+		"Name": "Python Greeting",
+		"Version": "1.0.0",
+		"Runtime": "python",
+		"Entry": "main.py",
+		"TriggerKeywords": ["greet"],
+		"SupportedOS": ["Windows", "Macos", "Linux"]
+	}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDirectory, "main.py"), []byte(`
+from wox_plugin import ActionContext, Context, PluginInitParams, Query, Result, ResultAction, WoxImage
+
+
+class GreetingPlugin:
+    async def init(self, ctx: Context, init_params: PluginInitParams) -> None:
+        self.api = init_params.api
+
+    async def query(self, ctx: Context, query: Query) -> list[Result]:
+        greeting = await self.api.get_setting(ctx, "greeting") or "hello"
+
+        async def notify(action_context: ActionContext) -> None:
+            await self.api.notify(ctx, "greeted " + query.search)
+
+        return [Result(title=greeting + " " + query.search, icon=WoxImage.new_emoji("👋"), actions=[ResultAction(name="notify", action=notify)])]
+
+
+plugin = GreetingPlugin()
+`), 0644))
+
+	h := NewHostPlugin(t, pluginDirectory)
+
+	result := h.Query("greet wox").Find("hello wox")
+	result.ExecuteAction("notify")
+	assert.Eventually(t, func() bool {
+		notifications := h.UI.Notifications()
+		return len(notifications) == 1 && notifications[0].Text == "greeted wox"
+	}, 5*time.Second, 100*time.Millisecond)
+
+	h.ChangeSetting("greeting", "hi")
+	assert.Equal(t, []string{"hi wox"}, h.Query("greet wox").Titles())
+}
@@ -0,0 +1,110 @@
+package plugintest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"wox/plugin"
+	_ "wox/plugin/host" // register hosts
+	"wox/resource"
+	"wox/util"
+
+	"github.com/samber/lo"
+)
+
+var prepareHostOnce sync.Once
+var prepareHostErr error
+
+// NewHostPlugin loads plugin in pluginDirectory into a dedicated host process of its runtime, E.g. python or nodejs,
+// requests from plugin are served by fake API through the real websocket host. Host process is stopped when test finishes.
+//
+// Test is skipped if runtime of plugin or host is not available, hosts are built by `make dev`
+func NewHostPlugin(t testing.TB, pluginDirectory string) *Harness {
+	t.Helper()
+	ctx := util.NewTraceContext()
+
+	prepareHostOnce.Do(func() {
+		prepareHostErr = prepareHostEnvironment(ctx)
+	})
+	if prepareHostErr != nil {
+		t.Fatalf("failed to prepare host environment: %s", prepareHostErr.Error())
+	}
+
+	metadata, parseErr := plugin.GetPluginManager().ParseMetadata(ctx, pluginDirectory)
+	if parseErr != nil {
+		t.Fatalf("failed to parse plugin metadata: %s", parseErr.Error())
+	}
+
+	host, found := lo.Find(plugin.AllHosts, func(item plugin.Host) bool {
+		return strings.EqualFold(string(item.GetRuntime(ctx)), metadata.Runtime)
+	})
+	if !found {
+		t.Fatalf("no host for runtime %s", metadata.Runtime)
+	}
+	if discoverer, ok := host.(plugin.RuntimeDiscoverer); ok {
+		if runtimeInfo := discoverer.GetRuntimeInfo(ctx, false); runtimeInfo.Path == "" {
+			t.Skipf("%s runtime is not available: %s", metadata.Runtime, runtimeInfo.GetProblemSummary())
+		}
+	}
+
+	// every test gets its own host process, so plugins of different tests don't share state
+	isIsolatedHost := false
+	if provider, ok := host.(plugin.IsolatedHostProvider); ok {
+		host = provider.NewIsolatedHost(ctx, metadata, pluginDirectory)
+		isIsolatedHost = true
+	}
+	if !host.IsStarted(ctx) {
+		if startErr := host.Start(ctx); startErr != nil {
+			host.Stop(ctx)
+			t.Skipf("failed to start %s host: %s", metadata.Runtime, startErr.Error())
+		}
+		// host process exits immediately if host is not built, only the placeholder is embedded
+		if !host.IsStarted(ctx) {
+			host.Stop(ctx)
+			t.Skipf("%s host is not started, build hosts with make dev", metadata.Runtime)
+		}
+	}
+
+	pluginImpl, loadErr := host.LoadPlugin(ctx, metadata, pluginDirectory)
+	if loadErr != nil {
+		if isIsolatedHost {
+			host.Stop(ctx)
+		}
+		t.Fatalf("failed to load plugin: %s", loadErr.Error())
+	}
+
+	h := newHarness(t, &plugin.Instance{
+		Metadata:        metadata,
+		PluginDirectory: pluginDirectory,
+		Plugin:          pluginImpl,
+		Host:            host,
+		IsIsolatedHost:  isIsolatedHost,
+	})
+	// requests from host find plugin instance in plugin manager
+	plugin.GetPluginManager().RegisterInstance(h.Instance)
+	t.Cleanup(func() {
+		plugin.GetPluginManager().UnloadPlugin(util.NewTraceContext(), h.Instance)
+	})
+
+	h.init()
+	return h
+}
+
+// prepareHostEnvironment creates wox data directories and extracts hosts into them, the same as Wox does on start.
+// A temp directory is used as wox data directory, so tests never touch ~/.wox of user
+func prepareHostEnvironment(ctx context.Context) error {
+	woxDataDirectory, tempErr := os.MkdirTemp("", "wox-plugintest-")
+	if tempErr != nil {
+		return fmt.Errorf("failed to create wox data directory: %w", tempErr)
+	}
+	if locationErr := util.GetLocation().InitWithDataDirectory(woxDataDirectory); locationErr != nil {
+		return fmt.Errorf("failed to init location: %w", locationErr)
+	}
+	if extractErr := resource.ExtractHosts(ctx); extractErr != nil {
+		return fmt.Errorf("failed to extract hosts: %w", extractErr)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package plugintest
+
+import (
+	"wox/setting"
+	"wox/setting/definition"
+	"wox/util"
+)
+
+// SettingStore keeps settings of the tested plugin in memory, nothing is written into plugin setting directory
+type SettingStore struct {
+	pluginSetting *setting.PluginSetting
+}
+
+// NewSettingStore creates a store filled with default values of setting definitions, like a newly installed plugin
+func NewSettingStore(definitions definition.PluginSettingDefinitions) *SettingStore {
+	return &SettingStore{
+		pluginSetting: &setting.PluginSetting{
+			Settings: definitions.GetAllDefaults(),
+		},
+	}
+}
+
+// PluginSetting returns setting used by plugin instance, E.g. to set TriggerKeywords or Disabled before querying
+func (s *SettingStore) PluginSetting() *setting.PluginSetting {
+	return s.pluginSetting
+}
+
+// Get returns raw stored value, platform specific values are stored with @<platform> suffix in key
+func (s *SettingStore) Get(key string) (string, bool) {
+	return s.pluginSetting.GetSetting(key)
+}
+
+// Set stores value without notifying plugin, use Harness.ChangeSetting to simulate user changing the setting
+func (s *SettingStore) Set(key string, value string) {
+	s.pluginSetting.Settings.Store(key, value)
+}
+
+// Values returns all stored settings
+func (s *SettingStore) Values() map[string]string {
+	values := map[string]string{}
+	s.pluginSetting.Settings.Range(func(key string, value string) bool {
+		values[key] = value
+		return true
+	})
+	return values
+}
+
+func getPlatformSpecificKey(key string) string {
+	return key + "@" + util.GetCurrentPlatform()
+}
@@ -0,0 +1,149 @@
+package plugintest
+
+import (
+	"context"
+	"sync"
+	"wox/share"
+
+	"github.com/samber/lo"
+)
+
+// UI is a share.UI which records what plugins ask ui to do instead of showing anything
+type UI struct {
+	// returned by GetActiveWindowName and GetActiveWindowPid, also used as query env
+	ActiveWindowName string
+	ActiveWindowPid  int
+	// returned by PickFiles
+	PickedFiles []string
+
+	lock           sync.Mutex
+	isVisible      bool
+	queries        []share.PlainQuery
+	notifications  []share.NotifyMsg
+	settingWindows []share.SettingWindowContext
+	themes         []share.Theme
+	currentTheme   share.Theme
+}
+
+func NewUI() *UI {
+	return &UI{}
+}
+
+func (u *UI) ChangeQuery(ctx context.Context, query share.PlainQuery) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.queries = append(u.queries, query)
+}
+
+func (u *UI) HideApp(ctx context.Context) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.isVisible = false
+}
+
+func (u *UI) ShowApp(ctx context.Context, showContext share.ShowContext) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.isVisible = true
+}
+
+func (u *UI) ToggleApp(ctx context.Context) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.isVisible = !u.isVisible
+}
+
+func (u *UI) OpenSettingWindow(ctx context.Context, windowContext share.SettingWindowContext) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.settingWindows = append(u.settingWindows, windowContext)
+}
+
+func (u *UI) PickFiles(ctx context.Context, params share.PickFilesParams) []string {
+	return u.PickedFiles
+}
+
+func (u *UI) GetActiveWindowName() string {
+	return u.ActiveWindowName
+}
+
+func (u *UI) GetActiveWindowPid() int {
+	return u.ActiveWindowPid
+}
+
+func (u *UI) GetServerPort(ctx context.Context) int {
+	return 0
+}
+
+func (u *UI) GetAllThemes(ctx context.Context) []share.Theme {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return append([]share.Theme{}, u.themes...)
+}
+
+func (u *UI) ChangeTheme(ctx context.Context, theme share.Theme) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.currentTheme = theme
+}
+
+func (u *UI) InstallTheme(ctx context.Context, theme share.Theme) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.themes = append(u.themes, theme)
+}
+
+func (u *UI) UninstallTheme(ctx context.Context, theme share.Theme) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.themes = lo.Filter(u.themes, func(item share.Theme, _ int) bool {
+		return item.ThemeId != theme.ThemeId
+	})
+}
+
+func (u *UI) RestoreTheme(ctx context.Context) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.currentTheme = share.Theme{}
+}
+
+func (u *UI) Notify(ctx context.Context, msg share.NotifyMsg) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.notifications = append(u.notifications, msg)
+}
+
+// IsVisible returns whether app is shown, app is hidden at the beginning
+func (u *UI) IsVisible() bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return u.isVisible
+}
+
+// ChangedQueries returns queries changed by plugins, in order
+func (u *UI) ChangedQueries() []share.PlainQuery {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return append([]share.PlainQuery{}, u.queries...)
+}
+
+// Notifications returns messages plugins notified, in order
+func (u *UI) Notifications() []share.NotifyMsg {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return append([]share.NotifyMsg{}, u.notifications...)
+}
+
+// OpenedSettingWindows returns contexts setting window is opened with, in order
+func (u *UI) OpenedSettingWindows() []share.SettingWindowContext {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return append([]share.SettingWindowContext{}, u.settingWindows...)
+}
+
+// CurrentTheme returns the theme changed by plugins, empty if theme is never changed or restored
+func (u *UI) CurrentTheme() share.Theme {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return u.currentTheme
+}
@@ -17,9 +17,16 @@ const (
 	QueryTypeSelection QueryType = "selection" // user selection query
 )
 
+// Query variables can be used in query hotkeys, ai command prompts and web search urls. See RenderQueryTemplate for the full syntax.
 const (
 	QueryVariableSelectedText     QueryVariable = "{wox:selected_text}"
 	QueryVariableActiveBrowserUrl QueryVariable = "{wox:active_browser_url}"
+	QueryVariableActiveWindow     QueryVariable = "{wox:active_window}"
+	QueryVariableClipboard        QueryVariable = "{wox:clipboard}"
+	QueryVariableDate             QueryVariable = "{wox:date}"
+	QueryVariableTime             QueryVariable = "{wox:time}"
+	QueryVariableQuery            QueryVariable = "{wox:query}"      // user typed arguments
+	QueryVariableSetting          QueryVariable = "{wox:setting:%s}" // plugin setting value of given key
 )
 
 const (
@@ -63,6 +70,31 @@ type Query struct {
 	Env QueryEnv
 }
 
+const (
+	contextKeyQuerySource     = "querySource"
+	contextKeyQuerySuperseded = "querySuperseded"
+)
+
+// NewQuerySourceContext marks queries sent with ctx as coming from source, e.g. launcher ui.
+// A new query cancels query requests of the previous query from the same source, queries without source never cancel each other
+func NewQuerySourceContext(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, contextKeyQuerySource, source)
+}
+
+// NewQueryRequestContext returns ctx for sending query request to plugin, it's canceled when the query is superseded by a newer query from the same source.
+// Only the query request should use it, work started by the query must keep using the query ctx
+func NewQueryRequestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	requestCtx, cancel := context.WithCancel(ctx)
+	if supersededCtx, ok := ctx.Value(contextKeyQuerySuperseded).(context.Context); ok {
+		stop := context.AfterFunc(supersededCtx, cancel)
+		return requestCtx, func() {
+			stop()
+			cancel()
+		}
+	}
+	return requestCtx, cancel
+}
+
 func (q *Query) IsGlobalQuery() bool {
 	return q.Type == QueryTypeInput && q.TriggerKeyword == ""
 }
@@ -208,6 +240,12 @@ type QueryResultCache struct {
 	Actions        *util.HashMap[string, func(ctx context.Context, actionContext ActionContext)]
 }
 
+// NewQueryInputWithPlugins splits input query into trigger keyword, command and search by trigger keywords and commands of given plugins,
+// the plugin owning the trigger keyword is returned, nil for global query. It's used by plugin tests which don't load plugins into manager
+func NewQueryInputWithPlugins(query string, pluginInstances []*Instance) (Query, *Instance) {
+	return newQueryInputWithPlugins(query, pluginInstances)
+}
+
 func newQueryInputWithPlugins(query string, pluginInstances []*Instance) (Query, *Instance) {
 	var terms = strings.Split(query, " ")
 	if len(terms) == 0 {
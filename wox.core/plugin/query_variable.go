@@ -0,0 +1,227 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"wox/util"
+	"wox/util/clipboard"
+)
+
+// QueryVariableContext provides values which are only known by the caller when rendering a query template
+type QueryVariableContext struct {
+	Query        string                   // user typed arguments, used by {wox:query}
+	SelectedText *string                  // used by {wox:selected_text}, nil means empty unless GrabSelection is true
+	Setting      func(key string) string  // used by {wox:setting:key}
+	Extra        map[string]func() string // caller specific variables, E.g. {lower_query} of web search
+	// grab current selection for {wox:selected_text} if SelectedText is nil. Only set it before wox is shown (E.g. query hotkeys),
+	// during a query wox has focus and grabbing would simulate copy on every keystroke, use query.Selection instead
+	GrabSelection bool
+}
+
+// variable tags: {wox:name}, {wox:name|default}, {wox:setting:key}, {wox:if name}, {wox:if !name}, {wox:else}, {wox:end}.
+// Only legacy variables of web search urls can be used without "wox:" prefix, so other text in braces is never rewritten
+var queryVariableTagRegex = regexp.MustCompile(`\{(?:wox:(if !?[a-z_]+(?::[^{}|]+)?|else|end|[a-z_]+(?::[^{}|]+)?(?:\|[^{}]*)?)|(query|lower_query|upper_query))\}`)
+
+type queryTemplateToken struct {
+	text     string // raw text of the token
+	tagType  string // empty for plain text, otherwise one of variable, if, else, end
+	name     string
+	negate   bool
+	fallback *string
+}
+
+type queryTemplateNode struct {
+	token    queryTemplateToken
+	children []queryTemplateNode // only for if nodes, body when condition is true
+	elses    []queryTemplateNode // only for if nodes, body when condition is false
+}
+
+// RenderQueryTemplate replaces variables in the template and evaluates conditionals.
+//
+//	{wox:selected_text}              variable, unknown variables are kept as it is. {query}, {lower_query} and {upper_query} don't need the prefix
+//	{wox:clipboard|nothing copied}   variable with default value if it's empty
+//	{wox:setting:api_key}            plugin setting value
+//	{wox:if selected_text}...{wox:else}...{wox:end}   conditional, {wox:if !name} negates the condition
+//
+// Variables are resolved lazily and only once, so expensive variables like selected text are only fetched when used
+func (m *Manager) RenderQueryTemplate(ctx context.Context, template string, variableContext QueryVariableContext) string {
+	if !strings.Contains(template, "{") {
+		return template
+	}
+
+	nodes, _ := parseQueryTemplate(tokenizeQueryTemplate(template), 0, false)
+	resolved := map[string]string{}
+	resolve := func(name string) (string, bool) {
+		if v, ok := resolved[name]; ok {
+			return v, true
+		}
+		v, ok := m.resolveQueryVariable(ctx, name, variableContext)
+		if ok {
+			resolved[name] = v
+		}
+		return v, ok
+	}
+
+	var sb strings.Builder
+	renderQueryTemplateNodes(&sb, nodes, resolve)
+	return sb.String()
+}
+
+func (m *Manager) resolveQueryVariable(ctx context.Context, name string, variableContext QueryVariableContext) (string, bool) {
+	if variableContext.Extra != nil {
+		if getter, ok := variableContext.Extra[name]; ok {
+			return getter(), true
+		}
+	}
+
+	if key, found := strings.CutPrefix(name, "setting:"); found {
+		if variableContext.Setting == nil {
+			return "", false
+		}
+		return variableContext.Setting(key), true
+	}
+
+	switch name {
+	case "query":
+		return variableContext.Query, true
+	case "selected_text":
+		if variableContext.SelectedText != nil {
+			return *variableContext.SelectedText, true
+		}
+		if !variableContext.GrabSelection {
+			return "", true
+		}
+		selection, selectedErr := util.GetSelected()
+		if selectedErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to get selected text: %s", selectedErr.Error()))
+			return "", true
+		}
+		if selection.Type != util.SelectionTypeText {
+			logger.Error(ctx, fmt.Sprintf("selected data is not text, type: %s", selection.Type))
+			return "", true
+		}
+		return selection.Text, true
+	case "active_browser_url":
+		return m.activeBrowserUrl, true
+	case "active_window":
+		if m.ui == nil {
+			return "", true
+		}
+		return m.ui.GetActiveWindowName(), true
+	case "clipboard":
+		data, readErr := clipboard.ReadFilesAndText()
+		if readErr != nil || data.GetType() != clipboard.ClipboardTypeText {
+			return "", true
+		}
+		return data.String(), true
+	case "date":
+		return time.Now().Format("2006-01-02"), true
+	case "time":
+		return time.Now().Format("15:04:05"), true
+	}
+
+	return "", false
+}
+
+func tokenizeQueryTemplate(template string) []queryTemplateToken {
+	var tokens []queryTemplateToken
+	last := 0
+	for _, loc := range queryVariableTagRegex.FindAllStringSubmatchIndex(template, -1) {
+		if loc[0] > last {
+			tokens = append(tokens, queryTemplateToken{text: template[last:loc[0]]})
+		}
+		last = loc[1]
+
+		tagText := template[loc[0]:loc[1]]
+		var body string
+		if loc[2] >= 0 {
+			body = template[loc[2]:loc[3]]
+		} else {
+			body = template[loc[4]:loc[5]]
+		}
+		switch {
+		case body == "else" || body == "end":
+			tokens = append(tokens, queryTemplateToken{text: tagText, tagType: body})
+		case strings.HasPrefix(body, "if "):
+			name := strings.TrimPrefix(body, "if ")
+			negate := strings.HasPrefix(name, "!")
+			tokens = append(tokens, queryTemplateToken{text: tagText, tagType: "if", name: strings.TrimPrefix(name, "!"), negate: negate})
+		default:
+			token := queryTemplateToken{text: tagText, tagType: "variable", name: body}
+			if name, fallback, hasFallback := strings.Cut(body, "|"); hasFallback {
+				token.name = name
+				token.fallback = &fallback
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	if last < len(template) {
+		tokens = append(tokens, queryTemplateToken{text: template[last:]})
+	}
+
+	return tokens
+}
+
+// parseQueryTemplate builds nodes until {wox:else} or {wox:end} of current level, returns the nodes and the index of the next token
+func parseQueryTemplate(tokens []queryTemplateToken, start int, inIf bool) ([]queryTemplateNode, int) {
+	var nodes []queryTemplateNode
+	i := start
+	for i < len(tokens) {
+		token := tokens[i]
+		switch token.tagType {
+		case "if":
+			node := queryTemplateNode{token: token}
+			var next int
+			node.children, next = parseQueryTemplate(tokens, i+1, true)
+			if next < len(tokens) && tokens[next].tagType == "else" {
+				node.elses, next = parseQueryTemplate(tokens, next+1, true)
+			}
+			if next < len(tokens) && tokens[next].tagType == "end" {
+				next++
+			}
+			nodes = append(nodes, node)
+			i = next
+		case "else", "end":
+			if inIf {
+				return nodes, i
+			}
+			// unmatched tags are treated as plain text
+			nodes = append(nodes, queryTemplateNode{token: queryTemplateToken{text: token.text}})
+			i++
+		default:
+			nodes = append(nodes, queryTemplateNode{token: token})
+			i++
+		}
+	}
+
+	return nodes, i
+}
+
+func renderQueryTemplateNodes(sb *strings.Builder, nodes []queryTemplateNode, resolve func(name string) (string, bool)) {
+	for _, node := range nodes {
+		switch node.token.tagType {
+		case "":
+			sb.WriteString(node.token.text)
+		case "variable":
+			value, ok := resolve(node.token.name)
+			if !ok {
+				sb.WriteString(node.token.text)
+				continue
+			}
+			if value == "" && node.token.fallback != nil {
+				value = *node.token.fallback
+			}
+			sb.WriteString(value)
+		case "if":
+			value, _ := resolve(node.token.name)
+			if (value != "") != node.token.negate {
+				renderQueryTemplateNodes(sb, node.children, resolve)
+			} else {
+				renderQueryTemplateNodes(sb, node.elses, resolve)
+			}
+		}
+	}
+}
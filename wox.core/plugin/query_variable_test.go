@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRenderQueryTemplate(t *testing.T) {
+	m := &Manager{activeBrowserUrl: "https://github.com"}
+	ctx := context.Background()
+	selectedText := "hello"
+	variableContext := QueryVariableContext{
+		Query:        "wox",
+		SelectedText: &selectedText,
+		Setting: func(key string) string {
+			if key == "lang" {
+				return "english"
+			}
+			return ""
+		},
+		Extra: map[string]func() string{
+			"upper_query": func() string { return "WOX" },
+		},
+	}
+
+	assert.Equal(t, "search wox", m.RenderQueryTemplate(ctx, "search {query}", variableContext))
+	assert.Equal(t, "search WOX", m.RenderQueryTemplate(ctx, "search {upper_query}", variableContext))
+	assert.Equal(t, "hello in english", m.RenderQueryTemplate(ctx, "{wox:selected_text} in {wox:setting:lang}", variableContext))
+	assert.Equal(t, "open https://github.com", m.RenderQueryTemplate(ctx, "open {wox:active_browser_url}", variableContext))
+
+	// unknown variables are kept as it is
+	assert.Equal(t, `{"code": 1} {unknown} {wox:new_ai_conversation}`, m.RenderQueryTemplate(ctx, `{"code": 1} {unknown} {wox:new_ai_conversation}`, variableContext))
+	// only legacy variables work without prefix, existing text in braces is kept
+	assert.Equal(t, "{date} {if query}{end} {query|x} {selected_text}", m.RenderQueryTemplate(ctx, "{date} {if query}{end} {query|x} {selected_text}", variableContext))
+
+	// default value
+	assert.Equal(t, "to chinese", m.RenderQueryTemplate(ctx, "to {wox:setting:target|chinese}", variableContext))
+	assert.Equal(t, "to english", m.RenderQueryTemplate(ctx, "to {wox:setting:lang|chinese}", variableContext))
+}
+
+func TestRenderQueryTemplateConditional(t *testing.T) {
+	m := &Manager{}
+	ctx := context.Background()
+	empty := ""
+	template := "{wox:if selected_text}explain {wox:selected_text}{wox:else}explain {wox:query}{wox:end}"
+
+	selectedText := "code"
+	assert.Equal(t, "explain code", m.RenderQueryTemplate(ctx, template, QueryVariableContext{Query: "input", SelectedText: &selectedText}))
+	assert.Equal(t, "explain input", m.RenderQueryTemplate(ctx, template, QueryVariableContext{Query: "input", SelectedText: &empty}))
+
+	// negate and nested conditionals
+	nested := "{wox:if !query}empty{wox:else}{wox:if selected_text}both{wox:else}query only{wox:end}{wox:end}"
+	assert.Equal(t, "empty", m.RenderQueryTemplate(ctx, nested, QueryVariableContext{SelectedText: &empty}))
+	assert.Equal(t, "both", m.RenderQueryTemplate(ctx, nested, QueryVariableContext{Query: "q", SelectedText: &selectedText}))
+	assert.Equal(t, "query only", m.RenderQueryTemplate(ctx, nested, QueryVariableContext{Query: "q", SelectedText: &empty}))
+
+	// selection is not grabbed during a query
+	assert.Equal(t, "explain input", m.RenderQueryTemplate(ctx, template, QueryVariableContext{Query: "input"}))
+
+	// unmatched tags are kept
+	assert.True(t, strings.HasSuffix(m.RenderQueryTemplate(ctx, "text {wox:end}", QueryVariableContext{}), "{wox:end}"))
+}
@@ -1,6 +1,11 @@
 package plugin
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
 
 type Runtime string
 
@@ -8,13 +13,47 @@ const (
 	PLUGIN_RUNTIME_GO     Runtime = "GO"
 	PLUGIN_RUNTIME_PYTHON Runtime = "PYTHON"
 	PLUGIN_RUNTIME_NODEJS Runtime = "NODEJS"
+
+	// plugin is a standalone executable, it talks to wox with json rpc over stdin/stdout
+	PLUGIN_RUNTIME_EXECUTABLE Runtime = "EXECUTABLE"
+
+	// plugin is a webassembly module running in a sandbox, it can only access files and network granted by user
+	PLUGIN_RUNTIME_WASM Runtime = "WASM"
+
+	// plugin is a single script with metadata in header comments, see ScriptCommand.
+	// Script commands are not declared by plugin.json, so it's not included in IsSupportedRuntime
+	PLUGIN_RUNTIME_SCRIPT Runtime = "SCRIPT"
 )
 
 func IsSupportedRuntime(runtime string) bool {
 	runtimeUpper := strings.ToUpper(runtime)
-	return runtimeUpper == string(PLUGIN_RUNTIME_PYTHON) || runtimeUpper == string(PLUGIN_RUNTIME_NODEJS) || runtimeUpper == string(PLUGIN_RUNTIME_GO)
+	return runtimeUpper == string(PLUGIN_RUNTIME_PYTHON) || runtimeUpper == string(PLUGIN_RUNTIME_NODEJS) || runtimeUpper == string(PLUGIN_RUNTIME_GO) || runtimeUpper == string(PLUGIN_RUNTIME_EXECUTABLE) || runtimeUpper == string(PLUGIN_RUNTIME_WASM)
 }
 
 func ConvertToRuntime(runtime string) Runtime {
 	return Runtime(strings.ToUpper(runtime))
 }
+
+// CheckRuntimeVersion returns error if the interpreter found by host doesn't satisfy the runtime version required by plugin
+func CheckRuntimeVersion(metadata Metadata, info RuntimeInfo) error {
+	if info.Path == "" {
+		return fmt.Errorf("no usable %s interpreter is found, run doctor for details", strings.ToLower(metadata.Runtime))
+	}
+	if metadata.RuntimeVersion == "" {
+		return nil
+	}
+
+	constraint, constraintErr := semver.NewConstraint(metadata.RuntimeVersion)
+	if constraintErr != nil {
+		return fmt.Errorf("invalid runtime version %s: %w", metadata.RuntimeVersion, constraintErr)
+	}
+	version, versionErr := semver.NewVersion(info.Version)
+	if versionErr != nil {
+		return fmt.Errorf("failed to parse %s version %s: %w", strings.ToLower(metadata.Runtime), info.Version, versionErr)
+	}
+	if !constraint.Check(version) {
+		return fmt.Errorf("plugin requires %s %s, but %s is %s", strings.ToLower(metadata.Runtime), metadata.RuntimeVersion, info.Path, info.Version)
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/go-homedir"
+	"github.com/samber/lo"
+)
+
+type ScriptCommandMode = string
+
+const (
+	ScriptCommandModeSilent  ScriptCommandMode = "silent"  // run script when user executes the result, output is ignored
+	ScriptCommandModeNotify  ScriptCommandMode = "notify"  // run script when user executes the result, last line of output is notified
+	ScriptCommandModePreview ScriptCommandMode = "preview" // run script while querying, output is displayed in preview
+	ScriptCommandModeLines   ScriptCommandMode = "lines"   // run script while querying, each line of output becomes a result
+)
+
+const (
+	scriptCommandHeaderPrefix   = "@wox."
+	scriptCommandMaxHeaderLines = 40 // metadata must be declared in the first lines of script
+)
+
+var scriptCommandCommentPrefixes = []string{"#", "//", "--", ";", "::", "REM ", "rem "}
+
+// ScriptCommand is a single file plugin, metadata is declared in header comments of the script. E.g.
+//
+//	#!/bin/bash
+//	# @wox.title Say Hello
+//	# @wox.keyword hello
+//	# @wox.mode notify
+//	# @wox.argument1 {"placeholder": "name", "optional": true}
+type ScriptCommand struct {
+	Id          string // optional, md5 of file name is used if empty
+	Title       string
+	Description string
+	Keyword     string
+	Icon        string // emoji, wox image string or image file path relative to script directory
+	Mode        ScriptCommandMode
+	Arguments   []ScriptCommandArgument
+	ScriptPath  string // absolute path of the script
+}
+
+type ScriptCommandArgument struct {
+	Placeholder string
+	Optional    bool
+}
+
+// ParseScriptCommand reads metadata from header comments of the script
+func ParseScriptCommand(scriptPath string) (ScriptCommand, error) {
+	file, openErr := os.Open(scriptPath)
+	if openErr != nil {
+		return ScriptCommand{}, openErr
+	}
+	defer file.Close()
+
+	command := ScriptCommand{ScriptPath: scriptPath, Mode: ScriptCommandModeSilent}
+	arguments := map[int]ScriptCommandArgument{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 0; lineNumber < scriptCommandMaxHeaderLines && scanner.Scan(); lineNumber++ {
+		key, value, found := parseScriptCommandHeader(scanner.Text())
+		if !found {
+			continue
+		}
+
+		switch {
+		case key == "id":
+			command.Id = value
+		case key == "title":
+			command.Title = value
+		case key == "description":
+			command.Description = value
+		case key == "keyword":
+			command.Keyword = value
+		case key == "icon":
+			command.Icon = value
+		case key == "mode":
+			command.Mode = strings.ToLower(value)
+		case strings.HasPrefix(key, "argument"):
+			var index int
+			if _, scanErr := fmt.Sscanf(key, "argument%d", &index); scanErr != nil || index < 1 {
+				return ScriptCommand{}, fmt.Errorf("invalid argument declaration: %s", key)
+			}
+			arguments[index] = parseScriptCommandArgument(value)
+		}
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return ScriptCommand{}, scanErr
+	}
+
+	if command.Title == "" {
+		return ScriptCommand{}, fmt.Errorf("missing @wox.title in script header: %s", scriptPath)
+	}
+	if command.Keyword == "" {
+		return ScriptCommand{}, fmt.Errorf("missing @wox.keyword in script header: %s", scriptPath)
+	}
+	if command.Mode != ScriptCommandModeSilent && command.Mode != ScriptCommandModeNotify && command.Mode != ScriptCommandModePreview && command.Mode != ScriptCommandModeLines {
+		return ScriptCommand{}, fmt.Errorf("unsupported mode in script header: %s", command.Mode)
+	}
+
+	// arguments must be declared continuously, argument1, argument2...
+	for i := 1; i <= len(arguments); i++ {
+		argument, exist := arguments[i]
+		if !exist {
+			return ScriptCommand{}, fmt.Errorf("missing @wox.argument%d in script header: %s", i, scriptPath)
+		}
+		command.Arguments = append(command.Arguments, argument)
+	}
+
+	return command, nil
+}
+
+// parseScriptCommandHeader parses lines like "# @wox.title Say Hello"
+func parseScriptCommandHeader(line string) (key string, value string, found bool) {
+	line = strings.TrimSpace(line)
+	for _, prefix := range scriptCommandCommentPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			line = strings.TrimSpace(strings.TrimPrefix(line, prefix))
+			if !strings.HasPrefix(line, scriptCommandHeaderPrefix) {
+				return "", "", false
+			}
+
+			line = strings.TrimPrefix(line, scriptCommandHeaderPrefix)
+			key, value, _ = strings.Cut(line, " ")
+			return strings.ToLower(key), strings.TrimSpace(value), true
+		}
+	}
+
+	return "", "", false
+}
+
+// parseScriptCommandArgument supports both json ({"placeholder": "name", "optional": true}) and plain placeholder text
+func parseScriptCommandArgument(value string) ScriptCommandArgument {
+	var argument ScriptCommandArgument
+	if strings.HasPrefix(value, "{") && json.Unmarshal([]byte(value), &argument) == nil {
+		return argument
+	}
+
+	return ScriptCommandArgument{Placeholder: value}
+}
+
+func (s *ScriptCommand) IsRunOnQuery() bool {
+	return s.Mode == ScriptCommandModePreview || s.Mode == ScriptCommandModeLines
+}
+
+func (s *ScriptCommand) ToMetadata() Metadata {
+	id := s.Id
+	if id == "" {
+		id = util.Md5([]byte("script:" + filepath.Base(s.ScriptPath)))
+	}
+
+	metadata := Metadata{
+		Id:              id,
+		Name:            s.Title,
+		Author:          "Script Command",
+		Version:         "1.0.0",
+		MinWoxVersion:   "2.0.0",
+		Runtime:         string(PLUGIN_RUNTIME_SCRIPT),
+		Description:     s.Description,
+		Icon:            s.getIcon(),
+		Entry:           filepath.Base(s.ScriptPath),
+		TriggerKeywords: []string{s.Keyword},
+		SupportedOS:     []string{"Windows", "Macos", "Linux"},
+	}
+	if s.IsRunOnQuery() {
+		// script is executed on every query, don't run it for every keystroke
+		metadata.Features = append(metadata.Features, MetadataFeature{
+			Name: MetadataFeatureDebounce,
+			Params: map[string]string{
+				"intervalMs": "300",
+			},
+		})
+	}
+
+	return metadata
+}
+
+func (s *ScriptCommand) getIcon() string {
+	if s.Icon == "" {
+		return WoxImageTypeEmoji + ":📜"
+	}
+	if _, parseErr := ParseWoxImage(s.Icon); parseErr == nil {
+		return s.Icon
+	}
+	if util.IsFileExists(filepath.Join(filepath.Dir(s.ScriptPath), s.Icon)) {
+		return WoxImageTypeRelativePath + ":" + s.Icon
+	}
+	if utf8.RuneCountInString(s.Icon) <= 2 {
+		return WoxImageTypeEmoji + ":" + s.Icon
+	}
+
+	return WoxImageTypeEmoji + ":📜"
+}
+
+// GetScriptCommandDirectory returns the directory to scan for script commands, user can change it in settings
+func (m *Manager) GetScriptCommandDirectory(ctx context.Context) string {
+	directory := setting.GetSettingManager().GetWoxSetting(ctx).ScriptCommandDirectory
+	if directory == "" {
+		return util.GetLocation().GetScriptCommandDirectory()
+	}
+
+	expandedDirectory, expandErr := homedir.Expand(directory)
+	if expandErr != nil {
+		return directory
+	}
+	return expandedDirectory
+}
+
+func (m *Manager) parseScriptCommands(ctx context.Context) []MetadataWithDirectory {
+	directory := m.GetScriptCommandDirectory(ctx)
+	entries, readErr := os.ReadDir(directory)
+	if readErr != nil {
+		logger.Warn(ctx, fmt.Sprintf("failed to read script command directory %s: %s", directory, readErr.Error()))
+		return nil
+	}
+
+	var metadataList []MetadataWithDirectory
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		command, parseErr := ParseScriptCommand(filepath.Join(directory, entry.Name()))
+		if parseErr != nil {
+			logger.Warn(ctx, fmt.Sprintf("skip script command %s: %s", entry.Name(), parseErr.Error()))
+			continue
+		}
+
+		metadata := command.ToMetadata()
+		if _, exist := lo.Find(metadataList, func(item MetadataWithDirectory) bool { return item.Metadata.Id == metadata.Id }); exist {
+			logger.Warn(ctx, fmt.Sprintf("skip script command %s: duplicated id %s", entry.Name(), metadata.Id))
+			continue
+		}
+		metadataList = append(metadataList, MetadataWithDirectory{Metadata: metadata, Directory: directory})
+	}
+
+	logger.Info(ctx, fmt.Sprintf("found %d script commands in %s", len(metadataList), directory))
+	return metadataList
+}
+
+// watchScriptCommands reloads script commands when scripts in the directory are added, changed or removed
+func (m *Manager) watchScriptCommands(ctx context.Context) {
+	m.scriptCommandLock.Lock()
+	defer m.scriptCommandLock.Unlock()
+
+	if m.scriptCommandWatcher != nil {
+		m.scriptCommandWatcher.Close()
+		m.scriptCommandWatcher = nil
+	}
+
+	directory := m.GetScriptCommandDirectory(ctx)
+	watcher, watchErr := util.WatchDirectoryChanges(ctx, directory, func(e fsnotify.Event) {
+		if e.Op == fsnotify.Chmod {
+			return
+		}
+
+		// editors may write a file several times when saving, debounce to reload only once
+		m.scriptCommandLock.Lock()
+		defer m.scriptCommandLock.Unlock()
+		if m.scriptCommandReloadTimer != nil {
+			m.scriptCommandReloadTimer.Stop()
+		}
+		m.scriptCommandReloadTimer = time.AfterFunc(time.Second, func() {
+			m.ReloadScriptCommands(util.NewTraceContext())
+		})
+	})
+	if watchErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to watch script command directory %s: %s", directory, watchErr.Error()))
+		return
+	}
+	m.scriptCommandWatcher = watcher
+}
+
+// ReloadScriptCommands unloads removed scripts, reloads existing scripts and loads new scripts
+func (m *Manager) ReloadScriptCommands(ctx context.Context) {
+	logger.Info(ctx, "start reloading script commands")
+	metadataList := m.parseScriptCommands(ctx)
+
+	scriptInstances := lo.Filter(m.GetPluginInstances(), func(item *Instance, _ int) bool {
+		return item.Metadata.Runtime == string(PLUGIN_RUNTIME_SCRIPT)
+	})
+	for _, instance := range scriptInstances {
+		isRemoved := !lo.ContainsBy(metadataList, func(item MetadataWithDirectory) bool { return item.Metadata.Id == instance.Metadata.Id })
+		if isRemoved {
+			logger.Info(ctx, fmt.Sprintf("script command %s is removed, unload it", instance.Metadata.Name))
+			m.UnloadPlugin(ctx, instance)
+		}
+	}
+
+	for _, metadata := range metadataList {
+		if reloadErr := m.ReloadPlugin(ctx, metadata); reloadErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to reload script command %s: %s", metadata.Metadata.Name, reloadErr.Error()))
+		}
+	}
+}
+
+// OnScriptCommandDirectoryChanged is called after user changed script command directory in settings
+func (m *Manager) OnScriptCommandDirectoryChanged(ctx context.Context) {
+	m.watchScriptCommands(ctx)
+	m.ReloadScriptCommands(ctx)
+}
@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestScript(t *testing.T, name string, content string) string {
+	scriptPath := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(scriptPath, []byte(content), 0755))
+	return scriptPath
+}
+
+func TestParseScriptCommand(t *testing.T) {
+	scriptPath := writeTestScript(t, "hello.sh", `#!/bin/bash
+# @wox.title Say Hello
+# @wox.keyword hello
+# @wox.description Say hello to someone
+# @wox.mode notify
+# @wox.icon 👋
+# @wox.argument1 {"placeholder": "name"}
+# @wox.argument2 greeting
+echo "hello $1"
+`)
+
+	command, err := ParseScriptCommand(scriptPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "Say Hello", command.Title)
+	assert.Equal(t, "hello", command.Keyword)
+	assert.Equal(t, ScriptCommandModeNotify, command.Mode)
+	assert.Equal(t, []ScriptCommandArgument{{Placeholder: "name"}, {Placeholder: "greeting"}}, command.Arguments)
+
+	metadata := command.ToMetadata()
+	assert.Equal(t, string(PLUGIN_RUNTIME_SCRIPT), metadata.Runtime)
+	assert.Equal(t, "hello.sh", metadata.Entry)
+	assert.Equal(t, []string{"hello"}, metadata.TriggerKeywords)
+	assert.Equal(t, "emoji:👋", metadata.Icon)
+	assert.False(t, metadata.IsSupportFeature(MetadataFeatureDebounce))
+}
+
+func TestParseScriptCommandCommentStyles(t *testing.T) {
+	command, err := ParseScriptCommand(writeTestScript(t, "list.js", `// @wox.title List
+// @wox.keyword ls
+// @wox.mode lines
+console.log("a")
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "ls", command.Keyword)
+	metadata := command.ToMetadata()
+	assert.True(t, metadata.IsSupportFeature(MetadataFeatureDebounce))
+
+	command, err = ParseScriptCommand(writeTestScript(t, "run.bat", `@echo off
+REM @wox.title Run
+REM @wox.keyword run
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, ScriptCommandModeSilent, command.Mode)
+}
+
+func TestParseScriptCommandInvalid(t *testing.T) {
+	_, err := ParseScriptCommand(writeTestScript(t, "no_title.sh", "# @wox.keyword k\n"))
+	assert.Error(t, err)
+
+	_, err = ParseScriptCommand(writeTestScript(t, "bad_mode.sh", "# @wox.title t\n# @wox.keyword k\n# @wox.mode unknown\n"))
+	assert.Error(t, err)
+
+	_, err = ParseScriptCommand(writeTestScript(t, "missing_argument.sh", "# @wox.title t\n# @wox.keyword k\n# @wox.argument2 b\n"))
+	assert.Error(t, err)
+}
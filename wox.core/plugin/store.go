@@ -8,137 +8,302 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
+	"wox/i18n"
+	"wox/setting"
+	"wox/share"
 	"wox/util"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/samber/lo"
 )
 
-type storeManifest struct {
-	Name string
-	Url  string
-}
-
 type StorePluginManifest struct {
 	Id             string
 	Name           string
 	Author         string
 	Version        string
 	MinWoxVersion  string
+	SupportedOS    []string
 	Runtime        Runtime
 	Description    string
 	IconUrl        string
 	Website        string
 	DownloadUrl    string
+	SHA256         string // hex encoded sha256 of package
+	Signature      string // base64 encoded ed25519 signature of package, signed by store
 	ScreenshotUrls []string
+	Permissions    setting.PluginPermissions // permissions requested by sandboxed plugins, shown to user before installing
 	DateCreated    string
 	DateUpdated    string
+	Versions       []StorePluginVersion // previous versions, user can install one of them if latest version is not compatible
+	Store          string               // name of the store plugin comes from
+
+	storePublicKey string
+}
+
+type StorePluginVersion struct {
+	Version       string
+	MinWoxVersion string
+	SupportedOS   []string
+	DownloadUrl   string
+	SHA256        string
+	Signature     string
+	Permissions   setting.PluginPermissions
+	DateUpdated   string
+}
+
+// CheckCompatibility returns error if this version of plugin can't run on current wox
+func (m StorePluginManifest) CheckCompatibility(ctx context.Context) error {
+	return CheckCompatibility(ctx, m.MinWoxVersion, m.SupportedOS, string(m.Runtime))
+}
+
+// GetVersion returns manifest of the given version, version and download url of previous versions replace the latest ones
+func (m StorePluginManifest) GetVersion(version string) (StorePluginManifest, error) {
+	if version == "" || version == m.Version {
+		return m, nil
+	}
+
+	storeVersion, found := lo.Find(m.Versions, func(item StorePluginVersion) bool {
+		return item.Version == version
+	})
+	if !found {
+		return StorePluginManifest{}, fmt.Errorf("version %s of plugin %s not found in store", version, m.Name)
+	}
+
+	manifest := m
+	manifest.Version = storeVersion.Version
+	manifest.MinWoxVersion = storeVersion.MinWoxVersion
+	manifest.SupportedOS = storeVersion.SupportedOS
+	manifest.DownloadUrl = storeVersion.DownloadUrl
+	manifest.SHA256 = storeVersion.SHA256
+	manifest.Signature = storeVersion.Signature
+	manifest.Permissions = storeVersion.Permissions
+	manifest.DateUpdated = storeVersion.DateUpdated
+	manifest.Versions = nil
+	return manifest, nil
+}
+
+// GetInstallVersion returns manifest of the version to install, newest compatible version is used if version is empty
+func (m StorePluginManifest) GetInstallVersion(ctx context.Context, version string) (StorePluginManifest, error) {
+	if version != "" {
+		return m.GetVersion(version)
+	}
+
+	compatibleVersions := m.GetCompatibleVersions(ctx)
+	if len(compatibleVersions) == 0 {
+		return StorePluginManifest{}, fmt.Errorf("plugin %s(%s) is not compatible: %w", m.Name, m.Version, m.CheckCompatibility(ctx))
+	}
+	return compatibleVersions[0], nil
+}
+
+// GetCompatibleVersions returns manifests of compatible versions, newest version first
+func (m StorePluginManifest) GetCompatibleVersions(ctx context.Context) []StorePluginManifest {
+	var compatibleVersions []StorePluginManifest
+	for _, version := range append([]string{m.Version}, lo.Map(m.Versions, func(item StorePluginVersion, _ int) string { return item.Version })...) {
+		manifest, versionErr := m.GetVersion(version)
+		if versionErr != nil || manifest.CheckCompatibility(ctx) != nil {
+			continue
+		}
+		compatibleVersions = append(compatibleVersions, manifest)
+	}
+
+	sort.SliceStable(compatibleVersions, func(i, j int) bool {
+		iVersion, iErr := semver.NewVersion(compatibleVersions[i].Version)
+		jVersion, jErr := semver.NewVersion(compatibleVersions[j].Version)
+		if iErr != nil || jErr != nil {
+			return false
+		}
+		return iVersion.GreaterThan(jVersion)
+	})
+	return compatibleVersions
 }
 
 var storeInstance *Store
 var storeOnce sync.Once
 
 type Store struct {
-	pluginManifests []StorePluginManifest
+	pluginManifests []StorePluginManifest // merged manifests of all stores, displayed to user
+	storeManifests  []StorePluginManifest // manifests of each store, store with higher priority first
+	manifestsLock   sync.RWMutex
+	sources         []setting.StoreSource // only used in tests, empty means sources in settings
+	notifiedUpdates *util.HashMap[string, bool]
 }
 
 func GetStoreManager() *Store {
 	storeOnce.Do(func() {
-		storeInstance = &Store{}
+		storeInstance = &Store{notifiedUpdates: util.NewHashMap[string, bool]()}
 	})
 	return storeInstance
 }
 
-func (s *Store) getStoreManifests(ctx context.Context) []storeManifest {
-	return []storeManifest{
-		{
-			Name: "Wox Official Plugin Store",
-			Url:  "https://raw.githubusercontent.com/Wox-launcher/Wox/v2/store-plugin.json",
-		},
+// getStoreSources returns enabled stores, store with higher priority first
+func (s *Store) getStoreSources(ctx context.Context) []setting.StoreSource {
+	sources := s.sources
+	if len(sources) == 0 {
+		sources = setting.GetSettingManager().GetWoxSetting(ctx).PluginStores
 	}
+
+	sources = lo.Filter(sources, func(source setting.StoreSource, _ int) bool {
+		return source.Enabled
+	})
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority > sources[j].Priority
+	})
+	return sources
 }
 
-// get plugin manifests from plugin stores, and update in the background every 10 minutes
+// get plugin manifests from plugin stores, and update in the background every 10 minutes.
+// Cached manifests are used until the first sync finishes, so store is available right after startup and offline.
+// Installed plugins are checked for updates every time manifests are updated
 func (s *Store) Start(ctx context.Context) {
-	s.pluginManifests = s.GetStorePluginManifests(ctx)
-
-	util.Go(ctx, "load store plugins immediately", func() {
-		pluginManifests := s.GetStorePluginManifests(util.NewTraceContext())
-		if len(pluginManifests) > 0 {
-			s.pluginManifests = pluginManifests
-		}
-	})
+	s.setStoreManifests(ctx, s.getStoreManifests(ctx, util.ReadCachedStoreManifests))
 
 	util.Go(ctx, "load store plugins", func() {
-		for range time.NewTicker(time.Minute * 10).C {
-			pluginManifests := s.GetStorePluginManifests(util.NewTraceContext())
-			if len(pluginManifests) > 0 {
-				s.pluginManifests = pluginManifests
+		for {
+			newCtx := util.NewTraceContext()
+			storeManifests := s.getStoreManifests(newCtx, util.ReadStoreManifests)
+			if len(storeManifests) > 0 {
+				s.setStoreManifests(newCtx, storeManifests)
+				s.checkUpdates(newCtx)
 			}
+			time.Sleep(time.Minute * 10)
 		}
 	})
 }
 
+// Refresh loads plugin manifests from stores again, e.g. user changed stores in settings
+func (s *Store) Refresh(ctx context.Context) {
+	s.setStoreManifests(ctx, s.getStoreManifests(ctx, util.ReadStoreManifests))
+}
+
+func (s *Store) setStoreManifests(ctx context.Context, storeManifests []StorePluginManifest) {
+	pluginManifests := mergeStorePluginManifests(ctx, storeManifests)
+
+	s.manifestsLock.Lock()
+	defer s.manifestsLock.Unlock()
+	s.storeManifests = storeManifests
+	s.pluginManifests = pluginManifests
+}
+
+// getPluginManifests returns merged manifests of all stores loaded by the last sync
+func (s *Store) getPluginManifests() []StorePluginManifest {
+	s.manifestsLock.RLock()
+	defer s.manifestsLock.RUnlock()
+	return s.pluginManifests
+}
+
+// getLoadedStoreManifests returns manifests of each store loaded by the last sync
+func (s *Store) getLoadedStoreManifests() []StorePluginManifest {
+	s.manifestsLock.RLock()
+	defer s.manifestsLock.RUnlock()
+	return s.storeManifests
+}
+
+// GetSyncStatus returns result of the last sync of each enabled store
+func (s *Store) GetSyncStatus(ctx context.Context) []util.StoreSyncStatus {
+	return lo.Map(s.getStoreSources(ctx), func(store setting.StoreSource, _ int) util.StoreSyncStatus {
+		return util.GetStoreSyncStatus(store.Name, store.Url)
+	})
+}
+
 func (s *Store) GetStorePluginManifests(ctx context.Context) []StorePluginManifest {
-	var storePluginManifests []StorePluginManifest
+	return mergeStorePluginManifests(ctx, s.getStoreManifests(ctx, util.ReadStoreManifests))
+}
 
-	for _, store := range s.getStoreManifests(ctx) {
-		pluginManifest, manifestErr := s.GetStorePluginManifest(ctx, store)
+// getStoreManifests returns plugin manifests of all enabled stores without merging, store with higher priority first
+func (s *Store) getStoreManifests(ctx context.Context, readManifests util.StoreManifestReader) []StorePluginManifest {
+	var storeManifests []StorePluginManifest
+	for _, store := range s.getStoreSources(ctx) {
+		pluginManifest, manifestErr := s.GetStorePluginManifest(ctx, store, readManifests)
 		if manifestErr != nil {
 			logger.Error(ctx, fmt.Sprintf("failed to get plugin manifest from %s store: %s", store.Name, manifestErr.Error()))
 			continue
 		}
+		storeManifests = append(storeManifests, pluginManifest...)
+	}
+	return storeManifests
+}
 
-		for _, manifest := range pluginManifest {
-			existingManifest, found := lo.Find(storePluginManifests, func(manifest StorePluginManifest) bool {
-				return manifest.Id == manifest.Id
-			})
-			if found {
-				existingVersion, existingErr := semver.NewVersion(existingManifest.Version)
-				currentVersion, currentErr := semver.NewVersion(manifest.Version)
-				if existingErr != nil && currentErr != nil {
-					if existingVersion.GreaterThan(currentVersion) {
-						logger.Info(ctx, fmt.Sprintf("skip %s(%s) from %s store, because it's already installed(%s)", manifest.Name, manifest.Version, store.Name, existingManifest.Version))
-						continue
-					}
-				}
+// mergeStorePluginManifests keeps one manifest for each plugin, manifests must be sorted by store priority
+func mergeStorePluginManifests(ctx context.Context, storeManifests []StorePluginManifest) []StorePluginManifest {
+	var storePluginManifests []StorePluginManifest
+	for _, manifest := range storeManifests {
+		existingManifest, existingIndex, found := lo.FindIndexOf(storePluginManifests, func(item StorePluginManifest) bool {
+			return item.Id == manifest.Id
+		})
+		if found {
+			// stores are sorted by priority, so plugin from store with lower priority is only used if it has a newer version
+			existingVersion, existingErr := semver.NewVersion(existingManifest.Version)
+			currentVersion, currentErr := semver.NewVersion(manifest.Version)
+			if existingErr == nil && currentErr == nil && currentVersion.GreaterThan(existingVersion) {
+				logger.Info(ctx, fmt.Sprintf("use %s(%s) from %s store instead of %s(%s) from %s store", manifest.Name, manifest.Version, manifest.Store, existingManifest.Name, existingManifest.Version, existingManifest.Store))
+				storePluginManifests[existingIndex] = manifest
+			} else {
+				logger.Info(ctx, fmt.Sprintf("skip %s(%s) from %s store, because %s store has %s", manifest.Name, manifest.Version, manifest.Store, existingManifest.Store, existingManifest.Version))
 			}
-
-			storePluginManifests = append(storePluginManifests, manifest)
+			continue
 		}
+
+		storePluginManifests = append(storePluginManifests, manifest)
 	}
 
 	logger.Info(ctx, fmt.Sprintf("found %d plugins from stores", len(storePluginManifests)))
 	return storePluginManifests
 }
 
-func (s *Store) GetStorePluginManifest(ctx context.Context, store storeManifest) ([]StorePluginManifest, error) {
+func (s *Store) GetStorePluginManifest(ctx context.Context, store setting.StoreSource, readManifests util.StoreManifestReader) ([]StorePluginManifest, error) {
 	logger.Info(ctx, fmt.Sprintf("start to get plugin manifest from %s(%s)", store.Name, store.Url))
 
-	response, getErr := util.HttpGet(ctx, store.Url)
-	if getErr != nil {
-		return nil, getErr
+	rawManifests, readErr := readManifests(ctx, store.Url)
+	if readErr != nil {
+		return nil, readErr
 	}
 
 	var storePluginManifests []StorePluginManifest
-	unmarshalErr := json.Unmarshal(response, &storePluginManifests)
-	if unmarshalErr != nil {
-		return nil, unmarshalErr
-	}
+	for _, rawManifest := range rawManifests {
+		var manifest StorePluginManifest
+		if unmarshalErr := json.Unmarshal(rawManifest, &manifest); unmarshalErr != nil {
+			return nil, unmarshalErr
+		}
 
-	for i := range storePluginManifests {
-		if IsSupportedRuntime(string(storePluginManifests[i].Runtime)) {
-			storePluginManifests[i].Runtime = ConvertToRuntime(string(storePluginManifests[i].Runtime))
+		if IsSupportedRuntime(string(manifest.Runtime)) {
+			manifest.Runtime = ConvertToRuntime(string(manifest.Runtime))
 		}
+		manifest.Store = store.Name
+		manifest.storePublicKey = store.PublicKey
+
+		// packages in local stores can be referenced relative to the store
+		if !util.IsHttpUrl(store.Url) {
+			manifest.DownloadUrl = getStoreLocalDownloadUrl(store.Url, manifest.DownloadUrl)
+			for i := range manifest.Versions {
+				manifest.Versions[i].DownloadUrl = getStoreLocalDownloadUrl(store.Url, manifest.Versions[i].DownloadUrl)
+			}
+		}
+
+		storePluginManifests = append(storePluginManifests, manifest)
 	}
 
 	return storePluginManifests, nil
 }
 
+func getStoreLocalDownloadUrl(storeUrl string, downloadUrl string) string {
+	if downloadUrl == "" || util.IsHttpUrl(downloadUrl) || filepath.IsAbs(downloadUrl) {
+		return downloadUrl
+	}
+
+	storeDirectory := util.GetStoreLocalPath(storeUrl)
+	if !util.IsDirExists(storeDirectory) {
+		storeDirectory = filepath.Dir(storeDirectory)
+	}
+	return filepath.Join(storeDirectory, downloadUrl)
+}
+
 func (s *Store) GetStorePluginManifestById(ctx context.Context, id string) (StorePluginManifest, error) {
-	manifest, found := lo.Find(s.pluginManifests, func(manifest StorePluginManifest) bool {
+	manifest, found := lo.Find(s.getPluginManifests(), func(manifest StorePluginManifest) bool {
 		return manifest.Id == id
 	})
 	if found {
@@ -149,7 +314,7 @@ func (s *Store) GetStorePluginManifestById(ctx context.Context, id string) (Stor
 }
 
 func (s *Store) Search(ctx context.Context, keyword string) []StorePluginManifest {
-	return lo.Filter(s.pluginManifests, func(manifest StorePluginManifest, _ int) bool {
+	return lo.Filter(s.getPluginManifests(), func(manifest StorePluginManifest, _ int) bool {
 		if keyword == "" {
 			return true
 		}
@@ -161,92 +326,56 @@ func (s *Store) Search(ctx context.Context, keyword string) []StorePluginManifes
 func (s *Store) Install(ctx context.Context, manifest StorePluginManifest) error {
 	logger.Info(ctx, fmt.Sprintf("start to install plugin %s(%s)", manifest.Name, manifest.Version))
 
+	if compatibleErr := manifest.CheckCompatibility(ctx); compatibleErr != nil {
+		logger.Error(ctx, fmt.Sprintf("plugin %s(%s) is not compatible: %s", manifest.Name, manifest.Version, compatibleErr.Error()))
+		return fmt.Errorf("plugin %s(%s) is not compatible: %w", manifest.Name, manifest.Version, compatibleErr)
+	}
+
 	// check if plugin's runtime is started
 	if !GetPluginManager().IsHostStarted(ctx, manifest.Runtime) {
 		logger.Error(ctx, fmt.Sprintf("%s runtime is not started, please start first", manifest.Runtime))
 		return fmt.Errorf("%s runtime is not started, please start first", manifest.Runtime)
 	}
 
-	// check if installed newer version
-	installedPlugin, exist := lo.Find(GetPluginManager().GetPluginInstances(), func(item *Instance) bool {
-		return item.Metadata.Id == manifest.Id
-	})
-	if exist {
-		logger.Info(ctx, fmt.Sprintf("found this plugin has installed %s(%s)", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version))
-		installedVersion, installedErr := semver.NewVersion(installedPlugin.Metadata.Version)
-		currentVersion, currentErr := semver.NewVersion(manifest.Version)
-		if installedErr == nil && currentErr == nil {
-			if installedVersion.GreaterThan(currentVersion) {
-				logger.Info(ctx, fmt.Sprintf("skip %s(%s) from %s store, because it's already installed(%s)", manifest.Name, manifest.Version, manifest.Name, installedPlugin.Metadata.Version))
-				return fmt.Errorf("skip %s(%s) from %s store, because it's already installed(%s)", manifest.Name, manifest.Version, manifest.Name, installedPlugin.Metadata.Version)
+	// download plugin, packages in local stores are installed in place
+	pluginZipPath := util.GetStoreLocalPath(manifest.DownloadUrl)
+	if util.IsHttpUrl(manifest.DownloadUrl) {
+		logger.Info(ctx, fmt.Sprintf("start to download plugin: %s", manifest.DownloadUrl))
+		pluginZipPath = path.Join(util.GetLocation().GetCacheDirectory(), fmt.Sprintf("%s@%s.wox", manifest.Id, manifest.Version))
+		downloadErr := util.HttpDownload(ctx, manifest.DownloadUrl, pluginZipPath)
+		if downloadErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to download plugin %s(%s): %s", manifest.Name, manifest.Version, downloadErr.Error()))
+			removeErr := os.Remove(pluginZipPath)
+			if removeErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to remove plugin zip %s: %s", pluginZipPath, removeErr.Error()))
 			}
+			return fmt.Errorf("failed to download plugin %s(%s): %s", manifest.Name, manifest.Version, downloadErr.Error())
 		}
-
-		uninstallErr := s.Uninstall(ctx, installedPlugin)
-		if uninstallErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to uninstall plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, uninstallErr.Error()))
-			return fmt.Errorf("failed to uninstall plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, uninstallErr.Error())
-		}
+		defer func() {
+			removeErr := os.Remove(pluginZipPath)
+			if removeErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to remove plugin zip %s: %s", pluginZipPath, removeErr.Error()))
+			}
+		}()
 	}
 
-	// download plugin
-	logger.Info(ctx, fmt.Sprintf("start to download plugin: %s", manifest.DownloadUrl))
-	pluginDirectory := path.Join(util.GetLocation().GetPluginDirectory(), fmt.Sprintf("%s_%s@%s", manifest.Id, manifest.Name, manifest.Version))
-	directoryErr := util.GetLocation().EnsureDirectoryExist(pluginDirectory)
-	if directoryErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to create plugin directory %s: %s", pluginDirectory, directoryErr.Error()))
-		return fmt.Errorf("failed to create plugin directory %s: %s", pluginDirectory, directoryErr.Error())
-	}
-	pluginZipPath := path.Join(pluginDirectory, "plugin.zip")
-	downloadErr := util.HttpDownload(ctx, manifest.DownloadUrl, pluginZipPath)
-	if downloadErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to download plugin %s(%s): %s", manifest.Name, manifest.Version, downloadErr.Error()))
-		removeErr := os.Remove(pluginZipPath)
-		if removeErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove plugin zip %s: %s", pluginZipPath, removeErr.Error()))
-		}
-		return fmt.Errorf("failed to download plugin %s(%s): %s", manifest.Name, manifest.Version, downloadErr.Error())
+	// verify package before extracting, so a tampered package never touches plugin directory
+	trustPolicy := setting.GetSettingManager().GetWoxSetting(ctx).PluginTrustPolicy
+	warning, verifyErr := VerifyPluginPackage(manifest, pluginZipPath, trustPolicy, getTrustedStorePublicKeys(manifest, trustPolicy))
+	if verifyErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to verify plugin %s(%s): %s", manifest.Name, manifest.Version, verifyErr.Error()))
+		return fmt.Errorf("failed to verify plugin %s(%s): %w", manifest.Name, manifest.Version, verifyErr)
 	}
-
-	//unzip plugin
-	logger.Info(ctx, fmt.Sprintf("start to unzip plugin %s(%s)", manifest.Name, manifest.Version))
-	unzipErr := util.Unzip(pluginZipPath, pluginDirectory)
-	if unzipErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to unzip plugin %s(%s): %s", manifest.Name, manifest.Version, unzipErr.Error()))
-		removeErr := os.Remove(pluginZipPath)
-		if removeErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove plugin zip %s: %s", pluginZipPath, removeErr.Error()))
-		}
-		return fmt.Errorf("failed to unzip plugin %s(%s): %s", manifest.Name, manifest.Version, unzipErr.Error())
+	if warning != "" {
+		logger.Warn(ctx, warning)
+		GetPluginManager().GetUI().Notify(ctx, share.NotifyMsg{
+			Text:           fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_store_unsigned_warning"), warning),
+			DisplaySeconds: 5,
+		})
 	}
 
-	//load plugin
-	logger.Info(ctx, fmt.Sprintf("start to load plugin %s(%s)", manifest.Name, manifest.Version))
-	loadErr := GetPluginManager().LoadPlugin(ctx, pluginDirectory)
-	if loadErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to load plugin %s(%s): %s", manifest.Name, manifest.Version, loadErr.Error()))
-
-		// remove plugin zip and directory
-		removeErr := os.RemoveAll(pluginDirectory)
-		if removeErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove plugin directory %s: %s", pluginDirectory, removeErr.Error()))
-		}
-		removeErr = os.Remove(pluginZipPath)
-		if removeErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove plugin zip %s: %s", pluginZipPath, removeErr.Error()))
-		}
-
-		return fmt.Errorf("failed to load plugin %s(%s): %s", manifest.Name, manifest.Version, loadErr.Error())
-	}
-
-	//remove plugin zip
-	removeErr := os.Remove(pluginZipPath)
-	if removeErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to remove plugin zip %s: %s", pluginZipPath, removeErr.Error()))
-		return fmt.Errorf("failed to remove plugin zip %s: %s", pluginZipPath, removeErr.Error())
-	}
-
-	return nil
+	// user only confirmed permissions listed in store manifest
+	return s.installPlugin(ctx, manifest.Id, manifest.Name, manifest.Version, pluginZipPath, manifest.Permissions, manifest.Store)
 }
 
 func (s *Store) ParsePluginManifestFromLocal(ctx context.Context, filePath string) (Metadata, error) {
@@ -288,71 +417,241 @@ func (s *Store) ParsePluginManifestFromLocal(ctx context.Context, filePath strin
 	return pluginMetadata, nil
 }
 
-func (s *Store) InstallFromLocal(ctx context.Context, filePath string) error {
+// InstallFromLocal installs plugin from a local package, confirmedPermissions are the permissions shown to user before installing
+func (s *Store) InstallFromLocal(ctx context.Context, filePath string, confirmedPermissions setting.PluginPermissions) error {
 	pluginMetadata, err := s.ParsePluginManifestFromLocal(ctx, filePath)
 	if err != nil {
 		return err
 	}
 
+	if compatibleErr := CheckMetadataCompatibility(ctx, pluginMetadata); compatibleErr != nil {
+		logger.Error(ctx, fmt.Sprintf("plugin %s(%s) is not compatible: %s", pluginMetadata.Name, pluginMetadata.Version, compatibleErr.Error()))
+		return fmt.Errorf("plugin %s(%s) is not compatible: %w", pluginMetadata.Name, pluginMetadata.Version, compatibleErr)
+	}
+
 	// check if plugin's runtime is started
 	if !GetPluginManager().IsHostStarted(ctx, ConvertToRuntime(pluginMetadata.Runtime)) {
 		logger.Error(ctx, fmt.Sprintf("%s runtime is not started, please start first", pluginMetadata.Runtime))
 		return fmt.Errorf("%s runtime is not started, please start first", pluginMetadata.Runtime)
 	}
 
+	return s.installPlugin(ctx, pluginMetadata.Id, pluginMetadata.Name, pluginMetadata.Version, filePath, confirmedPermissions, "")
+}
+
+// installPlugin installs a new version of plugin alongside the installed one. The package is extracted and validated in a staging
+// directory first, so the installed version keeps running if the package is broken. Once the new version is loaded, the previous
+// version is kept as backup for rollback. If the new version fails to load, it's removed and the previous version is restored.
+// Store name is empty if plugin is installed from a local package
+func (s *Store) installPlugin(ctx context.Context, id string, name string, version string, zipPath string, confirmedPermissions setting.PluginPermissions, storeName string) error {
 	// check if installed newer version
 	installedPlugin, exist := lo.Find(GetPluginManager().GetPluginInstances(), func(item *Instance) bool {
-		return item.Metadata.Id == pluginMetadata.Id
+		return item.Metadata.Id == id
 	})
 	if exist {
 		logger.Info(ctx, fmt.Sprintf("found this plugin has installed %s(%s)", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version))
 		installedVersion, installedErr := semver.NewVersion(installedPlugin.Metadata.Version)
-		currentVersion, currentErr := semver.NewVersion(pluginMetadata.Version)
+		currentVersion, currentErr := semver.NewVersion(version)
 		if installedErr == nil && currentErr == nil {
 			if installedVersion.GreaterThan(currentVersion) {
-				logger.Info(ctx, fmt.Sprintf("skip %s(%s) from %s store, because it's already installed(%s)", pluginMetadata.Name, pluginMetadata.Version, pluginMetadata.Name, installedPlugin.Metadata.Version))
-				return fmt.Errorf("skip %s(%s) from %s store, because it's already installed(%s)", pluginMetadata.Name, pluginMetadata.Version, pluginMetadata.Name, installedPlugin.Metadata.Version)
+				logger.Info(ctx, fmt.Sprintf("skip %s(%s), because it's already installed(%s)", name, version, installedPlugin.Metadata.Version))
+				return fmt.Errorf("skip %s(%s), because it's already installed(%s)", name, version, installedPlugin.Metadata.Version)
 			}
 		}
+	}
 
-		uninstallErr := s.Uninstall(ctx, installedPlugin)
-		if uninstallErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to uninstall plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, uninstallErr.Error()))
-			return fmt.Errorf("failed to uninstall plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, uninstallErr.Error())
+	stagingDirectory := path.Join(util.GetLocation().GetPluginStagingDirectory(), fmt.Sprintf("%s@%s", id, version))
+	defer func() {
+		if removeErr := os.RemoveAll(stagingDirectory); removeErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to remove plugin staging directory %s: %s", stagingDirectory, removeErr.Error()))
+		}
+	}()
+	metadata, stageErr := s.stagePlugin(ctx, id, stagingDirectory, zipPath)
+	if stageErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to install plugin %s(%s): %s", name, version, stageErr.Error()))
+		return fmt.Errorf("failed to install plugin %s(%s): %s", name, version, stageErr.Error())
+	}
+
+	var backup *pluginBackup
+	if exist {
+		if installedPlugin.IsDevPlugin {
+			uninstallErr := s.Uninstall(ctx, installedPlugin)
+			if uninstallErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to uninstall plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, uninstallErr.Error()))
+				return fmt.Errorf("failed to uninstall plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, uninstallErr.Error())
+			}
+		} else {
+			var backupErr error
+			backup, backupErr = s.backupPlugin(ctx, installedPlugin)
+			if backupErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to backup plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, backupErr.Error()))
+				return fmt.Errorf("failed to backup plugin %s(%s): %s", installedPlugin.Metadata.Name, installedPlugin.Metadata.Version, backupErr.Error())
+			}
 		}
 	}
 
-	pluginDirectory := path.Join(util.GetLocation().GetPluginDirectory(), fmt.Sprintf("%s_%s@%s", pluginMetadata.Id, pluginMetadata.Name, pluginMetadata.Version))
-	directoryErr := util.GetLocation().EnsureDirectoryExist(pluginDirectory)
+	pluginDirectory := path.Join(util.GetLocation().GetPluginDirectory(), fmt.Sprintf("%s_%s@%s", id, name, version))
+	installErr := s.activatePlugin(ctx, metadata, stagingDirectory, pluginDirectory, confirmedPermissions)
+	if installErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to install plugin %s(%s): %s", name, version, installErr.Error()))
+		removeErr := os.RemoveAll(pluginDirectory)
+		if removeErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to remove plugin directory %s: %s", pluginDirectory, removeErr.Error()))
+		}
+		if backup != nil {
+			s.restorePlugin(ctx, backup)
+		}
+		return fmt.Errorf("failed to install plugin %s(%s): %s", name, version, installErr.Error())
+	}
+
+	if saveErr := s.saveInstalledStore(ctx, metadata, storeName); saveErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to save installed store of plugin %s: %s", name, saveErr.Error()))
+	}
+
+	return nil
+}
+
+// stagePlugin extracts plugin package into staging directory and validates its plugin.json
+func (s *Store) stagePlugin(ctx context.Context, id string, stagingDirectory string, zipPath string) (Metadata, error) {
+	if removeErr := os.RemoveAll(stagingDirectory); removeErr != nil {
+		return Metadata{}, fmt.Errorf("failed to clean staging directory %s: %w", stagingDirectory, removeErr)
+	}
+	directoryErr := util.GetLocation().EnsureDirectoryExist(stagingDirectory)
 	if directoryErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to create plugin directory %s: %s", pluginDirectory, directoryErr.Error()))
-		return fmt.Errorf("failed to create plugin directory %s: %s", pluginDirectory, directoryErr.Error())
+		return Metadata{}, fmt.Errorf("failed to create staging directory %s: %w", stagingDirectory, directoryErr)
 	}
 
-	//unzip plugin
-	logger.Info(ctx, fmt.Sprintf("start to unzip plugin %s(%s)", pluginMetadata.Name, pluginMetadata.Version))
-	unzipErr := util.Unzip(filePath, pluginDirectory)
+	logger.Info(ctx, fmt.Sprintf("start to unzip plugin to %s", stagingDirectory))
+	unzipErr := util.UnzipWithLimits(zipPath, stagingDirectory, pluginPackageLimits)
 	if unzipErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to unzip plugin %s(%s): %s", pluginMetadata.Name, pluginMetadata.Version, unzipErr.Error()))
-		return fmt.Errorf("failed to unzip plugin %s(%s): %s", pluginMetadata.Name, pluginMetadata.Version, unzipErr.Error())
+		return Metadata{}, fmt.Errorf("failed to unzip plugin: %w", unzipErr)
+	}
+
+	metadata, parseErr := GetPluginManager().ParseMetadata(ctx, stagingDirectory)
+	if parseErr != nil {
+		return Metadata{}, parseErr
+	}
+	if metadata.Id != id {
+		return Metadata{}, fmt.Errorf("plugin id in package is %s, expected %s", metadata.Id, id)
+	}
+	if compatibleErr := CheckMetadataCompatibility(ctx, metadata); compatibleErr != nil {
+		return Metadata{}, fmt.Errorf("plugin is not compatible: %w", compatibleErr)
+	}
+
+	return metadata, nil
+}
+
+// activatePlugin moves staged plugin into plugin directory, installs its dependencies and loads it
+func (s *Store) activatePlugin(ctx context.Context, metadata Metadata, stagingDirectory string, pluginDirectory string, confirmedPermissions setting.PluginPermissions) error {
+	if removeErr := os.RemoveAll(pluginDirectory); removeErr != nil {
+		return fmt.Errorf("failed to clean plugin directory %s: %w", pluginDirectory, removeErr)
+	}
+	if renameErr := os.Rename(stagingDirectory, pluginDirectory); renameErr != nil {
+		return fmt.Errorf("failed to move plugin to %s: %w", pluginDirectory, renameErr)
+	}
+
+	grantErr := s.grantPermissions(ctx, metadata, confirmedPermissions)
+	if grantErr != nil {
+		return fmt.Errorf("failed to grant permissions: %w", grantErr)
 	}
 
-	//load plugin
-	logger.Info(ctx, fmt.Sprintf("start to load plugin %s(%s)", pluginMetadata.Name, pluginMetadata.Version))
+	dependencyErr := InstallDependencies(ctx, metadata, pluginDirectory)
+	if dependencyErr != nil {
+		return dependencyErr
+	}
+
+	logger.Info(ctx, fmt.Sprintf("start to load plugin %s(%s)", metadata.Name, metadata.Version))
 	loadErr := GetPluginManager().LoadPlugin(ctx, pluginDirectory)
 	if loadErr != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to load plugin %s(%s): %s", pluginMetadata.Name, pluginMetadata.Version, loadErr.Error()))
+		return fmt.Errorf("failed to load plugin: %w", loadErr)
+	}
 
-		// remove plugin directory
-		removeErr := os.RemoveAll(pluginDirectory)
-		if removeErr != nil {
-			logger.Error(ctx, fmt.Sprintf("failed to remove plugin directory %s: %s", pluginDirectory, removeErr.Error()))
+	return nil
+}
+
+// pluginBackup is the previous version of a plugin, kept after upgrading so user can roll back to it
+type pluginBackup struct {
+	instance           *Instance
+	backupDirectory    string
+	grantedPermissions *setting.PluginPermissions
+}
+
+// getPluginBackupRoot returns the directory containing previous version of plugin, the previous version is kept in a
+// sub directory with its original directory name, so it can be moved back to plugin directory after restart
+func getPluginBackupRoot(id string) string {
+	return path.Join(util.GetLocation().GetPluginBackupDirectory(), id)
+}
+
+// backupPlugin unloads installed plugin and moves its directory out of plugin directory, so it won't be loaded with the new version
+func (s *Store) backupPlugin(ctx context.Context, instance *Instance) (*pluginBackup, error) {
+	backupRoot := getPluginBackupRoot(instance.Metadata.Id)
+	if removeErr := os.RemoveAll(backupRoot); removeErr != nil {
+		return nil, removeErr
+	}
+	if directoryErr := util.GetLocation().EnsureDirectoryExist(backupRoot); directoryErr != nil {
+		return nil, directoryErr
+	}
+
+	backupDirectory := path.Join(backupRoot, filepath.Base(instance.PluginDirectory))
+
+	backup := &pluginBackup{instance: instance, backupDirectory: backupDirectory}
+	if instance.Setting != nil {
+		backup.grantedPermissions = instance.Setting.GrantedPermissions
+	}
+
+	GetPluginManager().UnloadPlugin(ctx, instance)
+	if renameErr := os.Rename(instance.PluginDirectory, backupDirectory); renameErr != nil {
+		// plugin is still in plugin directory, load it again
+		if loadErr := GetPluginManager().LoadPlugin(ctx, instance.PluginDirectory); loadErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to load plugin %s again: %s", instance.Metadata.Name, loadErr.Error()))
 		}
+		return nil, renameErr
+	}
+
+	logger.Info(ctx, fmt.Sprintf("backup plugin %s(%s) to %s", instance.Metadata.Name, instance.Metadata.Version, backupDirectory))
+	return backup, nil
+}
 
-		return fmt.Errorf("failed to load plugin %s(%s): %s", pluginMetadata.Name, pluginMetadata.Version, loadErr.Error())
+// restorePlugin moves backup of previous version back to plugin directory and loads it
+func (s *Store) restorePlugin(ctx context.Context, backup *pluginBackup) {
+	metadata := backup.instance.Metadata
+	logger.Info(ctx, fmt.Sprintf("restore plugin %s(%s)", metadata.Name, metadata.Version))
+
+	if renameErr := os.Rename(backup.backupDirectory, backup.instance.PluginDirectory); renameErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to restore plugin %s from %s: %s", metadata.Name, backup.backupDirectory, renameErr.Error()))
+		return
+	}
+	if removeErr := os.RemoveAll(path.Dir(backup.backupDirectory)); removeErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to remove plugin backup %s: %s", path.Dir(backup.backupDirectory), removeErr.Error()))
 	}
 
-	return nil
+	// new version may have granted different permissions
+	if ConvertToRuntime(metadata.Runtime) == PLUGIN_RUNTIME_WASM {
+		pluginSetting, loadErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Id, metadata.Name, metadata.SettingDefinitions)
+		if loadErr == nil {
+			pluginSetting.GrantedPermissions = backup.grantedPermissions
+			if saveErr := setting.GetSettingManager().SavePluginSetting(ctx, metadata.Id, pluginSetting); saveErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to restore permissions of plugin %s: %s", metadata.Name, saveErr.Error()))
+			}
+		}
+	}
+
+	if loadErr := GetPluginManager().LoadPlugin(ctx, backup.instance.PluginDirectory); loadErr != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to load restored plugin %s: %s", metadata.Name, loadErr.Error()))
+	}
+}
+
+// grantPermissions grants permissions requested in plugin.json of sandboxed plugins.
+// Requested permissions must be included in confirmed, otherwise plugin may get permissions user has never seen
+func (s *Store) grantPermissions(ctx context.Context, metadata Metadata, confirmed setting.PluginPermissions) error {
+	if ConvertToRuntime(metadata.Runtime) != PLUGIN_RUNTIME_WASM {
+		return nil
+	}
+
+	if !IsPermissionsCovered(metadata.Permissions, confirmed) {
+		return fmt.Errorf("plugin %s requests permissions which are not confirmed by user", metadata.Name)
+	}
+
+	return GetPluginManager().GrantPermissions(ctx, metadata)
 }
 
 func (s *Store) Uninstall(ctx context.Context, plugin *Instance) error {
@@ -370,12 +669,25 @@ func (s *Store) Uninstall(ctx context.Context, plugin *Instance) error {
 			query, _ := newQueryInputWithPlugins("wpm dev.remove "+plugin.DevPluginDirectory, GetPluginManager().GetPluginInstances())
 			wpmPlugin.Plugin.Query(ctx, query)
 		}
+	} else if plugin.Metadata.Runtime == string(PLUGIN_RUNTIME_SCRIPT) {
+		// script commands share the same directory, only remove the script itself
+		scriptPath := path.Join(plugin.PluginDirectory, plugin.Metadata.Entry)
+		removeErr := os.Remove(scriptPath)
+		if removeErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to remove script %s: %s", scriptPath, removeErr.Error()))
+			return removeErr
+		}
 	} else {
 		removeErr := os.RemoveAll(plugin.PluginDirectory)
 		if removeErr != nil {
 			logger.Error(ctx, fmt.Sprintf("failed to remove plugin directory %s: %s", plugin.PluginDirectory, removeErr.Error()))
 			return removeErr
 		}
+		// previous version is useless once plugin is uninstalled
+		backupRoot := getPluginBackupRoot(plugin.Metadata.Id)
+		if removeErr := os.RemoveAll(backupRoot); removeErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to remove plugin backup %s: %s", backupRoot, removeErr.Error()))
+		}
 	}
 
 	GetPluginManager().UnloadPlugin(ctx, plugin)
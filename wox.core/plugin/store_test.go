@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStorePluginManifests(t *testing.T) {
+	logger = util.GetLogger()
+
+	// a company store with one manifest per file, packages are relative to the store
+	companyStore := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(companyStore, "demo.json"), []byte(`{"Id": "demo", "Name": "demo", "Version": "1.0.0", "Runtime": "python", "DownloadUrl": "demo.wox"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(companyStore, "internal.json"), []byte(`{"Id": "internal", "Name": "internal", "Version": "2.0.0", "Runtime": "nodejs", "DownloadUrl": "packages/internal.wox"}`), 0644))
+
+	officialStore := filepath.Join(t.TempDir(), "store-plugin.json")
+	assert.NoError(t, os.WriteFile(officialStore, []byte(`[
+		{"Id": "demo", "Name": "demo", "Version": "1.0.0", "DownloadUrl": "https://example.com/demo.wox"},
+		{"Id": "internal", "Name": "internal", "Version": "2.1.0", "DownloadUrl": "https://example.com/internal.wox"}
+	]`), 0644))
+
+	store := &Store{sources: []setting.StoreSource{
+		{Name: "official", Url: "file://" + officialStore, Priority: 10, Enabled: true},
+		{Name: "company", Url: companyStore, Priority: 20, Enabled: true, PublicKey: "key"},
+		{Name: "disabled", Url: "/not/exist", Enabled: false},
+	}}
+	manifests := store.GetStorePluginManifests(context.Background())
+	assert.Len(t, manifests, 2)
+
+	// same version, store with higher priority wins
+	assert.Equal(t, "demo", manifests[0].Id)
+	assert.Equal(t, "company", manifests[0].Store)
+	assert.Equal(t, PLUGIN_RUNTIME_PYTHON, manifests[0].Runtime)
+	assert.Equal(t, filepath.Join(companyStore, "demo.wox"), manifests[0].DownloadUrl)
+	assert.Equal(t, "key", manifests[0].storePublicKey)
+
+	// newer version wins regardless of priority
+	assert.Equal(t, "internal", manifests[1].Id)
+	assert.Equal(t, "official", manifests[1].Store)
+	assert.Equal(t, "2.1.0", manifests[1].Version)
+}
@@ -12,7 +12,6 @@ import (
 	"wox/setting/definition"
 	"wox/share"
 	"wox/util"
-	"wox/util/clipboard"
 
 	"github.com/disintegration/imaging"
 	"github.com/samber/lo"
@@ -27,6 +26,15 @@ type commandSetting struct {
 	Model   string `json:"model"`
 	Prompt  string `json:"prompt"`
 	Vision  bool   `json:"vision"` // does the command interact with vision
+
+	// where the answer goes, see aiCommandOutput
+	Output       string `json:"output"`
+	OutputTarget string `json:"outputTarget"` // file path for append_file, command for new_chat and pipe
+	DiffPreview  bool   `json:"diffPreview"`  // show diff between input and answer after answered, useful for rewrite style prompts
+
+	// only available for commands installed from ai command store
+	Author  string `json:"author,omitempty"`
+	Version string `json:"version,omitempty"`
 }
 
 func (c *commandSetting) AIModel() (model ai.Model) {
@@ -39,11 +47,16 @@ func (c *commandSetting) AIModel() (model ai.Model) {
 }
 
 func init() {
-	plugin.AllSystemPlugin = append(plugin.AllSystemPlugin, &Plugin{})
+	plugin.AllSystemPlugin = append(plugin.AllSystemPlugin, &Plugin{
+		chatContexts: util.NewHashMap[string, []ai.Conversation](),
+	})
 }
 
 type Plugin struct {
 	api plugin.API
+
+	// previous conversations of commands opened by new_chat output, key is command
+	chatContexts *util.HashMap[string, []ai.Conversation]
 }
 
 func (c *Plugin) GetMetadata() plugin.Metadata {
@@ -61,6 +74,12 @@ func (c *Plugin) GetMetadata() plugin.Metadata {
 		TriggerKeywords: []string{
 			"ai",
 		},
+		Commands: []plugin.MetadataCommand{
+			{
+				Command:     "store",
+				Description: "i18n:plugin_ai_command_store",
+			},
+		},
 		SupportedOS: []string{
 			"Windows",
 			"Macos",
@@ -109,6 +128,36 @@ func (c *Plugin) GetMetadata() plugin.Metadata {
 							Width:   60,
 							Tooltip: "i18n:plugin_ai_command_vision_tooltip",
 						},
+						{
+							Key:     "output",
+							Label:   "i18n:plugin_ai_command_output",
+							Type:    definition.PluginSettingValueTableColumnTypeSelect,
+							Width:   100,
+							Tooltip: "i18n:plugin_ai_command_output_tooltip",
+							SelectOptions: []definition.PluginSettingValueSelectOption{
+								{Label: "i18n:plugin_ai_command_output_preview", Value: aiCommandOutputPreview},
+								{Label: "i18n:plugin_ai_command_output_replace", Value: aiCommandOutputReplace},
+								{Label: "i18n:plugin_ai_command_copy", Value: aiCommandOutputCopy},
+								{Label: "i18n:plugin_ai_command_output_append_file", Value: aiCommandOutputAppendFile},
+								{Label: "i18n:plugin_ai_command_output_new_chat", Value: aiCommandOutputNewChat},
+								{Label: "i18n:plugin_ai_command_output_pipe_option", Value: aiCommandOutputPipe},
+							},
+						},
+						{
+							Key:          "outputTarget",
+							Label:        "i18n:plugin_ai_command_output_target",
+							Type:         definition.PluginSettingValueTableColumnTypeText,
+							Width:        100,
+							TextMaxLines: 1,
+							Tooltip:      "i18n:plugin_ai_command_output_target_tooltip",
+						},
+						{
+							Key:     "diffPreview",
+							Label:   "i18n:plugin_ai_command_diff_preview",
+							Type:    definition.PluginSettingValueTableColumnTypeCheckbox,
+							Width:   60,
+							Tooltip: "i18n:plugin_ai_command_diff_preview_tooltip",
+						},
 					},
 				},
 			},
@@ -129,6 +178,7 @@ func (c *Plugin) GetMetadata() plugin.Metadata {
 
 func (c *Plugin) Init(ctx context.Context, initParams plugin.InitParams) {
 	c.api = initParams.API
+	GetAICommandStoreManager().Start(ctx)
 	c.api.OnSettingChanged(ctx, func(key string, value string) {
 		if key == "commands" {
 			c.api.Log(ctx, plugin.LogLevelInfo, fmt.Sprintf("ai command setting changed: %s", value))
@@ -152,9 +202,15 @@ func (c *Plugin) Query(ctx context.Context, query plugin.Query) []plugin.QueryRe
 		return c.querySelection(ctx, query)
 	}
 
+	// chat opened by new_chat output can only be continued by its target command, it's stale once query goes elsewhere
+	c.clearChatContextsExcept(query.Command)
+
 	if query.Command == "" {
 		return c.listAllCommands(ctx, query)
 	}
+	if query.Command == "store" {
+		return c.queryStore(ctx, query)
+	}
 
 	return c.queryCommand(ctx, query)
 }
@@ -186,8 +242,8 @@ func (c *Plugin) querySelection(ctx context.Context, query plugin.Query) []plugi
 			return current
 		}
 
+		var conversations []ai.Conversation
 		isFirstAnswer := true
-		answerText := ""
 		onAnswering := func(current plugin.RefreshableResult, deltaAnswer string, isFinished bool) plugin.RefreshableResult {
 			if isFirstAnswer {
 				current.Preview.PreviewData = ""
@@ -201,23 +257,10 @@ func (c *Plugin) querySelection(ctx context.Context, query plugin.Query) []plugi
 			if isFinished {
 				current.RefreshInterval = 0
 				current.SubTitle = fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_ai_command_answered_cost"), util.GetSystemTimestamp()-startAnsweringTime)
-				answerText = current.Preview.PreviewData
-
-				current.Actions = []plugin.QueryResultAction{
-					{
-						Name: "i18n:plugin_ai_command_copy",
-						Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-							clipboard.WriteText(answerText)
-						},
-					},
-				}
-
-				// paste to active window
-				pasteToActiveWindowAction, pasteToActiveWindowErr := getPasteToActiveWindowAction(ctx, c.api, func() {
-					clipboard.WriteText(answerText)
-				})
-				if pasteToActiveWindowErr == nil {
-					current.Actions = append(current.Actions, pasteToActiveWindowAction)
+				current.ContextData = current.Preview.PreviewData
+				current.Actions = c.getOutputActions(ctx, command, conversations)
+				if command.DiffPreview && query.Selection.Type == util.SelectionTypeText {
+					current.Preview = getDiffPreview(query.Selection.Text, current.ContextData)
 				}
 			}
 			return current
@@ -228,7 +271,6 @@ func (c *Plugin) querySelection(ctx context.Context, query plugin.Query) []plugi
 			return current
 		}
 
-		var conversations []ai.Conversation
 		if query.Selection.Type == util.SelectionTypeFile {
 			var images []image.Image
 			for _, imagePath := range query.Selection.FilePaths {
@@ -238,16 +280,17 @@ func (c *Plugin) querySelection(ctx context.Context, query plugin.Query) []plugi
 				}
 				images = append(images, img)
 			}
+			noSelectedText := ""
 			conversations = append(conversations, ai.Conversation{
 				Role:   ai.ConversationRoleUser,
-				Text:   command.Prompt,
+				Text:   c.renderPrompt(ctx, command.Prompt, plugin.QueryVariableSelectedText, plugin.QueryVariableContext{SelectedText: &noSelectedText}),
 				Images: images,
 			})
 		}
 		if query.Selection.Type == util.SelectionTypeText {
 			conversations = append(conversations, ai.Conversation{
 				Role: ai.ConversationRoleUser,
-				Text: fmt.Sprintf(command.Prompt, query.Selection.Text),
+				Text: c.renderPrompt(ctx, command.Prompt, plugin.QueryVariableSelectedText, plugin.QueryVariableContext{SelectedText: &query.Selection.Text}),
 			})
 		}
 
@@ -258,7 +301,7 @@ func (c *Plugin) querySelection(ctx context.Context, query plugin.Query) []plugi
 			Icon:            aiCommandIcon,
 			Preview:         plugin.WoxPreview{PreviewType: plugin.WoxPreviewTypeText, PreviewData: "i18n:plugin_ai_command_enter_to_start"},
 			RefreshInterval: 100,
-			OnRefresh: createLLMOnRefreshHandler(ctx, c.api.AIChatStream, command.AIModel(), conversations, func() bool {
+			OnRefresh: createLLMOnRefreshHandler(ctx, c.chatStream, command.AIModel(), conversations, func() bool {
 				return startGenerate
 			}, onPreparing, onAnswering, onAnswerErr),
 			Actions: []plugin.QueryResultAction{
@@ -320,13 +363,104 @@ func (c *Plugin) listAllCommands(ctx context.Context, query plugin.Query) []plug
 }
 
 func (c *Plugin) getAllCommands(ctx context.Context) (commands []commandSetting, err error) {
-	commandSettings := c.api.GetSetting(ctx, "commands")
-	if commandSettings == "" {
-		return nil, nil
+	return getAICommandSettings(ctx, c.api)
+}
+
+// chatStream allows answers to be cached, so running the same command on the same input again doesn't call the provider again
+func (c *Plugin) chatStream(ctx context.Context, model ai.Model, conversations []ai.Conversation, callback ai.ChatStreamFunc) error {
+	return c.api.AIChatStream(ai.WithResponseCache(ctx), model, conversations, callback)
+}
+
+// renderPrompt renders the prompt template, legacy %s placeholder is treated as legacyVariable
+func (c *Plugin) renderPrompt(ctx context.Context, prompt string, legacyVariable plugin.QueryVariable, variableContext plugin.QueryVariableContext) string {
+	variableContext.Setting = func(key string) string {
+		return c.api.GetSetting(ctx, key)
+	}
+	return plugin.GetPluginManager().RenderQueryTemplate(ctx, strings.ReplaceAll(prompt, "%s", legacyVariable), variableContext)
+}
+
+func (c *Plugin) queryStore(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	store := GetAICommandStoreManager()
+	manifests := store.Search(ctx, query.Search)
+	if len(manifests) == 0 {
+		// store may be empty because it can't be reached and has never been synced
+		syncErrors := lo.FilterMap(store.GetSyncStatus(ctx), func(status util.StoreSyncStatus, _ int) (string, bool) {
+			return fmt.Sprintf("%s: %s", status.Name, status.LastError), status.LastError != ""
+		})
+		return []plugin.QueryResult{
+			{
+				Title:    "i18n:plugin_ai_command_store_empty",
+				SubTitle: strings.Join(syncErrors, "; "),
+				Icon:     aiCommandIcon,
+			},
+		}
 	}
 
-	err = json.Unmarshal([]byte(commandSettings), &commands)
-	return
+	updatableManifests := store.GetUpdatableManifests(ctx, c.api)
+
+	var results []plugin.QueryResult
+	for _, manifest := range manifests {
+		subTitle := manifest.Description
+		if subTitle == "" {
+			subTitle = fmt.Sprintf("%s %s", query.TriggerKeyword, manifest.Command)
+		}
+
+		result := plugin.QueryResult{
+			Title:    manifest.Name,
+			SubTitle: subTitle,
+			Icon:     aiCommandIcon,
+			Preview: plugin.WoxPreview{
+				PreviewType: plugin.WoxPreviewTypeText,
+				PreviewData: manifest.Prompt,
+				PreviewProperties: map[string]string{
+					"Command": manifest.Command,
+					"Author":  manifest.Author,
+					"Version": manifest.GetVersion(),
+				},
+			},
+		}
+
+		installed, isInstalled := store.getInstalled(ctx, c.api, manifest)
+		if isInstalled && !installed.isFromStore() {
+			// command keyword is taken by a command created by user, don't offer actions which would overwrite or remove it
+			result.Tails = append(result.Tails, plugin.QueryResultTail{Type: plugin.QueryResultTailTypeText, Text: "i18n:plugin_ai_command_store_conflict"})
+			results = append(results, result)
+			continue
+		}
+		isUpdatable := lo.ContainsBy(updatableManifests, func(item AICommandStoreManifest) bool {
+			return item.Command == manifest.Command
+		})
+		if !isInstalled || isUpdatable {
+			actionName := "i18n:plugin_ai_command_store_install"
+			if isUpdatable {
+				actionName = "i18n:plugin_ai_command_store_update"
+				result.Tails = append(result.Tails, plugin.QueryResultTail{Type: plugin.QueryResultTailTypeText, Text: fmt.Sprintf("%s -> %s", installed.Version, manifest.GetVersion())})
+			}
+			result.Actions = append(result.Actions, plugin.QueryResultAction{
+				Name: actionName,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					installErr := store.Install(ctx, c.api, manifest)
+					if installErr != nil {
+						c.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_ai_command_store_install_failed"), installErr.Error()))
+					}
+				},
+			})
+		}
+		if isInstalled {
+			result.Actions = append(result.Actions, plugin.QueryResultAction{
+				Name: "i18n:plugin_ai_command_store_uninstall",
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					uninstallErr := store.Uninstall(ctx, c.api, manifest.Command)
+					if uninstallErr != nil {
+						c.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_ai_command_store_uninstall_failed"), uninstallErr.Error()))
+					}
+				},
+			})
+		}
+
+		results = append(results, result)
+	}
+	return results
 }
 
 func (c *Plugin) queryCommand(ctx context.Context, query plugin.Query) []plugin.QueryResult {
@@ -381,8 +515,13 @@ func (c *Plugin) queryCommand(ctx context.Context, query plugin.Query) []plugin.
 
 	var prompts = strings.Split(aiCommandSetting.Prompt, "{wox:new_ai_conversation}")
 	var conversations []ai.Conversation
+	// continue the chat opened by new_chat output of another command
+	chatContext, hasChatContext := c.chatContexts.Load(aiCommandSetting.Command)
+	if hasChatContext {
+		conversations = append(conversations, chatContext...)
+	}
 	for index, message := range prompts {
-		msg := fmt.Sprintf(message, query.Search)
+		msg := c.renderPrompt(ctx, message, plugin.QueryVariableQuery, plugin.QueryVariableContext{Query: query.Search, SelectedText: &query.Selection.Text})
 		if index%2 == 0 {
 			conversations = append(conversations, ai.Conversation{
 				Role: ai.ConversationRoleUser,
@@ -402,6 +541,9 @@ func (c *Plugin) queryCommand(ctx context.Context, query plugin.Query) []plugin.
 		current.ContextData = current.Preview.PreviewData
 		if isFinished {
 			current.RefreshInterval = 0 // stop refreshing
+			if aiCommandSetting.DiffPreview {
+				current.Preview = getDiffPreview(query.Search, current.ContextData)
+			}
 		}
 
 		return current
@@ -418,27 +560,29 @@ func (c *Plugin) queryCommand(ctx context.Context, query plugin.Query) []plugin.
 		Preview:         plugin.WoxPreview{PreviewType: plugin.WoxPreviewTypeMarkdown, PreviewData: ""},
 		Icon:            aiCommandIcon,
 		RefreshInterval: 100,
-		OnRefresh: createLLMOnRefreshHandler(ctx, c.api.AIChatStream, aiCommandSetting.AIModel(), conversations, func() bool {
+		OnRefresh: createLLMOnRefreshHandler(ctx, c.chatStream, aiCommandSetting.AIModel(), conversations, func() bool {
 			return true
 		}, nil, onAnswering, onAnswerErr),
-		Actions: []plugin.QueryResultAction{
-			{
-				Name: "i18n:plugin_ai_command_copy",
-				Icon: plugin.CopyIcon,
-				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-					clipboard.WriteText(actionContext.ContextData)
-				},
-			},
-		},
+		Actions: c.getOutputActions(ctx, aiCommandSetting, conversations),
 	}
-
-	// paste to active window
-	pasteToActiveWindowAction, pasteToActiveWindowErr := getPasteToActiveWindowAction(ctx, c.api, func() {
-		clipboard.WriteText(result.ContextData)
-	})
-	if pasteToActiveWindowErr == nil {
-		result.Actions = append(result.Actions, pasteToActiveWindowAction)
+	if hasChatContext {
+		// chat is consumed once user acts on the answer
+		for i := range result.Actions {
+			action := result.Actions[i].Action
+			result.Actions[i].Action = func(ctx context.Context, actionContext plugin.ActionContext) {
+				c.chatContexts.Delete(aiCommandSetting.Command)
+				action(ctx, actionContext)
+			}
+		}
 	}
 
 	return []plugin.QueryResult{result}
 }
+
+func (c *Plugin) clearChatContextsExcept(command string) {
+	for target := range c.chatContexts.ToMap() {
+		if target != command {
+			c.chatContexts.Delete(target)
+		}
+	}
+}
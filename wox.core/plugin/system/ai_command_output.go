@@ -0,0 +1,176 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"wox/ai"
+	"wox/i18n"
+	"wox/plugin"
+	"wox/share"
+	"wox/util"
+	"wox/util/clipboard"
+
+	"github.com/samber/lo"
+)
+
+type aiCommandOutput = string
+
+const (
+	aiCommandOutputPreview    aiCommandOutput = "preview"     // only show answer in preview, default
+	aiCommandOutputReplace    aiCommandOutput = "replace"     // replace the selection by pasting the answer into active window
+	aiCommandOutputCopy       aiCommandOutput = "copy"        // copy answer to clipboard
+	aiCommandOutputAppendFile aiCommandOutput = "append_file" // append answer to the file in OutputTarget
+	aiCommandOutputNewChat    aiCommandOutput = "new_chat"    // continue chatting with the answer as context, OutputTarget is the command to chat with
+	aiCommandOutputPipe       aiCommandOutput = "pipe"        // run the command in OutputTarget with the answer as input
+)
+
+// getOutputActions returns actions for a finished answer, the action of configured output goes first and is the default action.
+// Answer is read from ContextData of the result
+func (c *Plugin) getOutputActions(ctx context.Context, command commandSetting, conversations []ai.Conversation) []plugin.QueryResultAction {
+	var actions []plugin.QueryResultAction
+
+	output := command.Output
+	if output == "" {
+		output = aiCommandOutputPreview
+	}
+
+	switch output {
+	case aiCommandOutputReplace:
+		actions = append(actions, plugin.QueryResultAction{
+			Name:      "i18n:plugin_ai_command_output_replace",
+			IsDefault: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				clipboard.WriteText(actionContext.ContextData)
+				simulatePasteToActiveWindow(ctx, c.api)
+			},
+		})
+	case aiCommandOutputAppendFile:
+		actions = append(actions, plugin.QueryResultAction{
+			Name:      "i18n:plugin_ai_command_output_append_file",
+			IsDefault: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				if appendErr := appendAnswerToFile(command.OutputTarget, actionContext.ContextData); appendErr != nil {
+					c.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_ai_command_output_append_file_failed"), appendErr.Error()))
+				}
+			},
+		})
+	case aiCommandOutputNewChat:
+		actions = append(actions, plugin.QueryResultAction{
+			Name:                   "i18n:plugin_ai_command_output_new_chat",
+			IsDefault:              true,
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				target := command.OutputTarget
+				if target == "" {
+					target = command.Command
+				}
+				chatContext := append(append([]ai.Conversation{}, conversations...), ai.Conversation{Role: ai.ConversationRoleAI, Text: actionContext.ContextData})
+				c.chatContexts.Store(target, chatContext)
+				c.api.ChangeQuery(ctx, share.PlainQuery{
+					QueryType: plugin.QueryTypeInput,
+					QueryText: c.getCommandQueryText(target, ""),
+				})
+			},
+		})
+	case aiCommandOutputPipe:
+		if command.OutputTarget != "" {
+			actions = append(actions, plugin.QueryResultAction{
+				Name:                   fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_ai_command_output_pipe"), command.OutputTarget),
+				IsDefault:              true,
+				PreventHideAfterAction: true,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					c.api.ChangeQuery(ctx, share.PlainQuery{
+						QueryType: plugin.QueryTypeInput,
+						QueryText: c.getCommandQueryText(command.OutputTarget, actionContext.ContextData),
+					})
+				},
+			})
+		}
+	}
+
+	actions = append(actions, plugin.QueryResultAction{
+		Name:      "i18n:plugin_ai_command_copy",
+		Icon:      plugin.CopyIcon,
+		IsDefault: output == aiCommandOutputCopy,
+		Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+			clipboard.WriteText(actionContext.ContextData)
+		},
+	})
+
+	// paste to active window, replace output already does the same thing
+	if output != aiCommandOutputReplace {
+		var answer string
+		pasteToActiveWindowAction, pasteToActiveWindowErr := getPasteToActiveWindowAction(ctx, c.api, func() {
+			clipboard.WriteText(answer)
+		})
+		if pasteToActiveWindowErr == nil {
+			pasteToActiveWindowAction.IsDefault = output == aiCommandOutputPreview
+			pasteAction := pasteToActiveWindowAction.Action
+			pasteToActiveWindowAction.Action = func(ctx context.Context, actionContext plugin.ActionContext) {
+				answer = actionContext.ContextData
+				pasteAction(ctx, actionContext)
+			}
+			actions = append(actions, pasteToActiveWindowAction)
+		}
+	}
+
+	return actions
+}
+
+// getCommandQueryText returns the query to run an ai command, trigger keyword of ai commands may be changed by user
+func (c *Plugin) getCommandQueryText(command string, search string) string {
+	triggerKeywords := c.GetMetadata().TriggerKeywords
+	instance, found := lo.Find(plugin.GetPluginManager().GetPluginInstances(), func(item *plugin.Instance) bool {
+		return item.Metadata.Id == c.GetMetadata().Id
+	})
+	if found && instance.Setting != nil {
+		triggerKeywords = instance.GetTriggerKeywords()
+	}
+
+	return getAICommandQueryText(triggerKeywords, command, search)
+}
+
+// getAICommandQueryText uses the first trigger keyword which is not global, command is queried globally if there is none
+func getAICommandQueryText(triggerKeywords []string, command string, search string) string {
+	triggerKeyword, found := lo.Find(triggerKeywords, func(keyword string) bool {
+		return keyword != "*"
+	})
+	if !found {
+		return fmt.Sprintf("%s %s", command, search)
+	}
+	return fmt.Sprintf("%s %s %s", triggerKeyword, command, search)
+}
+
+// getDiffPreview shows what the answer changed compared to the input, useful for rewrite style prompts
+func getDiffPreview(input string, answer string) plugin.WoxPreview {
+	return plugin.WoxPreview{
+		PreviewType:    plugin.WoxPreviewTypeMarkdown,
+		PreviewData:    fmt.Sprintf("```diff\n%s```", util.DiffText(input, strings.TrimSpace(answer))),
+		ScrollPosition: plugin.WoxPreviewScrollPositionBottom,
+	}
+}
+
+func appendAnswerToFile(filePath string, answer string) error {
+	if filePath == "" {
+		return fmt.Errorf("output file is not configured")
+	}
+	if strings.HasPrefix(filePath, "~") {
+		homeDir, homeErr := os.UserHomeDir()
+		if homeErr != nil {
+			return homeErr
+		}
+		filePath = filepath.Join(homeDir, filePath[1:])
+	}
+
+	file, openErr := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if openErr != nil {
+		return openErr
+	}
+	defer file.Close()
+
+	_, writeErr := file.WriteString(answer + "\n\n")
+	return writeErr
+}
@@ -0,0 +1,81 @@
+package system
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"wox/ai"
+	"wox/plugin"
+	"wox/plugin/plugintest"
+	"wox/share"
+	"wox/util"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAICommandPlugin(t *testing.T) (*Plugin, *plugintest.UI) {
+	c := &Plugin{chatContexts: util.NewHashMap[string, []ai.Conversation]()}
+	ui := plugintest.NewUI()
+	instance := &plugin.Instance{Metadata: c.GetMetadata()}
+	c.api = plugintest.NewAPI(t, instance, ui, plugintest.NewSettingStore(nil))
+	return c, ui
+}
+
+func getDefaultAction(actions []plugin.QueryResultAction) plugin.QueryResultAction {
+	action, _ := lo.Find(actions, func(action plugin.QueryResultAction) bool {
+		return action.IsDefault
+	})
+	return action
+}
+
+func TestAICommandOutputActions(t *testing.T) {
+	ctx := context.Background()
+	c, ui := newTestAICommandPlugin(t)
+	answer := plugin.ActionContext{ContextData: "answer"}
+
+	actions := c.getOutputActions(ctx, commandSetting{Command: "translate", Output: aiCommandOutputCopy}, nil)
+	assert.Equal(t, "i18n:plugin_ai_command_copy", getDefaultAction(actions).Name)
+
+	actions = c.getOutputActions(ctx, commandSetting{Command: "translate", Output: aiCommandOutputReplace}, nil)
+	assert.Equal(t, "i18n:plugin_ai_command_output_replace", actions[0].Name)
+	assert.True(t, actions[0].IsDefault)
+
+	// answer is piped into another command
+	actions = c.getOutputActions(ctx, commandSetting{Command: "translate", Output: aiCommandOutputPipe, OutputTarget: "summary"}, nil)
+	getDefaultAction(actions).Action(ctx, answer)
+	assert.Equal(t, []share.PlainQuery{{QueryType: plugin.QueryTypeInput, QueryText: "ai summary answer"}}, ui.ChangedQueries())
+
+	// chat continues with the answer as context
+	conversations := []ai.Conversation{{Role: ai.ConversationRoleUser, Text: "question"}}
+	actions = c.getOutputActions(ctx, commandSetting{Command: "translate", Output: aiCommandOutputNewChat}, conversations)
+	getDefaultAction(actions).Action(ctx, answer)
+	assert.Equal(t, "ai translate ", ui.ChangedQueries()[1].QueryText)
+	chatContext, exist := c.chatContexts.Load("translate")
+	assert.True(t, exist)
+	assert.Equal(t, []ai.Conversation{conversations[0], {Role: ai.ConversationRoleAI, Text: "answer"}}, chatContext)
+}
+
+func TestAICommandOutputAppendFile(t *testing.T) {
+	ctx := context.Background()
+	c, ui := newTestAICommandPlugin(t)
+	filePath := path.Join(t.TempDir(), "answers.md")
+
+	actions := c.getOutputActions(ctx, commandSetting{Command: "note", Output: aiCommandOutputAppendFile, OutputTarget: filePath}, nil)
+	getDefaultAction(actions).Action(ctx, plugin.ActionContext{ContextData: "first"})
+	getDefaultAction(actions).Action(ctx, plugin.ActionContext{ContextData: "second"})
+	content, readErr := os.ReadFile(filePath)
+	assert.Nil(t, readErr)
+	assert.Equal(t, "first\n\nsecond\n\n", string(content))
+
+	// failure is notified to user
+	actions = c.getOutputActions(ctx, commandSetting{Command: "note", Output: aiCommandOutputAppendFile}, nil)
+	getDefaultAction(actions).Action(ctx, plugin.ActionContext{ContextData: "answer"})
+	assert.Len(t, ui.Notifications(), 1)
+}
+
+func TestGetAICommandQueryText(t *testing.T) {
+	assert.Equal(t, "gpt translate hello", getAICommandQueryText([]string{"*", "gpt"}, "translate", "hello"))
+	assert.Equal(t, "translate ", getAICommandQueryText([]string{"*"}, "translate", ""))
+}
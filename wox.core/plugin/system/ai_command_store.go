@@ -0,0 +1,318 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+	"wox/plugin"
+	"wox/util"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/samber/lo"
+)
+
+const aiCommandDefaultVersion = "1.0.0"
+
+type aiCommandStoreSource struct {
+	Name string
+	Url  string // http(s) url or local file path
+}
+
+type AICommandStoreManifest struct {
+	Name        string
+	Author      string
+	Command     string
+	Prompt      string
+	Description string
+	Version     string // semver, empty means 1.0.0
+	Vision      bool
+}
+
+func (m *AICommandStoreManifest) GetVersion() string {
+	if m.Version == "" {
+		return aiCommandDefaultVersion
+	}
+	return m.Version
+}
+
+var aiCommandStoreInstance *AICommandStore
+var aiCommandStoreOnce sync.Once
+
+type AICommandStore struct {
+	manifests     []AICommandStoreManifest
+	manifestsLock sync.RWMutex
+	sources       []aiCommandStoreSource // only used in tests, empty means default sources
+}
+
+func GetAICommandStoreManager() *AICommandStore {
+	aiCommandStoreOnce.Do(func() {
+		aiCommandStoreInstance = &AICommandStore{}
+	})
+	return aiCommandStoreInstance
+}
+
+func (s *AICommandStore) getStoreSources(ctx context.Context) []aiCommandStoreSource {
+	if len(s.sources) > 0 {
+		return s.sources
+	}
+
+	sources := []aiCommandStoreSource{
+		{
+			Name: "Wox Official AI Command Store",
+			Url:  "https://raw.githubusercontent.com/Wox-launcher/Wox/v2/store-ai-command.json",
+		},
+	}
+
+	// local store is useful for testing prompts before publishing them
+	localStorePath := path.Join(util.GetLocation().GetWoxDataDirectory(), "store-ai-command.json")
+	if _, statErr := os.Stat(localStorePath); statErr == nil {
+		sources = append(sources, aiCommandStoreSource{Name: "Local AI Command Store", Url: localStorePath})
+	}
+
+	return sources
+}
+
+// get ai command manifests from stores, and update in the background every 10 minutes.
+// Cached manifests are used until the first sync finishes, so store is available right after startup and offline
+func (s *AICommandStore) Start(ctx context.Context) {
+	s.setManifests(s.getStoreManifests(ctx, util.ReadCachedStoreManifests))
+
+	util.Go(ctx, "load store ai commands", func() {
+		for {
+			manifests := s.GetStoreManifests(util.NewTraceContext())
+			if len(manifests) > 0 {
+				s.setManifests(manifests)
+			}
+			time.Sleep(time.Minute * 10)
+		}
+	})
+}
+
+func (s *AICommandStore) setManifests(manifests []AICommandStoreManifest) {
+	s.manifestsLock.Lock()
+	defer s.manifestsLock.Unlock()
+	s.manifests = manifests
+}
+
+func (s *AICommandStore) getManifests() []AICommandStoreManifest {
+	s.manifestsLock.RLock()
+	defer s.manifestsLock.RUnlock()
+	return s.manifests
+}
+
+// GetSyncStatus returns result of the last sync of each store
+func (s *AICommandStore) GetSyncStatus(ctx context.Context) []util.StoreSyncStatus {
+	return lo.Map(s.getStoreSources(ctx), func(source aiCommandStoreSource, _ int) util.StoreSyncStatus {
+		return util.GetStoreSyncStatus(source.Name, source.Url)
+	})
+}
+
+func (s *AICommandStore) GetStoreManifests(ctx context.Context) []AICommandStoreManifest {
+	return s.getStoreManifests(ctx, util.ReadStoreManifests)
+}
+
+func (s *AICommandStore) getStoreManifests(ctx context.Context, readManifests util.StoreManifestReader) []AICommandStoreManifest {
+	var storeManifests []AICommandStoreManifest
+
+	for _, source := range s.getStoreSources(ctx) {
+		manifests, manifestErr := s.GetStoreManifest(ctx, source, readManifests)
+		if manifestErr != nil {
+			util.GetLogger().Error(ctx, fmt.Sprintf("failed to get ai command manifest from %s store: %s", source.Name, manifestErr.Error()))
+			continue
+		}
+
+		for _, manifest := range manifests {
+			_, existIndex, found := lo.FindIndexOf(storeManifests, func(item AICommandStoreManifest) bool {
+				return item.Command == manifest.Command
+			})
+			if found {
+				// keep the newer one if the same command exists in multiple stores
+				if isNewerAICommandVersion(manifest.GetVersion(), storeManifests[existIndex].GetVersion()) {
+					storeManifests[existIndex] = manifest
+				}
+				continue
+			}
+
+			storeManifests = append(storeManifests, manifest)
+		}
+	}
+
+	util.GetLogger().Info(ctx, fmt.Sprintf("found %d ai commands from stores", len(storeManifests)))
+	return storeManifests
+}
+
+func (s *AICommandStore) GetStoreManifest(ctx context.Context, source aiCommandStoreSource, readManifests util.StoreManifestReader) ([]AICommandStoreManifest, error) {
+	util.GetLogger().Info(ctx, fmt.Sprintf("start to get ai command manifest from %s(%s)", source.Name, source.Url))
+
+	rawManifests, readErr := readManifests(ctx, source.Url)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	var manifests []AICommandStoreManifest
+	for _, rawManifest := range rawManifests {
+		var manifest AICommandStoreManifest
+		if unmarshalErr := json.Unmarshal(rawManifest, &manifest); unmarshalErr != nil {
+			return nil, unmarshalErr
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	return lo.Filter(manifests, func(manifest AICommandStoreManifest, _ int) bool {
+		if manifest.Command == "" || manifest.Prompt == "" {
+			util.GetLogger().Warn(ctx, fmt.Sprintf("skip invalid ai command %s from %s store, command and prompt are required", manifest.Name, source.Name))
+			return false
+		}
+		return true
+	}), nil
+}
+
+func (s *AICommandStore) Search(ctx context.Context, keyword string) []AICommandStoreManifest {
+	return lo.Filter(s.getManifests(), func(manifest AICommandStoreManifest, _ int) bool {
+		if keyword == "" {
+			return true
+		}
+
+		return util.IsStringMatch(manifest.Name, keyword, false) || util.IsStringMatch(manifest.Command, keyword, false)
+	})
+}
+
+// Install adds the ai command into the commands table setting of AI Commands plugin,
+// if the command is already installed, it will be updated and the model and output user selected will be kept
+func (s *AICommandStore) Install(ctx context.Context, api plugin.API, manifest AICommandStoreManifest) error {
+	util.GetLogger().Info(ctx, fmt.Sprintf("start to install ai command %s(%s)", manifest.Command, manifest.GetVersion()))
+
+	commands, commandsErr := getAICommandSettings(ctx, api)
+	if commandsErr != nil {
+		return commandsErr
+	}
+
+	newCommands, installErr := installAICommand(commands, manifest)
+	if installErr != nil {
+		return installErr
+	}
+
+	return saveAICommandSettings(ctx, api, newCommands)
+}
+
+func (s *AICommandStore) Uninstall(ctx context.Context, api plugin.API, command string) error {
+	util.GetLogger().Info(ctx, fmt.Sprintf("uninstalling ai command: %s", command))
+
+	commands, commandsErr := getAICommandSettings(ctx, api)
+	if commandsErr != nil {
+		return commandsErr
+	}
+	installed, found := lo.Find(commands, func(item commandSetting) bool { return item.Command == command })
+	if !found {
+		return fmt.Errorf("ai command %s is not installed", command)
+	}
+	if !installed.isFromStore() {
+		return fmt.Errorf("ai command %s is created by user, it can only be removed in plugin settings", command)
+	}
+
+	return saveAICommandSettings(ctx, api, lo.Filter(commands, func(item commandSetting, _ int) bool {
+		return item.Command != command
+	}))
+}
+
+// GetUpdatableManifests returns store manifests which have newer version than the installed commands
+func (s *AICommandStore) GetUpdatableManifests(ctx context.Context, api plugin.API) []AICommandStoreManifest {
+	commands, commandsErr := getAICommandSettings(ctx, api)
+	if commandsErr != nil {
+		return nil
+	}
+
+	return getUpdatableAICommands(commands, s.getManifests())
+}
+
+// getInstalled returns the installed command which has the same command keyword as the manifest, it may be created by user
+func (s *AICommandStore) getInstalled(ctx context.Context, api plugin.API, manifest AICommandStoreManifest) (commandSetting, bool) {
+	commands, commandsErr := getAICommandSettings(ctx, api)
+	if commandsErr != nil {
+		return commandSetting{}, false
+	}
+
+	return lo.Find(commands, func(item commandSetting) bool {
+		return item.Command == manifest.Command
+	})
+}
+
+// isFromStore returns true if the command is installed from store, commands created by user don't have version
+func (c *commandSetting) isFromStore() bool {
+	return c.Version != ""
+}
+
+func installAICommand(commands []commandSetting, manifest AICommandStoreManifest) ([]commandSetting, error) {
+	newCommand := commandSetting{
+		Name:    manifest.Name,
+		Command: manifest.Command,
+		Prompt:  manifest.Prompt,
+		Vision:  manifest.Vision,
+		Author:  manifest.Author,
+		Version: manifest.GetVersion(),
+	}
+
+	for i, command := range commands {
+		if command.Command == manifest.Command {
+			// never overwrite prompts written by user
+			if !command.isFromStore() {
+				return nil, fmt.Errorf("ai command %s is already created by user, rename it before installing from store", manifest.Command)
+			}
+			newCommand.Model = command.Model
+			newCommand.Output = command.Output
+			newCommand.OutputTarget = command.OutputTarget
+			newCommand.DiffPreview = command.DiffPreview
+			commands[i] = newCommand
+			return commands, nil
+		}
+	}
+
+	return append(commands, newCommand), nil
+}
+
+func getUpdatableAICommands(commands []commandSetting, manifests []AICommandStoreManifest) []AICommandStoreManifest {
+	return lo.Filter(manifests, func(manifest AICommandStoreManifest, _ int) bool {
+		installed, found := lo.Find(commands, func(item commandSetting) bool {
+			return item.Command == manifest.Command
+		})
+		// commands created by user should not be overwritten by store
+		if !found || !installed.isFromStore() {
+			return false
+		}
+
+		return isNewerAICommandVersion(manifest.GetVersion(), installed.Version)
+	})
+}
+
+func isNewerAICommandVersion(version string, than string) bool {
+	v, vErr := semver.NewVersion(version)
+	t, tErr := semver.NewVersion(than)
+	if vErr != nil || tErr != nil {
+		return false
+	}
+	return v.GreaterThan(t)
+}
+
+func getAICommandSettings(ctx context.Context, api plugin.API) (commands []commandSetting, err error) {
+	commandSettings := api.GetSetting(ctx, "commands")
+	if commandSettings == "" {
+		return nil, nil
+	}
+
+	err = json.Unmarshal([]byte(commandSettings), &commands)
+	return
+}
+
+func saveAICommandSettings(ctx context.Context, api plugin.API, commands []commandSetting) error {
+	commandsJson, marshalErr := json.Marshal(commands)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	api.SaveSetting(ctx, "commands", string(commandsJson), false)
+	return nil
+}
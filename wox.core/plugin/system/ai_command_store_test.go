@@ -0,0 +1,61 @@
+package system
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestAICommandStoreLocalSource(t *testing.T) {
+	storePath := path.Join(t.TempDir(), "store-ai-command.json")
+	assert.Nil(t, os.WriteFile(storePath, []byte(`[
+		{"Name": "Translate", "Command": "translate", "Prompt": "translate %s", "Version": "1.0.0"},
+		{"Name": "Translate", "Command": "translate", "Prompt": "translate %s better", "Version": "1.1.0"},
+		{"Name": "Invalid", "Command": "invalid"}
+	]`), 0644))
+
+	store := &AICommandStore{sources: []aiCommandStoreSource{{Name: "test", Url: "file://" + storePath}}}
+	manifests := store.GetStoreManifests(context.Background())
+	assert.Len(t, manifests, 1)
+	assert.Equal(t, "1.1.0", manifests[0].Version)
+
+	store.manifests = manifests
+	assert.Len(t, store.Search(context.Background(), "trans"), 1)
+	assert.Len(t, store.Search(context.Background(), "summary"), 0)
+}
+
+func TestAICommandStoreInstallAndUpdate(t *testing.T) {
+	userCommand := commandSetting{Name: "Mine", Command: "mine", Prompt: "%s"}
+	manifest := AICommandStoreManifest{Name: "Translate", Command: "translate", Prompt: "translate %s"}
+
+	commands, installErr := installAICommand([]commandSetting{userCommand}, manifest)
+	assert.Nil(t, installErr)
+	assert.Len(t, commands, 2)
+	assert.Equal(t, aiCommandDefaultVersion, commands[1].Version)
+
+	// user selected model should be kept after update
+	commands[1].Model = `{"Name":"gpt-4o","Provider":"openai"}`
+	newManifest := AICommandStoreManifest{Name: "Translate", Command: "translate", Prompt: "translate %s better", Version: "1.1.0"}
+	assert.Len(t, getUpdatableAICommands(commands, []AICommandStoreManifest{manifest, newManifest}), 1)
+
+	commands, installErr = installAICommand(commands, newManifest)
+	assert.Nil(t, installErr)
+	assert.Len(t, commands, 2)
+	assert.Equal(t, "translate %s better", commands[1].Prompt)
+	assert.Equal(t, `{"Name":"gpt-4o","Provider":"openai"}`, commands[1].Model)
+	assert.Len(t, getUpdatableAICommands(commands, []AICommandStoreManifest{newManifest}), 0)
+
+	// commands created by user are never updated by store
+	assert.Len(t, getUpdatableAICommands(commands, []AICommandStoreManifest{{Command: "mine", Prompt: "x", Version: "9.0.0"}}), 0)
+}
+
+func TestAICommandStoreInstallConflict(t *testing.T) {
+	userCommand := commandSetting{Name: "My Translate", Command: "translate", Prompt: "my own prompt %s"}
+	manifest := AICommandStoreManifest{Name: "Translate", Command: "translate", Prompt: "translate %s"}
+
+	_, installErr := installAICommand([]commandSetting{userCommand}, manifest)
+	assert.NotNil(t, installErr)
+	assert.False(t, userCommand.isFromStore())
+}
@@ -0,0 +1,31 @@
+package calculator
+
+import (
+	"testing"
+	"wox/plugin"
+	"wox/plugin/plugintest"
+	"wox/share"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculatorPlugin(t *testing.T) {
+	h := plugintest.NewSystemPlugin(t, &CalculatorPlugin{})
+
+	assert.Equal(t, []string{"7"}, h.Query("1+2*3").Titles())
+	assert.Equal(t, []string{"9"}, h.Query("(1+2)*3").Titles())
+	// global query without operators is not calculated
+	assert.Empty(t, h.Query("42").Titles())
+	assert.Empty(t, h.Query("1+").Titles())
+
+	assert.Equal(t, []string{"Input expression to calculate"}, h.Query("calculator ").Titles())
+
+	// copied results are kept in histories, which can be recalculated
+	h.Query("sqrt(16)+1").Find("5").ExecuteAction("Copy result")
+	history := h.Query("calculator sqrt").Find("sqrt(16)+1")
+	assert.Equal(t, "5", history.SubTitle)
+	assert.Equal(t, []string{"Copy result", "Recalculate"}, history.ActionNames())
+
+	history.ExecuteAction("Recalculate")
+	assert.Equal(t, []share.PlainQuery{{QueryType: plugin.QueryTypeInput, QueryText: "sqrt(16)+1"}}, h.UI.ChangedQueries())
+}
@@ -80,7 +80,7 @@ func (i *PluginInstallerPlugin) queryForSelectionFile(ctx context.Context, fileP
 				PreventHideAfterAction: true,
 				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
 					i.api.Notify(ctx, fmt.Sprintf("Installing plugin: %s", pluginMetadata.Name))
-					installErr := plugin.GetStoreManager().InstallFromLocal(ctx, filePath)
+					installErr := plugin.GetStoreManager().InstallFromLocal(ctx, filePath, pluginMetadata.Permissions)
 					if installErr != nil {
 						i.api.Notify(ctx, fmt.Sprintf("Failed to install plugin: %s", installErr.Error()))
 					} else {
@@ -108,7 +108,7 @@ func (i *PluginInstallerPlugin) queryForSelectionFile(ctx context.Context, fileP
 - **Plugin ID**: %s
 
 ## Features
-%s`,
+%s%s`,
 				pluginMetadata.Name,
 				pluginMetadata.Version,
 				pluginMetadata.Author,
@@ -129,6 +129,13 @@ func (i *PluginInstallerPlugin) queryForSelectionFile(ctx context.Context, fileP
 					}
 					return strings.Join(features, "\n")
 				}(),
+				func() string {
+					// only sandboxed plugins are restricted by permissions, installing means granting them
+					if plugin.ConvertToRuntime(pluginMetadata.Runtime) != plugin.PLUGIN_RUNTIME_WASM {
+						return ""
+					}
+					return fmt.Sprintf("\n\n## Permissions\n%s", plugin.FormatPermissions(ctx, pluginMetadata.Permissions))
+				}(),
 			),
 		},
 		Score: 2000,
@@ -119,6 +119,7 @@ func createLLMOnRefreshHandler(ctx context.Context,
 	var locker sync.Locker = &sync.Mutex{}
 	var chatStreamDataTypeBuffer ai.ChatStreamDataType
 	var responseBuffer string
+	var isRestarted bool
 	return func(ctx context.Context, current plugin.RefreshableResult) plugin.RefreshableResult {
 		if !shouldStartAnswering() {
 			return current
@@ -139,6 +140,10 @@ func createLLMOnRefreshHandler(ctx context.Context,
 				if chatStreamDataType == ai.ChatStreamTypeError {
 					responseBuffer = response
 				}
+				if chatStreamDataType == ai.ChatStreamTypeRestarted {
+					responseBuffer = ""
+					isRestarted = true
+				}
 				util.GetLogger().Info(ctx, fmt.Sprintf("stream buffered: %s", responseBuffer))
 				locker.Unlock()
 			})
@@ -148,6 +153,14 @@ func createLLMOnRefreshHandler(ctx context.Context,
 			}
 		}
 
+		// answer shown so far is replaced by the restarted answer
+		locker.Lock()
+		if isRestarted {
+			isRestarted = false
+			current.Preview.PreviewData = ""
+		}
+		locker.Unlock()
+
 		if chatStreamDataTypeBuffer == ai.ChatStreamTypeFinished {
 			util.GetLogger().Info(ctx, "stream finished")
 			locker.Lock()
@@ -225,15 +238,7 @@ func getPasteToActiveWindowAction(ctx context.Context, api plugin.API, actionCal
 					if actionCallback != nil {
 						actionCallback()
 					}
-					util.Go(ctx, "ai command paste", func() {
-						time.Sleep(time.Millisecond * 150)
-						err := keyboard.SimulatePaste()
-						if err != nil {
-							api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("simulate paste clipboard failed, err=%s", err.Error()))
-						} else {
-							api.Log(ctx, plugin.LogLevelInfo, "simulate paste clipboard success")
-						}
-					})
+					simulatePasteToActiveWindow(ctx, api)
 				},
 			}, nil
 		}
@@ -241,3 +246,16 @@ func getPasteToActiveWindowAction(ctx context.Context, api plugin.API, actionCal
 
 	return plugin.QueryResultAction{}, fmt.Errorf("no active window")
 }
+
+// simulatePasteToActiveWindow pastes clipboard into the active window after wox is hidden
+func simulatePasteToActiveWindow(ctx context.Context, api plugin.API) {
+	util.Go(ctx, "ai command paste", func() {
+		time.Sleep(time.Millisecond * 150)
+		err := keyboard.SimulatePaste()
+		if err != nil {
+			api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("simulate paste clipboard failed, err=%s", err.Error()))
+		} else {
+			api.Log(ctx, plugin.LogLevelInfo, "simulate paste clipboard success")
+		}
+	})
+}
@@ -329,8 +329,11 @@ func (r *WebSearchPlugin) querySelection(ctx context.Context, query plugin.Query
 }
 
 func (r *WebSearchPlugin) replaceVariables(ctx context.Context, text string, query string) string {
-	result := strings.ReplaceAll(text, "{query}", query)
-	result = strings.ReplaceAll(result, "{lower_query}", strings.ToLower(query))
-	result = strings.ReplaceAll(result, "{upper_query}", strings.ToUpper(query))
-	return result
+	return plugin.GetPluginManager().RenderQueryTemplate(ctx, text, plugin.QueryVariableContext{
+		Query: query,
+		Extra: map[string]func() string{
+			"lower_query": func() string { return strings.ToLower(query) },
+			"upper_query": func() string { return strings.ToUpper(query) },
+		},
+	})
 }
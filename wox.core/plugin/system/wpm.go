@@ -13,6 +13,7 @@ import (
 	"wox/setting/definition"
 	"wox/share"
 	"wox/util"
+	"wox/util/clipboard"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
@@ -88,10 +89,22 @@ func (w *WPMPlugin) GetMetadata() plugin.Metadata {
 				Command:     "uninstall",
 				Description: "i18n:plugin_wpm_command_uninstall",
 			},
+			{
+				Command:     "update",
+				Description: "i18n:plugin_wpm_command_update",
+			},
 			{
 				Command:     "create",
 				Description: "i18n:plugin_wpm_command_create",
 			},
+			{
+				Command:     "lint",
+				Description: "i18n:plugin_wpm_command_lint",
+			},
+			{
+				Command:     "pack",
+				Description: "i18n:plugin_wpm_command_pack",
+			},
 			{
 				Command:     "dev.list",
 				Description: "i18n:plugin_wpm_command_dev_list",
@@ -237,6 +250,7 @@ func (w *WPMPlugin) loadDevPlugin(ctx context.Context, pluginDirectory string) {
 func (w *WPMPlugin) parseMetadata(ctx context.Context, directory string) (plugin.MetadataWithDirectory, error) {
 	// parse plugin.json in directory
 	metadata, metadataErr := plugin.GetPluginManager().ParseMetadata(ctx, directory)
+	plugin.GetPluginManager().UpdateLoadError(ctx, directory, metadataErr)
 	if metadataErr != nil {
 		return plugin.MetadataWithDirectory{}, fmt.Errorf("failed to parse plugin.json in %s: %s", directory, metadataErr.Error())
 	}
@@ -259,6 +273,18 @@ func (w *WPMPlugin) Query(ctx context.Context, query plugin.Query) []plugin.Quer
 		return w.uninstallCommand(ctx, query)
 	}
 
+	if query.Command == "update" {
+		return w.updateCommand(ctx, query)
+	}
+
+	if query.Command == "lint" {
+		return w.lintCommand(ctx, query)
+	}
+
+	if query.Command == "pack" {
+		return w.packCommand(ctx, query)
+	}
+
 	if query.Command == "dev.add" {
 		return w.addDevCommand(ctx, query)
 	}
@@ -275,6 +301,10 @@ func (w *WPMPlugin) Query(ctx context.Context, query plugin.Query) []plugin.Quer
 		return w.listDevCommand(ctx)
 	}
 
+	if query.Command == "" && query.Search == "" {
+		return w.updatesAvailableCommand(ctx, query)
+	}
+
 	return []plugin.QueryResult{}
 }
 
@@ -360,18 +390,168 @@ func (w *WPMPlugin) uninstallCommand(ctx context.Context, query plugin.Query) []
 	return results
 }
 
+func (w *WPMPlugin) updatesAvailableCommand(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	updates := plugin.GetStoreManager().GetUpdates(ctx)
+	if len(updates) == 0 {
+		return []plugin.QueryResult{}
+	}
+
+	return []plugin.QueryResult{
+		{
+			Id:       uuid.NewString(),
+			Title:    fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_updates_available"), len(updates)),
+			SubTitle: strings.Join(lo.Map(updates, func(update plugin.PluginUpdate, _ int) string { return update.Manifest.Name }), ", "),
+			Icon:     wpmIcon,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name:                   "i18n:plugin_wpm_show_updates",
+					PreventHideAfterAction: true,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						w.api.ChangeQuery(ctx, share.PlainQuery{
+							QueryType: plugin.QueryTypeInput,
+							QueryText: fmt.Sprintf("%s update ", query.TriggerKeyword),
+						})
+					},
+				},
+			},
+		},
+	}
+}
+
+func (w *WPMPlugin) updateCommand(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	var results []plugin.QueryResult
+	plugins := lo.Filter(plugin.GetPluginManager().GetPluginInstances(), func(pluginInstance *plugin.Instance, _ int) bool {
+		return !pluginInstance.IsSystemPlugin && !pluginInstance.IsDevPlugin
+	})
+	if query.Search != "" {
+		plugins = lo.Filter(plugins, func(pluginInstance *plugin.Instance, _ int) bool {
+			return IsStringMatchNoPinYin(ctx, pluginInstance.Metadata.Name, query.Search)
+		})
+	}
+
+	for _, pluginInstanceShadow := range plugins {
+		// action will be executed in another go routine, so we need to copy the variable
+		pluginInstance := pluginInstanceShadow
+
+		updateManifest, hasUpdate := plugin.GetStoreManager().GetUpdate(ctx, pluginInstance)
+		rollbackVersion, hasRollback := plugin.GetStoreManager().GetRollbackVersion(ctx, pluginInstance)
+		if !hasUpdate && !hasRollback {
+			continue
+		}
+
+		var actions []plugin.QueryResultAction
+		subTitle := fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_rollback_available"), pluginInstance.Metadata.Version, rollbackVersion)
+		if hasUpdate {
+			subTitle = fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_update_available"), pluginInstance.Metadata.Version, updateManifest.Version)
+			actions = append(actions, plugin.QueryResultAction{
+				Name: "i18n:plugin_wpm_update",
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					updateErr := plugin.GetStoreManager().Update(ctx, pluginInstance)
+					if updateErr != nil {
+						w.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_update_failed"), updateErr.Error()))
+					}
+				},
+			})
+		}
+		if hasRollback {
+			actions = append(actions, plugin.QueryResultAction{
+				Name: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_rollback"), rollbackVersion),
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					rollbackErr := plugin.GetStoreManager().Rollback(ctx, pluginInstance)
+					if rollbackErr != nil {
+						w.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_rollback_failed"), rollbackErr.Error()))
+					}
+				},
+			})
+		}
+
+		autoUpdateActionName := "i18n:plugin_wpm_enable_auto_update"
+		if pluginInstance.Setting.AutoUpdate {
+			autoUpdateActionName = "i18n:plugin_wpm_disable_auto_update"
+		}
+		actions = append(actions, plugin.QueryResultAction{
+			Name:                   autoUpdateActionName,
+			PreventHideAfterAction: true,
+			Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+				pluginInstance.Setting.AutoUpdate = !pluginInstance.Setting.AutoUpdate
+				pluginInstance.SaveSetting(ctx)
+				w.api.ChangeQuery(ctx, share.PlainQuery{
+					QueryType: plugin.QueryTypeInput,
+					QueryText: fmt.Sprintf("%s update %s", query.TriggerKeyword, query.Search),
+				})
+			},
+		})
+
+		icon := plugin.ParseWoxImageOrDefault(pluginInstance.Metadata.Icon, wpmIcon)
+		icon = plugin.ConvertRelativePathToAbsolutePath(ctx, icon, pluginInstance.PluginDirectory)
+		results = append(results, plugin.QueryResult{
+			Id:       uuid.NewString(),
+			Title:    pluginInstance.Metadata.Name,
+			SubTitle: subTitle,
+			Icon:     icon,
+			Actions:  actions,
+		})
+	}
+
+	if len(results) == 0 {
+		results = append(results, plugin.QueryResult{
+			Id:    uuid.NewString(),
+			Title: "i18n:plugin_wpm_up_to_date",
+			Icon:  wpmIcon,
+		})
+	}
+	return results
+}
+
 func (w *WPMPlugin) installCommand(ctx context.Context, query plugin.Query) []plugin.QueryResult {
 	var results []plugin.QueryResult
 	pluginManifests := plugin.GetStoreManager().Search(ctx, query.Search)
-	for _, pluginManifest := range pluginManifests {
+	for _, latestManifest := range pluginManifests {
+		// install newest compatible version by default, latest version may require a newer wox or other os
+		compatibleVersions := latestManifest.GetCompatibleVersions(ctx)
+		pluginManifest := latestManifest
+		if len(compatibleVersions) > 0 {
+			pluginManifest = compatibleVersions[0]
+		}
+
 		screenShotsMarkdown := lo.Map(pluginManifest.ScreenshotUrls, func(screenshot string, _ int) string {
 			return fmt.Sprintf("![screenshot](%s)", screenshot)
 		})
 
+		// sandboxed plugins can only access resources granted by user, installing means granting the permissions
+		installActionName := "i18n:plugin_wpm_install"
+		permissionsMarkdown := ""
+		if !pluginManifest.Permissions.IsEmpty() {
+			installActionName = "i18n:plugin_wpm_install_and_grant"
+			permissionsMarkdown = fmt.Sprintf("\n### %s\n\n%s\n", i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_permissions"), plugin.FormatPermissions(ctx, pluginManifest.Permissions))
+		}
+
+		subTitle := pluginManifest.Description
+		var actions []plugin.QueryResultAction
+		if len(compatibleVersions) == 0 {
+			subTitle = fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_incompatible"), latestManifest.CheckCompatibility(ctx).Error())
+		}
+		for i, compatibleVersion := range compatibleVersions {
+			installManifest := compatibleVersion
+			actionName := installActionName
+			if i > 0 {
+				actionName = fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_install_version"), installManifest.Version)
+			}
+			actions = append(actions, plugin.QueryResultAction{
+				Name: actionName,
+				Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+					installErr := plugin.GetStoreManager().Install(ctx, installManifest)
+					if installErr != nil {
+						w.api.Notify(ctx, "i18n:plugin_wpm_install_failed")
+					}
+				},
+			})
+		}
+
 		results = append(results, plugin.QueryResult{
 			Id:       uuid.NewString(),
 			Title:    pluginManifest.Name,
-			SubTitle: pluginManifest.Description,
+			SubTitle: subTitle,
 			Icon:     plugin.NewWoxImageUrl(pluginManifest.IconUrl),
 			Preview: plugin.WoxPreview{
 				PreviewType: plugin.WoxPreviewTypeMarkdown,
@@ -384,29 +564,152 @@ func (w *WPMPlugin) installCommand(ctx context.Context, query plugin.Query) []pl
 
 %s
 
+%s
 ### Screenshots
 
 %s
-`, pluginManifest.Description, pluginManifest.Website, strings.Join(screenShotsMarkdown, "\n")),
+`, pluginManifest.Description, pluginManifest.Website, permissionsMarkdown, strings.Join(screenShotsMarkdown, "\n")),
 				PreviewProperties: map[string]string{
 					"Author":  pluginManifest.Author,
 					"Version": pluginManifest.Version,
 					"Website": pluginManifest.Website,
 				},
 			},
+			Actions: actions,
+		})
+	}
+	return results
+}
+
+// getPackDirectory returns directory to lint or pack, plugins built into dist directory (E.g. nodejs plugins) are packed from there
+func (w *WPMPlugin) getPackDirectory(directory string) string {
+	distDirectory := path.Join(directory, "dist")
+	if _, statErr := os.Stat(path.Join(distDirectory, "plugin.json")); statErr == nil {
+		return distDirectory
+	}
+	return directory
+}
+
+// chooseLocalPluginResults lists local plugins for commands which need a plugin directory, e.g. wpm lint
+func (w *WPMPlugin) chooseLocalPluginResults(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	if len(w.localPlugins) == 0 {
+		return []plugin.QueryResult{
+			{
+				Id:    uuid.NewString(),
+				Title: "i18n:plugin_wpm_input_plugin_directory",
+				Icon:  wpmIcon,
+			},
+		}
+	}
+
+	return lo.Map(w.localPlugins, func(lp localPlugin, _ int) plugin.QueryResult {
+		iconImage := plugin.ParseWoxImageOrDefault(lp.metadata.Metadata.Icon, wpmIcon)
+		iconImage = plugin.ConvertIcon(ctx, iconImage, lp.metadata.Directory)
+
+		return plugin.QueryResult{
+			Id:       uuid.NewString(),
+			Title:    lp.metadata.Metadata.Name,
+			SubTitle: lp.metadata.Directory,
+			Icon:     iconImage,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name:                   "i18n:plugin_wpm_choose",
+					PreventHideAfterAction: true,
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						w.api.ChangeQuery(ctx, share.PlainQuery{
+							QueryType: plugin.QueryTypeInput,
+							QueryText: fmt.Sprintf("%s %s %s", query.TriggerKeyword, query.Command, lp.metadata.Directory),
+						})
+					},
+				},
+			},
+		}
+	})
+}
+
+func (w *WPMPlugin) lintProblemResults(ctx context.Context, problems []plugin.LintProblem) []plugin.QueryResult {
+	return lo.Map(problems, func(problem plugin.LintProblem, _ int) plugin.QueryResult {
+		icon := wpmIcon
+		if problem.Severity == plugin.LintSeverityError {
+			icon = plugin.ErrorIcon
+		}
+		return plugin.QueryResult{
+			Id:       uuid.NewString(),
+			Title:    problem.Message,
+			SubTitle: fmt.Sprintf("%s %s", i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_lint_"+problem.Severity), problem.Field),
+			Icon:     icon,
+			Actions: []plugin.QueryResultAction{
+				{
+					Name: "i18n:plugin_wpm_copy",
+					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
+						clipboard.WriteText(problem.String())
+					},
+				},
+			},
+		}
+	})
+}
+
+func (w *WPMPlugin) lintCommand(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	if query.Search == "" {
+		return w.chooseLocalPluginResults(ctx, query)
+	}
+
+	problems := plugin.LintPlugin(ctx, w.getPackDirectory(query.Search))
+	if len(problems) == 0 {
+		return []plugin.QueryResult{
+			{
+				Id:       uuid.NewString(),
+				Title:    "i18n:plugin_wpm_lint_no_problems",
+				SubTitle: query.Search,
+				Icon:     plugin.CorrectIcon,
+			},
+		}
+	}
+	return w.lintProblemResults(ctx, problems)
+}
+
+func (w *WPMPlugin) packCommand(ctx context.Context, query plugin.Query) []plugin.QueryResult {
+	if query.Search == "" {
+		return w.chooseLocalPluginResults(ctx, query)
+	}
+
+	pluginDirectory := w.getPackDirectory(query.Search)
+	problems := plugin.LintPlugin(ctx, pluginDirectory)
+	if plugin.HasLintErrors(problems) {
+		return w.lintProblemResults(ctx, problems)
+	}
+
+	// package is written next to plugin directory, so it's not packed into the next version
+	outputDirectory := path.Dir(pluginDirectory)
+	results := []plugin.QueryResult{
+		{
+			Id:       uuid.NewString(),
+			Title:    "i18n:plugin_wpm_pack",
+			SubTitle: fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_pack_to"), outputDirectory),
+			Icon:     wpmIcon,
 			Actions: []plugin.QueryResultAction{
 				{
-					Name: "i18n:plugin_wpm_install",
+					Name: "i18n:plugin_wpm_pack",
 					Action: func(ctx context.Context, actionContext plugin.ActionContext) {
-						installErr := plugin.GetStoreManager().Install(ctx, pluginManifest)
-						if installErr != nil {
-							w.api.Notify(ctx, "i18n:plugin_wpm_install_failed")
+						result, packErr := plugin.PackPlugin(ctx, pluginDirectory, outputDirectory)
+						if packErr != nil {
+							w.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_pack_failed"), packErr.Error()))
+							return
+						}
+
+						// authors paste the manifest into store after uploading the package
+						clipboard.WriteText(string(result.Manifest))
+						w.api.Notify(ctx, fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_wpm_pack_success"), result.PackagePath))
+						if openErr := util.ShellOpen(outputDirectory); openErr != nil {
+							w.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to open output directory: %s", openErr.Error()))
 						}
 					},
 				},
-			}})
+			},
+		},
 	}
-	return results
+	return append(results, w.lintProblemResults(ctx, problems)...)
 }
 
 func (w *WPMPlugin) listDevCommand(ctx context.Context) []plugin.QueryResult {
@@ -681,6 +984,14 @@ func (w *WPMPlugin) reloadLocalDistPlugin(ctx context.Context, localPlugin plugi
 	distPluginMetadata.IsDev = true
 	distPluginMetadata.DevPluginDirectory = localPlugin.Directory
 
+	// developer trusts the plugin under development, grant what it requests so changed permissions take effect immediately
+	if plugin.ConvertToRuntime(distPluginMetadata.Metadata.Runtime) == plugin.PLUGIN_RUNTIME_WASM {
+		if grantErr := plugin.GetPluginManager().GrantPermissions(ctx, distPluginMetadata.Metadata); grantErr != nil {
+			w.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to grant permissions: %s", grantErr.Error()))
+			return grantErr
+		}
+	}
+
 	reloadErr := plugin.GetPluginManager().ReloadPlugin(ctx, distPluginMetadata)
 	if reloadErr != nil {
 		w.api.Log(ctx, plugin.LogLevelError, fmt.Sprintf("Failed to reload plugin: %s", reloadErr.Error()))
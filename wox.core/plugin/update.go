@@ -0,0 +1,243 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"wox/i18n"
+	"wox/setting"
+	"wox/share"
+	"wox/util"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/samber/lo"
+)
+
+// PluginUpdate is a newer version of an installed plugin found in stores
+type PluginUpdate struct {
+	Instance *Instance
+	Manifest StorePluginManifest // manifest of the newest compatible version
+}
+
+// GetUpdate returns manifest of the newest compatible store version if it's newer than the installed plugin.
+// System plugins, dev plugins and script plugins are not installed from store, so they never have updates
+func (s *Store) GetUpdate(ctx context.Context, instance *Instance) (StorePluginManifest, bool) {
+	if instance.IsSystemPlugin || instance.IsDevPlugin || instance.Metadata.Runtime == string(PLUGIN_RUNTIME_SCRIPT) {
+		return StorePluginManifest{}, false
+	}
+
+	storeManifest, found := s.getInstalledStoreManifest(instance)
+	if !found {
+		return StorePluginManifest{}, false
+	}
+	installedVersion, installedErr := semver.NewVersion(instance.Metadata.Version)
+	if installedErr != nil {
+		return StorePluginManifest{}, false
+	}
+
+	compatibleVersions := storeManifest.GetCompatibleVersions(ctx)
+	if len(compatibleVersions) == 0 {
+		return StorePluginManifest{}, false
+	}
+	newestVersion, newestErr := semver.NewVersion(compatibleVersions[0].Version)
+	if newestErr != nil || !newestVersion.GreaterThan(installedVersion) {
+		return StorePluginManifest{}, false
+	}
+
+	return compatibleVersions[0], true
+}
+
+// getInstalledStoreManifest returns manifest of plugin from the store it's installed from. Stores with lower priority may have
+// newer versions of the same plugin, but they must not replace a plugin installed from another store.
+// Plugins without installed store use the store with the highest priority
+func (s *Store) getInstalledStoreManifest(instance *Instance) (StorePluginManifest, bool) {
+	installedStore := ""
+	if instance.Setting != nil {
+		installedStore = instance.Setting.InstalledStore
+	}
+
+	return lo.Find(s.getLoadedStoreManifests(), func(manifest StorePluginManifest) bool {
+		return manifest.Id == instance.Metadata.Id && (installedStore == "" || manifest.Store == installedStore)
+	})
+}
+
+// saveInstalledStore records the store plugin is installed from, so it only gets updates from the same store
+func (s *Store) saveInstalledStore(ctx context.Context, metadata Metadata, storeName string) error {
+	pluginSetting, loadErr := setting.GetSettingManager().LoadPluginSetting(ctx, metadata.Id, metadata.Name, metadata.SettingDefinitions)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	pluginSetting.InstalledStore = storeName
+	for _, instance := range GetPluginManager().GetPluginInstances() {
+		if instance.Metadata.Id == metadata.Id && instance.Setting != nil {
+			instance.Setting.InstalledStore = storeName
+		}
+	}
+	return setting.GetSettingManager().SavePluginSetting(ctx, metadata.Id, pluginSetting)
+}
+
+// GetUpdates returns installed plugins which have newer versions in stores
+func (s *Store) GetUpdates(ctx context.Context) []PluginUpdate {
+	var updates []PluginUpdate
+	for _, instance := range GetPluginManager().GetPluginInstances() {
+		if manifest, found := s.GetUpdate(ctx, instance); found {
+			updates = append(updates, PluginUpdate{Instance: instance, Manifest: manifest})
+		}
+	}
+	return updates
+}
+
+// Update installs the newest compatible version of plugin, the installed version is kept for rollback
+func (s *Store) Update(ctx context.Context, instance *Instance) error {
+	manifest, found := s.GetUpdate(ctx, instance)
+	if !found {
+		return fmt.Errorf("plugin %s(%s) is up to date", instance.Metadata.Name, instance.Metadata.Version)
+	}
+
+	return s.Install(ctx, manifest)
+}
+
+// isAutoUpdateAllowed returns false if new version requests permissions user hasn't granted to the installed version
+func (s *Store) isAutoUpdateAllowed(update PluginUpdate) bool {
+	if update.Instance.Setting == nil || !update.Instance.Setting.AutoUpdate {
+		return false
+	}
+	if update.Manifest.Runtime != PLUGIN_RUNTIME_WASM || update.Manifest.Permissions.IsEmpty() {
+		return true
+	}
+	if update.Instance.Setting.GrantedPermissions == nil {
+		return false
+	}
+	return IsPermissionsCovered(update.Manifest.Permissions, *update.Instance.Setting.GrantedPermissions)
+}
+
+// checkUpdates installs updates of plugins with auto update enabled, other updates are notified to user once per version
+func (s *Store) checkUpdates(ctx context.Context) {
+	var notifyUpdates []string
+	for _, update := range s.GetUpdates(ctx) {
+		if s.isAutoUpdateAllowed(update) {
+			logger.Info(ctx, fmt.Sprintf("auto update plugin %s from %s to %s", update.Instance.Metadata.Name, update.Instance.Metadata.Version, update.Manifest.Version))
+			installErr := s.Install(ctx, update.Manifest)
+			if installErr == nil {
+				continue
+			}
+			logger.Error(ctx, fmt.Sprintf("failed to auto update plugin %s: %s", update.Instance.Metadata.Name, installErr.Error()))
+		}
+
+		notifyKey := fmt.Sprintf("%s@%s", update.Manifest.Id, update.Manifest.Version)
+		if s.notifiedUpdates.Exist(notifyKey) {
+			continue
+		}
+		s.notifiedUpdates.Store(notifyKey, true)
+		notifyUpdates = append(notifyUpdates, fmt.Sprintf("%s(%s)", update.Manifest.Name, update.Manifest.Version))
+	}
+
+	if len(notifyUpdates) > 0 {
+		logger.Info(ctx, fmt.Sprintf("found plugin updates: %s", strings.Join(notifyUpdates, ", ")))
+		GetPluginManager().GetUI().Notify(ctx, share.NotifyMsg{
+			Text:           fmt.Sprintf(i18n.GetI18nManager().TranslateWox(ctx, "plugin_store_updates_available"), strings.Join(notifyUpdates, ", ")),
+			DisplaySeconds: 5,
+		})
+	}
+}
+
+// getPluginBackupDirectory returns directory of the previous version kept by the last upgrade
+func getPluginBackupDirectory(id string) (string, bool) {
+	backupRoot := getPluginBackupRoot(id)
+	entries, readErr := os.ReadDir(backupRoot)
+	if readErr != nil {
+		return "", false
+	}
+
+	entry, found := lo.Find(entries, func(entry os.DirEntry) bool {
+		return entry.IsDir()
+	})
+	if !found {
+		return "", false
+	}
+	return path.Join(backupRoot, entry.Name()), true
+}
+
+// GetRollbackVersion returns version of the plugin kept by the last upgrade
+func (s *Store) GetRollbackVersion(ctx context.Context, instance *Instance) (string, bool) {
+	if instance.IsDevPlugin {
+		return "", false
+	}
+
+	backupDirectory, found := getPluginBackupDirectory(instance.Metadata.Id)
+	if !found {
+		return "", false
+	}
+	metadata, parseErr := GetPluginManager().ParseMetadata(ctx, backupDirectory)
+	if parseErr != nil {
+		return "", false
+	}
+	return metadata.Version, true
+}
+
+// Rollback replaces installed plugin with the version kept by the last upgrade.
+// The replaced version is kept as backup instead, so a rollback can be undone by another rollback
+func (s *Store) Rollback(ctx context.Context, instance *Instance) error {
+	logger.Info(ctx, fmt.Sprintf("start to rollback plugin %s(%s)", instance.Metadata.Name, instance.Metadata.Version))
+
+	if instance.IsDevPlugin {
+		return fmt.Errorf("dev plugin %s can't be rolled back", instance.Metadata.Name)
+	}
+	backupDirectory, found := getPluginBackupDirectory(instance.Metadata.Id)
+	if !found {
+		return fmt.Errorf("no previous version of plugin %s to roll back to", instance.Metadata.Name)
+	}
+	metadata, parseErr := GetPluginManager().ParseMetadata(ctx, backupDirectory)
+	if parseErr != nil {
+		return fmt.Errorf("failed to parse previous version of plugin %s: %w", instance.Metadata.Name, parseErr)
+	}
+	if compatibleErr := CheckMetadataCompatibility(ctx, metadata); compatibleErr != nil {
+		return fmt.Errorf("previous version of plugin %s(%s) is not compatible: %w", metadata.Name, metadata.Version, compatibleErr)
+	}
+
+	// previous version is moved out of backup directory first, because backing up the installed version replaces it
+	stagingDirectory := path.Join(util.GetLocation().GetPluginStagingDirectory(), fmt.Sprintf("%s@%s", metadata.Id, metadata.Version))
+	if removeErr := os.RemoveAll(stagingDirectory); removeErr != nil {
+		return fmt.Errorf("failed to clean staging directory %s: %w", stagingDirectory, removeErr)
+	}
+	if directoryErr := util.GetLocation().EnsureDirectoryExist(path.Dir(stagingDirectory)); directoryErr != nil {
+		return fmt.Errorf("failed to create staging directory %s: %w", path.Dir(stagingDirectory), directoryErr)
+	}
+	if renameErr := os.Rename(backupDirectory, stagingDirectory); renameErr != nil {
+		return fmt.Errorf("failed to move previous version of plugin %s: %w", metadata.Name, renameErr)
+	}
+
+	backup, backupErr := s.backupPlugin(ctx, instance)
+	if backupErr != nil {
+		if renameErr := os.Rename(stagingDirectory, backupDirectory); renameErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to move previous version of plugin %s back to %s: %s", metadata.Name, backupDirectory, renameErr.Error()))
+		}
+		return fmt.Errorf("failed to backup plugin %s(%s): %w", instance.Metadata.Name, instance.Metadata.Version, backupErr)
+	}
+
+	// user didn't confirm anything when rolling back, previous version can't get more permissions than the installed version has been granted
+	var confirmedPermissions setting.PluginPermissions
+	if instance.Setting != nil && instance.Setting.GrantedPermissions != nil {
+		confirmedPermissions = *instance.Setting.GrantedPermissions
+	}
+	pluginDirectory := path.Join(util.GetLocation().GetPluginDirectory(), filepath.Base(backupDirectory))
+	activateErr := s.activatePlugin(ctx, metadata, stagingDirectory, pluginDirectory, confirmedPermissions)
+	if activateErr != nil {
+		// previous version is broken, drop it and keep the version user was running
+		logger.Error(ctx, fmt.Sprintf("failed to rollback plugin %s to %s: %s", metadata.Name, metadata.Version, activateErr.Error()))
+		for _, directory := range []string{pluginDirectory, stagingDirectory} {
+			if removeErr := os.RemoveAll(directory); removeErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to remove plugin directory %s: %s", directory, removeErr.Error()))
+			}
+		}
+		s.restorePlugin(ctx, backup)
+		return fmt.Errorf("failed to rollback plugin %s to %s: %w", metadata.Name, metadata.Version, activateErr)
+	}
+
+	logger.Info(ctx, fmt.Sprintf("rollback plugin %s from %s to %s", metadata.Name, instance.Metadata.Version, metadata.Version))
+	return nil
+}
@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"wox/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUpdate(t *testing.T) {
+	ctx := context.Background()
+	withTestRuntimeHost(t, RuntimeInfo{Path: "/usr/bin/python3", Version: "3.11.2"})
+
+	store := &Store{storeManifests: []StorePluginManifest{
+		{
+			Id:            "demo",
+			Name:          "demo",
+			Version:       "3.0.0",
+			MinWoxVersion: "99.0.0",
+			Runtime:       PLUGIN_RUNTIME_PYTHON,
+			Versions: []StorePluginVersion{
+				{Version: "2.0.0", MinWoxVersion: "2.0.0"},
+				{Version: "1.0.0", MinWoxVersion: "2.0.0"},
+			},
+		},
+	}}
+
+	// latest version requires a newer wox, newest compatible version is offered instead
+	installed := &Instance{Metadata: Metadata{Id: "demo", Version: "1.0.0", Runtime: "python"}}
+	update, found := store.GetUpdate(ctx, installed)
+	assert.True(t, found)
+	assert.Equal(t, "2.0.0", update.Version)
+
+	installed.Metadata.Version = "2.0.0"
+	_, found = store.GetUpdate(ctx, installed)
+	assert.False(t, found)
+
+	installed.Metadata.Version = "1.0.0"
+	installed.IsDevPlugin = true
+	_, found = store.GetUpdate(ctx, installed)
+	assert.False(t, found)
+
+	_, found = store.GetUpdate(ctx, &Instance{Metadata: Metadata{Id: "unknown", Version: "1.0.0"}})
+	assert.False(t, found)
+}
+
+func TestGetUpdateFromInstalledStore(t *testing.T) {
+	ctx := context.Background()
+	withTestRuntimeHost(t, RuntimeInfo{Path: "/usr/bin/python3", Version: "3.11.2"})
+
+	store := &Store{storeManifests: []StorePluginManifest{
+		{Id: "demo", Name: "demo", Version: "1.1.0", MinWoxVersion: "2.0.0", Runtime: PLUGIN_RUNTIME_PYTHON, Store: "official"},
+		{Id: "demo", Name: "demo", Version: "9.0.0", MinWoxVersion: "2.0.0", Runtime: PLUGIN_RUNTIME_PYTHON, Store: "third-party"},
+	}}
+
+	// newer version in store with lower priority must not replace plugin installed from another store
+	installed := &Instance{Metadata: Metadata{Id: "demo", Version: "1.0.0", Runtime: "python"}, Setting: &setting.PluginSetting{InstalledStore: "official"}}
+	update, found := store.GetUpdate(ctx, installed)
+	assert.True(t, found)
+	assert.Equal(t, "1.1.0", update.Version)
+	assert.Equal(t, "official", update.Store)
+
+	installed.Setting.InstalledStore = "third-party"
+	update, found = store.GetUpdate(ctx, installed)
+	assert.True(t, found)
+	assert.Equal(t, "9.0.0", update.Version)
+
+	installed.Setting.InstalledStore = ""
+	update, found = store.GetUpdate(ctx, installed)
+	assert.True(t, found)
+	assert.Equal(t, "official", update.Store)
+
+	installed.Setting.InstalledStore = "removed"
+	_, found = store.GetUpdate(ctx, installed)
+	assert.False(t, found)
+}
+
+func TestIsAutoUpdateAllowed(t *testing.T) {
+	store := &Store{}
+	granted := setting.PluginPermissions{Network: []string{"api.github.com"}}
+	update := PluginUpdate{
+		Instance: &Instance{Setting: &setting.PluginSetting{AutoUpdate: true, GrantedPermissions: &granted}},
+		Manifest: StorePluginManifest{Runtime: PLUGIN_RUNTIME_WASM, Permissions: granted},
+	}
+	assert.True(t, store.isAutoUpdateAllowed(update))
+
+	// new version requests permissions user hasn't granted yet
+	update.Manifest.Permissions = setting.PluginPermissions{Network: []string{"*"}}
+	assert.False(t, store.isAutoUpdateAllowed(update))
+
+	update.Manifest.Runtime = PLUGIN_RUNTIME_PYTHON
+	assert.True(t, store.isAutoUpdateAllowed(update))
+
+	update.Instance.Setting.AutoUpdate = false
+	assert.False(t, store.isAutoUpdateAllowed(update))
+}
@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"wox/ai"
+	"wox/setting"
+	"wox/util"
+)
+
+// embedding requests are split into batches, providers have limits on inputs of one request
+const embedBatchSize = 64
+
+var vectorIndexNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+// Embed converts texts into vectors using given embedding model
+func (m *Manager) Embed(ctx context.Context, model ai.Model, texts []string) ([][]float32, error) {
+	provider, providerErr := m.GetAIProvider(ctx, model.Provider)
+	if providerErr != nil {
+		return nil, providerErr
+	}
+	embeddingProvider, ok := provider.(ai.EmbeddingProvider)
+	if !ok {
+		return nil, fmt.Errorf("ai provider %s doesn't support embeddings", model.Provider)
+	}
+
+	var vectors [][]float32
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+		batch, embedErr := embeddingProvider.Embed(ctx, model, texts[start:end])
+		if embedErr != nil {
+			return nil, embedErr
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("ai provider %s returned %d embeddings for %d texts", model.Provider, len(batch), end-start)
+		}
+		vectors = append(vectors, batch...)
+
+		promptTokens := 0
+		for _, text := range texts[start:end] {
+			promptTokens += ai.EstimateTokens(model, text)
+		}
+		setting.GetSettingManager().AddAIUsage(ctx, string(model.Provider), promptTokens, 0)
+	}
+
+	return vectors, nil
+}
+
+// getVectorIndex returns the vector index of the plugin, each plugin can only access its own indexes
+func (m *Manager) getVectorIndex(ctx context.Context, pluginId string, indexName string, model ai.Model) (*ai.VectorIndex, error) {
+	if !vectorIndexNameRegex.MatchString(indexName) {
+		return nil, fmt.Errorf("invalid index name: %s, only letters, numbers, - and _ are allowed", indexName)
+	}
+
+	key := fmt.Sprintf("%s/%s", pluginId, indexName)
+	if index, exist := m.vectorIndexes.Load(key); exist && index.Model == model {
+		return index, nil
+	}
+
+	indexPath := path.Join(util.GetLocation().GetVectorIndexDirectory(), pluginId, indexName+".json")
+	index, loadErr := ai.LoadVectorIndex(ctx, indexPath, model)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	logger.Info(ctx, fmt.Sprintf("loaded vector index %s with %d documents", key, index.Len()))
+	m.vectorIndexes.Store(key, index)
+	return index, nil
+}
+
+func (m *Manager) IndexDocuments(ctx context.Context, pluginId string, indexName string, model ai.Model, documents []ai.VectorDocument) error {
+	index, indexErr := m.getVectorIndex(ctx, pluginId, indexName, model)
+	if indexErr != nil {
+		return indexErr
+	}
+
+	texts := make([]string, len(documents))
+	for i, document := range documents {
+		if strings.TrimSpace(document.Text) == "" {
+			return fmt.Errorf("document %s has empty text", document.Id)
+		}
+		texts[i] = document.Text
+	}
+
+	vectors, embedErr := m.Embed(ctx, model, texts)
+	if embedErr != nil {
+		return embedErr
+	}
+	for i := range documents {
+		documents[i].Vector = vectors[i]
+	}
+
+	return index.Upsert(documents)
+}
+
+func (m *Manager) RemoveDocuments(ctx context.Context, pluginId string, indexName string, model ai.Model, ids []string) error {
+	index, indexErr := m.getVectorIndex(ctx, pluginId, indexName, model)
+	if indexErr != nil {
+		return indexErr
+	}
+
+	return index.Delete(ids)
+}
+
+func (m *Manager) QueryDocuments(ctx context.Context, pluginId string, indexName string, model ai.Model, query string, limit int) ([]ai.VectorSearchResult, error) {
+	index, indexErr := m.getVectorIndex(ctx, pluginId, indexName, model)
+	if indexErr != nil {
+		return nil, indexErr
+	}
+	if index.Len() == 0 {
+		return []ai.VectorSearchResult{}, nil
+	}
+
+	vectors, embedErr := m.Embed(ctx, model, []string{query})
+	if embedErr != nil {
+		return nil, embedErr
+	}
+
+	return index.Search(vectors[0], limit), nil
+}
@@ -19,6 +19,9 @@ var LangFS embed.FS
 //go:embed ui
 var UIFS embed.FS
 
+//go:embed schema
+var SchemaFS embed.FS
+
 //go:embed app.png
 var appIcon []byte
 
@@ -28,15 +31,26 @@ var appIconWindows []byte
 var embedThemes = []string{}
 
 func Extract(ctx context.Context) error {
+	return extract(ctx, true)
+}
+
+// ExtractWithoutUIApp extracts embed files except flutter ui app, which is not needed in headless mode
+func ExtractWithoutUIApp(ctx context.Context) error {
+	return extract(ctx, false)
+}
+
+func extract(ctx context.Context, withUIApp bool) error {
 	start := util.GetSystemTimestamp()
-	extractHostErr := extractFiles(ctx, HostFS, util.GetLocation().GetHostDirectory(), "hosts", false)
+	extractHostErr := ExtractHosts(ctx)
 	if extractHostErr != nil {
 		return extractHostErr
 	}
 
-	flutterErr := extractFiles(ctx, UIFS, util.GetLocation().GetUIDirectory(), "ui/flutter", true)
-	if flutterErr != nil {
-		return flutterErr
+	if withUIApp {
+		flutterErr := extractFiles(ctx, UIFS, util.GetLocation().GetUIDirectory(), "ui/flutter", true)
+		if flutterErr != nil {
+			return flutterErr
+		}
 	}
 
 	themeErr := parseThemes(ctx)
@@ -48,6 +62,11 @@ func Extract(ctx context.Context) error {
 	return nil
 }
 
+// ExtractHosts extracts plugin host files into host directory, hosts are started from there
+func ExtractHosts(ctx context.Context) error {
+	return extractFiles(ctx, HostFS, util.GetLocation().GetHostDirectory(), "hosts", false)
+}
+
 func extractFiles(ctx context.Context, fs embed.FS, extractDirectory string, filePath string, recursive bool) error {
 	dir, err := fs.ReadDir(filePath)
 	if err != nil {
@@ -7,7 +7,9 @@ import (
 	"os"
 	"path"
 	"slices"
+	"strconv"
 	"sync"
+	"time"
 	"wox/i18n"
 	"wox/setting/definition"
 	"wox/share"
@@ -25,6 +27,9 @@ var logger *util.Log
 type Manager struct {
 	woxSetting *WoxSetting
 	woxAppData *WoxAppData
+
+	aiUsageLock      sync.Mutex
+	aiUsageSaveTimer *time.Timer
 }
 
 func GetSettingManager() *Manager {
@@ -113,12 +118,24 @@ func (m *Manager) loadWoxSetting(ctx context.Context) error {
 	if woxSetting.LastQueryMode == "" {
 		woxSetting.LastQueryMode = defaultWoxSetting.LastQueryMode
 	}
+	if woxSetting.PluginTrustPolicy == "" {
+		woxSetting.PluginTrustPolicy = defaultWoxSetting.PluginTrustPolicy
+	}
+	if woxSetting.PluginStores == nil {
+		woxSetting.PluginStores = defaultWoxSetting.PluginStores
+	}
+	if woxSetting.ThemeStores == nil {
+		woxSetting.ThemeStores = defaultWoxSetting.ThemeStores
+	}
 	if woxSetting.AppWidth == 0 {
 		woxSetting.AppWidth = defaultWoxSetting.AppWidth
 	}
 	if woxSetting.ThemeId == "" {
 		woxSetting.ThemeId = defaultWoxSetting.ThemeId
 	}
+	if woxSetting.AIContextStrategy == "" {
+		woxSetting.AIContextStrategy = defaultWoxSetting.AIContextStrategy
+	}
 
 	m.woxSetting = woxSetting
 
@@ -157,6 +174,9 @@ func (m *Manager) loadWoxAppData(ctx context.Context) error {
 	if woxAppData.FavoriteResults == nil {
 		woxAppData.FavoriteResults = util.NewHashMap[ResultHash, bool]()
 	}
+	if woxAppData.AIUsages == nil {
+		woxAppData.AIUsages = util.NewHashMap[string, AIUsage]()
+	}
 
 	// sort query histories by timestamp asc
 	slices.SortFunc(woxAppData.QueryHistories, func(i, j QueryHistory) int {
@@ -237,6 +257,55 @@ func (m *Manager) UpdateWoxSetting(ctx context.Context, key, value string) error
 		}
 
 		m.woxSetting.AIProviders = aiModels
+	} else if key == "AIModelSettings" {
+		// value is a json string
+		var aiModelSettings []AIModelSetting
+		if unmarshalErr := json.Unmarshal([]byte(value), &aiModelSettings); unmarshalErr != nil {
+			return unmarshalErr
+		}
+
+		m.woxSetting.AIModelSettings = aiModelSettings
+	} else if key == "AIContextStrategy" {
+		m.woxSetting.AIContextStrategy = value
+	} else if key == "AIMaxRetries" {
+		maxRetries, parseErr := strconv.Atoi(value)
+		if parseErr != nil {
+			return parseErr
+		}
+		m.woxSetting.AIMaxRetries = maxRetries
+	} else if key == "AIEnableResponseCache" {
+		m.woxSetting.AIEnableResponseCache = value == "true"
+	} else if key == "ScriptCommandDirectory" {
+		m.woxSetting.ScriptCommandDirectory = value
+	} else if key == "PythonPath" {
+		m.woxSetting.PythonPath = value
+	} else if key == "NodejsPath" {
+		m.woxSetting.NodejsPath = value
+	} else if key == "PipIndexUrl" {
+		m.woxSetting.PipIndexUrl = value
+	} else if key == "NpmRegistry" {
+		m.woxSetting.NpmRegistry = value
+	} else if key == "PluginTrustPolicy" {
+		if value != PluginTrustPolicyOfficialOnly && value != PluginTrustPolicyAllowUnsigned {
+			return fmt.Errorf("invalid plugin trust policy: %s", value)
+		}
+		m.woxSetting.PluginTrustPolicy = value
+	} else if key == "PluginStores" || key == "ThemeStores" {
+		// value is a json string
+		var stores []StoreSource
+		if unmarshalErr := json.Unmarshal([]byte(value), &stores); unmarshalErr != nil {
+			return unmarshalErr
+		}
+		for _, store := range stores {
+			if store.Url == "" {
+				return fmt.Errorf("url of store %s is empty", store.Name)
+			}
+		}
+		if key == "PluginStores" {
+			m.woxSetting.PluginStores = stores
+		} else {
+			m.woxSetting.ThemeStores = stores
+		}
 	} else {
 		return fmt.Errorf("unknown key: %s", key)
 	}
@@ -412,3 +481,32 @@ func (m *Manager) RemoveFavoriteResult(ctx context.Context, pluginId string, res
 	m.woxAppData.FavoriteResults.Delete(resultHash)
 	m.saveWoxAppData(ctx, "remove favorite result")
 }
+
+func (m *Manager) AddAIUsage(ctx context.Context, provider string, promptTokens int, completionTokens int) {
+	m.aiUsageLock.Lock()
+	defer m.aiUsageLock.Unlock()
+
+	usage, _ := m.woxAppData.AIUsages.Load(provider)
+	usage.RequestCount++
+	usage.PromptTokens += int64(promptTokens)
+	usage.CompletionTokens += int64(completionTokens)
+	usage.LastUsedTimestamp = util.GetSystemTimestamp()
+	m.woxAppData.AIUsages.Store(provider, usage)
+
+	// ai usage is added on every ai call, debounce to avoid writing app data too often
+	if m.aiUsageSaveTimer != nil {
+		m.aiUsageSaveTimer.Stop()
+	}
+	m.aiUsageSaveTimer = time.AfterFunc(5*time.Second, func() {
+		m.saveWoxAppData(util.NewTraceContext(), "add ai usage")
+	})
+}
+
+func (m *Manager) GetAIUsages(ctx context.Context) map[string]AIUsage {
+	var usages = make(map[string]AIUsage)
+	m.woxAppData.AIUsages.Range(func(provider string, usage AIUsage) bool {
+		usages[provider] = usage
+		return true
+	})
+	return usages
+}
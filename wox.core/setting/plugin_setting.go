@@ -26,9 +26,39 @@ type PluginSetting struct {
 	// So don't use this directly, use Instance.GetQueryCommands instead
 	QueryCommands []PluginQueryCommand
 
+	// Permissions granted by user when installing the plugin, only used by sandboxed runtimes (WASM).
+	// Nil means nothing is granted
+	GrantedPermissions *PluginPermissions
+
+	// Run plugin in a dedicated host process instead of the process shared by all plugins of the same runtime,
+	// only used by python and nodejs plugins
+	IsolatedHost bool
+
+	// Install new versions from store automatically, new versions requesting more permissions still need user confirmation
+	AutoUpdate bool
+
+	// Name of the store plugin is installed from, updates are only installed from this store.
+	// Empty if plugin is installed from a local package or before store was recorded
+	InstalledStore string
+
 	Settings *util.HashMap[string, string]
 }
 
+// PluginPermissions are capabilities of sandboxed plugins, declared in plugin.json and granted by user at install time
+type PluginPermissions struct {
+	FileSystem []PluginFileSystemPermission
+	Network    []string // hosts plugin can send http requests to, e.g. "api.github.com", "*.github.com" or "*" for all hosts
+}
+
+type PluginFileSystemPermission struct {
+	Path     string // absolute directory path, "~" is expanded to home directory
+	ReadOnly bool
+}
+
+func (p PluginPermissions) IsEmpty() bool {
+	return len(p.FileSystem) == 0 && len(p.Network) == 0
+}
+
 func (p *PluginSetting) GetSetting(key string) (string, bool) {
 	if p.Settings == nil {
 		return "", false
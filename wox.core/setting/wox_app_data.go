@@ -13,6 +13,7 @@ type WoxAppData struct {
 	QueryHistories  []QueryHistory
 	ActionedResults *util.HashMap[ResultHash, []ActionedResult]
 	FavoriteResults *util.HashMap[ResultHash, bool]
+	AIUsages        *util.HashMap[string, AIUsage] // key is ai provider name
 }
 
 type QueryHistory struct {
@@ -24,6 +25,14 @@ type ActionedResult struct {
 	Timestamp int64
 }
 
+// AIUsage is the accumulated usage of an ai provider, tokens are estimated by wox, not reported by provider
+type AIUsage struct {
+	RequestCount      int64
+	PromptTokens      int64
+	CompletionTokens  int64
+	LastUsedTimestamp int64
+}
+
 func NewResultHash(pluginId string, title, subTitle string) ResultHash {
 	return ResultHash(util.Md5([]byte(fmt.Sprintf("%s%s%s", pluginId, title, subTitle))))
 }
@@ -33,5 +42,6 @@ func GetDefaultWoxAppData(ctx context.Context) WoxAppData {
 		QueryHistories:  []QueryHistory{},
 		ActionedResults: util.NewHashMap[ResultHash, []ActionedResult](),
 		FavoriteResults: util.NewHashMap[ResultHash, bool](),
+		AIUsages:        util.NewHashMap[string, AIUsage](),
 	}
 }
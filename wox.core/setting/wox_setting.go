@@ -2,28 +2,43 @@ package setting
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"wox/i18n"
 	"wox/util"
 )
 
 type WoxSetting struct {
-	EnableAutostart      PlatformSettingValue[bool]
-	MainHotkey           PlatformSettingValue[string]
-	SelectionHotkey      PlatformSettingValue[string]
-	UsePinYin            bool
-	SwitchInputMethodABC bool
-	HideOnStart          bool
-	HideOnLostFocus      bool
-	ShowTray             bool
-	LangCode             i18n.LangCode
-	QueryHotkeys         PlatformSettingValue[[]QueryHotkey]
-	QueryShortcuts       []QueryShortcut
-	LastQueryMode        LastQueryMode
-	AIProviders          []AIProvider
+	EnableAutostart        PlatformSettingValue[bool]
+	MainHotkey             PlatformSettingValue[string]
+	SelectionHotkey        PlatformSettingValue[string]
+	UsePinYin              bool
+	SwitchInputMethodABC   bool
+	HideOnStart            bool
+	HideOnLostFocus        bool
+	ShowTray               bool
+	LangCode               i18n.LangCode
+	QueryHotkeys           PlatformSettingValue[[]QueryHotkey]
+	QueryShortcuts         []QueryShortcut
+	LastQueryMode          LastQueryMode
+	AIProviders            []AIProvider
+	AIModelSettings        []AIModelSetting
+	AIContextStrategy      string // see ai.ContextStrategy
+	AIMaxRetries           int    // retries on transient errors (rate limit, network etc) before failing over, 0 means default, negative means no retry
+	AIEnableResponseCache  bool   // cache answers of identical ai command runs in memory
+	ScriptCommandDirectory string // directory of script commands, empty means the default scripts directory in user data directory
+	PythonPath             string // interpreter of python plugin host, empty means discover automatically
+	NodejsPath             string // interpreter of nodejs plugin host, empty means discover automatically
+	PipIndexUrl            string // mirror of python package index used to install plugin dependencies, empty means pip default
+	NpmRegistry            string // mirror of npm registry used to install plugin dependencies, empty means npm default
+	PluginTrustPolicy      PluginTrustPolicy
+	PluginStores           []StoreSource
+	ThemeStores            []StoreSource
 
 	// UI related
 	AppWidth int
@@ -37,6 +52,13 @@ const (
 	LastQueryModeEmpty    LastQueryMode = "empty"    // empty last query
 )
 
+type PluginTrustPolicy = string
+
+const (
+	PluginTrustPolicyOfficialOnly  PluginTrustPolicy = "official_only"  // only install store plugins signed by official store key
+	PluginTrustPolicyAllowUnsigned PluginTrustPolicy = "allow_unsigned" // install unsigned store plugins with a warning
+)
+
 const (
 	DefaultThemeId = "e4006bd3-6bfe-4020-8d1c-4c32a8e567e5"
 )
@@ -54,12 +76,76 @@ func (q *QueryShortcut) PlaceholderCount() int {
 	return len(regexp.MustCompile(`(?m){\d}`).FindAllString(q.Query, -1))
 }
 
+type StoreSource struct {
+	Name      string
+	Url       string // http(s) url or local path of a json file with all manifests, or a local directory of json files with one manifest each
+	Priority  int    // when the same item with the same version exists in multiple stores, the one from store with higher priority is used
+	Enabled   bool
+	PublicKey string // base64 encoded ed25519 public key to verify plugins signed by this store
+}
+
+// UnmarshalJSON accepts priority as string, because table in setting UI edits all values as text
+func (s *StoreSource) UnmarshalJSON(data []byte) error {
+	type storeSource StoreSource
+	var source struct {
+		storeSource
+		Priority any
+	}
+	if unmarshalErr := json.Unmarshal(data, &source); unmarshalErr != nil {
+		return unmarshalErr
+	}
+
+	*s = StoreSource(source.storeSource)
+	switch priority := source.Priority.(type) {
+	case float64:
+		s.Priority = int(priority)
+	case string:
+		if strings.TrimSpace(priority) != "" {
+			p, parseErr := strconv.Atoi(strings.TrimSpace(priority))
+			if parseErr != nil {
+				return fmt.Errorf("invalid priority of store %s: %s", s.Name, priority)
+			}
+			s.Priority = p
+		}
+	}
+	return nil
+}
+
+func GetDefaultPluginStores() []StoreSource {
+	return []StoreSource{
+		{
+			Name:     "Wox Official Plugin Store",
+			Url:      "https://raw.githubusercontent.com/Wox-launcher/Wox/v2/store-plugin.json",
+			Priority: 100,
+			Enabled:  true,
+		},
+	}
+}
+
+func GetDefaultThemeStores() []StoreSource {
+	return []StoreSource{
+		{
+			Name:     "Wox Official Theme Store",
+			Url:      "https://raw.githubusercontent.com/Wox-launcher/Wox/v2/store-theme.json",
+			Priority: 100,
+			Enabled:  true,
+		},
+	}
+}
+
 type AIProvider struct {
 	Name   string // see ai.ProviderName
 	ApiKey string
 	Host   string
 }
 
+type AIModelSetting struct {
+	Provider      string // see ai.ProviderName
+	Name          string
+	ContextWindow int    // max tokens the model accepts, 0 means use the built-in default of the model family
+	FallbackModel string // json string of ai.Model, used when this model keeps failing, empty means no fallback
+}
+
 type QueryHotkey struct {
 	Hotkey            string
 	Query             string // Support plugin.QueryVariable
@@ -93,6 +179,10 @@ func GetDefaultWoxSetting(ctx context.Context) WoxSetting {
 		HideOnLostFocus:      true,
 		LangCode:             langCode,
 		LastQueryMode:        LastQueryModeEmpty,
+		PluginTrustPolicy:    PluginTrustPolicyAllowUnsigned,
+		PluginStores:         GetDefaultPluginStores(),
+		ThemeStores:          GetDefaultThemeStores(),
+		AIContextStrategy:    "drop_oldest",
 		AppWidth:             800,
 		ThemeId:              DefaultThemeId,
 		EnableAutostart: PlatformSettingValue[bool]{
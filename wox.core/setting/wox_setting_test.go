@@ -0,0 +1,22 @@
+package setting
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalStoreSource(t *testing.T) {
+	var stores []StoreSource
+	assert.NoError(t, json.Unmarshal([]byte(`[
+		{"Name": "official", "Url": "https://example.com/store.json", "Priority": 100, "Enabled": true},
+		{"Name": "company", "Url": "/data/store", "Priority": " 20 ", "Enabled": false, "PublicKey": "key"},
+		{"Name": "local", "Url": "/data/local", "Priority": ""}
+	]`), &stores))
+	assert.Equal(t, StoreSource{Name: "official", Url: "https://example.com/store.json", Priority: 100, Enabled: true}, stores[0])
+	assert.Equal(t, StoreSource{Name: "company", Url: "/data/store", Priority: 20, PublicKey: "key"}, stores[1])
+	assert.Equal(t, 0, stores[2].Priority)
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"Name": "bad", "Priority": "high"}`), &StoreSource{}), "invalid priority of store bad")
+}
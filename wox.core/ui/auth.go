@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"wox/util"
+)
+
+// SessionTokenQueryKey is used to pass session token in websocket url, because websocket clients may not be able to set headers
+const SessionTokenQueryKey = "token"
+
+// InitSessionToken generates a new token for current session and writes it into session token file,
+// which is only readable by current user. Every request to wox http server must carry this token,
+// so other users, processes without access to the file and web pages can't control wox
+func (m *Manager) InitSessionToken(ctx context.Context) error {
+	tokenBytes := make([]byte, 32)
+	if _, readErr := rand.Read(tokenBytes); readErr != nil {
+		return fmt.Errorf("failed to generate session token: %w", readErr)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	tokenPath := util.GetLocation().GetSessionTokenPath()
+	// remove previous token file, WriteFile doesn't change permission of existing file
+	if removeErr := os.Remove(tokenPath); removeErr != nil && !os.IsNotExist(removeErr) {
+		return fmt.Errorf("failed to remove previous session token: %w", removeErr)
+	}
+	if writeErr := os.WriteFile(tokenPath, []byte(token), 0600); writeErr != nil {
+		return fmt.Errorf("failed to write session token: %w", writeErr)
+	}
+
+	m.sessionToken = token
+	logger.Info(ctx, fmt.Sprintf("session token written to %s", tokenPath))
+	return nil
+}
+
+// ReadSessionToken reads token of the running wox instance, used by clients like wox cli
+func ReadSessionToken() (string, error) {
+	token, readErr := os.ReadFile(util.GetLocation().GetSessionTokenPath())
+	if readErr != nil {
+		return "", fmt.Errorf("failed to read session token, is wox running? %w", readErr)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// SetSessionTokenHeader sets session token to request header, the same as ui does
+func SetSessionTokenHeader(request *http.Request, token string) {
+	request.Header.Set("Authorization", "Bearer "+token)
+}
+
+// requireSessionToken rejects requests from browsers, requests with a non local host (DNS rebinding)
+// and requests without token of current session
+func requireSessionToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authErr := authorizeRequest(token, r); authErr != nil {
+			logger.Warn(util.NewTraceContext(), fmt.Sprintf("rejected request %s: %s", r.URL.Path, authErr.Error()))
+			writeUnauthorizedResponse(w, authErr.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func authorizeRequest(token string, r *http.Request) error {
+	// browsers always send origin for cross origin requests and websockets, wox ui and cli never do
+	if origin := r.Header.Get("Origin"); origin != "" {
+		return fmt.Errorf("requests from browser are not allowed, origin: %s", origin)
+	}
+
+	host, _, splitErr := net.SplitHostPort(r.Host)
+	if splitErr != nil {
+		host = r.Host
+	}
+	if host != "localhost" && host != "127.0.0.1" && host != "::1" {
+		return fmt.Errorf("host is not allowed: %s", r.Host)
+	}
+
+	requestToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if requestToken == "" && r.URL.Path == "/ws" {
+		requestToken = r.URL.Query().Get(SessionTokenQueryKey)
+	}
+	if requestToken == "" {
+		return errors.New("session token is missing")
+	}
+	if token == "" || subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) != 1 {
+		return errors.New("session token is invalid")
+	}
+
+	return nil
+}
+
+func writeUnauthorizedResponse(w http.ResponseWriter, errMsg string) {
+	d, _ := json.Marshal(RestResponse{
+		Success: false,
+		Message: errMsg,
+		Data:    "",
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write(d)
+}
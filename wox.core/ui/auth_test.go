@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizeRequest(t *testing.T) {
+	token := "session-token"
+
+	request := httptest.NewRequest("GET", "http://localhost:34987/ping", nil)
+	SetSessionTokenHeader(request, token)
+	assert.NoError(t, authorizeRequest(token, request))
+
+	request = httptest.NewRequest("GET", "http://127.0.0.1:34987/ping", nil)
+	assert.EqualError(t, authorizeRequest(token, request), "session token is missing")
+	SetSessionTokenHeader(request, "other-token")
+	assert.EqualError(t, authorizeRequest(token, request), "session token is invalid")
+
+	// websocket carries token in url
+	request = httptest.NewRequest("GET", "http://localhost:34987/ws?token=session-token", nil)
+	assert.NoError(t, authorizeRequest(token, request))
+	request = httptest.NewRequest("GET", "http://localhost:34987/deeplink?token=session-token", nil)
+	assert.EqualError(t, authorizeRequest(token, request), "session token is missing")
+
+	request = httptest.NewRequest("POST", "http://localhost:34987/deeplink", nil)
+	SetSessionTokenHeader(request, token)
+	request.Header.Set("Origin", "https://example.com")
+	assert.ErrorContains(t, authorizeRequest(token, request), "requests from browser are not allowed")
+
+	// DNS rebinding, a domain resolved to 127.0.0.1
+	request = httptest.NewRequest("POST", "http://example.com:34987/deeplink", nil)
+	SetSessionTokenHeader(request, token)
+	assert.ErrorContains(t, authorizeRequest(token, request), "host is not allowed")
+
+	// token is required even if session token is not initialized
+	request = httptest.NewRequest("GET", "http://localhost:34987/ping", nil)
+	SetSessionTokenHeader(request, "")
+	assert.Error(t, authorizeRequest("", request))
+}
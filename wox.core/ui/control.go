@@ -0,0 +1,292 @@
+package ui
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"wox/plugin"
+	"wox/share"
+	"wox/ui/dto"
+	"wox/util"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+)
+
+const controlMaxQueries = 20
+
+// results of recent queries from control api by query id, so concurrent clients don't overwrite results of each other.
+// Actions are executed by result id like ui does, plugin manager only keeps actions of the latest query, so a query from ui invalidates these results
+var controlQueries = map[string][]plugin.QueryResultUI{}
+var controlQueryIds []string // oldest first
+var controlQueriesLock sync.Mutex
+
+func saveControlResults(queryId string, results []plugin.QueryResultUI) {
+	controlQueriesLock.Lock()
+	defer controlQueriesLock.Unlock()
+
+	controlQueries[queryId] = results
+	controlQueryIds = append(controlQueryIds, queryId)
+	if len(controlQueryIds) > controlMaxQueries {
+		delete(controlQueries, controlQueryIds[0])
+		controlQueryIds = controlQueryIds[1:]
+	}
+}
+
+// getControlResults returns results of the query, results of the latest query are returned if query id is empty
+func getControlResults(queryId string) ([]plugin.QueryResultUI, error) {
+	controlQueriesLock.Lock()
+	defer controlQueriesLock.Unlock()
+
+	if queryId == "" {
+		if len(controlQueryIds) == 0 {
+			return []plugin.QueryResultUI{}, nil
+		}
+		queryId = controlQueryIds[len(controlQueryIds)-1]
+	}
+	results, exist := controlQueries[queryId]
+	if !exist {
+		return nil, fmt.Errorf("query not found or expired: %s", queryId)
+	}
+	return results, nil
+}
+
+func handleControlQuery(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+
+	body, _ := io.ReadAll(r.Body)
+	queryResult := gjson.GetBytes(body, "query")
+	if !queryResult.Exists() {
+		writeErrorResponse(w, "query is empty")
+		return
+	}
+
+	var plainQuery share.PlainQuery
+	unmarshalErr := json.Unmarshal([]byte(queryResult.Raw), &plainQuery)
+	if unmarshalErr != nil {
+		logger.Error(ctx, unmarshalErr.Error())
+		writeErrorResponse(w, unmarshalErr.Error())
+		return
+	}
+	if plainQuery.QueryType == "" {
+		plainQuery.QueryType = plugin.QueryTypeInput
+	}
+
+	results, queryErr := queryAndWait(ctx, plainQuery)
+	if queryErr != nil {
+		logger.Error(ctx, queryErr.Error())
+		writeErrorResponse(w, queryErr.Error())
+		return
+	}
+
+	queryId := uuid.NewString()
+	lo.ForEach(results, func(_ plugin.QueryResultUI, index int) {
+		results[index].QueryId = queryId
+	})
+	saveControlResults(queryId, results)
+
+	writeSuccessResponse(w, convertControlResults(results))
+}
+
+func handleControlResults(w http.ResponseWriter, r *http.Request) {
+	results, resultsErr := getControlResults(r.URL.Query().Get("queryId"))
+	if resultsErr != nil {
+		writeErrorResponse(w, resultsErr.Error())
+		return
+	}
+	writeSuccessResponse(w, convertControlResults(results))
+}
+
+func handleControlAction(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+
+	body, _ := io.ReadAll(r.Body)
+	resultIdResult := gjson.GetBytes(body, "resultId")
+	if !resultIdResult.Exists() {
+		writeErrorResponse(w, "resultId is empty")
+		return
+	}
+
+	// default action is executed if action is empty, the same as pressing enter
+	action, findErr := findControlAction(resultIdResult.String(), gjson.GetBytes(body, "action").String())
+	if findErr != nil {
+		writeErrorResponse(w, findErr.Error())
+		return
+	}
+
+	executeErr := plugin.GetPluginManager().ExecuteAction(ctx, resultIdResult.String(), action.Id)
+	if executeErr != nil {
+		logger.Error(ctx, executeErr.Error())
+		writeErrorResponse(w, executeErr.Error())
+		return
+	}
+
+	if !action.PreventHideAfterAction {
+		GetUIManager().GetUI(ctx).HideApp(ctx)
+	}
+
+	writeSuccessResponse(w, "")
+}
+
+func handleControlToggle(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+	GetUIManager().GetUI(ctx).ToggleApp(ctx)
+	writeSuccessResponse(w, "")
+}
+
+func handleControlSetting(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+
+	type keyValuePair struct {
+		PluginId string // empty for wox setting
+		Key      string
+		Value    string
+	}
+
+	var kv keyValuePair
+	decodeErr := json.NewDecoder(r.Body).Decode(&kv)
+	if decodeErr != nil {
+		writeErrorResponse(w, decodeErr.Error())
+		return
+	}
+	if kv.Key == "" {
+		writeErrorResponse(w, "key is empty")
+		return
+	}
+
+	var updateErr error
+	if kv.PluginId == "" {
+		updateErr = updateWoxSetting(ctx, kv.Key, kv.Value)
+	} else {
+		updateErr = updatePluginSetting(ctx, kv.PluginId, kv.Key, kv.Value)
+	}
+	if updateErr != nil {
+		writeErrorResponse(w, updateErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, "")
+}
+
+func handleControlLaunch(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+
+	var args LaunchArgs
+	decodeErr := json.NewDecoder(r.Body).Decode(&args)
+	if decodeErr != nil {
+		writeErrorResponse(w, decodeErr.Error())
+		return
+	}
+
+	launchErr := GetUIManager().PostLaunch(ctx, args)
+	if launchErr != nil {
+		logger.Error(ctx, launchErr.Error())
+		writeErrorResponse(w, launchErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, "")
+}
+
+// queryAndWait runs query like ui does and waits until all plugins finished, fallback results are returned if there is no result
+func queryAndWait(ctx context.Context, plainQuery share.PlainQuery) ([]plugin.QueryResultUI, error) {
+	var results = []plugin.QueryResultUI{}
+	if plainQuery.IsEmpty() {
+		return results, nil
+	}
+
+	query, queryPlugin, queryErr := plugin.GetPluginManager().NewQuery(ctx, plainQuery)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	// streaming results must not extend the timeout
+	timeout := time.NewTimer(time.Minute)
+	defer timeout.Stop()
+	resultChan, doneChan := plugin.GetPluginManager().Query(ctx, query)
+	for {
+		select {
+		case queryResults := <-resultChan:
+			results = append(results, queryResults...)
+		case <-doneChan:
+			// results may still be in channel when done is sent
+			for len(resultChan) > 0 {
+				results = append(results, <-resultChan...)
+			}
+			if len(results) == 0 {
+				results = append(results, plugin.GetPluginManager().QueryFallback(ctx, query, queryPlugin)...)
+			}
+			// results are shown by score in ui
+			slices.SortStableFunc(results, func(a, b plugin.QueryResultUI) int {
+				return cmp.Compare(b.Score, a.Score)
+			})
+			return results, nil
+		case <-timeout.C:
+			return nil, fmt.Errorf("query timeout, query: %s", query.String())
+		}
+	}
+}
+
+// findControlAction finds action of a result in recent control queries by action id or name (case insensitive),
+// the default action (or the first one) is returned if action is empty
+func findControlAction(resultId string, action string) (plugin.QueryResultActionUI, error) {
+	controlQueriesLock.Lock()
+	defer controlQueriesLock.Unlock()
+
+	var result plugin.QueryResultUI
+	var found bool
+	for i := len(controlQueryIds) - 1; i >= 0 && !found; i-- {
+		result, found = lo.Find(controlQueries[controlQueryIds[i]], func(item plugin.QueryResultUI) bool { return item.Id == resultId })
+	}
+	if !found {
+		return plugin.QueryResultActionUI{}, fmt.Errorf("result not found in recent queries: %s", resultId)
+	}
+	if len(result.Actions) == 0 {
+		return plugin.QueryResultActionUI{}, errors.New("result has no action")
+	}
+
+	if action == "" {
+		if defaultAction, defaultFound := lo.Find(result.Actions, func(item plugin.QueryResultActionUI) bool { return item.IsDefault }); defaultFound {
+			return defaultAction, nil
+		}
+		return result.Actions[0], nil
+	}
+
+	resultAction, actionFound := lo.Find(result.Actions, func(item plugin.QueryResultActionUI) bool {
+		return item.Id == action || strings.EqualFold(item.Name, action)
+	})
+	if !actionFound {
+		return plugin.QueryResultActionUI{}, fmt.Errorf("action not found for result %s: %s", result.Title, action)
+	}
+	return resultAction, nil
+}
+
+func convertControlResults(results []plugin.QueryResultUI) []dto.ControlResultDto {
+	return lo.Map(results, func(result plugin.QueryResultUI, _ int) dto.ControlResultDto {
+		return dto.ControlResultDto{
+			QueryId:  result.QueryId,
+			Id:       result.Id,
+			Title:    result.Title,
+			SubTitle: result.SubTitle,
+			Group:    result.Group,
+			Score:    result.Score,
+			Actions: lo.Map(result.Actions, func(action plugin.QueryResultActionUI, _ int) dto.ControlActionDto {
+				return dto.ControlActionDto{
+					Id:                     action.Id,
+					Name:                   action.Name,
+					IsDefault:              action.IsDefault,
+					PreventHideAfterAction: action.PreventHideAfterAction,
+				}
+			}),
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+	"wox/plugin"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControlResults(t *testing.T) {
+	first := []plugin.QueryResultUI{{Id: "r1", Actions: []plugin.QueryResultActionUI{{Id: "a1", Name: "Copy result", IsDefault: true}}}}
+	second := []plugin.QueryResultUI{{Id: "r2", Actions: []plugin.QueryResultActionUI{{Id: "a2", Name: "Open"}}}}
+	saveControlResults("q1", first)
+	saveControlResults("q2", second)
+
+	// results of a query are not replaced by a query of another client
+	results, resultsErr := getControlResults("q1")
+	assert.Nil(t, resultsErr)
+	assert.Equal(t, first, results)
+	results, _ = getControlResults("")
+	assert.Equal(t, second, results)
+
+	action, findErr := findControlAction("r1", "copy result")
+	assert.Nil(t, findErr)
+	assert.Equal(t, "a1", action.Id)
+	action, _ = findControlAction("r2", "")
+	assert.Equal(t, "a2", action.Id)
+
+	// only recent queries are kept
+	for i := 0; i < controlMaxQueries; i++ {
+		saveControlResults(fmt.Sprintf("q%d", i+3), nil)
+	}
+	_, resultsErr = getControlResults("q1")
+	assert.NotNil(t, resultsErr)
+	_, findErr = findControlAction("r1", "")
+	assert.NotNil(t, findErr)
+}
@@ -0,0 +1,9 @@
+package dto
+
+type AIUsageDto struct {
+	Provider          string
+	RequestCount      int64
+	PromptTokens      int64
+	CompletionTokens  int64
+	LastUsedTimestamp int64
+}
@@ -0,0 +1,19 @@
+package dto
+
+// ControlResultDto is a query result returned by control api, icons and previews are left out
+type ControlResultDto struct {
+	QueryId  string // results can be fetched again by query id, see /control/results
+	Id       string
+	Title    string
+	SubTitle string
+	Group    string
+	Score    int64
+	Actions  []ControlActionDto
+}
+
+type ControlActionDto struct {
+	Id                     string
+	Name                   string
+	IsDefault              bool
+	PreventHideAfterAction bool
+}
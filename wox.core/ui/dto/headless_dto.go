@@ -0,0 +1,16 @@
+package dto
+
+import "wox/share"
+
+type HeadlessStateDto struct {
+	IsVisible    bool
+	CurrentQuery share.PlainQuery
+	Events       []HeadlessEventDto
+}
+
+// HeadlessEventDto is a ui method invoked by wox or plugins in headless mode
+type HeadlessEventDto struct {
+	Timestamp int64
+	Method    string
+	Data      any
+}
@@ -21,11 +21,19 @@ type PluginDto struct {
 	TriggerKeywords    []string //User can add/update/delete trigger keywords
 	Commands           []plugin.MetadataCommand
 	SupportedOS        []string
+	Permissions        setting.PluginPermissions           // permissions requested by sandboxed plugins, user confirms them before installing
 	SettingDefinitions definition.PluginSettingDefinitions // only available when plugin is installed
 	Setting            setting.PluginSetting               // only available when plugin is installed
 	Features           []plugin.MetadataFeature            // only available when plugin is installed
+	ProcessStat        *plugin.ProcessStat                 // only available when plugin is installed and runs in a separate process
 	IsSystem           bool
 	IsDev              bool
 	IsInstalled        bool
-	IsDisable          bool // only available when plugin is installed
+	IsDisable          bool                                 // only available when plugin is installed
+	IncompatibleReason string                               // only available for store plugins, empty if latest version is compatible
+	CompatibleVersions []string                             // only available for store plugins, newest version first
+	VersionPermissions map[string]setting.PluginPermissions // only available for store plugins and plugin updates, permissions requested by each installable version
+	Store              string                               // only available for store plugins, name of the store plugin comes from
+	UpdateVersion      string                               // only available when plugin is installed, newest compatible store version if it's newer than installed version
+	RollbackVersion    string                               // only available when plugin is installed, version kept by the last upgrade
 }
@@ -6,19 +6,31 @@ import (
 )
 
 type WoxSettingDto struct {
-	EnableAutostart      bool
-	MainHotkey           string
-	SelectionHotkey      string
-	UsePinYin            bool
-	SwitchInputMethodABC bool
-	HideOnStart          bool
-	HideOnLostFocus      bool
-	ShowTray             bool
-	LangCode             i18n.LangCode
-	QueryHotkeys         []setting.QueryHotkey
-	QueryShortcuts       []setting.QueryShortcut
-	LastQueryMode        setting.LastQueryMode
-	AIProviders          []setting.AIProvider
+	EnableAutostart        bool
+	MainHotkey             string
+	SelectionHotkey        string
+	UsePinYin              bool
+	SwitchInputMethodABC   bool
+	HideOnStart            bool
+	HideOnLostFocus        bool
+	ShowTray               bool
+	LangCode               i18n.LangCode
+	QueryHotkeys           []setting.QueryHotkey
+	QueryShortcuts         []setting.QueryShortcut
+	LastQueryMode          setting.LastQueryMode
+	AIProviders            []setting.AIProvider
+	AIModelSettings        []setting.AIModelSetting
+	AIContextStrategy      string
+	AIMaxRetries           int
+	AIEnableResponseCache  bool
+	ScriptCommandDirectory string
+	PythonPath             string
+	NodejsPath             string
+	PipIndexUrl            string
+	NpmRegistry            string
+	PluginTrustPolicy      setting.PluginTrustPolicy
+	PluginStores           []setting.StoreSource
+	ThemeStores            []setting.StoreSource
 
 	// UI related
 	AppWidth int
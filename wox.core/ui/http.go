@@ -12,7 +12,6 @@ import (
 	"wox/util"
 
 	"github.com/olahol/melody"
-	"github.com/rs/cors"
 	"github.com/samber/lo"
 )
 
@@ -66,7 +65,7 @@ func writeErrorResponse(w http.ResponseWriter, errMsg string) {
 	w.Write(d)
 }
 
-func serveAndWait(ctx context.Context, port int) {
+func serveAndWait(ctx context.Context, port int, token string) {
 	m = melody.New()
 	m.Config.MaxMessageSize = 1024 * 1024 * 10 // 10MB
 	m.Config.MessageBufferSize = 1024 * 1024   // 1MB
@@ -119,7 +118,7 @@ func serveAndWait(ctx context.Context, port int) {
 	})
 
 	logger.Info(ctx, fmt.Sprintf("websocket server start at：ws://localhost:%d", port))
-	handler := cors.Default().Handler(mux)
+	handler := requireSessionToken(token, mux)
 	err := http.ListenAndServe(fmt.Sprintf("localhost:%d", port), handler)
 	if err != nil {
 		logger.Error(ctx, fmt.Sprintf("failed to start server: %s", err.Error()))
@@ -240,6 +239,9 @@ func convertPluginDto(ctx context.Context, pluginDto dto.PluginDto, pluginInstan
 		pluginDto.Setting.Settings = definitionSettings
 		pluginDto.Features = pluginInstance.Metadata.Features
 		pluginDto.TriggerKeywords = pluginInstance.GetTriggerKeywords()
+		if processStat, ok := pluginInstance.GetProcessStat(ctx); ok {
+			pluginDto.ProcessStat = &processStat
+		}
 	}
 
 	return pluginDto
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"wox/plugin"
+	"wox/share"
+	"wox/util"
+
+	"github.com/samber/lo"
+)
+
+// LaunchArgs are command line arguments of wox. Arguments telling wox what to show are forwarded to the running instance
+// if there is one, so desktop shortcuts and file managers can open wox in a specific mode
+type LaunchArgs struct {
+	Headless bool `json:"-"` // run without ui app, tray and hotkeys, E.g. for end-to-end tests on CI
+	Port     int  `json:"-"` // server port, 0 means default port
+
+	Query          string   // --query "wpm install x", query to show
+	SelectionFiles []string // --selection-file a.txt, can be repeated, files of selection query to show
+	Action         string   // --action plugin:command, command of a plugin to show, plugin is its id, name or trigger keyword
+	Deeplink       string   // wox://query?q=..., deeplink to open
+}
+
+// ParseLaunchArgs parses command line arguments, unknown arguments are ignored because OS may pass its own arguments when launching app.
+// Relative selection files are resolved against workingDirectory, because they may be handled by another process
+func ParseLaunchArgs(args []string, workingDirectory string) LaunchArgs {
+	var parsed LaunchArgs
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		// next argument is a value only if it's not another flag, E.g. "--query --headless" has no query
+		if !hasValue && lo.Contains([]string{"--port", "--query", "--selection-file", "--action"}, name) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--headless":
+			parsed.Headless = true
+		case "--port":
+			if port, err := strconv.Atoi(value); err == nil {
+				parsed.Port = port
+			}
+		case "--query":
+			parsed.Query = value
+		case "--selection-file":
+			if value != "" && !filepath.IsAbs(value) {
+				value = filepath.Join(workingDirectory, value)
+			}
+			parsed.SelectionFiles = append(parsed.SelectionFiles, value)
+		case "--action":
+			parsed.Action = value
+		default:
+			if strings.HasPrefix(args[i], "wox://") {
+				parsed.Deeplink = args[i]
+			}
+		}
+	}
+	return parsed
+}
+
+// HasContent returns true if arguments tell wox what to show, otherwise wox is just shown
+func (a LaunchArgs) HasContent() bool {
+	return a.Query != "" || len(a.SelectionFiles) > 0 || a.Action != "" || a.Deeplink != ""
+}
+
+// PostLaunch shows what launch arguments ask for. If more than one is given, deeplink goes first, then action, selection files and query
+func (m *Manager) PostLaunch(ctx context.Context, args LaunchArgs) error {
+	logger.Info(ctx, fmt.Sprintf("post launch: %+v", args))
+
+	if args.Deeplink != "" {
+		command, arguments, parseErr := parseDeeplink(args.Deeplink)
+		if parseErr != nil {
+			return parseErr
+		}
+		m.PostDeeplink(ctx, command, arguments)
+		return nil
+	}
+
+	var query share.PlainQuery
+	if args.Action != "" {
+		queryText, resolveErr := resolvePluginCommandQuery(args.Action)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		query = share.PlainQuery{QueryType: plugin.QueryTypeInput, QueryText: queryText}
+	} else if len(args.SelectionFiles) > 0 {
+		for _, file := range args.SelectionFiles {
+			if !util.IsFileExists(file) {
+				return fmt.Errorf("selection file not exist: %s", file)
+			}
+		}
+		query = share.PlainQuery{
+			QueryType:      plugin.QueryTypeSelection,
+			QuerySelection: util.Selection{Type: util.SelectionTypeFile, FilePaths: args.SelectionFiles},
+		}
+	} else if args.Query != "" {
+		query = share.PlainQuery{QueryType: plugin.QueryTypeInput, QueryText: args.Query}
+	}
+
+	if !query.IsEmpty() {
+		m.ui.ChangeQuery(ctx, query)
+	}
+	m.ui.ShowApp(ctx, share.ShowContext{SelectAll: false})
+	return nil
+}
+
+// parseDeeplink parses wox://command?key=value into command and arguments, E.g. wox://plugin/<id>?key=value has command plugin/<id>
+func parseDeeplink(deeplink string) (string, map[string]string, error) {
+	u, parseErr := url.Parse(deeplink)
+	if parseErr != nil {
+		return "", nil, fmt.Errorf("invalid deeplink: %w", parseErr)
+	}
+	if u.Scheme != "wox" || u.Host == "" {
+		return "", nil, fmt.Errorf("invalid deeplink: %s", deeplink)
+	}
+
+	arguments := map[string]string{}
+	for key, values := range u.Query() {
+		arguments[key] = values[0]
+	}
+	return u.Host + strings.TrimSuffix(u.Path, "/"), arguments, nil
+}
+
+// resolvePluginCommandQuery returns query of a plugin command, E.g. wpm:install is "wpm install "
+func resolvePluginCommandQuery(action string) (string, error) {
+	pluginName, command, _ := strings.Cut(action, ":")
+	if pluginName == "" {
+		return "", errors.New("plugin of action is empty, action should be plugin:command")
+	}
+
+	instance, found := lo.Find(plugin.GetPluginManager().GetPluginInstances(), func(item *plugin.Instance) bool {
+		return item.Metadata.Id == pluginName || strings.EqualFold(item.Metadata.Name, pluginName) || lo.Contains(item.GetTriggerKeywords(), pluginName)
+	})
+	if !found {
+		return "", fmt.Errorf("plugin not found: %s", pluginName)
+	}
+
+	triggerKeyword, hasTriggerKeyword := lo.Find(instance.GetTriggerKeywords(), func(keyword string) bool { return keyword != "*" })
+	if !hasTriggerKeyword {
+		return "", fmt.Errorf("plugin %s has no trigger keyword", instance.Metadata.Name)
+	}
+	if command == "" {
+		return triggerKeyword + " ", nil
+	}
+
+	if !lo.ContainsBy(instance.GetQueryCommands(), func(item plugin.MetadataCommand) bool { return item.Command == command }) {
+		return "", fmt.Errorf("plugin %s has no command: %s", instance.Metadata.Name, command)
+	}
+	return triggerKeyword + " " + command + " ", nil
+}
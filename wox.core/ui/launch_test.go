@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+	"wox/plugin"
+	"wox/setting"
+	"wox/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLaunchArgs(t *testing.T) {
+	workingDirectory := t.TempDir()
+
+	args := ParseLaunchArgs([]string{"--headless", "--port=35000", "-psn_0_123", "--query", "wpm install x"}, workingDirectory)
+	assert.Equal(t, LaunchArgs{Headless: true, Port: 35000, Query: "wpm install x"}, args)
+	assert.True(t, args.HasContent())
+
+	args = ParseLaunchArgs([]string{"--selection-file", "a.txt", "--selection-file=/b.txt", "--action", "wpm:install"}, workingDirectory)
+	assert.Equal(t, []string{filepath.Join(workingDirectory, "a.txt"), "/b.txt"}, args.SelectionFiles)
+	assert.Equal(t, "wpm:install", args.Action)
+
+	// flags are never taken as values of other flags
+	args = ParseLaunchArgs([]string{"--query", "--headless", "--action"}, workingDirectory)
+	assert.Equal(t, LaunchArgs{Headless: true}, args)
+
+	args = ParseLaunchArgs([]string{"wox://query?q=hello"}, workingDirectory)
+	assert.Equal(t, "wox://query?q=hello", args.Deeplink)
+
+	assert.False(t, ParseLaunchArgs(nil, workingDirectory).HasContent())
+}
+
+func TestParseDeeplink(t *testing.T) {
+	command, arguments, err := parseDeeplink("wox://query?q=hello%20world")
+	assert.NoError(t, err)
+	assert.Equal(t, "query", command)
+	assert.Equal(t, map[string]string{"q": "hello world"}, arguments)
+
+	command, arguments, err = parseDeeplink("wox://plugin/9f8f9b9b-1b2a-4b6e-8c1d-2f7a1e0c9d3e?page=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "plugin/9f8f9b9b-1b2a-4b6e-8c1d-2f7a1e0c9d3e", command)
+	assert.Equal(t, map[string]string{"page": "1"}, arguments)
+
+	_, _, err = parseDeeplink("https://example.com/query?q=1")
+	assert.Error(t, err)
+}
+
+func TestResolvePluginCommandQuery(t *testing.T) {
+	instance := &plugin.Instance{
+		Metadata: plugin.Metadata{
+			Id:              "launch-test-plugin",
+			Name:            "Launch Test",
+			TriggerKeywords: []string{"*", "lt"},
+			Commands:        []plugin.MetadataCommand{{Command: "install"}},
+		},
+		Setting: &setting.PluginSetting{Settings: util.NewHashMap[string, string]()},
+	}
+	plugin.GetPluginManager().RegisterInstance(instance)
+	t.Cleanup(func() {
+		plugin.GetPluginManager().UnloadPlugin(util.NewTraceContext(), instance)
+	})
+
+	for _, action := range []string{"launch-test-plugin:install", "launch test:install", "lt:install"} {
+		query, err := resolvePluginCommandQuery(action)
+		assert.NoError(t, err)
+		assert.Equal(t, "lt install ", query)
+	}
+
+	query, err := resolvePluginCommandQuery("lt")
+	assert.NoError(t, err)
+	assert.Equal(t, "lt ", query)
+
+	_, err = resolvePluginCommandQuery("lt:uninstall")
+	assert.EqualError(t, err, "plugin Launch Test has no command: uninstall")
+	_, err = resolvePluginCommandQuery("nope:install")
+	assert.EqualError(t, err, "plugin not found: nope")
+}
@@ -36,10 +36,13 @@ type Manager struct {
 	queryHotkeys     []*hotkey.Hotkey
 	ui               share.UI
 	serverPort       int
+	sessionToken     string
 	uiProcess        *os.Process
 	themes           *util.HashMap[string, share.Theme]
 	systemThemeIds   []string
 	isUIReadyHandled bool
+	isHeadless       bool
+	startupArgs      LaunchArgs // launch arguments of current instance, handled when ui is ready
 
 	activeWindowName string //active window name before wox is activated
 	activeWindowPid  int    //active window pid before wox is activated
@@ -59,6 +62,17 @@ func GetUIManager() *Manager {
 	return managerInstance
 }
 
+// EnableHeadless makes wox run without ui app, tray and hotkeys, ui methods are recorded instead.
+// Must be called before plugin manager is started, because plugins get ui from it
+func (m *Manager) EnableHeadless() {
+	m.isHeadless = true
+	m.ui = newHeadlessUI()
+}
+
+func (m *Manager) IsHeadless() bool {
+	return m.isHeadless
+}
+
 func (m *Manager) Start(ctx context.Context) error {
 	//load embed themes
 	embedThemes := resource.GetEmbedThemes(ctx)
@@ -160,6 +174,11 @@ func (m *Manager) Stop(ctx context.Context) {
 		return
 	}
 
+	if m.uiProcess == nil {
+		logger.Info(ctx, "ui app is not started, skip stopping")
+		return
+	}
+
 	logger.Info(ctx, "start stopping ui app")
 	var pid = m.uiProcess.Pid
 	killErr := m.uiProcess.Kill()
@@ -268,7 +287,7 @@ func (m *Manager) RegisterQueryHotkey(ctx context.Context, queryHotkey setting.Q
 }
 
 func (m *Manager) StartWebsocketAndWait(ctx context.Context) {
-	serveAndWait(ctx, m.serverPort)
+	serveAndWait(ctx, m.serverPort, m.sessionToken)
 }
 
 func (m *Manager) UpdateServerPort(port int) {
@@ -298,6 +317,7 @@ func (m *Manager) StartUIApp(ctx context.Context) error {
 		fmt.Sprintf("%d", m.serverPort),
 		fmt.Sprintf("%d", os.Getpid()),
 		fmt.Sprintf("%t", util.IsDev()),
+		util.GetLocation().GetSessionTokenPath(),
 	)
 	if cmdErr != nil {
 		return cmdErr
@@ -395,12 +415,24 @@ func (m *Manager) PostUIReady(ctx context.Context) {
 	}
 	m.isUIReadyHandled = true
 
+	if m.startupArgs.HasContent() {
+		if launchErr := m.PostLaunch(ctx, m.startupArgs); launchErr != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to handle launch arguments: %s", launchErr.Error()))
+		}
+		return
+	}
+
 	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
 	if !woxSetting.HideOnStart {
 		m.ui.ShowApp(ctx, share.ShowContext{SelectAll: false})
 	}
 }
 
+// SetStartupArgs keeps launch arguments of current instance, what they ask for is shown when ui is ready
+func (m *Manager) SetStartupArgs(args LaunchArgs) {
+	m.startupArgs = args
+}
+
 func (m *Manager) PostOnShow(ctx context.Context) {
 	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
 	if woxSetting.SwitchInputMethodABC {
@@ -461,6 +493,12 @@ func (m *Manager) HideTray() {
 }
 
 func (m *Manager) PostSettingUpdate(ctx context.Context, key, value string) {
+	// there is no tray and hotkeys in headless mode
+	if m.isHeadless && lo.Contains([]string{"ShowTray", "MainHotkey", "SelectionHotkey", "QueryHotkeys"}, key) {
+		logger.Info(ctx, fmt.Sprintf("skip applying %s in headless mode", key))
+		return
+	}
+
 	if key == "ShowTray" {
 		if value == "true" {
 			m.ShowTray()
@@ -487,6 +525,30 @@ func (m *Manager) PostSettingUpdate(ctx context.Context, key, value string) {
 			m.RegisterQueryHotkey(ctx, queryHotkey)
 		}
 	}
+	if key == "ScriptCommandDirectory" {
+		plugin.GetPluginManager().OnScriptCommandDirectoryChanged(ctx)
+	}
+	if key == "PluginStores" {
+		util.Go(ctx, "refresh store plugins", func() {
+			plugin.GetStoreManager().Refresh(ctx)
+		})
+	}
+	if key == "ThemeStores" {
+		util.Go(ctx, "refresh store themes", func() {
+			GetStoreManager().Refresh(ctx)
+		})
+	}
+	if key == "PythonPath" || key == "NodejsPath" {
+		runtime := plugin.PLUGIN_RUNTIME_PYTHON
+		if key == "NodejsPath" {
+			runtime = plugin.PLUGIN_RUNTIME_NODEJS
+		}
+		util.Go(ctx, fmt.Sprintf("restart %s host", runtime), func() {
+			if restartErr := plugin.GetPluginManager().RestartHost(ctx, runtime); restartErr != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to restart %s host: %s", runtime, restartErr.Error()))
+			}
+		})
+	}
 	if key == "EnableAutostart" {
 		enabled := value == "true"
 		err := autostart.SetAutostart(ctx, enabled)
@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"wox/ai"
 	"wox/i18n"
@@ -23,19 +26,23 @@ import (
 
 var routers = map[string]func(w http.ResponseWriter, r *http.Request){
 	// plugins
-	"/plugin/store":     handlePluginStore,
-	"/plugin/installed": handlePluginInstalled,
-	"/plugin/install":   handlePluginInstall,
-	"/plugin/uninstall": handlePluginUninstall,
-	"/plugin/disable":   handlePluginDisable,
-	"/plugin/enable":    handlePluginEnable,
+	"/plugin/store":        handlePluginStore,
+	"/plugin/store/status": handlePluginStoreStatus,
+	"/plugin/installed":    handlePluginInstalled,
+	"/plugin/load/errors":  handlePluginLoadErrors,
+	"/plugin/install":      handlePluginInstall,
+	"/plugin/uninstall":    handlePluginUninstall,
+	"/plugin/rollback":     handlePluginRollback,
+	"/plugin/disable":      handlePluginDisable,
+	"/plugin/enable":       handlePluginEnable,
 
 	//	themes
-	"/theme":           handleTheme,
-	"/theme/store":     handleThemeStore,
-	"/theme/installed": handleThemeInstalled,
-	"/theme/install":   handleThemeInstall,
-	"/theme/uninstall": handleThemeUninstall,
+	"/theme":              handleTheme,
+	"/theme/store":        handleThemeStore,
+	"/theme/store/status": handleThemeStoreStatus,
+	"/theme/installed":    handleThemeInstalled,
+	"/theme/install":      handleThemeInstall,
+	"/theme/uninstall":    handleThemeUninstall,
 
 	// settings
 	"/setting/wox":           handleSettingWox,
@@ -54,10 +61,22 @@ var routers = map[string]func(w http.ResponseWriter, r *http.Request){
 
 	// ai
 	"/ai/models": handleAIModels,
+	"/ai/usage":  handleAIUsage,
 
 	// doctor
 	"/doctor/check": handleDoctorCheck,
 
+	// control api for scripts and editors, see docs/control_api.md
+	"/control/query":   handleControlQuery,
+	"/control/results": handleControlResults,
+	"/control/action":  handleControlAction,
+	"/control/toggle":  handleControlToggle,
+	"/control/setting": handleControlSetting,
+	"/control/launch":  handleControlLaunch,
+
+	// headless, only available when wox is started with --headless
+	"/headless/state": handleHeadlessState,
+
 	// others
 	"/":                 handleHome,
 	"/show":             handleShow,
@@ -140,12 +159,33 @@ func handlePluginStore(w http.ResponseWriter, r *http.Request) {
 		})
 		plugins[i].Icon = plugin.NewWoxImageUrl(manifests[i].IconUrl)
 		plugins[i].IsInstalled = isInstalled
+		if compatibleErr := manifests[i].CheckCompatibility(getCtx); compatibleErr != nil {
+			plugins[i].IncompatibleReason = compatibleErr.Error()
+		}
+		compatibleVersions := manifests[i].GetCompatibleVersions(getCtx)
+		plugins[i].CompatibleVersions = lo.Map(compatibleVersions, func(item plugin.StorePluginManifest, _ int) string {
+			return item.Version
+		})
+		// user confirms permissions of the version being installed, which may differ from the latest version
+		plugins[i].VersionPermissions = lo.SliceToMap(compatibleVersions, func(item plugin.StorePluginManifest) (string, setting.PluginPermissions) {
+			return item.Version, item.Permissions
+		})
 		plugins[i] = convertPluginDto(getCtx, plugins[i], pluginInstance)
 	}
 
 	writeSuccessResponse(w, plugins)
 }
 
+// handlePluginStoreStatus returns last sync time and error of each plugin store, store list may be stale if sync failed
+func handlePluginStoreStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+	writeSuccessResponse(w, plugin.GetStoreManager().GetSyncStatus(ctx))
+}
+
+func handlePluginLoadErrors(w http.ResponseWriter, r *http.Request) {
+	writeSuccessResponse(w, plugin.GetPluginManager().GetLoadErrors())
+}
+
 func handlePluginInstalled(w http.ResponseWriter, r *http.Request) {
 	defer util.GoRecover(util.NewTraceContext(), "get installed plugins")
 
@@ -168,9 +208,17 @@ func handlePluginInstalled(w http.ResponseWriter, r *http.Request) {
 		storePlugin, foundErr := plugin.GetStoreManager().GetStorePluginManifestById(getCtx, pluginInstance.Metadata.Id)
 		if foundErr == nil {
 			installedPlugin.ScreenshotUrls = storePlugin.ScreenshotUrls
+			installedPlugin.Store = storePlugin.Store
 		} else {
 			installedPlugin.ScreenshotUrls = []string{}
 		}
+		if updateManifest, hasUpdate := plugin.GetStoreManager().GetUpdate(getCtx, pluginInstance); hasUpdate {
+			installedPlugin.UpdateVersion = updateManifest.Version
+			installedPlugin.VersionPermissions = map[string]setting.PluginPermissions{updateManifest.Version: updateManifest.Permissions}
+		}
+		if rollbackVersion, hasRollback := plugin.GetStoreManager().GetRollbackVersion(getCtx, pluginInstance); hasRollback {
+			installedPlugin.RollbackVersion = rollbackVersion
+		}
 
 		// load icon
 		iconImg, parseErr := plugin.ParseWoxImage(pluginInstance.Metadata.Icon)
@@ -213,7 +261,14 @@ func handlePluginInstall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	installErr := plugin.GetStoreManager().Install(ctx, findPlugin)
+	// user may choose a previous version if latest version is not compatible
+	installPlugin, versionErr := findPlugin.GetInstallVersion(ctx, gjson.GetBytes(body, "version").String())
+	if versionErr != nil {
+		writeErrorResponse(w, "can't install plugin: "+versionErr.Error())
+		return
+	}
+
+	installErr := plugin.GetStoreManager().Install(ctx, installPlugin)
 	if installErr != nil {
 		writeErrorResponse(w, "can't install plugin: "+installErr.Error())
 		return
@@ -255,6 +310,39 @@ func handlePluginUninstall(w http.ResponseWriter, r *http.Request) {
 	writeSuccessResponse(w, "")
 }
 
+func handlePluginRollback(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+
+	body, _ := io.ReadAll(r.Body)
+	idResult := gjson.GetBytes(body, "id")
+	if !idResult.Exists() {
+		writeErrorResponse(w, "id is empty")
+		return
+	}
+
+	pluginId := idResult.String()
+
+	plugins := plugin.GetPluginManager().GetPluginInstances()
+	findPlugin, exist := lo.Find(plugins, func(item *plugin.Instance) bool {
+		if item.Metadata.Id == pluginId {
+			return true
+		}
+		return false
+	})
+	if !exist {
+		writeErrorResponse(w, "can't find plugin")
+		return
+	}
+
+	rollbackErr := plugin.GetStoreManager().Rollback(ctx, findPlugin)
+	if rollbackErr != nil {
+		writeErrorResponse(w, "can't rollback plugin: "+rollbackErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, "")
+}
+
 func handlePluginDisable(w http.ResponseWriter, r *http.Request) {
 	ctx := util.NewTraceContext()
 
@@ -343,6 +431,12 @@ func handleThemeStore(w http.ResponseWriter, r *http.Request) {
 	writeSuccessResponse(w, themes)
 }
 
+// handleThemeStoreStatus returns last sync time and error of each theme store
+func handleThemeStoreStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+	writeSuccessResponse(w, GetStoreManager().GetSyncStatus(ctx))
+}
+
 func handleThemeInstalled(w http.ResponseWriter, r *http.Request) {
 	ctx := util.NewTraceContext()
 
@@ -461,20 +555,16 @@ func handleSettingWoxUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updateErr := setting.GetSettingManager().UpdateWoxSetting(util.NewTraceContext(), kv.Key, kv.Value)
+	updateErr := updateWoxSetting(util.NewTraceContext(), kv.Key, kv.Value)
 	if updateErr != nil {
 		writeErrorResponse(w, updateErr.Error())
 		return
 	}
 
-	GetUIManager().PostSettingUpdate(util.NewTraceContext(), kv.Key, kv.Value)
-
 	writeSuccessResponse(w, "")
 }
 
 func handleSettingPluginUpdate(w http.ResponseWriter, r *http.Request) {
-	ctx := util.NewTraceContext()
-
 	type keyValuePair struct {
 		PluginId string
 		Key      string
@@ -489,35 +579,72 @@ func handleSettingPluginUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	updateErr := updatePluginSetting(util.NewTraceContext(), kv.PluginId, kv.Key, kv.Value)
+	if updateErr != nil {
+		writeErrorResponse(w, updateErr.Error())
+		return
+	}
+
+	writeSuccessResponse(w, "")
+}
+
+func updateWoxSetting(ctx context.Context, key string, value string) error {
+	updateErr := setting.GetSettingManager().UpdateWoxSetting(ctx, key, value)
+	if updateErr != nil {
+		return updateErr
+	}
+
+	GetUIManager().PostSettingUpdate(ctx, key, value)
+	return nil
+}
+
+func updatePluginSetting(ctx context.Context, pluginId string, key string, value string) error {
 	pluginInstance, exist := lo.Find(plugin.GetPluginManager().GetPluginInstances(), func(item *plugin.Instance) bool {
-		if item.Metadata.Id == kv.PluginId {
+		if item.Metadata.Id == pluginId {
 			return true
 		}
 		return false
 	})
 	if !exist {
-		writeErrorResponse(w, "can't find plugin")
-		return
+		return errors.New("can't find plugin")
 	}
 
-	if kv.Key == "Disabled" {
-		pluginInstance.Setting.Disabled = kv.Value == "true"
+	if key == "Disabled" {
+		pluginInstance.Setting.Disabled = value == "true"
 		pluginInstance.SaveSetting(ctx)
-	} else if kv.Key == "TriggerKeywords" {
-		pluginInstance.Setting.TriggerKeywords = strings.Split(kv.Value, ",")
+	} else if key == "IsolatedHost" {
+		pluginInstance.Setting.IsolatedHost = value == "true"
+		pluginInstance.SaveSetting(ctx)
+		// plugin is moved between shared and isolated host process by loading it again
+		if pluginInstance.Host != nil {
+			reloadErr := plugin.GetPluginManager().ReloadPlugin(ctx, plugin.MetadataWithDirectory{
+				Metadata:           pluginInstance.Metadata,
+				Directory:          pluginInstance.PluginDirectory,
+				IsDev:              pluginInstance.IsDevPlugin,
+				DevPluginDirectory: pluginInstance.DevPluginDirectory,
+			})
+			if reloadErr != nil {
+				return reloadErr
+			}
+		}
+	} else if key == "AutoUpdate" {
+		pluginInstance.Setting.AutoUpdate = value == "true"
+		pluginInstance.SaveSetting(ctx)
+	} else if key == "TriggerKeywords" {
+		pluginInstance.Setting.TriggerKeywords = strings.Split(value, ",")
 		pluginInstance.SaveSetting(ctx)
 	} else {
 		var isPlatformSpecific = false
 		for _, settingDefinition := range pluginInstance.Metadata.SettingDefinitions {
-			if settingDefinition.Value != nil && settingDefinition.Value.GetKey() == kv.Key {
+			if settingDefinition.Value != nil && settingDefinition.Value.GetKey() == key {
 				isPlatformSpecific = settingDefinition.IsPlatformSpecific
 				break
 			}
 		}
-		pluginInstance.API.SaveSetting(util.NewTraceContext(), kv.Key, kv.Value, isPlatformSpecific)
+		pluginInstance.API.SaveSetting(ctx, key, value, isPlatformSpecific)
 	}
 
-	writeSuccessResponse(w, "")
+	return nil
 }
 
 func handleOpenUrl(w http.ResponseWriter, r *http.Request) {
@@ -764,6 +891,26 @@ func handleAIModels(w http.ResponseWriter, r *http.Request) {
 	writeSuccessResponse(w, results)
 }
 
+func handleAIUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := util.NewTraceContext()
+
+	var results = []dto.AIUsageDto{}
+	for provider, usage := range setting.GetSettingManager().GetAIUsages(ctx) {
+		results = append(results, dto.AIUsageDto{
+			Provider:          provider,
+			RequestCount:      usage.RequestCount,
+			PromptTokens:      usage.PromptTokens,
+			CompletionTokens:  usage.CompletionTokens,
+			LastUsedTimestamp: usage.LastUsedTimestamp,
+		})
+	}
+	slices.SortFunc(results, func(a, b dto.AIUsageDto) int {
+		return strings.Compare(a.Provider, b.Provider)
+	})
+
+	writeSuccessResponse(w, results)
+}
+
 func handleDoctorCheck(w http.ResponseWriter, r *http.Request) {
 	ctx := util.NewTraceContext()
 	results := plugin.RunDoctorChecks(ctx)
@@ -776,3 +923,21 @@ func handleDoctorCheck(w http.ResponseWriter, r *http.Request) {
 	}
 	writeSuccessResponse(w, allPassed)
 }
+
+func getHeadlessUI(w http.ResponseWriter) (*headlessUI, bool) {
+	headless, ok := GetUIManager().GetUI(util.NewTraceContext()).(*headlessUI)
+	if !ok {
+		writeErrorResponse(w, "wox is not running in headless mode")
+		return nil, false
+	}
+	return headless, true
+}
+
+func handleHeadlessState(w http.ResponseWriter, r *http.Request) {
+	headless, ok := getHeadlessUI(w)
+	if !ok {
+		return
+	}
+
+	writeSuccessResponse(w, headless.getState(r.URL.Query().Get("clear") == "true"))
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
 	"sync"
 	"time"
+	"wox/setting"
 	"wox/share"
 	"wox/util"
 
@@ -15,11 +17,6 @@ import (
 	"github.com/tidwall/pretty"
 )
 
-type storeManifest struct {
-	Name string
-	Url  string
-}
-
 var storeInstance *Store
 var storeOnce sync.Once
 
@@ -34,44 +31,64 @@ func GetStoreManager() *Store {
 	return storeInstance
 }
 
-func (s *Store) getStoreManifests(ctx context.Context) []storeManifest {
-	return []storeManifest{
-		{
-			Name: "Wox Official Theme Store",
-			Url:  "https://raw.githubusercontent.com/Wox-launcher/Wox/v2/store-theme.json",
-		},
-	}
+// getStoreSources returns enabled theme stores, store with higher priority first
+func (s *Store) getStoreSources(ctx context.Context) []setting.StoreSource {
+	sources := lo.Filter(setting.GetSettingManager().GetWoxSetting(ctx).ThemeStores, func(source setting.StoreSource, _ int) bool {
+		return source.Enabled
+	})
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority > sources[j].Priority
+	})
+	return sources
 }
 
+// Start loads cached themes, so store is available right after startup and offline, then syncs stores every 10 minutes in the background
 func (s *Store) Start(ctx context.Context) {
-	s.themes = s.GetStoreThemes(ctx)
+	s.themes = s.getStoreThemes(ctx, util.ReadCachedStoreManifests)
 
 	util.Go(ctx, "load theme plugins", func() {
-		for range time.NewTicker(time.Minute * 10).C {
-			pluginManifests := s.GetStoreThemes(util.NewTraceContext())
-			if len(pluginManifests) > 0 {
-				s.themes = pluginManifests
+		for {
+			themes := s.GetStoreThemes(util.NewTraceContext())
+			if len(themes) > 0 {
+				s.themes = themes
 			}
+			time.Sleep(time.Minute * 10)
 		}
 	})
 }
 
+// GetSyncStatus returns result of the last sync of each enabled store
+func (s *Store) GetSyncStatus(ctx context.Context) []util.StoreSyncStatus {
+	return lo.Map(s.getStoreSources(ctx), func(store setting.StoreSource, _ int) util.StoreSyncStatus {
+		return util.GetStoreSyncStatus(store.Name, store.Url)
+	})
+}
+
+// Refresh loads themes from stores again, e.g. user changed stores in settings
+func (s *Store) Refresh(ctx context.Context) {
+	s.themes = s.GetStoreThemes(ctx)
+}
+
 func (s *Store) GetStoreThemes(ctx context.Context) []share.Theme {
+	return s.getStoreThemes(ctx, util.ReadStoreManifests)
+}
+
+func (s *Store) getStoreThemes(ctx context.Context, readManifests util.StoreManifestReader) []share.Theme {
 	var storeThemeManifests []share.Theme
 
-	for _, store := range s.getStoreManifests(ctx) {
-		themeManifest, manifestErr := s.GetStoreTheme(ctx, store)
+	for _, store := range s.getStoreSources(ctx) {
+		themeManifest, manifestErr := s.GetStoreTheme(ctx, store, readManifests)
 		if manifestErr != nil {
 			logger.Error(ctx, fmt.Sprintf("failed to get theme manifest from %s store: %s", store.Name, manifestErr.Error()))
 			continue
 		}
 
 		for _, manifest := range themeManifest {
-			_, found := lo.Find(storeThemeManifests, func(manifest share.Theme) bool {
-				return manifest.ThemeId == manifest.ThemeId
+			// stores are sorted by priority, theme from store with lower priority is skipped
+			_, found := lo.Find(storeThemeManifests, func(item share.Theme) bool {
+				return item.ThemeId == manifest.ThemeId
 			})
 			if found {
-				//skip duplicated theme
 				continue
 			}
 
@@ -83,18 +100,21 @@ func (s *Store) GetStoreThemes(ctx context.Context) []share.Theme {
 	return storeThemeManifests
 }
 
-func (s *Store) GetStoreTheme(ctx context.Context, store storeManifest) ([]share.Theme, error) {
+func (s *Store) GetStoreTheme(ctx context.Context, store setting.StoreSource, readManifests util.StoreManifestReader) ([]share.Theme, error) {
 	logger.Info(ctx, fmt.Sprintf("start to get theme manifest from %s(%s)", store.Name, store.Url))
 
-	response, getErr := util.HttpGet(ctx, store.Url)
-	if getErr != nil {
-		return nil, getErr
+	rawManifests, readErr := readManifests(ctx, store.Url)
+	if readErr != nil {
+		return nil, readErr
 	}
 
 	var storeThemeManifests []share.Theme
-	unmarshalErr := json.Unmarshal(response, &storeThemeManifests)
-	if unmarshalErr != nil {
-		return nil, unmarshalErr
+	for _, rawManifest := range rawManifests {
+		var theme share.Theme
+		if unmarshalErr := json.Unmarshal(rawManifest, &theme); unmarshalErr != nil {
+			return nil, unmarshalErr
+		}
+		storeThemeManifests = append(storeThemeManifests, theme)
 	}
 
 	return storeThemeManifests, nil
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"wox/setting"
+	"wox/share"
+	"wox/ui/dto"
+	"wox/util"
+)
+
+// headlessUI is used when wox runs without ui app, E.g. end-to-end tests on CI machines without display.
+// Nothing is shown, ui methods are recorded as events which can be retrieved by /headless/state
+type headlessUI struct {
+	lock         sync.Mutex
+	events       []dto.HeadlessEventDto
+	isVisible    bool
+	currentQuery share.PlainQuery
+}
+
+func newHeadlessUI() *headlessUI {
+	return &headlessUI{}
+}
+
+func (u *headlessUI) record(ctx context.Context, method string, data any) {
+	logger.Info(ctx, fmt.Sprintf("[Headless UI] %s", method))
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.events = append(u.events, dto.HeadlessEventDto{
+		Timestamp: util.GetSystemTimestamp(),
+		Method:    method,
+		Data:      data,
+	})
+}
+
+func (u *headlessUI) ChangeQuery(ctx context.Context, query share.PlainQuery) {
+	u.lock.Lock()
+	u.currentQuery = query
+	u.lock.Unlock()
+	u.record(ctx, "ChangeQuery", query)
+}
+
+func (u *headlessUI) HideApp(ctx context.Context) {
+	u.setVisible(false)
+	u.record(ctx, "HideApp", nil)
+}
+
+func (u *headlessUI) ShowApp(ctx context.Context, showContext share.ShowContext) {
+	u.setVisible(true)
+	u.record(ctx, "ShowApp", showContext)
+}
+
+func (u *headlessUI) ToggleApp(ctx context.Context) {
+	u.lock.Lock()
+	u.isVisible = !u.isVisible
+	u.lock.Unlock()
+	u.record(ctx, "ToggleApp", nil)
+}
+
+func (u *headlessUI) OpenSettingWindow(ctx context.Context, windowContext share.SettingWindowContext) {
+	u.record(ctx, "OpenSettingWindow", windowContext)
+}
+
+// PickFiles picks nothing, there is no one to pick files in headless mode
+func (u *headlessUI) PickFiles(ctx context.Context, params share.PickFilesParams) []string {
+	u.record(ctx, "PickFiles", params)
+	return nil
+}
+
+func (u *headlessUI) GetActiveWindowName() string {
+	return GetUIManager().GetActiveWindowName()
+}
+
+func (u *headlessUI) GetActiveWindowPid() int {
+	return GetUIManager().GetActiveWindowPid()
+}
+
+func (u *headlessUI) GetServerPort(ctx context.Context) int {
+	return GetUIManager().serverPort
+}
+
+func (u *headlessUI) GetAllThemes(ctx context.Context) []share.Theme {
+	return GetUIManager().GetAllThemes(ctx)
+}
+
+func (u *headlessUI) ChangeTheme(ctx context.Context, theme share.Theme) {
+	woxSetting := setting.GetSettingManager().GetWoxSetting(ctx)
+	woxSetting.ThemeId = theme.ThemeId
+	setting.GetSettingManager().SaveWoxSetting(ctx)
+	u.record(ctx, "ChangeTheme", theme.ThemeId)
+}
+
+func (u *headlessUI) InstallTheme(ctx context.Context, theme share.Theme) {
+	GetStoreManager().Install(ctx, theme)
+	u.record(ctx, "InstallTheme", theme.ThemeId)
+}
+
+func (u *headlessUI) UninstallTheme(ctx context.Context, theme share.Theme) {
+	GetStoreManager().Uninstall(ctx, theme)
+	GetUIManager().ChangeToDefaultTheme(ctx)
+	u.record(ctx, "UninstallTheme", theme.ThemeId)
+}
+
+func (u *headlessUI) RestoreTheme(ctx context.Context) {
+	GetUIManager().RestoreTheme(ctx)
+	u.record(ctx, "RestoreTheme", nil)
+}
+
+func (u *headlessUI) Notify(ctx context.Context, msg share.NotifyMsg) {
+	u.record(ctx, "Notify", msg)
+}
+
+func (u *headlessUI) setVisible(visible bool) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.isVisible = visible
+}
+
+// getState returns visibility, current query and recorded events in order, events are removed if clear is true
+func (u *headlessUI) getState(clear bool) dto.HeadlessStateDto {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	state := dto.HeadlessStateDto{
+		IsVisible:    u.isVisible,
+		CurrentQuery: u.currentQuery,
+		Events:       append([]dto.HeadlessEventDto{}, u.events...),
+	}
+	if clear {
+		u.events = nil
+	}
+	return state
+}
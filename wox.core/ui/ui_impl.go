@@ -233,7 +233,13 @@ func onUIWebsocketResponse(ctx context.Context, response WebsocketMsg) {
 		return
 	}
 
-	resultChan, exist := GetUIManager().GetUI(ctx).(*uiImpl).requestMap.Load(requestID)
+	impl, ok := GetUIManager().GetUI(ctx).(*uiImpl)
+	if !ok {
+		logger.Error(ctx, fmt.Sprintf("no ui is waiting for response: %s", requestID))
+		return
+	}
+
+	resultChan, exist := impl.requestMap.Load(requestID)
 	if !exist {
 		logger.Error(ctx, fmt.Sprintf("response id not found: %s", requestID))
 		return
@@ -352,7 +358,7 @@ func handleWebsocketQuery(ctx context.Context, request WebsocketMsg) {
 	})
 	resultDebouncer.Start(ctx)
 	logger.Info(ctx, fmt.Sprintf("query %s: %s, result flushed (new start)", query.Type, query.String()))
-	resultChan, doneChan := plugin.GetPluginManager().Query(ctx, query)
+	resultChan, doneChan := plugin.GetPluginManager().Query(plugin.NewQuerySourceContext(ctx, "ui"), query)
 	for {
 		select {
 		case results := <-resultChan:
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"strings"
+)
+
+type DiffOperation = string
+
+const (
+	DiffOperationEqual  DiffOperation = " "
+	DiffOperationDelete DiffOperation = "-"
+	DiffOperationInsert DiffOperation = "+"
+)
+
+type DiffLine struct {
+	Operation DiffOperation
+	Text      string
+}
+
+// diffMaxCells limits the size of the longest common subsequence table, which needs memory of old lines * new lines
+const diffMaxCells = 1_000_000
+
+// DiffLines compares two texts line by line using longest common subsequence.
+// Texts too large to compare are shown as all old lines deleted and all new lines inserted
+func DiffLines(oldText string, newText string) []DiffLine {
+	oldLines := strings.Split(oldText, "\n")
+	newLines := strings.Split(newText, "\n")
+	if (len(oldLines)+1)*(len(newLines)+1) > diffMaxCells {
+		var lines []DiffLine
+		for _, line := range oldLines {
+			lines = append(lines, DiffLine{Operation: DiffOperationDelete, Text: line})
+		}
+		for _, line := range newLines {
+			lines = append(lines, DiffLine{Operation: DiffOperationInsert, Text: line})
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			lines = append(lines, DiffLine{Operation: DiffOperationEqual, Text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Operation: DiffOperationDelete, Text: oldLines[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Operation: DiffOperationInsert, Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		lines = append(lines, DiffLine{Operation: DiffOperationDelete, Text: oldLines[i]})
+	}
+	for ; j < len(newLines); j++ {
+		lines = append(lines, DiffLine{Operation: DiffOperationInsert, Text: newLines[j]})
+	}
+
+	return lines
+}
+
+// DiffText returns the line diff of two texts in unified diff style (without hunk headers)
+func DiffText(oldText string, newText string) string {
+	var sb strings.Builder
+	for _, line := range DiffLines(oldText, newText) {
+		sb.WriteString(line.Operation)
+		sb.WriteString(line.Text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDiffText(t *testing.T) {
+	assert.Equal(t, " a\n-b\n+B\n c\n", DiffText("a\nb\nc", "a\nB\nc"))
+	assert.Equal(t, " a\n+b\n", DiffText("a", "a\nb"))
+	assert.Equal(t, "-a\n+b\n", DiffText("a", "b"))
+
+	// large texts are not compared line by line
+	largeText := strings.Repeat("a\n", 2000)
+	lines := DiffLines(largeText, largeText)
+	assert.Len(t, lines, 4002)
+	assert.Equal(t, DiffOperationDelete, lines[0].Operation)
+	assert.Equal(t, DiffOperationInsert, lines[4001].Operation)
+}
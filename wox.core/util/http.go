@@ -2,6 +2,8 @@ package util
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -20,6 +22,31 @@ func HttpGet(ctx context.Context, url string) ([]byte, error) {
 	return resp.Body(), nil
 }
 
+// HttpGetConditional sends a GET request revalidating a cached response with its ETag and Last-Modified,
+// notModified is true if server responds 304, which means the cached response is still valid
+func HttpGetConditional(ctx context.Context, url string, etag string, lastModified string) (body []byte, header http.Header, notModified bool, err error) {
+	request := getClient().R()
+	if etag != "" {
+		request.SetHeader("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		request.SetHeader("If-Modified-Since", lastModified)
+	}
+
+	resp, err := request.Get(url)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if resp.StatusCode() == http.StatusNotModified {
+		return nil, resp.Header(), true, nil
+	}
+	if resp.IsError() {
+		return nil, nil, false, fmt.Errorf("unexpected response status: %s", resp.Status())
+	}
+
+	return resp.Body(), resp.Header(), false, nil
+}
+
 func HttpPost(ctx context.Context, url string, body any) ([]byte, error) {
 	resp, err := getClient().R().SetBody(body).Post(url)
 	if err != nil {
@@ -32,8 +32,13 @@ func (l *Location) Init() error {
 		return fmt.Errorf("failed to get user home dir: %w", err)
 	}
 
+	return l.InitWithDataDirectory(path.Join(dirname, ".wox"))
+}
+
+// InitWithDataDirectory uses given directory instead of ~/.wox as wox data directory, E.g. a temp directory in tests
+func (l *Location) InitWithDataDirectory(woxDataDirectory string) error {
 	// check if wox data directory exists, if not, create it
-	l.woxDataDirectory = path.Join(dirname, ".wox")
+	l.woxDataDirectory = woxDataDirectory
 	if directoryErr := l.EnsureDirectoryExist(l.woxDataDirectory); directoryErr != nil {
 		return directoryErr
 	}
@@ -106,6 +111,12 @@ func (l *Location) Init() error {
 	if directoryErr := l.EnsureDirectoryExist(l.GetBackupDirectory()); directoryErr != nil {
 		return directoryErr
 	}
+	if directoryErr := l.EnsureDirectoryExist(l.GetVectorIndexDirectory()); directoryErr != nil {
+		return directoryErr
+	}
+	if directoryErr := l.EnsureDirectoryExist(l.GetScriptCommandDirectory()); directoryErr != nil {
+		return directoryErr
+	}
 
 	return nil
 }
@@ -141,6 +152,17 @@ func (l *Location) GetPluginDirectory() string {
 	return path.Join(l.userDataDirectory, "plugins")
 }
 
+// GetPluginStagingDirectory returns the directory to extract plugin packages before installing. It's on the same volume as
+// plugin directory, so staged plugins can be renamed into plugin directory when user data directory is relocated
+func (l *Location) GetPluginStagingDirectory() string {
+	return path.Join(l.userDataDirectory, "plugin_staging")
+}
+
+// GetPluginBackupDirectory returns the directory to keep previous versions of upgraded plugins for rollback
+func (l *Location) GetPluginBackupDirectory() string {
+	return path.Join(l.userDataDirectory, "plugin_backup")
+}
+
 func (l *Location) GetThemeDirectory() string {
 	return path.Join(l.userDataDirectory, "themes")
 }
@@ -181,6 +203,15 @@ func (l *Location) GetBackupDirectory() string {
 	return path.Join(l.woxDataDirectory, "backup")
 }
 
+func (l *Location) GetVectorIndexDirectory() string {
+	return path.Join(l.woxDataDirectory, "vector_index")
+}
+
+// GetScriptCommandDirectory returns the default directory of script commands
+func (l *Location) GetScriptCommandDirectory() string {
+	return path.Join(l.userDataDirectory, "scripts")
+}
+
 func (l *Location) GetUIAppPath() string {
 	if IsWindows() {
 		return path.Join(l.GetUIDirectory(), "flutter", "wox", "wox.exe")
@@ -197,3 +228,8 @@ func (l *Location) GetUIAppPath() string {
 func (l *Location) GetAppLockPath() string {
 	return path.Join(l.GetWoxDataDirectory(), "wox.lock")
 }
+
+// GetSessionTokenPath returns path of the file keeping token of current session, clients read it to call wox http api
+func (l *Location) GetSessionTokenPath() string {
+	return path.Join(l.GetWoxDataDirectory(), "wox.token")
+}
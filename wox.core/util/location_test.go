@@ -0,0 +1,19 @@
+package util
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocationInitWithDataDirectory(t *testing.T) {
+	woxDataDirectory := t.TempDir()
+	location := &Location{}
+	assert.Nil(t, location.InitWithDataDirectory(woxDataDirectory))
+
+	// user data directory defaults to wox-user in wox data directory
+	assert.Equal(t, path.Join(woxDataDirectory, "wox-user"), location.GetUserDataDirectory())
+	assert.True(t, IsDirExists(location.GetPluginDirectory()))
+	assert.True(t, IsDirExists(location.GetHostDirectory()))
+}
@@ -1,9 +1,12 @@
 package util
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"syscall"
 )
 
 func ShellOpen(path string) error {
@@ -39,6 +42,36 @@ func ShellRunWithEnv(name string, envs []string, arg ...string) (*exec.Cmd, erro
 	return cmd, nil
 }
 
+// ShellRunWithStderr is same as ShellRunWithEnv, but stderr of the process is also written to the given writer
+func ShellRunWithStderr(name string, envs []string, stderr io.Writer, arg ...string) (*exec.Cmd, error) {
+	cmd := exec.Command(name, arg...)
+	cmd.Stdout = GetLogger().GetWriter()
+	cmd.Stderr = io.MultiWriter(GetLogger().GetWriter(), stderr)
+	cmd.Env = append(os.Environ(), envs...)
+	cmdErr := cmd.Start()
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+
+	return cmd, nil
+}
+
+// ShellCommand creates a command without starting it, caller is responsible for the stdio of the command
+func ShellCommand(name string, arg ...string) *exec.Cmd {
+	cmd := exec.Command(name, arg...)
+	return cmd
+}
+
+// ShellCommandContext is same as ShellCommand, but the whole process group of the command is killed when ctx is done
+func ShellCommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, arg...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
+
 func ShellRunOutput(name string, arg ...string) ([]byte, error) {
 	cmd := exec.Command(name, arg...)
 	output, err := cmd.CombinedOutput()
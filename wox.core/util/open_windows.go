@@ -1,9 +1,12 @@
 package util
 
 import (
+	"context"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"syscall"
 )
 
@@ -42,6 +45,43 @@ func ShellRunWithEnv(name string, envs []string, arg ...string) (*exec.Cmd, erro
 	return cmd, nil
 }
 
+// ShellRunWithStderr is same as ShellRunWithEnv, but stderr of the process is also written to the given writer
+func ShellRunWithStderr(name string, envs []string, stderr io.Writer, arg ...string) (*exec.Cmd, error) {
+	cmd := exec.Command(name, arg...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // Hide the window
+	cmd.Stdout = GetLogger().GetWriter()
+	cmd.Stderr = io.MultiWriter(GetLogger().GetWriter(), stderr)
+	cmd.Env = append(append(os.Environ(), "PYTHONIOENCODING=utf-8"), envs...)
+	cmdErr := cmd.Start()
+	if cmdErr != nil {
+		return nil, cmdErr
+	}
+
+	return cmd, nil
+}
+
+// ShellCommand creates a command without starting it, caller is responsible for the stdio of the command
+func ShellCommand(name string, arg ...string) *exec.Cmd {
+	cmd := exec.Command(name, arg...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // Hide the window
+	return cmd
+}
+
+// ShellCommandContext is same as ShellCommand, but the whole process tree of the command is killed when ctx is done
+func ShellCommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, arg...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // Hide the window
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		kill.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+		if killErr := kill.Run(); killErr != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	return cmd
+}
+
 func ShellRunOutput(name string, arg ...string) ([]byte, error) {
 	cmd := exec.Command(name, arg...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // Hide the window
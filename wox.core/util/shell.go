@@ -0,0 +1,31 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+var ErrShellTimeout = errors.New("timeout")
+
+// ShellRunWithTimeout runs the command and waits for it to exit, prepare is used to set dir, env and stdio of the command before start.
+// The whole process tree of the command is killed when ctx is done or timeout is reached, ErrShellTimeout is returned for the latter
+func ShellRunWithTimeout(ctx context.Context, timeout time.Duration, prepare func(cmd *exec.Cmd), name string, arg ...string) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := ShellCommandContext(timeoutCtx, name, arg...)
+	// don't wait forever for stdio held by orphaned grandchildren
+	cmd.WaitDelay = time.Second
+	prepare(cmd)
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if timeoutCtx.Err() != nil {
+		return fmt.Errorf("%w after %s", ErrShellTimeout, timeout)
+	}
+	return runErr
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellRunWithTimeout(t *testing.T) {
+	if IsWindows() {
+		t.Skip("sh is not available on windows")
+	}
+
+	var output bytes.Buffer
+	runErr := ShellRunWithTimeout(context.Background(), time.Second, func(cmd *exec.Cmd) {
+		cmd.Env = []string{"WOX_TEST=hello"}
+		cmd.Stdout = &output
+	}, "sh", "-c", "echo $WOX_TEST")
+	assert.Nil(t, runErr)
+	assert.Equal(t, "hello\n", output.String())
+
+	// child processes are killed with the command, so run returns right after timeout
+	start := time.Now()
+	runErr = ShellRunWithTimeout(context.Background(), 200*time.Millisecond, func(cmd *exec.Cmd) {}, "sh", "-c", "sleep 10 & sleep 10")
+	assert.True(t, errors.Is(runErr, ErrShellTimeout))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// caller cancellation is not reported as timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	runErr = ShellRunWithTimeout(ctx, time.Minute, func(cmd *exec.Cmd) {}, "sh", "-c", "sleep 10")
+	assert.Equal(t, context.Canceled, runErr)
+}
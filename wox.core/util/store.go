@@ -0,0 +1,235 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// StoreSyncStatus is the result of the last sync of a store
+type StoreSyncStatus struct {
+	Name         string
+	Url          string
+	LastSyncTime int64  // unix milliseconds of the last successful sync, 0 means never synced
+	LastError    string // error of the last sync, empty if it succeeded
+}
+
+// storeCache is the last response of a http store. It's saved in cache directory, so stores can be shown
+// offline and right after startup, and revalidated with ETag or Last-Modified instead of downloading again
+type storeCache struct {
+	Url          string
+	ETag         string
+	LastModified string
+	SyncTime     int64
+	Content      string
+}
+
+// StoreManifestReader reads raw manifests of a store, it's either ReadStoreManifests or ReadCachedStoreManifests
+type StoreManifestReader func(ctx context.Context, url string) ([]json.RawMessage, error)
+
+var storeSyncStatuses = NewHashMap[string, StoreSyncStatus]()
+
+func IsHttpUrl(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+// ReadStoreManifests reads manifests from a store. Url can be a http(s) url or a local json file containing an array of manifests,
+// or a local directory of json files, each file contains one manifest or an array of manifests.
+// Manifests of http stores are cached, cached manifests are returned if the store can't be reached
+func ReadStoreManifests(ctx context.Context, url string) ([]json.RawMessage, error) {
+	if IsHttpUrl(url) {
+		return readHttpStoreManifests(ctx, url, getStoreCacheDirectory())
+	}
+
+	manifests, readErr := readLocalStoreManifests(url)
+	updateStoreSyncStatus(url, GetSystemTimestamp(), readErr)
+	return manifests, readErr
+}
+
+// ReadCachedStoreManifests reads manifests of http stores from cache without network requests, local stores are read directly
+func ReadCachedStoreManifests(ctx context.Context, url string) ([]json.RawMessage, error) {
+	if !IsHttpUrl(url) {
+		return ReadStoreManifests(ctx, url)
+	}
+
+	cache, found := loadStoreCache(getStoreCacheDirectory(), url)
+	if !found {
+		return nil, fmt.Errorf("store %s has not been synced yet", url)
+	}
+	return parseStoreManifests([]byte(cache.Content))
+}
+
+// GetStoreSyncStatus returns result of the last sync of a store, sync time of http stores is kept across restarts in cache
+func GetStoreSyncStatus(name string, url string) StoreSyncStatus {
+	status, found := storeSyncStatuses.Load(url)
+	if !found && IsHttpUrl(url) {
+		if cache, cacheFound := loadStoreCache(getStoreCacheDirectory(), url); cacheFound {
+			status.LastSyncTime = cache.SyncTime
+		}
+	}
+
+	status.Name = name
+	status.Url = url
+	return status
+}
+
+func updateStoreSyncStatus(url string, syncTime int64, syncErr error) {
+	status, _ := storeSyncStatuses.Load(url)
+	if syncErr != nil {
+		status.LastError = syncErr.Error()
+	} else {
+		status.LastSyncTime = syncTime
+		status.LastError = ""
+	}
+	storeSyncStatuses.Store(url, status)
+}
+
+func readHttpStoreManifests(ctx context.Context, url string, cacheDirectory string) ([]json.RawMessage, error) {
+	cache, cacheFound := loadStoreCache(cacheDirectory, url)
+	if cacheFound {
+		// sync time of the cache is the last successful sync before restart
+		if _, statusFound := storeSyncStatuses.Load(url); !statusFound {
+			updateStoreSyncStatus(url, cache.SyncTime, nil)
+		}
+	}
+
+	manifests, syncErr := syncHttpStore(ctx, url, cacheDirectory, cache, cacheFound)
+	updateStoreSyncStatus(url, GetSystemTimestamp(), syncErr)
+	if syncErr == nil {
+		return manifests, nil
+	}
+	if !cacheFound {
+		return nil, syncErr
+	}
+
+	GetLogger().Warn(ctx, fmt.Sprintf("failed to sync store %s, use cached manifests synced at %s: %s", url, FormatTimestamp(cache.SyncTime), syncErr.Error()))
+	return parseStoreManifests([]byte(cache.Content))
+}
+
+func syncHttpStore(ctx context.Context, url string, cacheDirectory string, cache storeCache, cacheFound bool) ([]json.RawMessage, error) {
+	etag, lastModified := "", ""
+	if cacheFound {
+		etag, lastModified = cache.ETag, cache.LastModified
+	}
+
+	body, header, notModified, getErr := HttpGetConditional(ctx, url, etag, lastModified)
+	if getErr != nil {
+		return nil, getErr
+	}
+	if notModified {
+		body = []byte(cache.Content)
+	}
+
+	manifests, parseErr := parseStoreManifests(body)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	cache = storeCache{Url: url, SyncTime: GetSystemTimestamp(), Content: string(body), ETag: cache.ETag, LastModified: cache.LastModified}
+	if !notModified {
+		cache.ETag = header.Get("ETag")
+		cache.LastModified = header.Get("Last-Modified")
+	}
+	if saveErr := saveStoreCache(cacheDirectory, cache); saveErr != nil {
+		GetLogger().Error(ctx, fmt.Sprintf("failed to save cache of store %s: %s", url, saveErr.Error()))
+	}
+
+	return manifests, nil
+}
+
+func getStoreCacheDirectory() string {
+	return path.Join(GetLocation().GetCacheDirectory(), "stores")
+}
+
+func getStoreCachePath(cacheDirectory string, url string) string {
+	return path.Join(cacheDirectory, Md5([]byte(url))+".json")
+}
+
+func loadStoreCache(cacheDirectory string, url string) (storeCache, bool) {
+	content, readErr := os.ReadFile(getStoreCachePath(cacheDirectory, url))
+	if readErr != nil {
+		return storeCache{}, false
+	}
+
+	var cache storeCache
+	if unmarshalErr := json.Unmarshal(content, &cache); unmarshalErr != nil || cache.Url != url {
+		return storeCache{}, false
+	}
+	return cache, true
+}
+
+func saveStoreCache(cacheDirectory string, cache storeCache) error {
+	if directoryErr := GetLocation().EnsureDirectoryExist(cacheDirectory); directoryErr != nil {
+		return directoryErr
+	}
+
+	content, marshalErr := json.Marshal(cache)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	// write to a temp file first, a half written cache would hide the store when offline
+	cachePath := getStoreCachePath(cacheDirectory, cache.Url)
+	if writeErr := os.WriteFile(cachePath+".tmp", content, 0644); writeErr != nil {
+		return writeErr
+	}
+	return os.Rename(cachePath+".tmp", cachePath)
+}
+
+func readLocalStoreManifests(url string) ([]json.RawMessage, error) {
+	localPath := GetStoreLocalPath(url)
+	if !IsDirExists(localPath) {
+		content, readErr := os.ReadFile(localPath)
+		if readErr != nil {
+			return nil, readErr
+		}
+		return parseStoreManifests(content)
+	}
+
+	files, globErr := filepath.Glob(filepath.Join(localPath, "*.json"))
+	if globErr != nil {
+		return nil, globErr
+	}
+	sort.Strings(files)
+
+	var manifests []json.RawMessage
+	for _, file := range files {
+		content, readErr := os.ReadFile(file)
+		if readErr != nil {
+			return nil, readErr
+		}
+		fileManifests, parseErr := parseStoreManifests(content)
+		if parseErr != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, parseErr)
+		}
+		manifests = append(manifests, fileManifests...)
+	}
+	return manifests, nil
+}
+
+// GetStoreLocalPath returns local path of a store which is not a http(s) url
+func GetStoreLocalPath(url string) string {
+	localPath, _ := homedir.Expand(strings.TrimPrefix(url, "file://"))
+	return localPath
+}
+
+func parseStoreManifests(content []byte) ([]json.RawMessage, error) {
+	content = bytes.TrimSpace(content)
+	if bytes.HasPrefix(content, []byte("{")) {
+		return []json.RawMessage{content}, nil
+	}
+
+	var manifests []json.RawMessage
+	if unmarshalErr := json.Unmarshal(content, &manifests); unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return manifests, nil
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadHttpStoreManifestsWithCache(t *testing.T) {
+	ctx := context.Background()
+	cacheDirectory := t.TempDir()
+
+	requests := 0
+	online := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[{"Id": "a"}, {"Id": "b"}]`))
+	}))
+	defer server.Close()
+
+	manifests, readErr := readHttpStoreManifests(ctx, server.URL, cacheDirectory)
+	assert.NoError(t, readErr)
+	assert.Len(t, manifests, 2)
+	cache, found := loadStoreCache(cacheDirectory, server.URL)
+	assert.True(t, found)
+	assert.Equal(t, `"v1"`, cache.ETag)
+
+	// not modified, manifests come from cache
+	manifests, readErr = readHttpStoreManifests(ctx, server.URL, cacheDirectory)
+	assert.NoError(t, readErr)
+	assert.Len(t, manifests, 2)
+	assert.Equal(t, 2, requests)
+
+	// offline, cached manifests are used and error is recorded
+	online = false
+	manifests, readErr = readHttpStoreManifests(ctx, server.URL, cacheDirectory)
+	assert.NoError(t, readErr)
+	assert.Len(t, manifests, 2)
+	status := GetStoreSyncStatus("test", server.URL)
+	assert.Contains(t, status.LastError, "503")
+	assert.LessOrEqual(t, cache.SyncTime, status.LastSyncTime)
+
+	_, readErr = readHttpStoreManifests(ctx, server.URL, t.TempDir())
+	assert.Error(t, readErr)
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"strings"
+	"sync"
+)
+
+// TailWriter keeps the last N lines written to it, e.g. to show the last errors of a crashed process
+type TailWriter struct {
+	maxLines int
+	lines    []string
+	partial  string // last line without line break yet
+	lock     sync.Mutex
+}
+
+func NewTailWriter(maxLines int) *TailWriter {
+	return &TailWriter{maxLines: maxLines}
+}
+
+func (t *TailWriter) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	parts := strings.Split(t.partial+string(p), "\n")
+	t.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t.lines = append(t.lines, line)
+	}
+	if len(t.lines) > t.maxLines {
+		t.lines = t.lines[len(t.lines)-t.maxLines:]
+	}
+
+	return len(p), nil
+}
+
+// Lines returns the kept lines, including the last line without line break
+func (t *TailWriter) Lines() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	lines := append([]string{}, t.lines...)
+	if strings.TrimSpace(t.partial) != "" {
+		lines = append(lines, t.partial)
+	}
+	if len(lines) > t.maxLines {
+		lines = lines[len(lines)-t.maxLines:]
+	}
+	return lines
+}
+
+func (t *TailWriter) Reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.lines = nil
+	t.partial = ""
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTailWriter(t *testing.T) {
+	w := NewTailWriter(2)
+	w.Write([]byte("line1\nline2\r\n"))
+	w.Write([]byte("\nline3\nline"))
+	assert.Equal(t, []string{"line3", "line"}, w.Lines())
+
+	w.Write([]byte("4\n"))
+	assert.Equal(t, []string{"line3", "line4"}, w.Lines())
+
+	w.Reset()
+	assert.Empty(t, w.Lines())
+}
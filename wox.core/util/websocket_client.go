@@ -14,8 +14,10 @@ type WebsocketClient struct {
 	conn                 *websocket.Conn
 	cancelReceiveMsgChan chan bool
 	onReceiveMsg         func(data []byte)
+	onDisconnected       func()
 	reconnectCount       int
 	isConnected          bool
+	isClosed             bool // closed by caller, don't reconnect anymore
 	mu                   sync.RWMutex
 }
 
@@ -70,8 +72,18 @@ func (w *WebsocketClient) receiveMsg(ctx context.Context) {
 			GetLogger().Info(ctx, "disconnect signal received, stop receiving message")
 			return
 		default:
-			messageType, messageData, err := w.conn.ReadMessage()
+			conn := w.conn
+			if conn == nil {
+				return
+			}
+			messageType, messageData, err := conn.ReadMessage()
 			if err != nil {
+				if w.isClosed {
+					return
+				}
+				if w.onDisconnected != nil {
+					w.onDisconnected()
+				}
 				w.reconnect(ctx, fmt.Sprintf("failed to read message from websocket server (%s)", err.Error()))
 				return
 			}
@@ -86,6 +98,11 @@ func (w *WebsocketClient) receiveMsg(ctx context.Context) {
 }
 
 func (w *WebsocketClient) reconnect(ctx context.Context, reason string) {
+	if w.isClosed {
+		GetLogger().Info(ctx, fmt.Sprintf("%s, websocket client is closed, skip reconnecting", reason))
+		return
+	}
+
 	GetLogger().Info(ctx, fmt.Sprintf("%s, try reconnecting", reason))
 	connErr := w.Connect(ctx)
 	if connErr != nil {
@@ -122,9 +139,19 @@ func (w *WebsocketClient) sendMsg(ctx context.Context, msgType int, data []byte)
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.conn == nil {
+		return fmt.Errorf("websocket is not connected")
+	}
+
 	return w.conn.WriteMessage(msgType, data)
 }
 
+// Close disconnects from server and stops reconnecting
+func (w *WebsocketClient) Close(ctx context.Context) {
+	w.isClosed = true
+	w.disconnect(ctx)
+}
+
 func (w *WebsocketClient) disconnect(ctx context.Context) {
 	if w.cancelReceiveMsgChan == nil && w.conn == nil && !w.isConnected {
 		return
@@ -150,3 +177,8 @@ func (w *WebsocketClient) disconnect(ctx context.Context) {
 func (w *WebsocketClient) OnMessage(ctx context.Context, callback func(data []byte)) {
 	w.onReceiveMsg = callback
 }
+
+// OnDisconnected is called when connection to server is lost unexpectedly, before reconnecting
+func (w *WebsocketClient) OnDisconnected(ctx context.Context, callback func()) {
+	w.onDisconnected = callback
+}
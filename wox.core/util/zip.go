@@ -1,11 +1,33 @@
 package util
 
 import (
+	"archive/zip"
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/saracen/fastzip"
 )
 
+// UnzipLimits restricts content of a zip file, 0 means no limit
+type UnzipLimits struct {
+	MaxFileCount int
+	MaxTotalSize uint64 // total uncompressed size in bytes
+}
+
 func Unzip(source, destination string) error {
+	return UnzipWithLimits(source, destination, UnzipLimits{})
+}
+
+// UnzipWithLimits extracts zip file after checking every entry stays in destination (zip slip) and the content doesn't exceed limits
+func UnzipWithLimits(source, destination string, limits UnzipLimits) error {
+	if checkErr := checkZip(source, limits); checkErr != nil {
+		return checkErr
+	}
+
 	e, err := fastzip.NewExtractor(source, destination)
 	if err != nil {
 		return err
@@ -18,3 +40,64 @@ func Unzip(source, destination string) error {
 
 	return nil
 }
+
+func checkZip(source string, limits UnzipLimits) error {
+	reader, openErr := zip.OpenReader(source)
+	if openErr != nil {
+		return openErr
+	}
+	defer reader.Close()
+
+	if limits.MaxFileCount > 0 && len(reader.File) > limits.MaxFileCount {
+		return fmt.Errorf("zip contains %d files, exceeds limit %d", len(reader.File), limits.MaxFileCount)
+	}
+
+	var totalSize uint64
+	for _, file := range reader.File {
+		if !isZipPathInside(file.Name) {
+			return fmt.Errorf("zip entry %s is outside of destination", file.Name)
+		}
+
+		// size in header is verified by zip reader when extracting, so it can be trusted here
+		totalSize += file.UncompressedSize64
+		if limits.MaxTotalSize > 0 && totalSize > limits.MaxTotalSize {
+			return fmt.Errorf("zip content exceeds size limit %d bytes", limits.MaxTotalSize)
+		}
+
+		// a symlink pointing outside would let following entries be written through it
+		if file.Mode()&os.ModeSymlink != 0 {
+			target, targetErr := readZipSymlink(file)
+			if targetErr != nil {
+				return targetErr
+			}
+			if filepath.IsAbs(target) || strings.HasPrefix(target, "/") || !isZipPathInside(filepath.Join(filepath.Dir(filepath.FromSlash(file.Name)), target)) {
+				return fmt.Errorf("zip symlink %s points outside of destination: %s", file.Name, target)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isZipPathInside(name string) bool {
+	name = filepath.FromSlash(strings.ReplaceAll(name, "\\", "/"))
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" || strings.HasPrefix(name, string(filepath.Separator)) {
+		return false
+	}
+	cleaned := filepath.Clean(name)
+	return cleaned != ".." && !strings.HasPrefix(cleaned, ".."+string(filepath.Separator))
+}
+
+func readZipSymlink(file *zip.File) (string, error) {
+	r, openErr := file.Open()
+	if openErr != nil {
+		return "", openErr
+	}
+	defer r.Close()
+
+	target, readErr := io.ReadAll(io.LimitReader(r, 4096))
+	if readErr != nil {
+		return "", readErr
+	}
+	return string(target), nil
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testZipEntry struct {
+	name    string
+	content string
+	symlink bool
+}
+
+func writeTestZip(t *testing.T, entries ...testZipEntry) string {
+	zipPath := filepath.Join(t.TempDir(), "test.zip")
+	f, createErr := os.Create(zipPath)
+	assert.NoError(t, createErr)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(0644)
+		if entry.symlink {
+			header.SetMode(os.ModeSymlink | 0777)
+		}
+		fw, headerErr := w.CreateHeader(header)
+		assert.NoError(t, headerErr)
+		_, writeErr := fw.Write([]byte(entry.content))
+		assert.NoError(t, writeErr)
+	}
+	assert.NoError(t, w.Close())
+	return zipPath
+}
+
+func TestUnzip(t *testing.T) {
+	destination := t.TempDir()
+	assert.NoError(t, Unzip(writeTestZip(t, testZipEntry{name: "plugin.json", content: "{}"}, testZipEntry{name: "lib/main.py", content: "print(1)"}), destination))
+	assert.FileExists(t, filepath.Join(destination, "lib", "main.py"))
+
+	assert.ErrorContains(t, Unzip(writeTestZip(t, testZipEntry{name: "../evil.sh", content: "x"}), t.TempDir()), "outside of destination")
+	assert.ErrorContains(t, Unzip(writeTestZip(t, testZipEntry{name: "lib/../../evil.sh", content: "x"}), t.TempDir()), "outside of destination")
+	assert.ErrorContains(t, Unzip(writeTestZip(t, testZipEntry{name: "/etc/evil.sh", content: "x"}), t.TempDir()), "outside of destination")
+}
+
+func TestUnzipSymlink(t *testing.T) {
+	if IsWindows() {
+		t.Skip("creating symlink requires privilege on windows")
+	}
+
+	destination := t.TempDir()
+	assert.NoError(t, Unzip(writeTestZip(t, testZipEntry{name: "main.py", content: "x"}, testZipEntry{name: "lib/main.py", content: "../main.py", symlink: true}), destination))
+
+	assert.ErrorContains(t, Unzip(writeTestZip(t, testZipEntry{name: "lib", content: "../../", symlink: true}, testZipEntry{name: "lib/evil.sh", content: "x"}), t.TempDir()), "points outside of destination")
+	assert.ErrorContains(t, Unzip(writeTestZip(t, testZipEntry{name: "lib", content: "/etc", symlink: true}), t.TempDir()), "points outside of destination")
+}
+
+func TestUnzipWithLimits(t *testing.T) {
+	zipPath := writeTestZip(t, testZipEntry{name: "a.txt", content: strings.Repeat("a", 100)}, testZipEntry{name: "b.txt", content: strings.Repeat("b", 100)})
+
+	assert.NoError(t, UnzipWithLimits(zipPath, t.TempDir(), UnzipLimits{MaxFileCount: 2, MaxTotalSize: 200}))
+	assert.ErrorContains(t, UnzipWithLimits(zipPath, t.TempDir(), UnzipLimits{MaxFileCount: 1}), "exceeds limit 1")
+	assert.ErrorContains(t, UnzipWithLimits(zipPath, t.TempDir(), UnzipLimits{MaxTotalSize: 150}), "exceeds size limit 150")
+}
@@ -0,0 +1,194 @@
+package woxplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type LogLevel = string
+
+const (
+	LogLevelInfo    LogLevel = "Info"
+	LogLevelError   LogLevel = "Error"
+	LogLevelDebug   LogLevel = "Debug"
+	LogLevelWarning LogLevel = "Warning"
+)
+
+// API is the same set of methods Wox exposes to its built-in plugins
+type API interface {
+	ChangeQuery(ctx context.Context, query ChangeQueryParam)
+	HideApp(ctx context.Context)
+	ShowApp(ctx context.Context)
+	Notify(ctx context.Context, message string)
+	Log(ctx context.Context, level LogLevel, msg string)
+	GetTranslation(ctx context.Context, key string) string
+	GetSetting(ctx context.Context, key string) string
+	SaveSetting(ctx context.Context, key string, value string, isPlatformSpecific bool)
+	OnSettingChanged(ctx context.Context, callback func(key string, value string))
+	OnGetDynamicSetting(ctx context.Context, callback func(key string) string)
+	OnDeepLink(ctx context.Context, callback func(arguments map[string]string))
+	OnUnload(ctx context.Context, callback func())
+	RegisterQueryCommands(ctx context.Context, commands []MetadataCommand)
+	AIChatStream(ctx context.Context, model AIModel, conversations []Conversation, callback ChatStreamFunc) error
+	AIIndexDocuments(ctx context.Context, index string, model AIModel, documents []VectorDocument) error
+	AIRemoveDocuments(ctx context.Context, index string, model AIModel, ids []string) error
+	AIQueryDocuments(ctx context.Context, index string, model AIModel, query string, limit int) ([]VectorSearchResult, error)
+}
+
+type apiImpl struct {
+	client *client
+}
+
+// call invokes a method without result, errors are written to stderr since these methods have no error to return
+func (a *apiImpl) call(ctx context.Context, method string, params map[string]string) {
+	if _, err := a.client.invoke(ctx, method, params); err != nil {
+		a.client.logError(fmt.Sprintf("invoke %s failed: %s", method, err))
+	}
+}
+
+func (a *apiImpl) ChangeQuery(ctx context.Context, query ChangeQueryParam) {
+	params := map[string]string{
+		"queryType": query.QueryType,
+		"queryText": query.QueryText,
+	}
+	if query.QueryType == QueryTypeSelection {
+		selectionJson, _ := json.Marshal(query.QuerySelection)
+		params["querySelection"] = string(selectionJson)
+	}
+	a.call(ctx, "ChangeQuery", params)
+}
+
+func (a *apiImpl) HideApp(ctx context.Context) {
+	a.call(ctx, "HideApp", map[string]string{})
+}
+
+func (a *apiImpl) ShowApp(ctx context.Context) {
+	a.call(ctx, "ShowApp", map[string]string{})
+}
+
+func (a *apiImpl) Notify(ctx context.Context, message string) {
+	a.call(ctx, "Notify", map[string]string{"message": message})
+}
+
+func (a *apiImpl) Log(ctx context.Context, level LogLevel, msg string) {
+	a.call(ctx, "Log", map[string]string{"level": level, "msg": msg})
+}
+
+func (a *apiImpl) GetTranslation(ctx context.Context, key string) string {
+	return a.getString(ctx, "GetTranslation", map[string]string{"key": key})
+}
+
+func (a *apiImpl) GetSetting(ctx context.Context, key string) string {
+	return a.getString(ctx, "GetSetting", map[string]string{"key": key})
+}
+
+func (a *apiImpl) SaveSetting(ctx context.Context, key string, value string, isPlatformSpecific bool) {
+	a.call(ctx, "SaveSetting", map[string]string{
+		"key":                key,
+		"value":              value,
+		"isPlatformSpecific": strconv.FormatBool(isPlatformSpecific),
+	})
+}
+
+func (a *apiImpl) OnSettingChanged(ctx context.Context, callback func(key string, value string)) {
+	callbackId := newId()
+	a.client.settingChangedCallbacks.Store(callbackId, callback)
+	a.call(ctx, "OnPluginSettingChanged", map[string]string{"callbackId": callbackId})
+}
+
+func (a *apiImpl) OnGetDynamicSetting(ctx context.Context, callback func(key string) string) {
+	callbackId := newId()
+	a.client.dynamicSettingCallbacks.Store(callbackId, callback)
+	a.call(ctx, "OnGetDynamicSetting", map[string]string{"callbackId": callbackId})
+}
+
+func (a *apiImpl) OnDeepLink(ctx context.Context, callback func(arguments map[string]string)) {
+	callbackId := newId()
+	a.client.deepLinkCallbacks.Store(callbackId, callback)
+	a.call(ctx, "OnDeepLink", map[string]string{"callbackId": callbackId})
+}
+
+func (a *apiImpl) OnUnload(ctx context.Context, callback func()) {
+	callbackId := newId()
+	a.client.unloadCallbacks.Store(callbackId, callback)
+	a.call(ctx, "OnUnload", map[string]string{"callbackId": callbackId})
+}
+
+func (a *apiImpl) RegisterQueryCommands(ctx context.Context, commands []MetadataCommand) {
+	commandsJson, _ := json.Marshal(commands)
+	a.call(ctx, "RegisterQueryCommands", map[string]string{"commands": string(commandsJson)})
+}
+
+func (a *apiImpl) AIChatStream(ctx context.Context, model AIModel, conversations []Conversation, callback ChatStreamFunc) error {
+	modelJson, _ := json.Marshal(model)
+	conversationsJson, _ := json.Marshal(conversations)
+	callbackId := newId()
+	a.client.chatStreamCallbacks.Store(callbackId, callback)
+
+	_, err := a.client.invoke(ctx, "AIChatStream", map[string]string{
+		"callbackId":    callbackId,
+		"model":         string(modelJson),
+		"conversations": string(conversationsJson),
+	})
+	if err != nil {
+		a.client.chatStreamCallbacks.Delete(callbackId)
+	}
+	return err
+}
+
+func (a *apiImpl) AIIndexDocuments(ctx context.Context, index string, model AIModel, documents []VectorDocument) error {
+	modelJson, _ := json.Marshal(model)
+	documentsJson, _ := json.Marshal(documents)
+	_, err := a.client.invoke(ctx, "AIIndexDocuments", map[string]string{
+		"index":     index,
+		"model":     string(modelJson),
+		"documents": string(documentsJson),
+	})
+	return err
+}
+
+func (a *apiImpl) AIRemoveDocuments(ctx context.Context, index string, model AIModel, ids []string) error {
+	modelJson, _ := json.Marshal(model)
+	idsJson, _ := json.Marshal(ids)
+	_, err := a.client.invoke(ctx, "AIRemoveDocuments", map[string]string{
+		"index": index,
+		"model": string(modelJson),
+		"ids":   string(idsJson),
+	})
+	return err
+}
+
+func (a *apiImpl) AIQueryDocuments(ctx context.Context, index string, model AIModel, query string, limit int) ([]VectorSearchResult, error) {
+	modelJson, _ := json.Marshal(model)
+	result, err := a.client.invoke(ctx, "AIQueryDocuments", map[string]string{
+		"index": index,
+		"model": string(modelJson),
+		"query": query,
+		"limit": strconv.Itoa(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []VectorSearchResult
+	if resultString, ok := result.(string); ok && resultString != "" {
+		if unmarshalErr := json.Unmarshal([]byte(resultString), &results); unmarshalErr != nil {
+			return nil, unmarshalErr
+		}
+	}
+	return results, nil
+}
+
+func (a *apiImpl) getString(ctx context.Context, method string, params map[string]string) string {
+	result, err := a.client.invoke(ctx, method, params)
+	if err != nil {
+		a.client.logError(fmt.Sprintf("invoke %s failed: %s", method, err))
+		return ""
+	}
+	if resultString, ok := result.(string); ok {
+		return resultString
+	}
+	return ""
+}
@@ -0,0 +1,380 @@
+package woxplugin
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jsonRpcTypeRequest  = "WOX_JSONRPC_REQUEST"
+	jsonRpcTypeResponse = "WOX_JSONRPC_RESPONSE"
+
+	// jsonRpcMethodCancel is sent by Wox when it doesn't wait for a request anymore, Params["Id"] is the request id
+	jsonRpcMethodCancel = "$/cancel"
+)
+
+// wox doesn't answer some requests if parameters are invalid, don't wait forever
+const invokeTimeout = 30 * time.Second
+
+type traceIdKey struct{}
+
+type jsonRpcMessage struct {
+	TraceId    string
+	Id         string
+	PluginId   string            `json:",omitempty"`
+	PluginName string            `json:",omitempty"`
+	Method     string            `json:",omitempty"`
+	Type       string            `json:",omitempty"`
+	Params     map[string]string `json:",omitempty"`
+	Result     any               `json:",omitempty"`
+	Error      string            `json:",omitempty"`
+	Deadline   int64             `json:",omitempty"` // unix milliseconds, Wox doesn't wait for the response after it
+}
+
+type client struct {
+	plugin   Plugin
+	pluginId string
+	api      *apiImpl
+	reader   io.Reader
+	writer   io.Writer
+	lock     sync.Mutex // guards writer
+
+	// call sends a request to Wox and returns the response synchronously, used by wasm plugins instead of reader and writer.
+	// Wasm plugins are single threaded, requests from Wox are handled one by one and actions are executed before responding
+	call func(request []byte) []byte
+
+	pending   sync.Map // request id -> chan jsonRpcMessage
+	running   sync.Map // request id -> context.CancelFunc, requests from Wox being handled
+	actions   sync.Map // action id -> func(ctx, ActionContext)
+	refreshes sync.Map // result id -> func(ctx, RefreshableResult) RefreshableResult
+
+	settingChangedCallbacks sync.Map
+	dynamicSettingCallbacks sync.Map
+	deepLinkCallbacks       sync.Map
+	unloadCallbacks         sync.Map
+	chatStreamCallbacks     sync.Map
+}
+
+func newClient(p Plugin, pluginId string, reader io.Reader, writer io.Writer) *client {
+	c := &client{plugin: p, pluginId: pluginId, reader: reader, writer: writer}
+	c.api = &apiImpl{client: c}
+	return c
+}
+
+func (c *client) serve() error {
+	reader := bufio.NewReader(c.reader)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			c.onMessage(line)
+		}
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				return nil
+			}
+			return readErr
+		}
+	}
+}
+
+func (c *client) onMessage(data string) {
+	var message jsonRpcMessage
+	if unmarshalErr := json.Unmarshal([]byte(data), &message); unmarshalErr != nil {
+		c.logError(fmt.Sprintf("failed to unmarshal message: %s", unmarshalErr))
+		return
+	}
+
+	switch message.Type {
+	case jsonRpcTypeResponse:
+		if resultChan, exist := c.pending.LoadAndDelete(message.Id); exist {
+			resultChan.(chan jsonRpcMessage) <- message
+		}
+	case jsonRpcTypeRequest:
+		if c.pluginId == "" {
+			c.pluginId = message.PluginId
+		}
+		if message.Method == jsonRpcMethodCancel {
+			if cancel, exist := c.running.LoadAndDelete(message.Params["Id"]); exist {
+				cancel.(context.CancelFunc)()
+			}
+			return
+		}
+		// register before handling, so a cancel notification right after the request can find it
+		ctx, cancel := c.newRequestContext(message)
+		c.running.Store(message.Id, cancel)
+		go c.handleRequest(ctx, cancel, message)
+	}
+}
+
+func (c *client) handleRequest(ctx context.Context, cancel context.CancelFunc, request jsonRpcMessage) {
+	defer cancel()
+	defer c.running.Delete(request.Id)
+
+	response := c.respond(ctx, request)
+	if ctx.Err() != nil {
+		// Wox has given up waiting for the response
+		return
+	}
+	if sendErr := c.send(response); sendErr != nil {
+		c.logError(fmt.Sprintf("failed to send response of %s: %s", request.Method, sendErr))
+	}
+}
+
+// newRequestContext returns the context of a request from Wox, it's done when the deadline is exceeded or Wox cancels the request
+func (c *client) newRequestContext(request jsonRpcMessage) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(context.Background(), traceIdKey{}, request.TraceId)
+	if request.Deadline > 0 {
+		return context.WithDeadline(ctx, time.UnixMilli(request.Deadline))
+	}
+	return context.WithCancel(ctx)
+}
+
+// respond handles a request from Wox and returns the response
+func (c *client) respond(ctx context.Context, request jsonRpcMessage) jsonRpcMessage {
+	response := jsonRpcMessage{
+		TraceId: request.TraceId,
+		Id:      request.Id,
+		Method:  request.Method,
+		Type:    jsonRpcTypeResponse,
+	}
+
+	result, err := c.handleMethod(ctx, request)
+	if err != nil {
+		response.Error = err.Error()
+	} else {
+		response.Result = result
+	}
+
+	return response
+}
+
+func (c *client) handleMethod(ctx context.Context, request jsonRpcMessage) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panic: %v", request.Method, r)
+		}
+	}()
+
+	params := request.Params
+	switch request.Method {
+	case "init":
+		c.plugin.Init(ctx, InitParams{API: c.api, PluginDirectory: params["PluginDirectory"]})
+		return nil, nil
+	case "query":
+		return c.query(ctx, params)
+	case "action":
+		if action, exist := c.actions.Load(params["ActionId"]); exist {
+			actionFunc := action.(func(context.Context, ActionContext))
+			actionContext := ActionContext{ContextData: params["ContextData"]}
+			if c.call != nil {
+				actionFunc(ctx, actionContext)
+			} else {
+				// action keeps running after responding, it's not bound to the request
+				go actionFunc(context.WithoutCancel(ctx), actionContext)
+			}
+		}
+		return nil, nil
+	case "refresh":
+		return c.refresh(ctx, params)
+	case "onPluginSettingChange":
+		if callback, exist := c.settingChangedCallbacks.Load(params["CallbackId"]); exist {
+			callback.(func(string, string))(params["Key"], params["Value"])
+		}
+		return nil, nil
+	case "onGetDynamicSetting":
+		if callback, exist := c.dynamicSettingCallbacks.Load(params["CallbackId"]); exist {
+			return callback.(func(string) string)(params["Key"]), nil
+		}
+		return "", nil
+	case "onDeepLink":
+		if callback, exist := c.deepLinkCallbacks.Load(params["CallbackId"]); exist {
+			var arguments map[string]string
+			if unmarshalErr := json.Unmarshal([]byte(params["Arguments"]), &arguments); unmarshalErr != nil {
+				return nil, unmarshalErr
+			}
+			callback.(func(map[string]string))(arguments)
+		}
+		return nil, nil
+	case "onUnload":
+		if callback, exist := c.unloadCallbacks.Load(params["CallbackId"]); exist {
+			callback.(func())()
+		}
+		return nil, nil
+	case "onLLMStream":
+		if callback, exist := c.chatStreamCallbacks.Load(params["CallbackId"]); exist {
+			streamType := params["StreamType"]
+			callback.(ChatStreamFunc)(streamType, params["Data"])
+			if streamType == ChatStreamTypeFinished || streamType == ChatStreamTypeError {
+				c.chatStreamCallbacks.Delete(params["CallbackId"])
+			}
+		}
+		return nil, nil
+	case "unloadPlugin":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown method: %s", request.Method)
+}
+
+func (c *client) query(ctx context.Context, params map[string]string) ([]Result, error) {
+	query := Query{
+		Type:           params["Type"],
+		RawQuery:       params["RawQuery"],
+		TriggerKeyword: params["TriggerKeyword"],
+		Command:        params["Command"],
+		Search:         params["Search"],
+	}
+	if params["Selection"] != "" {
+		if unmarshalErr := json.Unmarshal([]byte(params["Selection"]), &query.Selection); unmarshalErr != nil {
+			return nil, fmt.Errorf("failed to unmarshal selection: %w", unmarshalErr)
+		}
+	}
+	if params["Env"] != "" {
+		if unmarshalErr := json.Unmarshal([]byte(params["Env"]), &query.Env); unmarshalErr != nil {
+			return nil, fmt.Errorf("failed to unmarshal env: %w", unmarshalErr)
+		}
+	}
+
+	// actions and refreshes of previous query are useless now
+	c.actions.Clear()
+	c.refreshes.Clear()
+
+	results := c.plugin.Query(ctx, query)
+	for i := range results {
+		if results[i].Id == "" {
+			results[i].Id = newId()
+		}
+		c.cacheActions(results[i].Actions)
+		if results[i].RefreshInterval > 0 && results[i].OnRefresh != nil {
+			c.refreshes.Store(results[i].Id, results[i].OnRefresh)
+		}
+	}
+	if results == nil {
+		results = []Result{}
+	}
+
+	return results, nil
+}
+
+type refreshableResultWithResultId struct {
+	ResultId string
+	RefreshableResult
+}
+
+func (c *client) refresh(ctx context.Context, params map[string]string) (refreshableResultWithResultId, error) {
+	var current refreshableResultWithResultId
+	if unmarshalErr := json.Unmarshal([]byte(params["RefreshableResult"]), &current); unmarshalErr != nil {
+		return current, fmt.Errorf("failed to unmarshal refreshable result: %w", unmarshalErr)
+	}
+
+	onRefresh, exist := c.refreshes.Load(params["ResultId"])
+	if !exist {
+		return current, nil
+	}
+
+	newResult := onRefresh.(func(context.Context, RefreshableResult) RefreshableResult)(ctx, current.RefreshableResult)
+	c.cacheActions(newResult.Actions)
+	return refreshableResultWithResultId{ResultId: current.ResultId, RefreshableResult: newResult}, nil
+}
+
+func (c *client) cacheActions(actions []ResultAction) {
+	for i := range actions {
+		if actions[i].Action == nil {
+			continue
+		}
+		if actions[i].Id == "" {
+			actions[i].Id = newId()
+		}
+		c.actions.Store(actions[i].Id, actions[i].Action)
+	}
+}
+
+// invoke calls a method of Wox and waits for the result
+func (c *client) invoke(ctx context.Context, method string, params map[string]string) (any, error) {
+	traceId, _ := ctx.Value(traceIdKey{}).(string)
+	if traceId == "" {
+		traceId = newId()
+	}
+
+	request := jsonRpcMessage{
+		TraceId:  traceId,
+		Id:       newId(),
+		PluginId: c.pluginId,
+		Method:   method,
+		Type:     jsonRpcTypeRequest,
+		Params:   params,
+	}
+	if c.call != nil {
+		return c.invokeSync(request)
+	}
+
+	resultChan := make(chan jsonRpcMessage, 1)
+	c.pending.Store(request.Id, resultChan)
+	defer c.pending.Delete(request.Id)
+
+	if sendErr := c.send(request); sendErr != nil {
+		return nil, sendErr
+	}
+
+	select {
+	case response := <-resultChan:
+		if response.Error != "" {
+			return nil, errors.New(response.Error)
+		}
+		return response.Result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(invokeTimeout):
+		return nil, fmt.Errorf("invoke %s timeout", method)
+	}
+}
+
+func (c *client) invokeSync(request jsonRpcMessage) (any, error) {
+	data, marshalErr := json.Marshal(request)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	var response jsonRpcMessage
+	if unmarshalErr := json.Unmarshal(c.call(data), &response); unmarshalErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal response of %s: %w", request.Method, unmarshalErr)
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return response.Result, nil
+}
+
+func (c *client) send(message jsonRpcMessage) error {
+	data, marshalErr := json.Marshal(message)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, writeErr := c.writer.Write(append(data, '\n'))
+	return writeErr
+}
+
+// logError writes to stderr, Wox collects stderr of plugin process into its log
+func (c *client) logError(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+}
+
+func newId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
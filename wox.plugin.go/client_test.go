@@ -0,0 +1,172 @@
+package woxplugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+type testPlugin struct {
+	api     API
+	setting string
+	actions chan string
+}
+
+func (p *testPlugin) Init(ctx context.Context, initParams InitParams) {
+	p.api = initParams.API
+	p.setting = p.api.GetSetting(ctx, "greeting")
+}
+
+func (p *testPlugin) Query(ctx context.Context, query Query) []Result {
+	return []Result{
+		{
+			Title: p.setting + " " + query.Search,
+			Actions: []ResultAction{
+				{
+					Name: "open",
+					Action: func(ctx context.Context, actionContext ActionContext) {
+						p.actions <- actionContext.ContextData
+					},
+				},
+			},
+		},
+	}
+}
+
+// fakeWox talks to the plugin like Wox does
+type fakeWox struct {
+	t        *testing.T
+	toWox    *bufio.Reader
+	toPlugin io.Writer
+}
+
+func (w *fakeWox) send(message jsonRpcMessage) {
+	data, _ := json.Marshal(message)
+	if _, err := w.toPlugin.Write(append(data, '\n')); err != nil {
+		w.t.Fatal(err)
+	}
+}
+
+func (w *fakeWox) receive() jsonRpcMessage {
+	line, err := w.toWox.ReadString('\n')
+	if err != nil {
+		w.t.Fatal(err)
+	}
+	var message jsonRpcMessage
+	if err := json.Unmarshal([]byte(line), &message); err != nil {
+		w.t.Fatal(err)
+	}
+	return message
+}
+
+func startTestPlugin(t *testing.T, p Plugin) *fakeWox {
+	pluginIn, woxOut := io.Pipe()
+	woxIn, pluginOut := io.Pipe()
+	c := newClient(p, "test-plugin", pluginIn, pluginOut)
+	go c.serve()
+	t.Cleanup(func() {
+		woxOut.Close()
+	})
+
+	return &fakeWox{t: t, toWox: bufio.NewReader(woxIn), toPlugin: woxOut}
+}
+
+func TestClientInitAndQuery(t *testing.T) {
+	p := &testPlugin{actions: make(chan string, 1)}
+	wox := startTestPlugin(t, p)
+
+	// plugin calls GetSetting during init, init response is sent after that
+	wox.send(jsonRpcMessage{TraceId: "trace", Id: "1", Method: "init", Type: jsonRpcTypeRequest, Params: map[string]string{"PluginDirectory": "/tmp"}})
+	getSetting := wox.receive()
+	if getSetting.Method != "GetSetting" || getSetting.Params["key"] != "greeting" || getSetting.PluginId != "test-plugin" || getSetting.TraceId != "trace" {
+		t.Fatalf("unexpected request: %+v", getSetting)
+	}
+	wox.send(jsonRpcMessage{Id: getSetting.Id, Type: jsonRpcTypeResponse, Result: "hello"})
+	if initResponse := wox.receive(); initResponse.Id != "1" || initResponse.Error != "" {
+		t.Fatalf("unexpected init response: %+v", initResponse)
+	}
+
+	wox.send(jsonRpcMessage{Id: "2", Method: "query", Type: jsonRpcTypeRequest, Params: map[string]string{"Search": "wox", "Env": `{"ActiveWindowPid":1}`}})
+	queryResponse := wox.receive()
+	resultsJson, _ := json.Marshal(queryResponse.Result)
+	var results []Result
+	if err := json.Unmarshal(resultsJson, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Title != "hello wox" || results[0].Id == "" || results[0].Actions[0].Id == "" {
+		t.Fatalf("unexpected results: %s", resultsJson)
+	}
+
+	wox.send(jsonRpcMessage{Id: "3", Method: "action", Type: jsonRpcTypeRequest, Params: map[string]string{"ActionId": results[0].Actions[0].Id, "ContextData": "data"}})
+	wox.receive()
+	select {
+	case contextData := <-p.actions:
+		if contextData != "data" {
+			t.Fatalf("unexpected context data: %s", contextData)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("action is not executed")
+	}
+}
+
+func TestClientUnknownMethod(t *testing.T) {
+	wox := startTestPlugin(t, &testPlugin{})
+
+	wox.send(jsonRpcMessage{Id: "1", Method: "notExist", Type: jsonRpcTypeRequest})
+	if response := wox.receive(); response.Error == "" {
+		t.Fatalf("expect error for unknown method: %+v", response)
+	}
+}
+
+type blockingPlugin struct {
+	canceled chan error
+}
+
+func (p *blockingPlugin) Init(ctx context.Context, initParams InitParams) {
+}
+
+func (p *blockingPlugin) Query(ctx context.Context, query Query) []Result {
+	<-ctx.Done()
+	p.canceled <- ctx.Err()
+	return nil
+}
+
+func TestClientCancelRequest(t *testing.T) {
+	p := &blockingPlugin{canceled: make(chan error, 1)}
+	wox := startTestPlugin(t, p)
+
+	wox.send(jsonRpcMessage{Id: "1", Method: "query", Type: jsonRpcTypeRequest, Params: map[string]string{"Search": "wox"}})
+	wox.send(jsonRpcMessage{Id: "2", Method: jsonRpcMethodCancel, Type: jsonRpcTypeRequest, Params: map[string]string{"Id": "1"}})
+	select {
+	case err := <-p.canceled:
+		if err != context.Canceled {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("query is not canceled")
+	}
+
+	// canceled request and cancel notification are not answered, next response is for the next request
+	wox.send(jsonRpcMessage{Id: "3", Method: "notExist", Type: jsonRpcTypeRequest})
+	if response := wox.receive(); response.Id != "3" {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+}
+
+func TestClientRequestDeadline(t *testing.T) {
+	p := &blockingPlugin{canceled: make(chan error, 1)}
+	wox := startTestPlugin(t, p)
+
+	wox.send(jsonRpcMessage{Id: "1", Method: "query", Type: jsonRpcTypeRequest, Deadline: time.Now().Add(50 * time.Millisecond).UnixMilli()})
+	select {
+	case err := <-p.canceled:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("query is not stopped after deadline")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/Wox-launcher/Wox/wox.plugin.go
+
+go 1.23.0
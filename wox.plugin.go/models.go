@@ -0,0 +1,211 @@
+package woxplugin
+
+import (
+	"context"
+)
+
+type QueryType = string
+
+const (
+	QueryTypeInput     QueryType = "input"
+	QueryTypeSelection QueryType = "selection"
+)
+
+type SelectionType = string
+
+const (
+	SelectionTypeText SelectionType = "text"
+	SelectionTypeFile SelectionType = "file"
+)
+
+type Selection struct {
+	Type SelectionType
+	// Only available when Type is SelectionTypeText
+	Text string
+	// Only available when Type is SelectionTypeFile
+	FilePaths []string
+}
+
+type QueryEnv struct {
+	ActiveWindowTitle string // active window title when user query, empty if not available
+	ActiveWindowPid   int    // active window pid when user query, 0 if not available
+	ActiveBrowserUrl  string // active browser url when user query, empty if not available
+}
+
+type Query struct {
+	Type QueryType
+	// Raw query, this includes trigger keyword if it has
+	RawQuery string
+	// Trigger keyword of a query. It can be empty if user is using global trigger keyword
+	TriggerKeyword string
+	// Command part of a query, empty if no command
+	Command string
+	// Search part of a query
+	Search string
+	// User selected or drag-drop data, only available when Type is QueryTypeSelection
+	Selection Selection
+	Env       QueryEnv
+}
+
+type WoxImageType = string
+
+const (
+	WoxImageTypeAbsolutePath WoxImageType = "absolute"
+	WoxImageTypeRelativePath WoxImageType = "relative"
+	WoxImageTypeBase64       WoxImageType = "base64"
+	WoxImageTypeSvg          WoxImageType = "svg"
+	WoxImageTypeEmoji        WoxImageType = "emoji"
+	WoxImageTypeUrl          WoxImageType = "url"
+)
+
+type WoxImage struct {
+	ImageType WoxImageType
+	ImageData string
+}
+
+type WoxPreviewType = string
+
+const (
+	WoxPreviewTypeMarkdown WoxPreviewType = "markdown"
+	WoxPreviewTypeText     WoxPreviewType = "text"
+	WoxPreviewTypeImage    WoxPreviewType = "image"
+	WoxPreviewTypeUrl      WoxPreviewType = "url"
+	WoxPreviewTypeFile     WoxPreviewType = "file"
+)
+
+type WoxPreview struct {
+	PreviewType       WoxPreviewType
+	PreviewData       string
+	PreviewProperties map[string]string
+}
+
+type ResultTailType = string
+
+const (
+	ResultTailTypeText  ResultTailType = "text"
+	ResultTailTypeImage ResultTailType = "image"
+)
+
+type ResultTail struct {
+	Type  ResultTailType
+	Text  string   // only available when type is ResultTailTypeText
+	Image WoxImage // only available when type is ResultTailTypeImage
+}
+
+type ActionContext struct {
+	// Additional data associate with this result
+	ContextData string
+}
+
+type ResultAction struct {
+	// Action id, optional, a random id is assigned if empty
+	Id string
+	// Name support i18n
+	Name string
+	Icon WoxImage
+	// If true, Wox will use this action as default action
+	IsDefault bool
+	// If true, Wox will not hide after user select this result
+	PreventHideAfterAction bool
+	// Hotkey to trigger this action. E.g. "ctrl+Shift+Space", "Ctrl+1", "Command+K"
+	Hotkey string
+	Action func(ctx context.Context, actionContext ActionContext) `json:"-"`
+}
+
+type Result struct {
+	// Result id, optional, a random id is assigned if empty
+	Id string
+	// Title support i18n
+	Title string
+	// SubTitle support i18n
+	SubTitle string
+	Icon     WoxImage
+	Preview  WoxPreview
+	// the higher the score, the more likely to be displayed on top
+	Score       int64
+	Group       string
+	GroupScore  int64
+	Tails       []ResultTail
+	ContextData string
+	Actions     []ResultAction
+	// refresh result after specified interval, in milliseconds. If this value is 0, Wox will not refresh this result
+	RefreshInterval int
+	OnRefresh       func(ctx context.Context, current RefreshableResult) RefreshableResult `json:"-"`
+}
+
+type RefreshableResult struct {
+	Title           string
+	SubTitle        string
+	Icon            WoxImage
+	Preview         WoxPreview
+	Tails           []ResultTail
+	ContextData     string
+	RefreshInterval int
+	Actions         []ResultAction
+}
+
+type MetadataCommand struct {
+	Command     string
+	Description string
+}
+
+type ChangeQueryParam struct {
+	QueryType      QueryType
+	QueryText      string
+	QuerySelection Selection
+}
+
+type AIModel struct {
+	Name     string
+	Provider string
+}
+
+type ConversationRole = string
+
+const (
+	ConversationRoleUser   ConversationRole = "user"
+	ConversationRoleAI     ConversationRole = "ai"
+	ConversationRoleSystem ConversationRole = "system"
+)
+
+type Conversation struct {
+	Role      ConversationRole
+	Text      string
+	Timestamp int64
+}
+
+type ChatStreamDataType = string
+
+const (
+	ChatStreamTypeStreaming ChatStreamDataType = "streaming"
+	ChatStreamTypeFinished  ChatStreamDataType = "finished"
+	ChatStreamTypeError     ChatStreamDataType = "error"
+)
+
+type ChatStreamFunc func(streamType ChatStreamDataType, data string)
+
+type VectorDocument struct {
+	Id       string
+	Text     string
+	Metadata map[string]string
+}
+
+type VectorSearchResult struct {
+	Id       string
+	Text     string
+	Metadata map[string]string
+	Score    float64 // cosine similarity, from -1 to 1, higher is more similar
+}
+
+type HttpRequestParam struct {
+	Url     string
+	Method  string // GET if empty
+	Headers map[string]string
+	Body    string
+}
+
+type HttpResponse struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       string
+}
@@ -0,0 +1,29 @@
+// Package woxplugin is the SDK for writing Wox plugins as standalone executables.
+//
+// Set "Runtime" to "EXECUTABLE" and "Entry" to the path of the compiled binary in plugin.json,
+// then call Run in main. Wox talks to the plugin with json rpc over stdin/stdout, so plugins
+// must not write to stdout, everything written by fmt.Print* is redirected to stderr by Run.
+package woxplugin
+
+import (
+	"context"
+	"os"
+)
+
+type InitParams struct {
+	API             API
+	PluginDirectory string
+}
+
+type Plugin interface {
+	Init(ctx context.Context, initParams InitParams)
+	Query(ctx context.Context, query Query) []Result
+}
+
+// Run serves the plugin until Wox closes stdin
+func Run(p Plugin) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+
+	return newClient(p, os.Getenv("WOX_PLUGIN_ID"), os.Stdin, stdout).serve()
+}
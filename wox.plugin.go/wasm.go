@@ -0,0 +1,115 @@
+//go:build wasip1
+
+package woxplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// wasmClient serves the plugin registered by RunWasm
+var wasmClient *client
+
+// allocations keeps memory shared with Wox alive until it's freed, key is the pointer
+var allocations = map[uint32][]byte{}
+
+//go:wasmimport wox call
+func woxCall(ptr uint32, size uint32) uint64
+
+//go:wasmexport wox_alloc
+func woxAlloc(size uint32) uint32 {
+	if size == 0 {
+		size = 1
+	}
+	buf := make([]byte, size)
+	ptr := uint32(uintptr(unsafe.Pointer(&buf[0])))
+	allocations[ptr] = buf
+	return ptr
+}
+
+//go:wasmexport wox_free
+func woxFree(ptr uint32, size uint32) {
+	delete(allocations, ptr)
+}
+
+//go:wasmexport wox_handle
+func woxHandle(ptr uint32, size uint32) uint64 {
+	var response jsonRpcMessage
+	var request jsonRpcMessage
+	if unmarshalErr := json.Unmarshal(readAllocation(ptr, size), &request); unmarshalErr != nil {
+		response = jsonRpcMessage{Type: jsonRpcTypeResponse, Error: fmt.Sprintf("failed to unmarshal request: %s", unmarshalErr)}
+	} else if wasmClient == nil {
+		response = jsonRpcMessage{TraceId: request.TraceId, Id: request.Id, Method: request.Method, Type: jsonRpcTypeResponse, Error: "plugin is not registered, call RunWasm in init"}
+	} else {
+		ctx, cancel := wasmClient.newRequestContext(request)
+		response = wasmClient.respond(ctx, request)
+		cancel()
+	}
+
+	data, _ := json.Marshal(response)
+	responsePtr := woxAlloc(uint32(len(data)))
+	copy(allocations[responsePtr], data)
+	return uint64(responsePtr)<<32 | uint64(len(data))
+}
+
+// RunWasm registers the plugin when it's compiled to a WASI reactor module, it must be called in init
+// since main is not executed. Build the plugin with:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o plugin.wasm
+func RunWasm(p Plugin) {
+	wasmClient = newClient(p, os.Getenv("WOX_PLUGIN_ID"), nil, nil)
+	wasmClient.call = func(request []byte) []byte {
+		ptr := woxAlloc(uint32(len(request)))
+		copy(allocations[ptr], request)
+		packed := woxCall(ptr, uint32(len(request)))
+		woxFree(ptr, uint32(len(request)))
+
+		response := readAllocation(uint32(packed>>32), uint32(packed))
+		woxFree(uint32(packed>>32), uint32(packed))
+		return response
+	}
+}
+
+// HttpRequest sends a http request through Wox, wasm plugins have no network access except hosts declared
+// in the network permissions of plugin.json and granted by user
+func HttpRequest(ctx context.Context, request HttpRequestParam) (HttpResponse, error) {
+	if wasmClient == nil {
+		return HttpResponse{}, fmt.Errorf("plugin is not registered, call RunWasm in init")
+	}
+
+	params := map[string]string{
+		"url":    request.Url,
+		"method": request.Method,
+		"body":   request.Body,
+	}
+	if len(request.Headers) > 0 {
+		headers, _ := json.Marshal(request.Headers)
+		params["headers"] = string(headers)
+	}
+	result, invokeErr := wasmClient.invoke(ctx, "HttpRequest", params)
+	if invokeErr != nil {
+		return HttpResponse{}, invokeErr
+	}
+
+	var response HttpResponse
+	resultString, _ := result.(string)
+	if unmarshalErr := json.Unmarshal([]byte(resultString), &response); unmarshalErr != nil {
+		return HttpResponse{}, fmt.Errorf("failed to unmarshal http response: %w", unmarshalErr)
+	}
+	return response, nil
+}
+
+// readAllocation copies memory written by Wox, returns nil if the pointer is not allocated by wox_alloc
+func readAllocation(ptr uint32, size uint32) []byte {
+	buf, exist := allocations[ptr]
+	if !exist || int(size) > len(buf) {
+		return nil
+	}
+
+	data := make([]byte, size)
+	copy(data, buf[:size])
+	return data
+}
@@ -1,6 +1,6 @@
 import "winston-daily-rotate-file"
 import { WebSocketServer } from "ws"
-import { handleRequestFromWox,PluginJsonRpcTypeRequest, PluginJsonRpcTypeResponse } from "./jsonrpc"
+import { handleRequestFromWox, PluginJsonRpcMethodCancel, PluginJsonRpcTypeRequest, PluginJsonRpcTypeResponse } from "./jsonrpc"
 import { logger } from "./logger"
 import * as crypto from "crypto"
 import Deferred from "promise-deferred"
@@ -44,6 +44,10 @@ export const waitingForResponse: {
   [key: string]: Deferred.Deferred<unknown>
 } = {}
 
+// requests from Wox being handled, and those canceled by Wox. Promises can't be aborted, so responses of canceled requests are dropped
+const runningRequests = new Set<string>()
+const canceledRequests = new Set<string>()
+
 const wss = new WebSocketServer({ port: Number.parseInt(port) })
 wss.on("connection", function connection(ws) {
   logger.updateWebSocket(ws)
@@ -95,12 +99,35 @@ wss.on("connection", function connection(ws) {
 
     const ctx = NewContextWithValue(TraceIdKey, jsonRpcRequest.TraceId)
 
+    if (jsonRpcRequest.Method === PluginJsonRpcMethodCancel) {
+      if (runningRequests.has(jsonRpcRequest.Params.Id)) {
+        canceledRequests.add(jsonRpcRequest.Params.Id)
+      }
+      return
+    }
+
     logger.debug(ctx, `receive request from wox, plugin:${jsonRpcRequest.PluginName}, method: ${jsonRpcRequest.Method}`)
 
+    runningRequests.add(jsonRpcRequest.Id)
+    // Wox has given up waiting for the response, don't send it
+    const isAbandoned = () => {
+      runningRequests.delete(jsonRpcRequest.Id)
+      const isCanceled = canceledRequests.delete(jsonRpcRequest.Id)
+      const isExpired = jsonRpcRequest.Deadline !== undefined && jsonRpcRequest.Deadline > 0 && Date.now() > jsonRpcRequest.Deadline
+      if (isCanceled || isExpired) {
+        logger.info(ctx, `[${jsonRpcRequest.PluginName}] request ${jsonRpcRequest.Id} is canceled or exceeded deadline, method: ${jsonRpcRequest.Method}`)
+        return true
+      }
+      return false
+    }
+
     // eslint-disable-next-line @typescript-eslint/ban-ts-comment
     // @ts-ignore
     handleRequestFromWox(ctx, jsonRpcRequest, ws)
       .then((result: unknown) => {
+        if (isAbandoned()) {
+          return
+        }
         const response: PluginJsonRpcResponse = {
           TraceId: jsonRpcRequest.TraceId,
           Id: jsonRpcRequest.Id,
@@ -116,6 +143,9 @@ wss.on("connection", function connection(ws) {
         })
       })
       .catch((error: Error) => {
+        if (isAbandoned()) {
+          return
+        }
         const response: PluginJsonRpcResponse = {
           TraceId: jsonRpcRequest.TraceId,
           Id: jsonRpcRequest.Id,
@@ -159,6 +189,11 @@ wss.on("connection", function connection(ws) {
       return
     }
 
+    if (pluginJsonRpcResponse.Error) {
+      promiseInstance.reject(new Error(pluginJsonRpcResponse.Error))
+      return
+    }
+
     promiseInstance.resolve(pluginJsonRpcResponse.Result)
   }
 })
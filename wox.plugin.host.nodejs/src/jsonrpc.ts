@@ -12,6 +12,8 @@ const pluginInstances = new Map<PluginJsonRpcRequest["PluginId"], PluginInstance
 export const PluginJsonRpcTypeRequest: string = "WOX_JSONRPC_REQUEST"
 export const PluginJsonRpcTypeResponse: string = "WOX_JSONRPC_RESPONSE"
 export const PluginJsonRpcTypeSystemLog: string = "WOX_JSONRPC_SYSTEM_LOG"
+// sent by Wox when it doesn't wait for a request anymore, Params.Id is the request id
+export const PluginJsonRpcMethodCancel: string = "$/cancel"
 
 // eslint-disable-next-line @typescript-eslint/ban-ts-comment
 // @ts-ignore
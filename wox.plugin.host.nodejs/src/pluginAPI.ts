@@ -124,4 +124,20 @@ export class PluginAPI implements PublicAPI {
     this.llmStreamCallbacks.set(callbackId, callback)
     await this.invokeMethod(ctx, "LLMStream", { callbackId, conversations: JSON.stringify(conversations) })
   }
+
+  async AIIndexDocuments(ctx: Context, index: string, model: AI.AIModel, documents: AI.VectorDocument[]): Promise<void> {
+    await this.invokeMethod(ctx, "AIIndexDocuments", { index, model: JSON.stringify(model), documents: JSON.stringify(documents) })
+  }
+
+  async AIRemoveDocuments(ctx: Context, index: string, model: AI.AIModel, ids: string[]): Promise<void> {
+    await this.invokeMethod(ctx, "AIRemoveDocuments", { index, model: JSON.stringify(model), ids: JSON.stringify(ids) })
+  }
+
+  async AIQueryDocuments(ctx: Context, index: string, model: AI.AIModel, query: string, limit: number): Promise<AI.VectorSearchResult[]> {
+    const result = await this.invokeMethod(ctx, "AIQueryDocuments", { index, model: JSON.stringify(model), query, limit: limit.toString() })
+    if (!result) {
+      return []
+    }
+    return JSON.parse(result as string) as AI.VectorSearchResult[]
+  }
 }
@@ -38,6 +38,7 @@ export interface RefreshableResultWithResultId  {
     Type: string
     Method: string
     Params: MapString
+    Deadline?: number // unix milliseconds, Wox doesn't wait for the response after it
   }
   
   export interface PluginJsonRpcResponse {
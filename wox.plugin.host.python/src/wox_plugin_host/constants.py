@@ -3,3 +3,6 @@
 PLUGIN_JSONRPC_TYPE_REQUEST = "WOX_JSONRPC_REQUEST"
 PLUGIN_JSONRPC_TYPE_RESPONSE = "WOX_JSONRPC_RESPONSE"
 PLUGIN_JSONRPC_TYPE_SYSTEM_LOG = "WOX_JSONRPC_SYSTEM_LOG"
+
+# sent by Wox when it doesn't wait for a request anymore, Params["Id"] is the request id
+PLUGIN_JSONRPC_METHOD_CANCEL = "$/cancel"
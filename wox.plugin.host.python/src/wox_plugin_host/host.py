@@ -1,13 +1,14 @@
 import asyncio
 import json
+import time
 import uuid
 import traceback
 from wox_plugin import Context
 import websockets
 
 from . import logger
-from .constants import PLUGIN_JSONRPC_TYPE_REQUEST, PLUGIN_JSONRPC_TYPE_RESPONSE
-from .plugin_manager import waiting_for_response
+from .constants import PLUGIN_JSONRPC_TYPE_REQUEST, PLUGIN_JSONRPC_TYPE_RESPONSE, PLUGIN_JSONRPC_METHOD_CANCEL
+from .plugin_manager import waiting_for_response, running_requests
 from .jsonrpc import handle_request_from_wox
 
 
@@ -64,9 +65,21 @@ async def handle_message(ws: websockets.asyncio.server.ServerConnection, message
                     deferred.set_result(msg_data.get("Result"))
                 del waiting_for_response[msg_data["Id"]]
         elif PLUGIN_JSONRPC_TYPE_REQUEST in message:
+            if msg_data.get("Method") == PLUGIN_JSONRPC_METHOD_CANCEL:
+                # Wox doesn't wait for the request anymore, cancel it without responding
+                task = running_requests.pop(msg_data.get("Params", {}).get("Id", ""), None)
+                if task:
+                    task.cancel()
+                return
+
             # Handle request from Wox
+            task = asyncio.create_task(handle_request_from_wox(ctx, msg_data, ws))
+            running_requests[msg_data["Id"]] = task
             try:
-                result = await handle_request_from_wox(ctx, msg_data, ws)
+                timeout = None
+                if msg_data.get("Deadline"):
+                    timeout = max(msg_data["Deadline"] / 1000 - time.time(), 0)
+                result = await asyncio.wait_for(task, timeout)
                 # Clean result for serialization
                 cleaned_result = _clean_for_serialization(result)
 
@@ -78,6 +91,8 @@ async def handle_message(ws: websockets.asyncio.server.ServerConnection, message
                     "Result": cleaned_result,
                 }
                 await ws.send(json.dumps(response))
+            except (asyncio.CancelledError, asyncio.TimeoutError):
+                await logger.info(trace_id, f"request {msg_data['Id']} is canceled or exceeded deadline, method: {msg_data['Method']}")
             except Exception as e:
                 error_stack = traceback.format_exc()
                 error_response = {
@@ -89,6 +104,8 @@ async def handle_message(ws: websockets.asyncio.server.ServerConnection, message
                 }
                 await logger.error(trace_id, f"handle request failed: {str(e)}\nStack trace:\n{error_stack}")
                 await ws.send(json.dumps(error_response))
+            finally:
+                running_requests.pop(msg_data["Id"], None)
         else:
             await logger.error(trace_id, f"unknown message type: {message}")
     except Exception as e:
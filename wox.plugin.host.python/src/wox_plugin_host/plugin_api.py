@@ -12,6 +12,8 @@ from wox_plugin import (
     Conversation,
     AIModel,
     ChatStreamCallback,
+    VectorDocument,
+    VectorSearchResult,
 )
 from .constants import PLUGIN_JSONRPC_TYPE_REQUEST
 from .plugin_manager import waiting_for_response
@@ -154,3 +156,43 @@ class PluginAPI(PublicAPI):
                 "conversations": json.dumps([conv.__dict__ for conv in conversations]),
             },
         )
+
+    async def ai_index_documents(self, ctx: Context, index: str, model: AIModel, documents: list[VectorDocument]) -> None:
+        """Embed documents and save them into local vector index"""
+        await self.invoke_method(
+            ctx,
+            "AIIndexDocuments",
+            {
+                "index": index,
+                "model": model.to_json(),
+                "documents": json.dumps([document.to_dict() for document in documents]),
+            },
+        )
+
+    async def ai_remove_documents(self, ctx: Context, index: str, model: AIModel, ids: list[str]) -> None:
+        """Remove documents from local vector index"""
+        await self.invoke_method(
+            ctx,
+            "AIRemoveDocuments",
+            {
+                "index": index,
+                "model": model.to_json(),
+                "ids": json.dumps(ids),
+            },
+        )
+
+    async def ai_query_documents(self, ctx: Context, index: str, model: AIModel, query: str, limit: int) -> list[VectorSearchResult]:
+        """Query the most similar documents from local vector index"""
+        result = await self.invoke_method(
+            ctx,
+            "AIQueryDocuments",
+            {
+                "index": index,
+                "model": model.to_json(),
+                "query": query,
+                "limit": str(limit),
+            },
+        )
+        if not result:
+            return []
+        return [VectorSearchResult.from_dict(item) for item in json.loads(result)]
@@ -16,3 +16,5 @@ class PluginInstance:
 # Global state with strong typing
 plugin_instances: Dict[str, PluginInstance] = {}
 waiting_for_response: Dict[str, asyncio.Future[Any]] = {}
+# requests from Wox being handled, request id -> task
+running_requests: Dict[str, asyncio.Task[Any]] = {}
@@ -1,6 +1,6 @@
 export namespace AI {
   export type ConversationRole = "user" | "system"
-  export type ChatStreamDataType = "streaming" | "finished" | "error"
+  export type ChatStreamDataType = "streaming" | "finished" | "error" | "restarted"
 
   export interface Conversation {
     Role: ConversationRole
@@ -9,4 +9,25 @@ export namespace AI {
   }
 
   export type ChatStreamFunc = (dataType: ChatStreamDataType, data: string) => void
+
+  export interface AIModel {
+    Name: string
+    Provider: string
+  }
+
+  export interface VectorDocument {
+    Id: string
+    Text: string
+    Metadata?: Record<string, string>
+  }
+
+  export interface VectorSearchResult {
+    Id: string
+    Text: string
+    Metadata: Record<string, string>
+    /**
+     * Cosine similarity from -1 to 1, higher is more similar
+     */
+    Score: number
+  }
 }
@@ -237,6 +237,22 @@ export interface PublicAPI {
    * Chat using LLM
    */
   LLMStream: (ctx: Context, conversations: AI.Conversation[], callback: AI.ChatStreamFunc) => Promise<void>
+
+  /**
+   * Embed documents with the embedding model and save them into the plugin's local vector index.
+   * Documents with the same id are replaced, changing the model of an index clears its existing documents.
+   */
+  AIIndexDocuments: (ctx: Context, index: string, model: AI.AIModel, documents: AI.VectorDocument[]) => Promise<void>
+
+  /**
+   * Remove documents from the plugin's local vector index
+   */
+  AIRemoveDocuments: (ctx: Context, index: string, model: AI.AIModel, ids: string[]) => Promise<void>
+
+  /**
+   * Return the documents most similar to the query, ordered by score desc
+   */
+  AIQueryDocuments: (ctx: Context, index: string, model: AI.AIModel, query: string, limit: number) => Promise<AI.VectorSearchResult[]>
 }
 
 export type WoxImageType = "absolute" | "relative" | "base64" | "svg" | "url" | "emoji" | "lottie"
@@ -32,6 +32,8 @@ from .models.ai import (
     Conversation,
     ConversationRole,
     ChatStreamDataType,
+    VectorDocument,
+    VectorSearchResult,
 )
 from .models.image import WoxImage, WoxImageType
 from .models.preview import WoxPreview, WoxPreviewType, WoxPreviewScrollPosition
@@ -63,6 +65,8 @@ __all__: List[str] = [
     "Conversation",
     "ConversationRole",
     "ChatStreamDataType",
+    "VectorDocument",
+    "VectorSearchResult",
     "user_message",
     "ai_message",
     # Query
@@ -3,7 +3,7 @@ from typing import Protocol, Callable, Dict, List
 from .models.query import MetadataCommand
 from .models.context import Context
 from .models.query import ChangeQueryParam
-from .models.ai import AIModel, Conversation, ChatStreamCallback
+from .models.ai import AIModel, Conversation, ChatStreamCallback, VectorDocument, VectorSearchResult
 
 
 class PublicAPI(Protocol):
@@ -81,3 +81,18 @@ class PublicAPI(Protocol):
                      - data: str, the stream content
         """
         ...
+
+    async def ai_index_documents(self, ctx: Context, index: str, model: AIModel, documents: List[VectorDocument]) -> None:
+        """
+        Embed documents with the embedding model and save them into the plugin's local vector index.
+        Documents with the same id are replaced. Changing the model of an index clears its existing documents.
+        """
+        ...
+
+    async def ai_remove_documents(self, ctx: Context, index: str, model: AIModel, ids: List[str]) -> None:
+        """Remove documents from the plugin's local vector index"""
+        ...
+
+    async def ai_query_documents(self, ctx: Context, index: str, model: AIModel, query: str, limit: int) -> List[VectorSearchResult]:
+        """Return the documents most similar to the query, ordered by score desc"""
+        ...
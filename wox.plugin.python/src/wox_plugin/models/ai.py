@@ -1,5 +1,5 @@
 from enum import Enum
-from typing import List, Callable, Optional
+from typing import Any, Dict, List, Callable, Optional
 import time
 from dataclasses import dataclass, field
 import json
@@ -18,6 +18,7 @@ class ChatStreamDataType(str, Enum):
     STREAMING = "streaming"  # Currently streaming
     FINISHED = "finished"  # Stream completed
     ERROR = "error"  # Error occurred
+    RESTARTED = "restarted"  # Broken stream restarted with a different answer, data received before should be discarded
 
 
 ChatStreamCallback = Callable[[ChatStreamDataType, str], None]
@@ -100,3 +101,40 @@ class Conversation:
             role=ConversationRole.AI,
             text=text,
         )
+
+
+@dataclass
+class VectorDocument:
+    """Document stored in a vector index"""
+
+    id: str
+    text: str
+    metadata: Dict[str, str] = field(default_factory=dict)
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Convert to dict with camelCase naming"""
+        return {
+            "Id": self.id,
+            "Text": self.text,
+            "Metadata": self.metadata,
+        }
+
+
+@dataclass
+class VectorSearchResult:
+    """Document found in a vector index, score is the cosine similarity from -1 to 1"""
+
+    id: str
+    text: str
+    metadata: Dict[str, str] = field(default_factory=dict)
+    score: float = 0
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "VectorSearchResult":
+        """Create from dict with camelCase naming"""
+        return cls(
+            id=data.get("Id", ""),
+            text=data.get("Text", ""),
+            metadata=data.get("Metadata") or {},
+            score=data.get("Score", 0),
+        )
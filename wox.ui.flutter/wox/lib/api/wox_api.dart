@@ -37,18 +37,30 @@ class WoxApi {
     return await WoxHttpUtil.instance.postData("/plugin/store", null);
   }
 
+  Future<List<StoreSyncStatus>> findPluginStoreStatus() async {
+    return await WoxHttpUtil.instance.postData("/plugin/store/status", null);
+  }
+
   Future<List<PluginDetail>> findInstalledPlugins() async {
     return await WoxHttpUtil.instance.postData("/plugin/installed", null);
   }
 
-  Future<void> installPlugin(String id) async {
-    await WoxHttpUtil.instance.postData("/plugin/install", {"id": id});
+  Future<List<PluginLoadError>> findPluginLoadErrors() async {
+    return await WoxHttpUtil.instance.postData("/plugin/load/errors", null);
+  }
+
+  Future<void> installPlugin(String id, {String version = ""}) async {
+    await WoxHttpUtil.instance.postData("/plugin/install", {"id": id, "version": version});
   }
 
   Future<void> uninstallPlugin(String id) async {
     await WoxHttpUtil.instance.postData("/plugin/uninstall", {"id": id});
   }
 
+  Future<void> rollbackPlugin(String id) async {
+    await WoxHttpUtil.instance.postData("/plugin/rollback", {"id": id});
+  }
+
   Future<void> disablePlugin(String id) async {
     await WoxHttpUtil.instance.postData("/plugin/disable", {"id": id});
   }
@@ -61,6 +73,10 @@ class WoxApi {
     return await WoxHttpUtil.instance.postData("/theme/store", null);
   }
 
+  Future<List<StoreSyncStatus>> findThemeStoreStatus() async {
+    return await WoxHttpUtil.instance.postData("/theme/store/status", null);
+  }
+
   Future<List<WoxTheme>> findInstalledThemes() async {
     return await WoxHttpUtil.instance.postData("/theme/installed", null);
   }
@@ -126,6 +142,10 @@ class WoxApi {
     return await WoxHttpUtil.instance.postData("/ai/models", null);
   }
 
+  Future<List<AIUsage>> findAIUsages() async {
+    return await WoxHttpUtil.instance.postData("/ai/usage", null);
+  }
+
   Future<bool> doctorCheck() async {
     return await WoxHttpUtil.instance.postData("/doctor/check", null);
   }
@@ -8,6 +8,7 @@ import 'package:wox/components/wox_theme_icon_view.dart';
 import 'package:wox/entity/wox_image.dart';
 import 'package:wox/entity/wox_theme.dart';
 import 'package:wox/enums/wox_image_type_enum.dart';
+import 'package:wox/utils/env.dart';
 
 class WoxImageView extends StatelessWidget {
   final WoxImage woxImage;
@@ -21,6 +22,7 @@ class WoxImageView extends StatelessWidget {
     if (woxImage.imageType == WoxImageTypeEnum.WOX_IMAGE_TYPE_URL.code) {
       return Image.network(
         woxImage.imageData,
+        headers: Env.isServerUrl(woxImage.imageData) ? Env.sessionTokenHeaders : null,
         width: width,
         height: height,
         fit: BoxFit.contain,
@@ -0,0 +1,48 @@
+import 'package:fluent_ui/fluent_ui.dart';
+import 'package:wox/entity/wox_plugin.dart';
+
+// shows plugins which are not loaded because their plugin.json is invalid, with positions of every problem
+class WoxPluginLoadErrorsView extends StatelessWidget {
+  final List<PluginLoadError> loadErrors;
+
+  const WoxPluginLoadErrorsView({super.key, required this.loadErrors});
+
+  List<String> getErrorLines(PluginLoadError loadError) {
+    if (loadError.schemaErrors.isEmpty) {
+      return [loadError.message];
+    }
+    return loadError.schemaErrors.map((e) => '${loadError.file}:$e').toList();
+  }
+
+  @override
+  Widget build(BuildContext context) {
+    if (loadErrors.isEmpty) {
+      return const SizedBox();
+    }
+
+    return Padding(
+      padding: const EdgeInsets.only(bottom: 10),
+      child: InfoBar(
+        title: Text('${loadErrors.length} plugin(s) failed to load'),
+        severity: InfoBarSeverity.warning,
+        isLong: true,
+        content: Column(
+          crossAxisAlignment: CrossAxisAlignment.start,
+          children: [
+            for (final loadError in loadErrors) ...[
+              Padding(
+                padding: const EdgeInsets.only(top: 6),
+                child: Text(loadError.name, style: const TextStyle(fontWeight: FontWeight.bold)),
+              ),
+              for (final line in getErrorLines(loadError))
+                SelectableText(
+                  line,
+                  style: TextStyle(color: Colors.grey[150], fontSize: 12),
+                ),
+            ],
+          ],
+        ),
+      ),
+    );
+  }
+}
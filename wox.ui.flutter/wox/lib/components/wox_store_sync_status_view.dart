@@ -0,0 +1,45 @@
+import 'package:fluent_ui/fluent_ui.dart';
+import 'package:wox/entity/wox_setting.dart';
+
+// shows when stores were synced last time, store list may be served from cache if a store can't be reached
+class WoxStoreSyncStatusView extends StatelessWidget {
+  final List<StoreSyncStatus> statuses;
+
+  const WoxStoreSyncStatusView({super.key, required this.statuses});
+
+  String formatSyncTime(int timestamp) {
+    if (timestamp == 0) {
+      return 'never';
+    }
+    return DateTime.fromMillisecondsSinceEpoch(timestamp).toString().substring(0, 16);
+  }
+
+  @override
+  Widget build(BuildContext context) {
+    if (statuses.isEmpty) {
+      return const SizedBox();
+    }
+
+    final lastSyncTime = statuses.map((e) => e.lastSyncTime).reduce((a, b) => a > b ? a : b);
+    final errors = statuses.where((e) => e.lastError.isNotEmpty).map((e) => '${e.name}: ${e.lastError}').toList();
+    return Padding(
+      padding: const EdgeInsets.only(bottom: 10),
+      child: Row(
+        children: [
+          Text(
+            'Synced: ${formatSyncTime(lastSyncTime)}',
+            style: TextStyle(color: Colors.grey, fontSize: 12),
+          ),
+          if (errors.isNotEmpty)
+            Padding(
+              padding: const EdgeInsets.only(left: 6),
+              child: Tooltip(
+                message: errors.join('\n'),
+                child: Icon(FluentIcons.warning, size: 12, color: Colors.orange),
+              ),
+            ),
+        ],
+      ),
+    );
+  }
+}
@@ -16,3 +16,21 @@ class AIModel {
     return data;
   }
 }
+
+class AIUsage {
+  late String provider;
+  late int requestCount;
+  late int promptTokens;
+  late int completionTokens;
+  late int lastUsedTimestamp;
+
+  AIUsage({required this.provider, required this.requestCount, required this.promptTokens, required this.completionTokens, required this.lastUsedTimestamp});
+
+  AIUsage.fromJson(Map<String, dynamic> json) {
+    provider = json['Provider'];
+    requestCount = json['RequestCount'] ?? 0;
+    promptTokens = json['PromptTokens'] ?? 0;
+    completionTokens = json['CompletionTokens'] ?? 0;
+    lastUsedTimestamp = json['LastUsedTimestamp'] ?? 0;
+  }
+}
@@ -10,6 +10,7 @@ class PluginDetail {
   late WoxImage icon;
   late String website;
   late String entry;
+  late String runtime;
   late List<String> triggerKeywords;
   late List<MetadataCommand> commands;
   late List<String> supportedOS;
@@ -18,9 +19,17 @@ class PluginDetail {
   late bool isDev;
   late bool isInstalled;
   late bool isDisable;
+  late String incompatibleReason;
+  late List<String> compatibleVersions;
+  late String store;
+  late String updateVersion;
+  late String rollbackVersion;
   late List<PluginSettingDefinitionItem> settingDefinitions;
   late PluginSetting setting;
   late List<MetadataFeature> features;
+  late PluginPermissions permissions;
+  late Map<String, PluginPermissions> versionPermissions;
+  PluginProcessStat? processStat;
 
   PluginDetail.empty() {
     id = '';
@@ -31,6 +40,7 @@ class PluginDetail {
     icon = WoxImage.empty();
     website = '';
     entry = '';
+    runtime = '';
     triggerKeywords = <String>[];
     commands = <MetadataCommand>[];
     supportedOS = <String>[];
@@ -39,9 +49,16 @@ class PluginDetail {
     isDev = false;
     isInstalled = false;
     isDisable = false;
+    incompatibleReason = '';
+    compatibleVersions = <String>[];
+    store = '';
+    updateVersion = '';
+    rollbackVersion = '';
     settingDefinitions = <PluginSettingDefinitionItem>[];
     setting = PluginSetting.empty();
     features = <MetadataFeature>[];
+    permissions = PluginPermissions.empty();
+    versionPermissions = <String, PluginPermissions>{};
   }
 
   PluginDetail.fromJson(Map<String, dynamic> json) {
@@ -53,10 +70,21 @@ class PluginDetail {
     icon = WoxImage.fromJson(json['Icon']);
     website = json['Website'];
     entry = json['Entry'];
+    runtime = json['Runtime'] ?? '';
     isSystem = json['IsSystem'] ?? false;
     isDev = json['IsDev'] ?? false;
     isInstalled = json['IsInstalled'] ?? false;
     isDisable = json['IsDisable'] ?? false;
+    incompatibleReason = json['IncompatibleReason'] ?? '';
+    store = json['Store'] ?? '';
+    updateVersion = json['UpdateVersion'] ?? '';
+    rollbackVersion = json['RollbackVersion'] ?? '';
+
+    if (json['CompatibleVersions'] != null) {
+      compatibleVersions = (json['CompatibleVersions'] as List).map((e) => e.toString()).toList();
+    } else {
+      compatibleVersions = <String>[];
+    }
 
     if (json['TriggerKeywords'] != null) {
       triggerKeywords = (json['TriggerKeywords'] as List).map((e) => e.toString()).toList();
@@ -108,6 +136,42 @@ class PluginDetail {
     } else {
       features = <MetadataFeature>[];
     }
+
+    if (json['Permissions'] != null) {
+      permissions = PluginPermissions.fromJson(json['Permissions']);
+    } else {
+      permissions = PluginPermissions.empty();
+    }
+
+    versionPermissions = <String, PluginPermissions>{};
+    if (json['VersionPermissions'] != null) {
+      (json['VersionPermissions'] as Map<String, dynamic>).forEach((version, v) {
+        versionPermissions[version] = PluginPermissions.fromJson(v);
+      });
+    }
+
+    if (json['ProcessStat'] != null) {
+      processStat = PluginProcessStat.fromJson(json['ProcessStat']);
+    }
+  }
+
+  // permissions requested by the given version, which may differ from the latest version
+  PluginPermissions getPermissions(String version) {
+    return versionPermissions[version] ?? permissions;
+  }
+}
+
+class PluginProcessStat {
+  late int pid;
+  late double cpu;
+  late double memory;
+  late bool isShared;
+
+  PluginProcessStat.fromJson(Map<String, dynamic> json) {
+    pid = json['Pid'];
+    cpu = (json['CPU'] as num).toDouble();
+    memory = (json['Memory'] as num).toDouble();
+    isShared = json['IsShared'] ?? false;
   }
 }
 
@@ -130,12 +194,16 @@ class MetadataCommand {
 
 class PluginSetting {
   late bool disabled;
+  late bool isolatedHost;
+  late bool autoUpdate;
   late List<String> triggerKeywords;
   late List<PluginQueryCommand> queryCommands;
   late Map<String, String> settings;
 
   PluginSetting.empty() {
     disabled = false;
+    isolatedHost = false;
+    autoUpdate = false;
     triggerKeywords = <String>[];
     queryCommands = <PluginQueryCommand>[];
     settings = <String, String>{};
@@ -143,6 +211,8 @@ class PluginSetting {
 
   PluginSetting.fromJson(Map<String, dynamic> json) {
     disabled = json['Disabled'];
+    isolatedHost = json['IsolatedHost'] ?? false;
+    autoUpdate = json['AutoUpdate'] ?? false;
 
     if (json['TriggerKeywords'] == null) {
       triggerKeywords = <String>[];
@@ -191,3 +261,81 @@ class MetadataFeature {
     }
   }
 }
+
+class PluginPermissions {
+  late List<PluginFileSystemPermission> fileSystem;
+  late List<String> network;
+
+  PluginPermissions.empty() {
+    fileSystem = <PluginFileSystemPermission>[];
+    network = <String>[];
+  }
+
+  PluginPermissions.fromJson(Map<String, dynamic> json) {
+    fileSystem = <PluginFileSystemPermission>[];
+    if (json['FileSystem'] != null) {
+      json['FileSystem'].forEach((v) {
+        fileSystem.add(PluginFileSystemPermission.fromJson(v));
+      });
+    }
+
+    if (json['Network'] != null) {
+      network = (json['Network'] as List).map((e) => e.toString()).toList();
+    } else {
+      network = <String>[];
+    }
+  }
+
+  bool get isEmpty => fileSystem.isEmpty && network.isEmpty;
+}
+
+class PluginFileSystemPermission {
+  late String path;
+  late bool readOnly;
+
+  PluginFileSystemPermission.fromJson(Map<String, dynamic> json) {
+    path = json['Path'];
+    readOnly = json['ReadOnly'] ?? false;
+  }
+}
+
+// plugin which is not loaded because its plugin.json is invalid
+class PluginLoadError {
+  late String directory;
+  late String file;
+  late String name;
+  late String message;
+  late List<MetadataSchemaError> schemaErrors;
+
+  PluginLoadError.fromJson(Map<String, dynamic> json) {
+    directory = json['Directory'] ?? "";
+    file = json['File'] ?? "";
+    name = json['Name'] ?? "";
+    message = json['Message'] ?? "";
+    schemaErrors = <MetadataSchemaError>[];
+    if (json['SchemaErrors'] != null) {
+      json['SchemaErrors'].forEach((v) {
+        schemaErrors.add(MetadataSchemaError.fromJson(v));
+      });
+    }
+  }
+}
+
+class MetadataSchemaError {
+  late String path;
+  late int line;
+  late int column;
+  late String message;
+
+  MetadataSchemaError.fromJson(Map<String, dynamic> json) {
+    path = json['Path'] ?? "";
+    line = json['Line'] ?? 0;
+    column = json['Column'] ?? 0;
+    message = json['Message'] ?? "";
+  }
+
+  @override
+  String toString() {
+    return path.isEmpty ? '$line:$column: $message' : '$line:$column: $path: $message';
+  }
+}
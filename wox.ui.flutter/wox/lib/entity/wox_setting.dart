@@ -12,6 +12,18 @@ class WoxSetting {
   late List<QueryShortcut> queryShortcuts;
   late String lastQueryMode;
   late List<AIProvider> aiProviders;
+  late List<AIModelSetting> aiModelSettings;
+  late String aiContextStrategy;
+  late int aiMaxRetries;
+  late bool aiEnableResponseCache;
+  late String scriptCommandDirectory;
+  late String pythonPath;
+  late String nodejsPath;
+  late String pipIndexUrl;
+  late String npmRegistry;
+  late String pluginTrustPolicy;
+  late List<StoreSource> pluginStores;
+  late List<StoreSource> themeStores;
   late int appWidth;
   late String themeId;
 
@@ -29,6 +41,18 @@ class WoxSetting {
     required this.queryShortcuts,
     required this.lastQueryMode,
     required this.aiProviders,
+    required this.aiModelSettings,
+    required this.aiContextStrategy,
+    required this.aiMaxRetries,
+    required this.aiEnableResponseCache,
+    required this.scriptCommandDirectory,
+    required this.pythonPath,
+    required this.nodejsPath,
+    required this.pipIndexUrl,
+    required this.npmRegistry,
+    required this.pluginTrustPolicy,
+    required this.pluginStores,
+    required this.themeStores,
     required this.appWidth,
     required this.themeId,
   });
@@ -73,6 +97,43 @@ class WoxSetting {
       aiProviders = <AIProvider>[];
     }
 
+    if (json['AIModelSettings'] != null) {
+      aiModelSettings = <AIModelSetting>[];
+      json['AIModelSettings'].forEach((v) {
+        aiModelSettings.add(AIModelSetting.fromJson(v));
+      });
+    } else {
+      aiModelSettings = <AIModelSetting>[];
+    }
+
+    aiContextStrategy = json['AIContextStrategy'] ?? "drop_oldest";
+    aiMaxRetries = json['AIMaxRetries'] ?? 0;
+    aiEnableResponseCache = json['AIEnableResponseCache'] ?? false;
+    scriptCommandDirectory = json['ScriptCommandDirectory'] ?? "";
+    pythonPath = json['PythonPath'] ?? "";
+    nodejsPath = json['NodejsPath'] ?? "";
+    pipIndexUrl = json['PipIndexUrl'] ?? "";
+    npmRegistry = json['NpmRegistry'] ?? "";
+    pluginTrustPolicy = json['PluginTrustPolicy'] ?? "";
+
+    if (json['PluginStores'] != null) {
+      pluginStores = <StoreSource>[];
+      json['PluginStores'].forEach((v) {
+        pluginStores.add(StoreSource.fromJson(v));
+      });
+    } else {
+      pluginStores = <StoreSource>[];
+    }
+
+    if (json['ThemeStores'] != null) {
+      themeStores = <StoreSource>[];
+      json['ThemeStores'].forEach((v) {
+        themeStores.add(StoreSource.fromJson(v));
+      });
+    } else {
+      themeStores = <StoreSource>[];
+    }
+
     appWidth = json['AppWidth'];
     themeId = json['ThemeId'];
   }
@@ -92,6 +153,18 @@ class WoxSetting {
     data['QueryShortcuts'] = queryShortcuts;
     data['LastQueryMode'] = lastQueryMode;
     data['AIProviders'] = aiProviders;
+    data['AIModelSettings'] = aiModelSettings;
+    data['AIContextStrategy'] = aiContextStrategy;
+    data['AIMaxRetries'] = aiMaxRetries;
+    data['AIEnableResponseCache'] = aiEnableResponseCache;
+    data['ScriptCommandDirectory'] = scriptCommandDirectory;
+    data['PythonPath'] = pythonPath;
+    data['NodejsPath'] = nodejsPath;
+    data['PipIndexUrl'] = pipIndexUrl;
+    data['NpmRegistry'] = npmRegistry;
+    data['PluginTrustPolicy'] = pluginTrustPolicy;
+    data['PluginStores'] = pluginStores;
+    data['ThemeStores'] = themeStores;
     data['AppWidth'] = appWidth;
     data['ThemeId'] = themeId;
     return data;
@@ -142,6 +215,48 @@ class QueryShortcut {
   }
 }
 
+class StoreSource {
+  late String name;
+  late String url;
+  late int priority;
+  late bool enabled;
+  late String publicKey;
+
+  StoreSource({required this.name, required this.url, required this.priority, required this.enabled, required this.publicKey});
+
+  StoreSource.fromJson(Map<String, dynamic> json) {
+    name = json['Name'] ?? "";
+    url = json['Url'] ?? "";
+    priority = json['Priority'] ?? 0;
+    enabled = json['Enabled'] ?? false;
+    publicKey = json['PublicKey'] ?? "";
+  }
+
+  Map<String, dynamic> toJson() {
+    final Map<String, dynamic> data = <String, dynamic>{};
+    data['Name'] = name;
+    data['Url'] = url;
+    data['Priority'] = priority;
+    data['Enabled'] = enabled;
+    data['PublicKey'] = publicKey;
+    return data;
+  }
+}
+
+class StoreSyncStatus {
+  late String name;
+  late String url;
+  late int lastSyncTime;
+  late String lastError;
+
+  StoreSyncStatus.fromJson(Map<String, dynamic> json) {
+    name = json['Name'] ?? "";
+    url = json['Url'] ?? "";
+    lastSyncTime = json['LastSyncTime'] ?? 0;
+    lastError = json['LastError'] ?? "";
+  }
+}
+
 class SettingWindowContext {
   late String path;
   late String param;
@@ -176,3 +291,28 @@ class AIProvider {
     return data;
   }
 }
+
+class AIModelSetting {
+  late String provider;
+  late String name;
+  late int contextWindow;
+  late String fallbackModel;
+
+  AIModelSetting({required this.provider, required this.name, required this.contextWindow, required this.fallbackModel});
+
+  AIModelSetting.fromJson(Map<String, dynamic> json) {
+    provider = json['Provider'];
+    name = json['Name'];
+    contextWindow = json['ContextWindow'] ?? 0;
+    fallbackModel = json['FallbackModel'] ?? "";
+  }
+
+  Map<String, dynamic> toJson() {
+    final Map<String, dynamic> data = <String, dynamic>{};
+    data['Provider'] = provider;
+    data['Name'] = name;
+    data['ContextWindow'] = contextWindow;
+    data['FallbackModel'] = fallbackModel;
+    return data;
+  }
+}
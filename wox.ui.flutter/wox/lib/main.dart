@@ -34,16 +34,20 @@ Future<void> initArgs(List<String> arguments) async {
     Env.isDev = true;
     Env.serverPort = 34987;
     Env.serverPid = -1;
+    Env.sessionTokenPath = Env.defaultSessionTokenPath();
+    Env.loadSessionToken();
     return;
   }
 
-  if (arguments.length != 3) {
+  if (arguments.length != 4) {
     throw Exception("Invalid arguments");
   }
 
   Env.serverPort = int.parse(arguments[0]);
   Env.serverPid = int.parse(arguments[1]);
   Env.isDev = arguments[2] == "true";
+  Env.sessionTokenPath = arguments[3];
+  Env.loadSessionToken();
 }
 
 Future<void> initialServices(List<String> arguments) async {
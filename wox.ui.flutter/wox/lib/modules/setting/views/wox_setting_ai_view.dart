@@ -2,8 +2,10 @@ import 'dart:convert';
 
 import 'package:fluent_ui/fluent_ui.dart';
 import 'package:get/get.dart';
+import 'package:wox/api/wox_api.dart';
 import 'package:wox/components/plugin/wox_setting_plugin_table_view.dart';
 import 'package:wox/entity/setting/wox_plugin_setting_table.dart';
+import 'package:wox/entity/wox_ai.dart';
 import 'package:wox/modules/setting/wox_setting_controller.dart';
 
 class WoxSettingAIView extends GetView<WoxSettingController> {
@@ -115,7 +117,170 @@ class WoxSettingAIView extends GetView<WoxSettingController> {
                 );
               }),
             ),
+            formField(
+              label: controller.tr("ai_model_settings"),
+              tips: controller.tr("ai_model_settings_tips"),
+              child: Obx(() {
+                return WoxSettingPluginTable(
+                  value: json.encode(controller.woxSetting.value.aiModelSettings.map((e) => {...e.toJson(), "ContextWindow": e.contextWindow.toString()}).toList()),
+                  tableWidth: 750,
+                  item: PluginSettingValueTable.fromJson({
+                    "Key": "AIModelSettings",
+                    "Columns": [
+                      {
+                        "Key": "Provider",
+                        "Label": "Provider Name",
+                        "Width": 120,
+                        "Type": "select",
+                        "SelectOptions": [
+                          {"Label": "OpenAI", "Value": "openai"},
+                          {"Label": "Google", "Value": "google"},
+                          {"Label": "Ollama", "Value": "ollama"},
+                          {"Label": "Groq", "Value": "groq"},
+                        ],
+                        "TextMaxLines": 1,
+                        "Validators": [
+                          {"Type": "not_empty"}
+                        ],
+                      },
+                      {
+                        "Key": "Name",
+                        "Label": "Model Name",
+                        "Tooltip": "The name of the model, E.g. gpt-4o-mini",
+                        "Type": "text",
+                        "TextMaxLines": 1,
+                        "Validators": [
+                          {"Type": "not_empty"}
+                        ],
+                      },
+                      {
+                        "Key": "ContextWindow",
+                        "Label": "Context Window",
+                        "Tooltip": "Max tokens the model accepts, including the answer",
+                        "Width": 150,
+                        "Type": "text",
+                        "TextMaxLines": 1,
+                        "Validators": [
+                          {"Type": "is_number", "IsInteger": true, "IsFloat": false}
+                        ],
+                      },
+                      {
+                        "Key": "FallbackModel",
+                        "Label": "Fallback Model",
+                        "Tooltip": "Model to use when this model keeps failing, E.g. rate limited or provider is down",
+                        "Width": 200,
+                        "Type": "selectAIModel",
+                      }
+                    ],
+                    "SortColumnKey": "Provider"
+                  }),
+                  onUpdate: (key, value) {
+                    // table editor stores all text columns as string, convert context window back to int
+                    final modelSettings = (json.decode(value) as List).map((e) {
+                      e["ContextWindow"] = int.tryParse(e["ContextWindow"].toString()) ?? 0;
+                      return e;
+                    }).toList();
+                    controller.updateConfig("AIModelSettings", json.encode(modelSettings));
+                  },
+                );
+              }),
+            ),
+            formField(
+              label: controller.tr("ai_context_strategy"),
+              tips: controller.tr("ai_context_strategy_tips"),
+              child: Obx(() {
+                return ComboBox<String>(
+                  items: [
+                    ComboBoxItem(value: "drop_oldest", child: Text(controller.tr("ai_context_strategy_drop_oldest"))),
+                    ComboBoxItem(value: "keep_system_and_latest", child: Text(controller.tr("ai_context_strategy_keep_system_and_latest"))),
+                  ],
+                  value: controller.woxSetting.value.aiContextStrategy,
+                  onChanged: (v) {
+                    if (v != null) {
+                      controller.updateConfig("AIContextStrategy", v);
+                    }
+                  },
+                );
+              }),
+            ),
+            formField(
+              label: controller.tr("ai_max_retries"),
+              tips: controller.tr("ai_max_retries_tips"),
+              child: Obx(() {
+                return ComboBox<int>(
+                  items: [
+                    ComboBoxItem(value: 0, child: Text(controller.tr("ai_max_retries_default"))),
+                    ComboBoxItem(value: -1, child: Text(controller.tr("ai_max_retries_disabled"))),
+                    ...[1, 2, 3, 5].map((e) => ComboBoxItem(value: e, child: Text(e.toString()))),
+                  ],
+                  value: controller.woxSetting.value.aiMaxRetries,
+                  onChanged: (v) {
+                    if (v != null) {
+                      controller.updateConfig("AIMaxRetries", v.toString());
+                    }
+                  },
+                );
+              }),
+            ),
+            formField(
+              label: controller.tr("ai_enable_response_cache"),
+              tips: controller.tr("ai_enable_response_cache_tips"),
+              child: Obx(() {
+                return ToggleSwitch(
+                  checked: controller.woxSetting.value.aiEnableResponseCache,
+                  onChanged: (bool value) {
+                    controller.updateConfig("AIEnableResponseCache", value.toString());
+                  },
+                );
+              }),
+            ),
+            formField(
+              label: controller.tr("ai_usage"),
+              tips: controller.tr("ai_usage_tips"),
+              child: FutureBuilder(
+                  future: WoxApi.instance.findAIUsages(),
+                  builder: (context, snapshot) {
+                    if (snapshot.connectionState != ConnectionState.done || snapshot.data == null) {
+                      return const SizedBox();
+                    }
+
+                    final usages = snapshot.data as List<AIUsage>;
+                    if (usages.isEmpty) {
+                      return Text(controller.tr("ai_usage_empty"));
+                    }
+
+                    return SizedBox(
+                      width: 750,
+                      child: Table(
+                        border: TableBorder.all(color: Colors.grey[60]),
+                        children: [
+                          TableRow(children: [
+                            usageCell(controller.tr("ai_usage_provider"), isHeader: true),
+                            usageCell(controller.tr("ai_usage_requests"), isHeader: true),
+                            usageCell(controller.tr("ai_usage_prompt_tokens"), isHeader: true),
+                            usageCell(controller.tr("ai_usage_completion_tokens"), isHeader: true),
+                            usageCell(controller.tr("ai_usage_last_used"), isHeader: true),
+                          ]),
+                          ...usages.map((usage) => TableRow(children: [
+                                usageCell(usage.provider),
+                                usageCell(usage.requestCount.toString()),
+                                usageCell(usage.promptTokens.toString()),
+                                usageCell(usage.completionTokens.toString()),
+                                usageCell(usage.lastUsedTimestamp == 0 ? "-" : DateTime.fromMillisecondsSinceEpoch(usage.lastUsedTimestamp).toString().substring(0, 19)),
+                              ])),
+                        ],
+                      ),
+                    );
+                  }),
+            ),
           ])),
     );
   }
+
+  Widget usageCell(String text, {bool isHeader = false}) {
+    return Padding(
+      padding: const EdgeInsets.all(8),
+      child: Text(text, style: TextStyle(fontWeight: isHeader ? FontWeight.bold : FontWeight.normal)),
+    );
+  }
 }
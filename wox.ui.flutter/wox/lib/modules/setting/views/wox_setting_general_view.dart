@@ -209,7 +209,12 @@ class WoxSettingGeneralView extends GetView<WoxSettingController> {
                           "Label": "Query",
                           "Tooltip": "The query when the hotkey is triggered. Following variables are supported:\n\n"
                               "{wox:selected_text} represent the selected text.\n"
-                              "{wox:active_browser_url} represent the url of active browser tab.",
+                              "{wox:active_browser_url} represent the url of active browser tab.\n"
+                              "{wox:active_window} represent the name of active window.\n"
+                              "{wox:clipboard} represent the text in clipboard.\n"
+                              "{wox:date} and {wox:time} represent current date and time.\n\n"
+                              "Use {wox:clipboard|default} to provide a default value, "
+                              "and {wox:if selected_text}...{wox:else}...{wox:end} for conditionals.",
                           "Type": "text",
                           "TextMaxLines": 1,
                           "Validators": [
@@ -272,6 +277,238 @@ class WoxSettingGeneralView extends GetView<WoxSettingController> {
                   );
                 }),
               ),
+              formField(
+                label: controller.tr("script_command_directory"),
+                tips: controller.tr("script_command_directory_tips"),
+                child: Obx(() {
+                  final textController = TextEditingController(text: controller.woxSetting.value.scriptCommandDirectory);
+                  return Focus(
+                    onFocusChange: (hasFocus) {
+                      if (!hasFocus && textController.text != controller.woxSetting.value.scriptCommandDirectory) {
+                        controller.updateConfig("ScriptCommandDirectory", textController.text);
+                      }
+                    },
+                    child: TextBox(
+                      controller: textController,
+                      placeholder: "~/.wox/wox-user/scripts",
+                    ),
+                  );
+                }),
+              ),
+              formField(
+                label: controller.tr("python_path"),
+                tips: controller.tr("python_path_tips"),
+                child: Obx(() {
+                  final textController = TextEditingController(text: controller.woxSetting.value.pythonPath);
+                  return Focus(
+                    onFocusChange: (hasFocus) {
+                      if (!hasFocus && textController.text != controller.woxSetting.value.pythonPath) {
+                        controller.updateConfig("PythonPath", textController.text);
+                      }
+                    },
+                    child: TextBox(
+                      controller: textController,
+                      placeholder: "/usr/bin/python3",
+                    ),
+                  );
+                }),
+              ),
+              formField(
+                label: controller.tr("nodejs_path"),
+                tips: controller.tr("nodejs_path_tips"),
+                child: Obx(() {
+                  final textController = TextEditingController(text: controller.woxSetting.value.nodejsPath);
+                  return Focus(
+                    onFocusChange: (hasFocus) {
+                      if (!hasFocus && textController.text != controller.woxSetting.value.nodejsPath) {
+                        controller.updateConfig("NodejsPath", textController.text);
+                      }
+                    },
+                    child: TextBox(
+                      controller: textController,
+                      placeholder: "/usr/local/bin/node",
+                    ),
+                  );
+                }),
+              ),
+              formField(
+                label: controller.tr("pip_index_url"),
+                tips: controller.tr("pip_index_url_tips"),
+                child: Obx(() {
+                  final textController = TextEditingController(text: controller.woxSetting.value.pipIndexUrl);
+                  return Focus(
+                    onFocusChange: (hasFocus) {
+                      if (!hasFocus && textController.text != controller.woxSetting.value.pipIndexUrl) {
+                        controller.updateConfig("PipIndexUrl", textController.text);
+                      }
+                    },
+                    child: TextBox(
+                      controller: textController,
+                      placeholder: "https://pypi.org/simple",
+                    ),
+                  );
+                }),
+              ),
+              formField(
+                label: controller.tr("npm_registry"),
+                tips: controller.tr("npm_registry_tips"),
+                child: Obx(() {
+                  final textController = TextEditingController(text: controller.woxSetting.value.npmRegistry);
+                  return Focus(
+                    onFocusChange: (hasFocus) {
+                      if (!hasFocus && textController.text != controller.woxSetting.value.npmRegistry) {
+                        controller.updateConfig("NpmRegistry", textController.text);
+                      }
+                    },
+                    child: TextBox(
+                      controller: textController,
+                      placeholder: "https://registry.npmjs.org",
+                    ),
+                  );
+                }),
+              ),
+              formField(
+                label: controller.tr("plugin_trust_policy"),
+                tips: controller.tr("plugin_trust_policy_tips"),
+                child: Obx(() {
+                  return ComboBox<String>(
+                    items: [
+                      ComboBoxItem(value: "official_only", child: Text(controller.tr("plugin_trust_policy_official_only"))),
+                      ComboBoxItem(value: "allow_unsigned", child: Text(controller.tr("plugin_trust_policy_allow_unsigned"))),
+                    ],
+                    value: controller.woxSetting.value.pluginTrustPolicy,
+                    onChanged: (v) {
+                      if (v != null) {
+                        controller.updateConfig("PluginTrustPolicy", v);
+                      }
+                    },
+                  );
+                }),
+              ),
+              formField(
+                label: controller.tr("plugin_stores"),
+                tips: controller.tr("plugin_stores_tips"),
+                child: Obx(() {
+                  return WoxSettingPluginTable(
+                    value: json.encode(controller.woxSetting.value.pluginStores),
+                    item: PluginSettingValueTable.fromJson({
+                      "Key": "PluginStores",
+                      "Columns": [
+                        {
+                          "Key": "Name",
+                          "Label": "Name",
+                          "Tooltip": "Name of the store, shown as source of items from it.",
+                          "Width": 120,
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                          "Validators": [
+                            {"Type": "not_empty"}
+                          ],
+                        },
+                        {
+                          "Key": "Url",
+                          "Label": "Url",
+                          "Tooltip": "Http(s) url or local path of the store json file, or a local directory of json files.",
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                          "Validators": [
+                            {"Type": "not_empty"}
+                          ],
+                        },
+                        {
+                          "Key": "Priority",
+                          "Label": "Priority",
+                          "Tooltip": "When the same item with the same version is in multiple stores, the one from the store with higher priority is used.",
+                          "Width": 60,
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                        },
+                        {
+                          "Key": "Enabled",
+                          "Label": "Enabled",
+                          "Width": 60,
+                          "Type": "checkbox",
+                        },
+                        {
+                          "Key": "PublicKey",
+                          "Label": "Public key",
+                          "Tooltip": "Base64 encoded ed25519 public key to verify plugins signed by this store.",
+                          "Width": 120,
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                        }
+                      ],
+                      "SortColumnKey": "Priority",
+                      "SortOrder": "desc"
+                    }),
+                    onUpdate: (key, value) {
+                      controller.updateConfig("PluginStores", value);
+                    },
+                  );
+                }),
+              ),
+              formField(
+                label: controller.tr("theme_stores"),
+                tips: controller.tr("theme_stores_tips"),
+                child: Obx(() {
+                  return WoxSettingPluginTable(
+                    value: json.encode(controller.woxSetting.value.themeStores),
+                    item: PluginSettingValueTable.fromJson({
+                      "Key": "ThemeStores",
+                      "Columns": [
+                        {
+                          "Key": "Name",
+                          "Label": "Name",
+                          "Tooltip": "Name of the store, shown as source of items from it.",
+                          "Width": 120,
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                          "Validators": [
+                            {"Type": "not_empty"}
+                          ],
+                        },
+                        {
+                          "Key": "Url",
+                          "Label": "Url",
+                          "Tooltip": "Http(s) url or local path of the store json file, or a local directory of json files.",
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                          "Validators": [
+                            {"Type": "not_empty"}
+                          ],
+                        },
+                        {
+                          "Key": "Priority",
+                          "Label": "Priority",
+                          "Tooltip": "When the same item with the same version is in multiple stores, the one from the store with higher priority is used.",
+                          "Width": 60,
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                        },
+                        {
+                          "Key": "Enabled",
+                          "Label": "Enabled",
+                          "Width": 60,
+                          "Type": "checkbox",
+                        },
+                        {
+                          "Key": "PublicKey",
+                          "Label": "Public key",
+                          "Tooltip": "Base64 encoded ed25519 public key to verify plugins signed by this store.",
+                          "Width": 120,
+                          "Type": "text",
+                          "TextMaxLines": 1,
+                        }
+                      ],
+                      "SortColumnKey": "Priority",
+                      "SortOrder": "desc"
+                    }),
+                    onUpdate: (key, value) {
+                      controller.updateConfig("ThemeStores", value);
+                    },
+                  );
+                }),
+              ),
             ]));
       }),
     );
@@ -13,6 +13,8 @@ import 'package:wox/components/plugin/wox_setting_plugin_select_ai_model_view.da
 import 'package:wox/components/plugin/wox_setting_plugin_select_view.dart';
 import 'package:wox/components/plugin/wox_setting_plugin_table_view.dart';
 import 'package:wox/components/wox_image_view.dart';
+import 'package:wox/components/wox_plugin_load_errors_view.dart';
+import 'package:wox/components/wox_store_sync_status_view.dart';
 import 'package:wox/entity/setting/wox_plugin_setting_label.dart';
 import 'package:wox/entity/setting/wox_plugin_setting_select_ai_model.dart';
 import 'package:wox/entity/setting/wox_plugin_setting_table.dart';
@@ -66,6 +68,11 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
             }),
           ),
         ),
+        Obx(() {
+          return controller.isStorePluginList.value
+              ? WoxStoreSyncStatusView(statuses: controller.pluginStoreStatus.toList())
+              : WoxPluginLoadErrorsView(loadErrors: controller.pluginLoadErrors.toList());
+        }),
         Expanded(
           child: Scrollbar(
             thumbVisibility: false,
@@ -144,6 +151,14 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
       if (plugin.isInstalled) {
         return Icon(FluentIcons.skype_circle_check, color: isActive ? Colors.white : Colors.green);
       }
+      if (plugin.compatibleVersions.isEmpty) {
+        return Icon(FluentIcons.blocked2, color: isActive ? Colors.white : Colors.red);
+      }
+    } else if (plugin.updateVersion.isNotEmpty) {
+      return Tooltip(
+        message: 'Update to ${plugin.updateVersion}',
+        child: Icon(FluentIcons.sync, color: isActive ? Colors.white : Colors.blue),
+      );
     }
     return const SizedBox();
   }
@@ -196,6 +211,28 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
                       ),
                     ),
                   ),
+                if (!plugin.isInstalled && plugin.incompatibleReason.isNotEmpty)
+                  Padding(
+                    padding: const EdgeInsets.only(left: 10.0),
+                    child: Tooltip(
+                      message: plugin.incompatibleReason,
+                      child: Container(
+                        padding: const EdgeInsets.all(4),
+                        decoration: BoxDecoration(
+                          color: Colors.red,
+                          border: Border.all(color: Colors.red),
+                          borderRadius: BorderRadius.circular(4),
+                        ),
+                        child: const Text(
+                          'incompatible',
+                          style: TextStyle(
+                            color: Colors.white,
+                            fontSize: 12,
+                          ),
+                        ),
+                      ),
+                    ),
+                  ),
               ],
             ),
           ),
@@ -210,6 +247,16 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
                     color: Colors.grey,
                   ),
                 ),
+                if (plugin.store.isNotEmpty)
+                  Padding(
+                    padding: const EdgeInsets.only(left: 18.0),
+                    child: Text(
+                      'from ${plugin.store}',
+                      style: const TextStyle(
+                        color: Colors.grey,
+                      ),
+                    ),
+                  ),
                 Padding(
                   padding: const EdgeInsets.only(left: 18.0),
                   child: HyperlinkButton(
@@ -253,16 +300,61 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
                       child: const Text('Uninstall'),
                     ),
                   ),
-                if (!plugin.isInstalled)
+                if (plugin.isInstalled && plugin.updateVersion.isNotEmpty)
+                  Padding(
+                    padding: const EdgeInsets.only(right: 8.0),
+                    child: Builder(builder: (context) {
+                      return FilledButton(
+                        onPressed: () {
+                          installPlugin(context, plugin, plugin.updateVersion);
+                        },
+                        child: Text('Update to ${plugin.updateVersion}'),
+                      );
+                    }),
+                  ),
+                if (plugin.isInstalled && plugin.rollbackVersion.isNotEmpty)
                   Padding(
                     padding: const EdgeInsets.only(right: 8.0),
                     child: Button(
                       onPressed: () {
-                        controller.installPlugin(plugin);
+                        controller.rollbackPlugin(plugin);
                       },
-                      child: const Text('Install'),
+                      child: Text('Rollback to ${plugin.rollbackVersion}'),
                     ),
                   ),
+                if (!plugin.isInstalled)
+                  Padding(
+                    padding: const EdgeInsets.only(right: 8.0),
+                    child: Builder(builder: (context) {
+                      // latest version may be incompatible, install the newest compatible one
+                      final installVersion = plugin.compatibleVersions.isEmpty ? '' : plugin.compatibleVersions.first;
+                      return Button(
+                        onPressed: installVersion.isEmpty
+                            ? null
+                            : () {
+                                installPlugin(context, plugin, installVersion);
+                              },
+                        child: Text(installVersion.isEmpty || installVersion == plugin.version ? 'Install' : 'Install $installVersion'),
+                      );
+                    }),
+                  ),
+                if (!plugin.isInstalled && plugin.compatibleVersions.length > 1)
+                  Padding(
+                    padding: const EdgeInsets.only(right: 8.0),
+                    child: Builder(builder: (context) {
+                      return DropDownButton(
+                        title: const Text('Other versions'),
+                        items: plugin.compatibleVersions.skip(1).map((version) {
+                          return MenuFlyoutItem(
+                            text: Text(version),
+                            onPressed: () {
+                              installPlugin(context, plugin, version);
+                            },
+                          );
+                        }).toList(),
+                      );
+                    }),
+                  ),
                 if (plugin.isInstalled && !plugin.isDisable)
                   Padding(
                     padding: const EdgeInsets.only(right: 8.0),
@@ -344,6 +436,50 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
     );
   }
 
+  void installPlugin(BuildContext context, PluginDetail plugin, String version) {
+    final permissions = plugin.getPermissions(version);
+    if (permissions.isEmpty) {
+      controller.installPlugin(plugin, version: version);
+      return;
+    }
+
+    // sandboxed plugin can only access what user granted, installing it means granting the permissions
+    showDialog(
+        context: context,
+        builder: (context) {
+          return ContentDialog(
+            title: const Text('Grant permissions'),
+            content: Column(
+              mainAxisSize: MainAxisSize.min,
+              crossAxisAlignment: CrossAxisAlignment.start,
+              children: [
+                Text('${plugin.name} ${version.isEmpty ? '' : '$version '}requests the following permissions:'),
+                ...permissionItems(permissions),
+              ],
+            ),
+            actions: [
+              Row(
+                mainAxisAlignment: MainAxisAlignment.end,
+                children: [
+                  Button(
+                    child: const Text('Cancel'),
+                    onPressed: () => Navigator.pop(context),
+                  ),
+                  const SizedBox(width: 16),
+                  FilledButton(
+                    child: const Text('Install'),
+                    onPressed: () {
+                      Navigator.pop(context);
+                      controller.installPlugin(plugin, version: version);
+                    },
+                  ),
+                ],
+              )
+            ],
+          );
+        });
+  }
+
   Widget pluginTabDescription() {
     return Padding(
       padding: const EdgeInsets.all(16),
@@ -364,11 +500,67 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
                   ],
                 )
               : const SizedBox(),
+          ...pluginProcessItems(controller.activePluginDetail.value),
+          ...pluginUpdateItems(controller.activePluginDetail.value),
         ],
       ),
     );
   }
 
+  List<Widget> pluginUpdateItems(PluginDetail plugin) {
+    // only plugins installed from store can be updated
+    if (!plugin.isInstalled || plugin.isSystem || plugin.isDev) {
+      return [];
+    }
+
+    return [
+      const SizedBox(height: 20),
+      ToggleSwitch(
+        checked: plugin.setting.autoUpdate,
+        content: const Text('Install new versions automatically'),
+        onChanged: (bool value) {
+          controller.updatePluginAutoUpdate(plugin, value);
+        },
+      ),
+    ];
+  }
+
+  List<Widget> pluginProcessItems(PluginDetail plugin) {
+    if (!plugin.isInstalled) {
+      return [];
+    }
+
+    // python and nodejs plugins share one host process by default, user can run a plugin in its own process
+    var runtime = plugin.runtime.toUpperCase();
+    var supportIsolatedHost = runtime == "PYTHON" || runtime == "NODEJS";
+    var processStat = plugin.processStat;
+    if (!supportIsolatedHost && processStat == null) {
+      return [];
+    }
+
+    return [
+      const SizedBox(height: 20),
+      if (processStat != null)
+        Text(
+          '${processStat.isShared ? 'Shared host process' : 'Process'} ${processStat.pid}: '
+          'CPU ${processStat.cpu.toStringAsFixed(1)}%, memory ${(processStat.memory / 1024 / 1024).toStringAsFixed(1)} MB',
+        ),
+      if (supportIsolatedHost)
+        Padding(
+          padding: const EdgeInsets.only(top: 10),
+          child: ToggleSwitch(
+            checked: plugin.setting.isolatedHost || plugin.features.any((element) => element.name == "isolatedHost"),
+            content: const Text('Run in a dedicated host process'),
+            onChanged: plugin.features.any((element) => element.name == "isolatedHost")
+                ? null
+                : (bool value) {
+                    controller.updatePluginIsolatedHost(plugin, value);
+                  },
+          ),
+        ),
+    ];
+  }
+
   Widget pluginTabSetting() {
     return Obx(() {
       var plugin = controller.activePluginDetail.value;
@@ -570,7 +762,7 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
       child: Text('This plugin requires no data access'),
     );
 
-    if (plugin.features.isEmpty) {
+    if (plugin.features.isEmpty && plugin.permissions.isEmpty) {
       return noDataAccess;
     }
 
@@ -592,7 +784,7 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
       params.add("llm");
     }
 
-    if (params.isEmpty) {
+    if (params.isEmpty && plugin.permissions.isEmpty) {
       return noDataAccess;
     }
 
@@ -634,12 +826,32 @@ class WoxSettingPluginView extends GetView<WoxSettingController> {
               }
               return Text(e);
             }),
+            ...permissionItems(plugin.permissions),
           ],
         ),
       ),
     );
   }
 
+  List<Widget> permissionItems(PluginPermissions permissions) {
+    return [
+      ...permissions.fileSystem.map((e) {
+        return privacyItem(
+          material.Icons.folder,
+          e.readOnly ? 'Read files: ${e.path}' : 'Read and write files: ${e.path}',
+          'This plugin runs in a sandbox, it can only access files in its own directory and the granted directories',
+        );
+      }),
+      ...permissions.network.map((e) {
+        return privacyItem(
+          material.Icons.language,
+          'Network access: $e',
+          'This plugin runs in a sandbox, it can only send requests to the granted hosts',
+        );
+      }),
+    ];
+  }
+
   Widget privacyItem(IconData icon, String title, String description) {
     return Padding(
       padding: const EdgeInsets.only(top: 20.0),
@@ -4,6 +4,7 @@ import 'package:flutter/services.dart';
 import 'package:get/get.dart';
 import 'package:wox/components/wox_theme_icon_view.dart';
 import 'package:wox/components/wox_theme_preview.dart';
+import 'package:wox/components/wox_store_sync_status_view.dart';
 import 'package:wox/entity/wox_theme.dart';
 import 'package:wox/modules/setting/wox_setting_controller.dart';
 import 'package:wox/utils/colors.dart';
@@ -43,6 +44,9 @@ class WoxSettingThemeView extends GetView<WoxSettingController> {
             }),
           ),
         ),
+        Obx(() {
+          return controller.isStoreThemeList.value ? WoxStoreSyncStatusView(statuses: controller.themeStoreStatus.toList()) : const SizedBox();
+        }),
         Expanded(
           child: base.Scrollbar(
             child: Obx(() {
@@ -4,6 +4,7 @@ import 'package:url_launcher/url_launcher.dart';
 import 'package:uuid/v4.dart';
 import 'package:wox/api/wox_api.dart';
 import 'package:wox/entity/wox_plugin.dart';
+import 'package:wox/entity/wox_setting.dart';
 import 'package:wox/entity/wox_theme.dart';
 import 'package:wox/modules/launcher/wox_launcher_controller.dart';
 import 'package:wox/utils/log.dart';
@@ -19,6 +20,8 @@ class WoxSettingController extends GetxController {
   final filteredPluginDetails = <PluginDetail>[].obs;
   final activePluginDetail = PluginDetail.empty().obs;
   final isStorePluginList = true.obs;
+  final pluginStoreStatus = <StoreSyncStatus>[].obs;
+  final pluginLoadErrors = <PluginLoadError>[].obs;
   late TabController activePluginTabController;
 
   //themes
@@ -26,6 +29,7 @@ class WoxSettingController extends GetxController {
   final filteredThemeList = <WoxTheme>[].obs;
   final activeTheme = WoxTheme.empty().obs;
   final isStoreThemeList = true.obs;
+  final themeStoreStatus = <StoreSyncStatus>[].obs;
 
   //lang
   var langMap = <String, String>{}.obs;
@@ -59,6 +63,7 @@ class WoxSettingController extends GetxController {
     storePlugins.sort((a, b) => a.name.compareTo(b.name));
     pluginDetails.clear();
     pluginDetails.addAll(storePlugins);
+    pluginStoreStatus.value = await WoxApi.instance.findPluginStoreStatus();
   }
 
   Future<void> loadInstalledPlugins() async {
@@ -66,6 +71,7 @@ class WoxSettingController extends GetxController {
     installedPlugin.sort((a, b) => a.name.compareTo(b.name));
     pluginDetails.clear();
     pluginDetails.addAll(installedPlugin);
+    pluginLoadErrors.value = await WoxApi.instance.findPluginLoadErrors();
   }
 
   Future<void> refreshPluginList() async {
@@ -100,9 +106,9 @@ class WoxSettingController extends GetxController {
     }
   }
 
-  Future<void> installPlugin(PluginDetail plugin) async {
-    Logger.instance.info(const UuidV4().generate(), 'installing plugin: ${plugin.name}');
-    await WoxApi.instance.installPlugin(plugin.id);
+  Future<void> installPlugin(PluginDetail plugin, {String version = ""}) async {
+    Logger.instance.info(const UuidV4().generate(), 'installing plugin: ${plugin.name} $version');
+    await WoxApi.instance.installPlugin(plugin.id, version: version);
     await refreshPluginList();
   }
 
@@ -124,6 +130,12 @@ class WoxSettingController extends GetxController {
     await refreshPluginList();
   }
 
+  Future<void> rollbackPlugin(PluginDetail plugin) async {
+    Logger.instance.info(const UuidV4().generate(), 'rolling back plugin: ${plugin.name} to ${plugin.rollbackVersion}');
+    await WoxApi.instance.rollbackPlugin(plugin.id);
+    await refreshPluginList();
+  }
+
   filterPlugins() {
     filteredPluginDetails.clear();
 
@@ -147,7 +159,17 @@ class WoxSettingController extends GetxController {
     await updatePluginSetting(pluginId, "TriggerKeywords", triggerKeywords.join(","));
   }
 
-  bool shouldShowSettingTab() {
+  Future<void> updatePluginIsolatedHost(PluginDetail plugin, bool isolatedHost) async {
+    await updatePluginSetting(plugin.id, "IsolatedHost", isolatedHost.toString());
+    await refreshPluginList();
+  }
+
+  Future<void> updatePluginAutoUpdate(PluginDetail plugin, bool autoUpdate) async {
+    await updatePluginSetting(plugin.id, "AutoUpdate", autoUpdate.toString());
+    await refreshPluginList();
+  }
+
+    bool shouldShowSettingTab() {
     return activePluginDetail.value.isInstalled && activePluginDetail.value.settingDefinitions.isNotEmpty;
   }
 
@@ -163,6 +185,7 @@ class WoxSettingController extends GetxController {
   Future<void> loadStoreThemes() async {
     final storeThemes = await WoxApi.instance.findStoreThemes();
     storeThemes.sort((a, b) => a.themeName.compareTo(b.themeName));
+    themeStoreStatus.value = await WoxApi.instance.findThemeStoreStatus();
     themeList.clear();
     for (var theme in storeThemes) {
       themeList.add(theme);
@@ -25,8 +25,14 @@ class EntityFactory {
       return (json as List).map((e) => WoxTheme.fromJson(e)).toList() as T;
     } else if (T.toString() == "List<AIModel>") {
       return (json as List).map((e) => AIModel.fromJson(e)).toList() as T;
+    } else if (T.toString() == "List<AIUsage>") {
+      return (json as List).map((e) => AIUsage.fromJson(e)).toList() as T;
     } else if (T.toString() == "List<WoxLang>") {
       return (json as List).map((e) => WoxLang.fromJson(e)).toList() as T;
+    } else if (T.toString() == "List<StoreSyncStatus>") {
+      return (json as List).map((e) => StoreSyncStatus.fromJson(e)).toList() as T;
+    } else if (T.toString() == "List<PluginLoadError>") {
+      return (json as List).map((e) => PluginLoadError.fromJson(e)).toList() as T;
     } else {
       return json as T;
     }
@@ -1,5 +1,28 @@
+import 'dart:io';
+
 class Env {
   static late int serverPort;
   static late int serverPid;
   static late bool isDev;
+  static late String sessionTokenPath;
+  static String sessionToken = "";
+
+  // wox core writes a new session token every time it starts, all requests to wox core must carry it
+  static void loadSessionToken() {
+    try {
+      sessionToken = File(sessionTokenPath).readAsStringSync().trim();
+    } catch (e) {
+      sessionToken = "";
+    }
+  }
+
+  static String defaultSessionTokenPath() {
+    final home = Platform.environment["HOME"] ?? Platform.environment["USERPROFILE"] ?? "";
+    return "$home${Platform.pathSeparator}.wox${Platform.pathSeparator}wox.token";
+  }
+
+  static Map<String, String> get sessionTokenHeaders => {"Authorization": "Bearer $sessionToken"};
+
+  // only send session token to wox core, never to other servers
+  static bool isServerUrl(String url) => url.startsWith("http://localhost:$serverPort/");
 }
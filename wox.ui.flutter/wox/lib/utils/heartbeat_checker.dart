@@ -34,7 +34,7 @@ class HeartbeatChecker {
     }
 
     try {
-      var res = await Dio().get("http://localhost:${Env.serverPort}/ping");
+      var res = await Dio().get("http://localhost:${Env.serverPort}/ping", options: Options(headers: Env.sessionTokenHeaders));
       if (res.statusCode == 200) {
         return true;
       }
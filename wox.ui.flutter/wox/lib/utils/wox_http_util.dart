@@ -17,7 +17,7 @@ class WoxHttpUtil {
 
   Future<T> getData<T>(String url, {Map<String, dynamic>? params}) async {
     try {
-      final response = await _dio.get(_baseUrl + url, queryParameters: params);
+      final response = await _dio.get(_baseUrl + url, queryParameters: params, options: Options(headers: Env.sessionTokenHeaders));
       WoxResponse woxResponse = WoxResponse.fromJson(response.data);
       if (woxResponse.success == false) throw Exception(woxResponse.message);
       return EntityFactory.generateOBJ<T>(woxResponse.data);
@@ -31,7 +31,7 @@ class WoxHttpUtil {
     final traceId = const UuidV4().generate();
     Logger.instance.info(traceId, 'Posting data to $_baseUrl$url');
     try {
-      final response = await _dio.post(_baseUrl + url, data: data);
+      final response = await _dio.post(_baseUrl + url, data: data, options: Options(headers: Env.sessionTokenHeaders));
       WoxResponse woxResponse = WoxResponse.fromJson(response.data);
       if (woxResponse.success == false) throw Exception(woxResponse.message);
       return EntityFactory.generateOBJ<T>(woxResponse.data);
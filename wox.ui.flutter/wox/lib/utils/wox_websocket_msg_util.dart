@@ -10,6 +10,7 @@ import 'package:wox/entity/wox_websocket_msg.dart';
 import 'package:wox/enums/wox_image_type_enum.dart';
 import 'package:wox/enums/wox_msg_method_enum.dart';
 import 'package:wox/modules/launcher/wox_launcher_controller.dart';
+import 'package:wox/utils/env.dart';
 import 'package:wox/utils/log.dart';
 
 class WoxWebsocketMsgUtil {
@@ -35,7 +36,9 @@ class WoxWebsocketMsgUtil {
     _channel?.sink.close();
     _channel = null;
 
-    _channel = WebSocketChannel.connect(uri);
+    // session token changes if wox core restarted, websocket can't carry headers in all platforms so token is passed in url
+    Env.loadSessionToken();
+    _channel = WebSocketChannel.connect(uri.replace(queryParameters: {"token": Env.sessionToken}));
     _channel!.stream.listen(
       (event) {
         isConnecting = false;